@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/search"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// command is a sub command of the ada-api binary, e.g. "ada-api seed"
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{name: "seed", description: "Populate the database with fake data for development", run: runSeed},
+	{name: "backup", description: "Back up the sqlite database, the server can keep running", run: runBackup},
+	{name: "restore", description: "Restore the sqlite database from a backup", run: runRestore},
+	{name: "export", description: "Export all the records in a JSON lines file", run: runExport},
+	{name: "import", description: "Import the records of an export file", run: runImport},
+	{name: "fsck", description: "Check the integrity of the database and repair it", run: runFsck},
+	{name: "reindex", description: "Rebuild the search index from the database", run: runReindex},
+	{name: "migrate", description: "Create or update the tables of the database to the current version", run: runMigrate},
+	{name: "migrate-storage", description: "Copy the files of the media to another storage", run: runMigrateStorage},
+}
+
+// findCommand find a sub command by its name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// usage print the help of the http server and the list of sub commands
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage of %s:\n\n", os.Args[0])
+	flag.PrintDefaults()
+
+	fmt.Fprintf(out, "\nCommands:\n\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-16s %s\n", cmd.name, cmd.description)
+	}
+
+	fmt.Fprintf(out, "\nUse \"%s <command> --help\" for more information about a command.\n", os.Args[0])
+}
+
+// openDatabase open the sqlite database and migrate all the models
+func openDatabase(dsn string, logLevel logger.LogLevel) (*gorm.DB, error) {
+	db, err := openExistingDatabase(dsn, logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Migrate(db)
+	if err != nil {
+		return nil, fmt.Errorf("automigration failed: %w", err)
+	}
+
+	err = search.Register(db, search.NewEngine(db))
+	if err != nil {
+		return nil, fmt.Errorf("search index failed: %w", err)
+	}
+
+	return db, nil
+}
+
+// openExistingDatabase open the sqlite database as it is, without migrating it nor indexing
+// the changes, for the commands which read it
+func openExistingDatabase(dsn string, logLevel logger.LogLevel) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logLevel),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DB connection failed: %w", err)
+	}
+
+	return db, nil
+}
+
+// runMigrate create or update the tables of the database, the server does it when it starts
+func runMigrate(args []string) error {
+	var dsn string
+
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to migrate")
+	_ = flags.Parse(args)
+
+	_, err := openDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database %s migrated\n", dsn)
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/ada-social-network/api/dump"
+	"gorm.io/gorm/logger"
+)
+
+// runBackup copy the sqlite database in a backup file, it can run while the server is running
+func runBackup(args []string) error {
+	var dsn string
+	var output string
+
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to back up")
+	flags.StringVar(&output, "output", "", "backup file to create (required)")
+	_ = flags.Parse(args)
+
+	if output == "" {
+		return errors.New("missing --output backup file")
+	}
+
+	db, err := openExistingDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err = dump.Backup(ctx, db, output)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Database %s backed up in %s\n", dsn, output)
+	return nil
+}
+
+// runRestore replace the sqlite database by a backup file, the server must be stopped
+func runRestore(args []string) error {
+	var dsn string
+	var input string
+
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to restore, its content is replaced")
+	flags.StringVar(&input, "input", "", "backup file to restore (required)")
+	_ = flags.Parse(args)
+
+	if input == "" {
+		return errors.New("missing --input backup file")
+	}
+
+	db, err := openDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	err = dump.Restore(context.Background(), db, input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Database %s restored from %s\n", dsn, input)
+	return nil
+}
+
+// runExport write all the records of the database as JSON lines
+func runExport(args []string) error {
+	var dsn string
+	var output string
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to export")
+	flags.StringVar(&output, "output", "-", "export file to create, '-' for the standard output")
+	_ = flags.Parse(args)
+
+	db, err := openExistingDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	report, err := dump.Export(db, w, version)
+	if err != nil {
+		return err
+	}
+
+	printDumpReport(report, "exported")
+	return nil
+}
+
+// runImport insert the records of an export in the database
+func runImport(args []string) error {
+	var dsn string
+	var input string
+	var options dump.ImportOptions
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to import into")
+	flags.StringVar(&input, "input", "-", "export file to import, '-' for the standard input")
+	flags.BoolVar(&options.SkipExisting, "skip-existing", false, "Ignore records already in the database instead of failing")
+	_ = flags.Parse(args)
+
+	db, err := openDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := dump.Import(db, r, options)
+	if err != nil {
+		return err
+	}
+
+	printDumpReport(report, "imported")
+	return nil
+}
+
+// printDumpReport print the count of records per table, on stderr so an export can be piped
+func printDumpReport(report dump.Report, action string) {
+	for _, table := range report {
+		fmt.Fprintf(os.Stderr, "%-12s %d %s\n", table.Table, table.Records, action)
+	}
+}
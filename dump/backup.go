@@ -0,0 +1,168 @@
+package dump
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// backupStepPages is the number of pages copied at once, other connections can
+// write in the database between two steps
+const backupStepPages = 256
+
+// backupRetryDelay is the delay before a step is retried when the database is locked
+const backupRetryDelay = 20 * time.Millisecond
+
+// ErrNotSQLite is returned when the database is not a sqlite database
+var ErrNotSQLite = errors.New("online backup is only available for sqlite databases")
+
+// Backup copy the database of db in the file dest using the SQLite online
+// backup API, so it can run while the server is writing in the database. The copy is
+// written in a temporary file renamed to dest once it is complete.
+func Backup(ctx context.Context, db *gorm.DB, dest string) error {
+	_, err := os.Stat(dest)
+	if err == nil {
+		return fmt.Errorf("backup file %s already exists", dest)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err == nil {
+		err = backupTo(ctx, db, tmp.Name())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// backupTo copy the database of db in the file dest
+func backupTo(ctx context.Context, db *gorm.DB, dest string) error {
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	return copyDatabase(ctx, db, destDB, false)
+}
+
+// Restore replace the content of the database of db by the content of the
+// backup file src
+func Restore(ctx context.Context, db *gorm.DB, src string) error {
+	_, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	srcDB, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	return copyDatabase(ctx, db, srcDB, true)
+}
+
+// copyDatabase copy the main database of src in dest, or the opposite when reverse is true
+func copyDatabase(ctx context.Context, db *gorm.DB, other *sql.DB, reverse bool) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	otherConn, err := other.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer otherConn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		return otherConn.Raw(func(otherDriverConn interface{}) error {
+			src, ok := driverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return ErrNotSQLite
+			}
+
+			dest, ok := otherDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return ErrNotSQLite
+			}
+
+			if reverse {
+				src, dest = dest, src
+			}
+
+			backup, err := dest.Backup("main", src, "main")
+			if err != nil {
+				return err
+			}
+
+			for {
+				remaining := backup.Remaining()
+
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					_ = backup.Close()
+					return err
+				}
+
+				if done {
+					return backup.Finish()
+				}
+
+				// nothing is copied while another connection locks the database, the step
+				// is retried after a while instead of spinning
+				wait := time.Duration(0)
+				if backup.Remaining() == remaining {
+					wait = backupRetryDelay
+				}
+
+				err = sleep(ctx, wait)
+				if err != nil {
+					_ = backup.Close()
+					return err
+				}
+			}
+		})
+	})
+}
+
+// sleep wait for a delay unless the context is done before
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay == 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
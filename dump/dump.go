@@ -0,0 +1,86 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// Format identifies an export file of the ada social network
+	Format = "ada-social-network"
+	// Version is the version of the export format written by Export. Version 2 stores
+	// the commented resource of comments in target_type and target_id, version 3 the
+	// distinct actors of notifications.
+	Version = 3
+)
+
+const (
+	kindHeader = "header"
+	kindRecord = "record"
+	kindFooter = "footer"
+)
+
+// line is a line of an export file, the first line is a header, then each
+// record is on its own line and the last line is a footer
+type line struct {
+	Kind string `json:"kind"`
+
+	// header
+	Format     string `json:"format,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	ExportedAt string `json:"exportedAt,omitempty"`
+	AppVersion string `json:"appVersion,omitempty"`
+
+	// record
+	Table string                     `json:"table,omitempty"`
+	Data  map[string]json.RawMessage `json:"data,omitempty"`
+
+	// footer
+	Records int `json:"records,omitempty"`
+}
+
+// table is a model with its gorm schema
+type table struct {
+	model  interface{}
+	schema *schema.Schema
+}
+
+// tables returns the tables of all the models, in the order of models.All
+func tables(db *gorm.DB) ([]table, error) {
+	cache := &sync.Map{}
+	result := []table{}
+
+	for _, model := range models.All() {
+		s, err := schema.Parse(model, cache, db.NamingStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("parse model %T: %w", model, err)
+		}
+
+		result = append(result, table{model: model, schema: s})
+	}
+
+	return result, nil
+}
+
+// newRows returns a pointer to an empty slice of the table model
+func (t table) newRows() reflect.Value {
+	return reflect.New(reflect.SliceOf(t.schema.ModelType))
+}
+
+// columns returns the fields stored in database
+func (t table) columns() []*schema.Field {
+	fields := []*schema.Field{}
+	for _, field := range t.schema.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,327 @@
+package dump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/seed"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func initDB(t *testing.T, dsn string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(models.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func initSeededDB(t *testing.T, dsn string) *gorm.DB {
+	db := initDB(t, dsn)
+
+	options := seed.DefaultOptions()
+	options.Now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := seed.Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func countRows(t *testing.T, db *gorm.DB) map[string]int64 {
+	counts := map[string]int64{}
+
+	for _, model := range models.All() {
+		var count int64
+		err := db.Model(model).Count(&count).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		_ = stmt.Parse(model)
+		counts[stmt.Schema.Table] = count
+	}
+
+	return counts
+}
+
+func TestExportImport(t *testing.T) {
+	src := initSeededDB(t, "file:export-src?mode=memory&cache=shared")
+	dest := initDB(t, "file:export-dest?mode=memory&cache=shared")
+
+	buf := &bytes.Buffer{}
+
+	exported, err := Export(src, buf, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := Import(dest, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range exported {
+		if exported[i] != imported[i] {
+			t.Errorf("Import want:%v, got:%v", exported[i], imported[i])
+		}
+	}
+
+	want, got := countRows(t, src), countRows(t, dest)
+	for table, count := range want {
+		if got[table] != count {
+			t.Errorf("Import %s want:%d, got:%d", table, count, got[table])
+		}
+	}
+
+	var srcUser, destUser models.User
+	src.First(&srcUser, "email = ?", seed.AdminEmail)
+	dest.First(&destUser, "email = ?", seed.AdminEmail)
+
+	if srcUser.ID != destUser.ID || srcUser.Password != destUser.Password || !srcUser.CreatedAt.Equal(destUser.CreatedAt) || destUser.DeletedAt != nil {
+		t.Errorf("Import user want:%v, got:%v", srcUser, destUser)
+	}
+
+	// importing twice fails unless existing records are skipped
+	_, err = Import(dest, bytes.NewReader(buf.Bytes()), ImportOptions{})
+	if err == nil {
+		t.Error("Import should fail on existing records")
+	}
+
+	imported, err = Import(dest, bytes.NewReader(buf.Bytes()), ImportOptions{SkipExisting: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range imported {
+		if table.Records != 0 {
+			t.Errorf("Import with skip existing %s want:0, got:%d", table.Table, table.Records)
+		}
+	}
+}
+
+func TestExportPages(t *testing.T) {
+	db := initDB(t, "file:export-pages?mode=memory&cache=shared")
+
+	tags := make([]models.Tag, 2*exportBatchSize+1)
+	for i := range tags {
+		tags[i].Name = fmt.Sprintf("tag%d", i)
+	}
+
+	err := db.CreateInBatches(tags, 100).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	report, err := Export(db, buf, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range report {
+		if table.Table == "tags" && table.Records != len(tags) {
+			t.Errorf("Export tags want:%d, got:%d", len(tags), table.Records)
+		}
+	}
+
+	if count := strings.Count(buf.String(), `"table":"tags"`); count != len(tags) {
+		t.Errorf("Export tag lines want:%d, got:%d", len(tags), count)
+	}
+}
+
+func TestExportOutdatedSchema(t *testing.T) {
+	db := initSeededDB(t, "file:export-outdated?mode=memory&cache=shared")
+
+	err := db.Migrator().DropTable(&models.PollChoice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Migrator().DropColumn(&models.Post{}, "snippet_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Export(db, &bytes.Buffer{}, "test")
+	if !errors.Is(err, repository.ErrOutdatedSchema) || !strings.Contains(err.Error(), "posts.snippet_id, poll_choices") {
+		t.Errorf("Export of an outdated database want:%v, got:%v", repository.ErrOutdatedSchema, err)
+	}
+
+	if db.Migrator().HasTable(&models.PollChoice{}) {
+		t.Error("Export should not migrate the database")
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	db := initDB(t, "file:import-invalid?mode=memory&cache=shared")
+
+	header := `{"kind":"header","format":"ada-social-network","version":1}` + "\n"
+	record := `{"kind":"record","table":"categories","data":{"id":"80a08d36-cfea-4898-aee3-6902fa562f0b","name":"foo"}}` + "\n"
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{
+			name:  "not an export",
+			input: `{"foo":"bar"}`,
+			want:  ErrInvalidFormat,
+		},
+		{
+			name:  "future version",
+			input: `{"kind":"header","format":"ada-social-network","version":99}`,
+			want:  ErrUnsupportedVersion,
+		},
+		{
+			name:  "missing footer",
+			input: header + record,
+			want:  ErrTruncated,
+		},
+		{
+			name:  "missing records",
+			input: header + record + `{"kind":"footer","records":2}`,
+			want:  ErrTruncated,
+		},
+		{
+			name:  "unknown table",
+			input: header + `{"kind":"record","table":"foo","data":{}}` + "\n",
+			want:  ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(db, strings.NewReader(tt.input), ImportOptions{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Import want:%v, got:%v", tt.want, err)
+			}
+
+			var count int64
+			db.Model(&models.Category{}).Count(&count)
+			if count != 0 {
+				t.Errorf("Import should be rolled back, got %d categories", count)
+			}
+		})
+	}
+}
+
+func TestImportVersion1(t *testing.T) {
+	db := initDB(t, "file:import-version-1?mode=memory&cache=shared")
+
+	input := `{"kind":"header","format":"ada-social-network","version":1}
+{"kind":"record","table":"comments","data":{"id":"80a08d36-cfea-4898-aee3-6902fa562f0b","bda_post_id":"80a08d36-cfea-4898-aee3-6902fa562f1d","content":"lorem ipsum"}}
+{"kind":"footer","records":1}`
+
+	_, err := Import(db, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := &models.Comment{}
+	db.First(comment, "id = ?", "80a08d36-cfea-4898-aee3-6902fa562f0b")
+	if comment.TargetType != models.BdaPostComment || comment.TargetID.String() != "80a08d36-cfea-4898-aee3-6902fa562f1d" {
+		t.Errorf("Import comment target want:bdaPost 80a08d36-cfea-4898-aee3-6902fa562f1d, got:%s %s", comment.TargetType, comment.TargetID)
+	}
+}
+
+func TestImportVersion2(t *testing.T) {
+	db := initDB(t, "file:import-version-2?mode=memory&cache=shared")
+
+	input := `{"kind":"header","format":"ada-social-network","version":2}
+{"kind":"record","table":"notifications","data":{"id":"80a08d36-cfea-4898-aee3-6902fa562f0b","type":"like","target_type":"post","actor_id":"80a08d36-cfea-4898-aee3-6902fa562f1d","count":3}}
+{"kind":"footer","records":1}`
+
+	_, err := Import(db, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification := &models.Notification{}
+	db.First(notification, "id = ?", "80a08d36-cfea-4898-aee3-6902fa562f0b")
+	if notification.Count != 3 || notification.ActorsCount != 1 {
+		t.Errorf("Import notification counts want:3 events of 1 actor, got:%d events of %d actors", notification.Count, notification.ActorsCount)
+	}
+}
+
+func TestImportLegacyPictures(t *testing.T) {
+	db := initDB(t, "file:import-legacy-pictures?mode=memory&cache=shared")
+
+	input := `{"kind":"header","format":"ada-social-network","version":2}
+{"kind":"record","table":"media","data":{"id":"80a08d36-cfea-4898-aee3-6902fa562f0b","hash":"ab"}}
+{"kind":"record","table":"users","data":{"id":"80a08d36-cfea-4898-aee3-6902fa562f1d","email":"ada@legacy.dev","profil_pic":"https://api.ada.dev/media/80a08d36-cfea-4898-aee3-6902fa562f0b/file","cover_pic":"https://example.com/cover.png"}}
+{"kind":"footer","records":2}`
+
+	_, err := Import(db, strings.NewReader(input), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{}
+	db.First(user, "id = ?", "80a08d36-cfea-4898-aee3-6902fa562f1d")
+	if user.ProfilPicID == nil || user.ProfilPicID.String() != "80a08d36-cfea-4898-aee3-6902fa562f0b" || user.CoverPicID != nil {
+		t.Errorf("Import legacy pictures want:the media as profil pic and no cover pic, got:%v %v", user.ProfilPicID, user.CoverPicID)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	src := initSeededDB(t, filepath.Join(dir, "src.db"))
+	backup := filepath.Join(dir, "backup.db")
+
+	err := Backup(context.Background(), src, backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Backup(context.Background(), src, backup)
+	if err == nil {
+		t.Error("Backup should not overwrite an existing file")
+	}
+
+	// a failed backup leaves no partial file
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = Backup(canceled, src, filepath.Join(dir, "canceled.db"))
+	if err == nil {
+		t.Error("Backup should fail when it is canceled")
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "canceled.db*")); len(files) != 0 {
+		t.Errorf("Backup canceled want:no file, got:%v", files)
+	}
+
+	dest := initDB(t, filepath.Join(dir, "dest.db"))
+
+	err = Restore(context.Background(), dest, backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := countRows(t, src), countRows(t, dest)
+	for table, count := range want {
+		if got[table] != count {
+			t.Errorf("Restore %s want:%d, got:%d", table, count, got[table])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ada-social-network/api/repository"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is the number of rows loaded at once during an export
+const exportBatchSize = 500
+
+// TableReport is the number of records of a table exported or imported
+type TableReport struct {
+	Table   string
+	Records int
+}
+
+// Report is the result of an export or an import
+type Report []TableReport
+
+// Export write all the records of the database in w as JSON lines. Columns are
+// written with their database name so the export does not depend on the API
+// representation of the resources. The DB is not migrated, its schema must be up to date.
+// The records are read in one transaction, so the export is consistent while the server
+// is writing in the database.
+func Export(db *gorm.DB, w io.Writer, appVersion string) (Report, error) {
+	err := repository.CheckSchema(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder := json.NewEncoder(w)
+
+	err = encoder.Encode(line{
+		Kind:       kindHeader,
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		AppVersion: appVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := Report{}
+	total := 0
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tables {
+			records, err := exportTable(tx, encoder, t)
+			if err != nil {
+				return fmt.Errorf("export %s: %w", t.schema.Table, err)
+			}
+
+			report = append(report, TableReport{Table: t.schema.Table, Records: records})
+			total += records
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Encode(line{Kind: kindFooter, Records: total})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// exportTable write all the records of a table, they are loaded by pages of ids
+func exportTable(db *gorm.DB, encoder *json.Encoder, t table) (int, error) {
+	columns := t.columns()
+	primaryKey := t.schema.PrioritizedPrimaryField
+	records := 0
+
+	var lastID interface{}
+	for {
+		rows := t.newRows()
+
+		query := db.Model(t.model).Order(primaryKey.DBName).Limit(exportBatchSize)
+		if lastID != nil {
+			query = query.Where(primaryKey.DBName+" > ?", lastID)
+		}
+
+		err := query.Find(rows.Interface()).Error
+		if err != nil {
+			return records, err
+		}
+
+		slice := rows.Elem()
+		for i := 0; i < slice.Len(); i++ {
+			data := map[string]json.RawMessage{}
+
+			for _, field := range columns {
+				value, err := json.Marshal(slice.Index(i).FieldByIndex(field.StructField.Index).Interface())
+				if err != nil {
+					return records, fmt.Errorf("column %s: %w", field.DBName, err)
+				}
+
+				data[field.DBName] = value
+			}
+
+			err = encoder.Encode(line{Kind: kindRecord, Table: t.schema.Table, Data: data})
+			if err != nil {
+				return records, err
+			}
+
+			records++
+		}
+
+		if slice.Len() < exportBatchSize {
+			return records, nil
+		}
+
+		lastID = slice.Index(slice.Len() - 1).FieldByIndex(primaryKey.StructField.Index).Interface()
+	}
+}
@@ -0,0 +1,192 @@
+package dump
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidFormat is returned when the file is not an export
+	ErrInvalidFormat = errors.New("invalid export format")
+	// ErrUnsupportedVersion is returned when the export is more recent than the application
+	ErrUnsupportedVersion = errors.New("unsupported export version")
+	// ErrTruncated is returned when the export does not end with a footer
+	ErrTruncated = errors.New("truncated export")
+)
+
+// ImportOptions define how records are imported
+type ImportOptions struct {
+	// SkipExisting ignores records whose primary key or unique key already exists,
+	// otherwise the import fails on the first conflict
+	SkipExisting bool
+}
+
+// Import read an export written by Export and insert all its records in a
+// single transaction: nothing is imported if any record is invalid. The report
+// contains the number of records actually inserted.
+func Import(db *gorm.DB, r io.Reader, options ImportOptions) (Report, error) {
+	tables, err := tables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]table{}
+	for _, t := range tables {
+		byName[t.schema.Table] = t
+	}
+
+	decoder := json.NewDecoder(r)
+
+	header := line{}
+	err = decoder.Decode(&header)
+	if err != nil || header.Kind != kindHeader || header.Format != Format {
+		return nil, ErrInvalidFormat
+	}
+
+	if header.Version < 1 || header.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, header.Version)
+	}
+
+	counts := map[string]int{}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		total := 0
+
+		for {
+			l := line{}
+			err := decoder.Decode(&l)
+			if errors.Is(err, io.EOF) {
+				return ErrTruncated
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidFormat, err)
+			}
+
+			switch l.Kind {
+			case kindRecord:
+				t, ok := byName[l.Table]
+				if !ok {
+					return fmt.Errorf("%w: unknown table %s", ErrInvalidFormat, l.Table)
+				}
+
+				insert := tx
+				if options.SkipExisting {
+					insert = tx.Clauses(clause.OnConflict{DoNothing: true})
+				}
+
+				err = upgradeRecord(tx, header.Version, l.Table, l.Data)
+				if err != nil {
+					return fmt.Errorf("upgrade %s record %d: %w", l.Table, total+1, err)
+				}
+
+				created, err := importRecord(insert, t, l.Data)
+				if err != nil {
+					return fmt.Errorf("import %s record %d: %w", l.Table, total+1, err)
+				}
+
+				counts[l.Table] += int(created)
+				total++
+			case kindFooter:
+				if l.Records != total {
+					return fmt.Errorf("%w: %d records expected, %d found", ErrTruncated, l.Records, total)
+				}
+
+				return nil
+			default:
+				return fmt.Errorf("%w: unexpected %q line", ErrInvalidFormat, l.Kind)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := Report{}
+	for _, t := range tables {
+		report = append(report, TableReport{Table: t.schema.Table, Records: counts[t.schema.Table]})
+	}
+
+	return report, nil
+}
+
+// upgradeRecord move the columns of a record of an older export to the columns of the
+// current version
+func upgradeRecord(tx *gorm.DB, version int, table string, data map[string]json.RawMessage) error {
+	// only bda posts could be commented before version 2
+	if version < 2 && table == "comments" {
+		if bdaPostID, ok := data["bda_post_id"]; ok {
+			data["target_type"] = json.RawMessage(strconv.Quote(string(models.BdaPostComment)))
+			data["target_id"] = bdaPostID
+		}
+	}
+
+	// only the last actor of notifications is known before version 3, their actors are
+	// recorded by the next migration
+	if version < 3 && table == "notifications" {
+		data["actors_count"] = json.RawMessage("1")
+	}
+
+	// the pictures were saved as free text before the media were uploaded, as in
+	// repository.Migrate only those referencing a media imported before them are kept
+	for _, legacy := range repository.LegacyPictures {
+		picture := ""
+		if legacy.Table != table || json.Unmarshal(data[legacy.Column], &picture) != nil {
+			continue
+		}
+
+		if mediaID, ok := data[legacy.MediaColumn]; ok && string(mediaID) != "null" {
+			continue
+		}
+
+		mediaID, ok := repository.LegacyMediaID(picture)
+		if !ok {
+			continue
+		}
+
+		var count int64
+		err := tx.Model(&models.Media{}).Where("id = ?", mediaID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			data[legacy.MediaColumn] = json.RawMessage(strconv.Quote(mediaID.String()))
+		}
+	}
+
+	return nil
+}
+
+// importRecord insert a record, columns missing from data keep their zero value
+// and unknown columns are ignored so older exports can still be imported
+func importRecord(tx *gorm.DB, t table, data map[string]json.RawMessage) (int64, error) {
+	row := reflect.New(t.schema.ModelType)
+
+	for _, field := range t.columns() {
+		raw, ok := data[field.DBName]
+		if !ok {
+			continue
+		}
+
+		value := reflect.New(field.FieldType)
+		err := json.Unmarshal(raw, value.Interface())
+		if err != nil {
+			return 0, fmt.Errorf("column %s: %w", field.DBName, err)
+		}
+
+		row.Elem().FieldByIndex(field.StructField.Index).Set(value.Elem())
+	}
+
+	result := tx.Create(row.Interface())
+
+	return result.RowsAffected, result.Error
+}
@@ -30,6 +30,31 @@ func Conflict(c *gin.Context, err error) {
 	HTTPError(c, http.StatusConflict, err.Error(), err)
 }
 
+// PreconditionFailed respond with a precondition failed error
+func PreconditionFailed(c *gin.Context, err error) {
+	HTTPError(c, http.StatusPreconditionFailed, "Resource has been modified, fetch it again before updating it", err)
+}
+
+// Forbidden respond with a forbidden error
+func Forbidden(c *gin.Context, err error) {
+	HTTPError(c, http.StatusForbidden, err.Error(), err)
+}
+
+// TooLarge respond with a request entity too large error
+func TooLarge(c *gin.Context, err error) {
+	HTTPError(c, http.StatusRequestEntityTooLarge, err.Error(), err)
+}
+
+// UnsupportedMediaType respond with an unsupported media type error
+func UnsupportedMediaType(c *gin.Context, err error) {
+	HTTPError(c, http.StatusUnsupportedMediaType, err.Error(), err)
+}
+
+// BadRequest respond with a bad request error
+func BadRequest(c *gin.Context, err error) {
+	HTTPError(c, http.StatusBadRequest, err.Error(), err)
+}
+
 // Internal respond with an internal error
 func Internal(c *gin.Context, err error) {
 	HTTPError(c, http.StatusInternalServerError, "Internal error", err)
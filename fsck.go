@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ada-social-network/api/fsck"
+	"gorm.io/gorm/logger"
+)
+
+// runFsck check the integrity of the database and optionally repair it
+func runFsck(args []string) error {
+	var dsn string
+	var format string
+	var options fsck.Options
+
+	flags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to check")
+	flags.StringVar(&format, "format", "text", "Report format, can be 'text' or 'json'")
+	flags.BoolVar(&options.Repair, "repair", false, "Repair the issues found in a single transaction")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "With --repair, show what would be repaired and roll back")
+	_ = flags.Parse(args)
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	db, err := openExistingDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	report, err := fsck.Run(db, options)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = printFsckReport(os.Stdout, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.Unrepaired() > 0 {
+		return fmt.Errorf("%d issues left", report.Unrepaired())
+	}
+
+	return nil
+}
+
+// printFsckReport print the issues of each check as a table
+func printFsckReport(out io.Writer, report *fsck.Report) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	for _, check := range report.Checks {
+		if len(check.Issues) == 0 {
+			continue
+		}
+
+		status := "not repaired"
+		switch {
+		case !check.Repairable:
+			status = "must be fixed by hand"
+		case report.DryRun:
+			status = fmt.Sprintf("%d rows would be repaired", check.Repaired)
+		case report.Repair:
+			status = fmt.Sprintf("%d rows repaired", check.Repaired)
+		}
+
+		fmt.Fprintf(w, "%s: %s (%d issues, %s)\n", check.Name, check.Description, len(check.Issues), status)
+		for _, issue := range check.Issues {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", issue.Table, issue.ID, issue.Detail)
+		}
+	}
+
+	fmt.Fprintf(w, "%d checks run, %d issues found\n", len(report.Checks), report.Issues)
+
+	return w.Flush()
+}
@@ -0,0 +1,559 @@
+package fsck
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// reference is a column of a table referencing the id of another table
+type reference struct {
+	table  string
+	column string
+	target string
+	// optional references use the nil uuid when they are not set
+	optional bool
+	// where restricts the rows holding the reference, e.g. to a type of target
+	where string
+}
+
+// orphanRepair is how rows referencing a missing row are repaired
+type orphanRepair int
+
+const (
+	// reportOnly does not repair the rows, they must be fixed by hand
+	reportOnly orphanRepair = iota
+	// deleteOrphans delete the rows
+	deleteOrphans
+	// resetOrphans set the reference back to the nil uuid
+	resetOrphans
+	// clearOrphans set the nullable reference to NULL
+	clearOrphans
+)
+
+// tablesWithID are all the tables identified by an uuid
+var tablesWithID = []string{"promos", "users", "categories", "topics", "posts", "bda_posts", "comments", "likes", "follows", "subscriptions", "notifications", "notification_preferences", "notification_actors", "mentions", "tags", "taggings", "blocks", "conversations", "conversation_members", "messages", "media", "attachments", "snippets", "snippet_revisions", "link_previews", "polls", "poll_options", "poll_votes", "poll_choices"}
+
+// checks returns all the checks in the order they must be run: rows referencing
+// deleted rows are checked after the deleted rows
+func checks() []check {
+	return []check{
+		invalidIDsCheck(),
+		orphanCheck(reference{table: "users", column: "promo_id", target: "promos", optional: true}, resetOrphans),
+		orphanCheck(reference{table: "users", column: "profil_pic_id", target: "media", optional: true}, clearOrphans),
+		orphanCheck(reference{table: "users", column: "cover_pic_id", target: "media", optional: true}, clearOrphans),
+		orphanCheck(reference{table: "promos", column: "profile_pic_id", target: "media", optional: true}, clearOrphans),
+		orphanCheck(reference{table: "media", column: "user_id", target: "users", optional: true}, resetOrphans),
+		orphanCheck(reference{table: "topics", column: "category_id", target: "categories"}, deleteOrphans),
+		orphanCheck(reference{table: "topics", column: "user_id", target: "users"}, reportOnly),
+		orphanCheck(reference{table: "posts", column: "topic_id", target: "topics"}, deleteOrphans),
+		orphanCheck(reference{table: "posts", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "bda_posts", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "comments", column: "target_id", target: "posts", where: "t.target_type = 'post'"}, deleteOrphans),
+		orphanCheck(reference{table: "comments", column: "target_id", target: "topics", where: "t.target_type = 'topic'"}, deleteOrphans),
+		orphanCheck(reference{table: "comments", column: "target_id", target: "bda_posts", where: "t.target_type = 'bdaPost'"}, deleteOrphans),
+		// deleted comments kept for their replies have no author
+		orphanCheck(reference{table: "comments", column: "user_id", target: "users", optional: true}, deleteOrphans),
+		orphanCheck(reference{table: "comments", column: "parent_id", target: "comments", optional: true}, deleteOrphans),
+		orphanCheck(reference{table: "likes", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "likes", column: "post_id", target: "posts", optional: true}, deleteOrphans),
+		orphanCheck(reference{table: "likes", column: "bda_post_id", target: "bda_posts", optional: true}, deleteOrphans),
+		orphanCheck(reference{table: "likes", column: "comment_id", target: "comments", optional: true}, deleteOrphans),
+		likesWithoutTargetCheck(),
+		duplicateLikesCheck(),
+		orphanCheck(reference{table: "follows", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "follows", column: "followed_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "subscriptions", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "subscriptions", column: "target_id", target: "topics", where: "t.target_type = 'topic'"}, deleteOrphans),
+		orphanCheck(reference{table: "subscriptions", column: "target_id", target: "categories", where: "t.target_type = 'category'"}, deleteOrphans),
+		orphanCheck(reference{table: "blocks", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "blocks", column: "blocked_id", target: "users"}, deleteOrphans),
+		// the groups started by deleted users are kept without author
+		orphanCheck(reference{table: "conversations", column: "user_id", target: "users", optional: true}, resetOrphans),
+		orphanCheck(reference{table: "conversation_members", column: "conversation_id", target: "conversations"}, deleteOrphans),
+		orphanCheck(reference{table: "conversation_members", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "messages", column: "conversation_id", target: "conversations"}, deleteOrphans),
+		orphanCheck(reference{table: "messages", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "notifications", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "notifications", column: "actor_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "notification_preferences", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "notification_actors", column: "notification_id", target: "notifications"}, deleteOrphans),
+		orphanCheck(reference{table: "notification_actors", column: "actor_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "mentions", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "mentions", column: "source_id", target: "posts", where: "t.source_type = 'post'"}, deleteOrphans),
+		orphanCheck(reference{table: "mentions", column: "source_id", target: "comments", where: "t.source_type = 'comment'"}, deleteOrphans),
+		orphanCheck(reference{table: "mentions", column: "source_id", target: "topics", where: "t.source_type = 'topic'"}, deleteOrphans),
+		orphanCheck(reference{table: "mentions", column: "source_id", target: "bda_posts", where: "t.source_type = 'bdaPost'"}, deleteOrphans),
+		orphanCheck(reference{table: "taggings", column: "tag_id", target: "tags"}, deleteOrphans),
+		orphanCheck(reference{table: "taggings", column: "source_id", target: "posts", where: "t.source_type = 'post'"}, deleteOrphans),
+		orphanCheck(reference{table: "taggings", column: "source_id", target: "topics", where: "t.source_type = 'topic'"}, deleteOrphans),
+		orphanCheck(reference{table: "taggings", column: "source_id", target: "bda_posts", where: "t.source_type = 'bdaPost'"}, deleteOrphans),
+		orphanCheck(reference{table: "attachments", column: "media_id", target: "media"}, deleteOrphans),
+		orphanCheck(reference{table: "attachments", column: "source_id", target: "posts", where: "t.source_type = 'post'"}, deleteOrphans),
+		orphanCheck(reference{table: "attachments", column: "source_id", target: "bda_posts", where: "t.source_type = 'bdaPost'"}, deleteOrphans),
+		orphanCheck(reference{table: "attachments", column: "source_id", target: "comments", where: "t.source_type = 'comment'"}, deleteOrphans),
+		orphanCheck(reference{table: "attachments", column: "source_id", target: "messages", where: "t.source_type = 'message'"}, deleteOrphans),
+		orphanCheck(reference{table: "snippets", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "snippet_revisions", column: "snippet_id", target: "snippets"}, deleteOrphans),
+		orphanCheck(reference{table: "posts", column: "snippet_id", target: "snippets", optional: true}, clearOrphans),
+		orphanCheck(reference{table: "link_previews", column: "post_id", target: "posts"}, deleteOrphans),
+		orphanCheck(reference{table: "polls", column: "target_id", target: "topics", where: "t.target_type = 'topic'"}, deleteOrphans),
+		orphanCheck(reference{table: "polls", column: "target_id", target: "bda_posts", where: "t.target_type = 'bdaPost'"}, deleteOrphans),
+		orphanCheck(reference{table: "polls", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "poll_options", column: "poll_id", target: "polls"}, deleteOrphans),
+		orphanCheck(reference{table: "poll_votes", column: "poll_id", target: "polls"}, deleteOrphans),
+		orphanCheck(reference{table: "poll_votes", column: "user_id", target: "users"}, deleteOrphans),
+		orphanCheck(reference{table: "poll_choices", column: "vote_id", target: "poll_votes"}, deleteOrphans),
+		orphanCheck(reference{table: "poll_choices", column: "option_id", target: "poll_options"}, deleteOrphans),
+		topicPostsCountCheck(),
+		commentRepliesCountCheck(),
+		commentPathsCheck(),
+		userFollowCountsCheck(),
+		tagUsesCountCheck(),
+		// the rows deleted by the previous repairs are removed from the search index too
+		staleSearchDocumentsCheck(),
+	}
+}
+
+// searchTables are the tables of the searchable resources by kind of search document
+var searchTables = []struct {
+	kind  string
+	table string
+}{{"topic", "topics"}, {"post", "posts"}, {"bdaPost", "bda_posts"}, {"comment", "comments"}, {"user", "users"}}
+
+// invalidIDsCheck find rows whose id is not an uuid, they can not be reached by the API
+func invalidIDsCheck() check {
+	return check{
+		name:        "invalid-ids",
+		description: "rows whose id is not a valid uuid",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			issues := []Issue{}
+
+			for _, table := range tablesWithID {
+				var rowIDs []string
+				err := db.Table(table).Pluck("id", &rowIDs).Error
+				if err != nil {
+					return nil, err
+				}
+
+				for _, id := range rowIDs {
+					parsed, err := uuid.FromString(id)
+					if err != nil || uuid.Equal(parsed, uuid.Nil) {
+						issues = append(issues, Issue{Table: table, ID: id, Detail: fmt.Sprintf("%q is not a valid id", id)})
+					}
+				}
+			}
+
+			return withCheck("invalid-ids", issues), nil
+		},
+		repair: deleteRows,
+	}
+}
+
+// orphanCheck find rows referencing a row which does not exist
+func orphanCheck(ref reference, repair orphanRepair) check {
+	name := fmt.Sprintf("orphan-%s-%s", ref.table, ref.column)
+	if ref.where != "" {
+		name = fmt.Sprintf("orphan-%s-%s", ref.table, ref.target)
+	}
+
+	c := check{
+		name:        name,
+		description: fmt.Sprintf("%s whose %s does not exist in %s", ref.table, ref.column, ref.target),
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID        string
+				Reference string
+			}{}
+
+			query := db.Table(ref.table + " AS t").
+				Select("t.id AS id, t." + ref.column + " AS reference").
+				Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s r WHERE r.id = t.%s)", ref.target, ref.column))
+			if ref.where != "" {
+				query = query.Where(ref.where)
+			}
+			if ref.optional {
+				query = query.Where(fmt.Sprintf("t.%s IS NOT NULL AND t.%s <> '' AND t.%s <> ?", ref.column, ref.column, ref.column), nilUUID)
+			}
+
+			err := query.Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{
+					Table:  ref.table,
+					ID:     row.ID,
+					Detail: fmt.Sprintf("%s %q does not exist in %s", ref.column, row.Reference, ref.target),
+				})
+			}
+
+			return withCheck(name, issues), nil
+		},
+	}
+
+	switch repair {
+	case deleteOrphans:
+		c.repair = deleteRows
+	case resetOrphans:
+		c.repair = func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id IN ?", ref.table, ref.column), nilUUID, ids(issues))
+			return result.RowsAffected, result.Error
+		}
+	case clearOrphans:
+		c.repair = func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = NULL WHERE id IN ?", ref.table, ref.column), ids(issues))
+			return result.RowsAffected, result.Error
+		}
+	}
+
+	return c
+}
+
+// likesWithoutTargetCheck find likes which do not reference any liked resource
+func likesWithoutTargetCheck() check {
+	return check{
+		name:        "likes-without-target",
+		description: "likes which are not on a post, a bda post or a comment",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			var likeIDs []string
+			err := db.Table("likes").
+				Where("COALESCE(post_id, ?) = ? AND COALESCE(bda_post_id, ?) = ? AND COALESCE(comment_id, ?) = ?", nilUUID, nilUUID, nilUUID, nilUUID, nilUUID, nilUUID).
+				Pluck("id", &likeIDs).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, id := range likeIDs {
+				issues = append(issues, Issue{Table: "likes", ID: id, Detail: "like without liked resource"})
+			}
+
+			return withCheck("likes-without-target", issues), nil
+		},
+		repair: deleteRows,
+	}
+}
+
+// duplicateLikesCheck find likes given several times by a user to the same resource,
+// the oldest like is kept
+func duplicateLikesCheck() check {
+	return check{
+		name:        "duplicate-likes",
+		description: "likes given more than once by a user to the same resource",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID     string
+				UserID string
+			}{}
+
+			err := db.Raw(`SELECT l.id AS id, l.user_id AS user_id FROM likes l
+				WHERE EXISTS (
+					SELECT 1 FROM likes o
+					WHERE o.user_id = l.user_id
+					AND COALESCE(o.post_id, @nil) = COALESCE(l.post_id, @nil)
+					AND COALESCE(o.bda_post_id, @nil) = COALESCE(l.bda_post_id, @nil)
+					AND COALESCE(o.comment_id, @nil) = COALESCE(l.comment_id, @nil)
+					AND (o.created_at < l.created_at OR (COALESCE(o.created_at, 0) = COALESCE(l.created_at, 0) AND o.id < l.id))
+				)`, sql.Named("nil", nilUUID)).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{Table: "likes", ID: row.ID, Detail: fmt.Sprintf("user %s already liked this resource", row.UserID)})
+			}
+
+			return withCheck("duplicate-likes", issues), nil
+		},
+		repair: deleteRows,
+	}
+}
+
+// topicPostsCountCheck find topics whose posts counter does not match their posts
+func topicPostsCountCheck() check {
+	return check{
+		name:        "topic-posts-count",
+		description: "topics whose posts counter is not their number of posts",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID         string
+				PostsCount int
+				Actual     int
+			}{}
+
+			err := db.Raw(`SELECT id, posts_count, actual FROM (
+					SELECT t.id AS id, t.posts_count AS posts_count, (SELECT COUNT(*) FROM posts p WHERE p.topic_id = t.id) AS actual
+					FROM topics t
+				) counts WHERE posts_count <> actual`).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{Table: "topics", ID: row.ID, Detail: fmt.Sprintf("posts count is %d instead of %d", row.PostsCount, row.Actual)})
+			}
+
+			return withCheck("topic-posts-count", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec("UPDATE topics SET posts_count = (SELECT COUNT(*) FROM posts p WHERE p.topic_id = topics.id) WHERE id IN ?", ids(issues))
+			return result.RowsAffected, result.Error
+		},
+	}
+}
+
+// commentRepliesCountCheck find comments whose replies counter does not match their replies
+func commentRepliesCountCheck() check {
+	return check{
+		name:        "comment-replies-count",
+		description: "comments whose replies counter is not their number of replies",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID           string
+				RepliesCount int
+				Actual       int
+			}{}
+
+			err := db.Raw(`SELECT id, replies_count, actual FROM (
+					SELECT c.id AS id, c.replies_count AS replies_count, (SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id) AS actual
+					FROM comments c
+				) counts WHERE replies_count <> actual`).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{Table: "comments", ID: row.ID, Detail: fmt.Sprintf("replies count is %d instead of %d", row.RepliesCount, row.Actual)})
+			}
+
+			return withCheck("comment-replies-count", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec("UPDATE comments SET replies_count = (SELECT COUNT(*) FROM comments r WHERE r.parent_id = comments.id) WHERE id IN ?", ids(issues))
+			return result.RowsAffected, result.Error
+		},
+	}
+}
+
+// commentPathsCheck find comments created before replies which have no thread path,
+// they are listed out of order in threads
+func commentPathsCheck() check {
+	return check{
+		name:        "comment-paths",
+		description: "comments which are not replies and have no thread path",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			var commentIDs []string
+			err := db.Table("comments").
+				Where("parent_id IS NULL AND COALESCE(path, '') = ''").
+				Pluck("id", &commentIDs).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, id := range commentIDs {
+				issues = append(issues, Issue{Table: "comments", ID: id, Detail: "comment without thread path"})
+			}
+
+			return withCheck("comment-paths", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			comments := []models.Comment{}
+			err := tx.Find(&comments, "id IN ?", ids(issues)).Error
+			if err != nil {
+				return 0, err
+			}
+
+			var repaired int64
+			for _, comment := range comments {
+				result := tx.Exec("UPDATE comments SET path = ? WHERE id = ?", models.CommentPathSegment(&comment), comment.ID)
+				if result.Error != nil {
+					return repaired, result.Error
+				}
+
+				repaired += result.RowsAffected
+			}
+
+			return repaired, nil
+		},
+	}
+}
+
+// tagUsesCountCheck find tags whose uses counter does not match their taggings
+func tagUsesCountCheck() check {
+	return check{
+		name:        "tag-uses-count",
+		description: "tags whose uses counter is not their number of taggings",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID        string
+				UsesCount int
+				Actual    int
+			}{}
+
+			err := db.Raw(`SELECT id, uses_count, actual FROM (
+					SELECT t.id AS id, t.uses_count AS uses_count, (SELECT COUNT(*) FROM taggings g WHERE g.tag_id = t.id) AS actual
+					FROM tags t
+				) counts WHERE uses_count <> actual`).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{Table: "tags", ID: row.ID, Detail: fmt.Sprintf("uses count is %d instead of %d", row.UsesCount, row.Actual)})
+			}
+
+			return withCheck("tag-uses-count", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec("UPDATE tags SET uses_count = (SELECT COUNT(*) FROM taggings g WHERE g.tag_id = tags.id) WHERE id IN ?", ids(issues))
+			return result.RowsAffected, result.Error
+		},
+	}
+}
+
+// userFollowCountsCheck find users whose followers or following counters do not match
+// their follows
+func userFollowCountsCheck() check {
+	return check{
+		name:        "user-follow-counts",
+		description: "users whose followers or following counters are not their number of follows",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			rows := []struct {
+				ID                   string
+				FollowersCount       int
+				FollowingCount       int
+				ActualFollowersCount int
+				ActualFollowingCount int
+			}{}
+
+			err := db.Raw(`SELECT * FROM (
+					SELECT u.id AS id, u.followers_count AS followers_count, u.following_count AS following_count,
+						(SELECT COUNT(*) FROM follows f WHERE f.followed_id = u.id) AS actual_followers_count,
+						(SELECT COUNT(*) FROM follows f WHERE f.user_id = u.id) AS actual_following_count
+					FROM users u
+				) counts WHERE followers_count <> actual_followers_count OR following_count <> actual_following_count`).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+
+			issues := []Issue{}
+			for _, row := range rows {
+				issues = append(issues, Issue{
+					Table: "users",
+					ID:    row.ID,
+					Detail: fmt.Sprintf("followers count is %d instead of %d, following count is %d instead of %d",
+						row.FollowersCount, row.ActualFollowersCount, row.FollowingCount, row.ActualFollowingCount),
+				})
+			}
+
+			return withCheck("user-follow-counts", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			result := tx.Exec(`UPDATE users SET
+					followers_count = (SELECT COUNT(*) FROM follows f WHERE f.followed_id = users.id),
+					following_count = (SELECT COUNT(*) FROM follows f WHERE f.user_id = users.id)
+				WHERE id IN ?`, ids(issues))
+			return result.RowsAffected, result.Error
+		},
+	}
+}
+
+// staleSearchDocumentsCheck find the search documents of resources which do not exist, they
+// are still returned by the search
+func staleSearchDocumentsCheck() check {
+	return check{
+		name:        "stale-search-documents",
+		description: "search documents whose resource does not exist",
+		find: func(db *gorm.DB) ([]Issue, error) {
+			issues := []Issue{}
+
+			// the index is created by the server, it may not exist yet
+			if !db.Migrator().HasTable("search_documents") {
+				return issues, nil
+			}
+
+			for _, searchTable := range searchTables {
+				var documentIDs []string
+				err := db.Table("search_documents AS d").
+					Where("d.kind = ?", searchTable.kind).
+					Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s r WHERE r.id = d.id)", searchTable.table)).
+					Pluck("d.id", &documentIDs).Error
+				if err != nil {
+					return nil, err
+				}
+
+				for _, id := range documentIDs {
+					issues = append(issues, Issue{Table: "search_documents", ID: id, Detail: fmt.Sprintf("%s %q does not exist in %s", searchTable.kind, id, searchTable.table)})
+				}
+			}
+
+			return withCheck("stale-search-documents", issues), nil
+		},
+		repair: func(tx *gorm.DB, issues []Issue) (int64, error) {
+			// the FTS5 index only exists when sqlite is built with FTS5
+			hasIndex := tx.Migrator().HasTable("search_index")
+
+			var repaired int64
+			for _, searchTable := range searchTables {
+				stale := fmt.Sprintf("kind = ? AND NOT EXISTS (SELECT 1 FROM %s r WHERE r.id = search_documents.id)", searchTable.table)
+
+				if hasIndex {
+					err := tx.Exec("DELETE FROM search_index WHERE rowid IN (SELECT row_id FROM search_documents WHERE "+stale+")", searchTable.kind).Error
+					if err != nil {
+						return repaired, err
+					}
+				}
+
+				result := tx.Exec("DELETE FROM search_documents WHERE "+stale, searchTable.kind)
+				if result.Error != nil {
+					return repaired, result.Error
+				}
+
+				repaired += result.RowsAffected
+			}
+
+			return repaired, nil
+		},
+	}
+}
+
+// deleteRows delete the rows of the issues, the search documents of the deleted resources
+// are removed by staleSearchDocumentsCheck
+func deleteRows(tx *gorm.DB, issues []Issue) (int64, error) {
+	byTable := map[string][]string{}
+	for _, issue := range issues {
+		byTable[issue.Table] = append(byTable[issue.Table], issue.ID)
+	}
+
+	var deleted int64
+	for table, rowIDs := range byTable {
+		result := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id IN ?", table), rowIDs)
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+
+		deleted += result.RowsAffected
+	}
+
+	return deleted, nil
+}
+
+// withCheck set the check name of issues
+func withCheck(name string, issues []Issue) []Issue {
+	for i := range issues {
+		issues[i].Check = name
+	}
+
+	return issues
+}
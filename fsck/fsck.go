@@ -0,0 +1,151 @@
+package fsck
+
+import (
+	"fmt"
+
+	"github.com/ada-social-network/api/repository"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Issue is an integrity problem on a row
+type Issue struct {
+	Check  string `json:"check"`
+	Table  string `json:"table"`
+	ID     string `json:"id"`
+	Detail string `json:"detail"`
+}
+
+// CheckReport is the result of a check
+type CheckReport struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Issues      []Issue `json:"issues"`
+	// Repairable is false when the issues can only be fixed by hand
+	Repairable bool `json:"repairable"`
+	// Repaired is the number of rows changed by the repair
+	Repaired int64 `json:"repaired"`
+}
+
+// Report is the result of all the checks
+type Report struct {
+	Checks []CheckReport `json:"checks"`
+	Issues int           `json:"issues"`
+	// Repair is true when the repairs have been run
+	Repair bool `json:"repair"`
+	// DryRun is true when the repairs have been rolled back
+	DryRun bool `json:"dryRun"`
+}
+
+// Options define how the checks are run
+type Options struct {
+	// Repair fixes the issues found
+	Repair bool
+	// DryRun runs the repairs and rolls them back, to know what would be changed
+	DryRun bool
+}
+
+// check find integrity issues and optionally repair them
+type check struct {
+	name        string
+	description string
+	find        func(db *gorm.DB) ([]Issue, error)
+	// repair is nil when issues can not be fixed automatically
+	repair func(tx *gorm.DB, issues []Issue) (int64, error)
+}
+
+// errDryRun is used to roll back the transaction of a dry run
+var errDryRun = fmt.Errorf("dry run")
+
+// Run all the checks. Repairs are run in a single transaction and each check
+// sees the repairs of the previous ones, e.g. likes of the comments deleted
+// because their bda post does not exist are deleted too. The DB is checked as
+// it is, its schema must be up to date.
+func Run(db *gorm.DB, options Options) (*Report, error) {
+	err := repository.CheckSchema(db)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Repair: options.Repair, DryRun: options.Repair && options.DryRun}
+
+	run := func(tx *gorm.DB) error {
+		for _, c := range checks() {
+			issues, err := c.find(tx)
+			if err != nil {
+				return fmt.Errorf("check %s: %w", c.name, err)
+			}
+
+			checkReport := CheckReport{
+				Name:        c.name,
+				Description: c.description,
+				Issues:      issues,
+				Repairable:  c.repair != nil,
+			}
+
+			if options.Repair && c.repair != nil && len(issues) > 0 {
+				checkReport.Repaired, err = c.repair(tx, issues)
+				if err != nil {
+					return fmt.Errorf("repair %s: %w", c.name, err)
+				}
+			}
+
+			report.Checks = append(report.Checks, checkReport)
+			report.Issues += len(issues)
+		}
+
+		return nil
+	}
+
+	if !options.Repair {
+		err := run(db)
+		return report, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := run(tx)
+		if err != nil {
+			return err
+		}
+
+		if options.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Unrepaired returns the number of issues left after the run
+func (r *Report) Unrepaired() int {
+	if !r.Repair || r.DryRun {
+		return r.Issues
+	}
+
+	count := 0
+	for _, c := range r.Checks {
+		if !c.Repairable {
+			count += len(c.Issues)
+		}
+	}
+
+	return count
+}
+
+// nilUUID is the value of an unset optional reference
+var nilUUID = uuid.Nil.String()
+
+// ids returns the ids of the rows of issues
+func ids(issues []Issue) []string {
+	result := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		result = append(result, issue.ID)
+	}
+
+	return result
+}
@@ -0,0 +1,210 @@
+package fsck
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/search"
+	"github.com/ada-social-network/api/seed"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func initCorruptedDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(models.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := seed.DefaultOptions()
+	options.Now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	options.Likes = 0
+
+	_, err = seed.Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := &models.Comment{}
+	db.First(comment, "target_type = ?", models.BdaPostComment)
+
+	bdaPost := &models.BdaPost{}
+	db.First(bdaPost, "id = ?", comment.TargetID)
+
+	user := &models.User{}
+	db.First(user)
+
+	statements := []string{
+		// a bda post deleted without its comments
+		"DELETE FROM bda_posts WHERE id = '" + bdaPost.ID.String() + "'",
+		// a like on a comment of the deleted bda post, given twice
+		"INSERT INTO likes (id, user_id, comment_id) VALUES ('80a08d36-cfea-4898-aee3-6902fa562f01', '" + user.ID.String() + "', '" + comment.ID.String() + "')",
+		"INSERT INTO likes (id, user_id, comment_id) VALUES ('80a08d36-cfea-4898-aee3-6902fa562f02', '" + user.ID.String() + "', '" + comment.ID.String() + "')",
+		// a user of a deleted promo
+		"UPDATE users SET promo_id = '80a08d36-cfea-4898-aee3-6902fa562f03' WHERE id = '" + user.ID.String() + "'",
+		// a follow of a deleted user, not counted
+		"INSERT INTO follows (id, user_id, followed_id) VALUES ('80a08d36-cfea-4898-aee3-6902fa562f04', '" + user.ID.String() + "', '80a08d36-cfea-4898-aee3-6902fa562f05')",
+		// a subscription to a deleted topic
+		"INSERT INTO subscriptions (id, user_id, target_type, target_id) VALUES ('80a08d36-cfea-4898-aee3-6902fa562f06', '" + user.ID.String() + "', 'topic', '80a08d36-cfea-4898-aee3-6902fa562f07')",
+		// a tag counting a deleted tagging
+		"INSERT INTO tags (id, name, uses_count) VALUES ('80a08d36-cfea-4898-aee3-6902fa562f08', 'fsck', 1)",
+		// a comment created before replies, and a wrong replies counter
+		"UPDATE comments SET path = '', replies_count = replies_count + 1 WHERE id = (SELECT id FROM comments WHERE parent_id IS NULL AND target_id <> '" + bdaPost.ID.String() + "' LIMIT 1)",
+		// a wrong posts counter
+		"UPDATE topics SET posts_count = posts_count + 1 WHERE id IN (SELECT id FROM topics LIMIT 1)",
+	}
+
+	for _, statement := range statements {
+		err = db.Exec(statement).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func issuesByCheck(report *Report) map[string]int {
+	result := map[string]int{}
+	for _, c := range report.Checks {
+		if len(c.Issues) > 0 {
+			result[c.Name] = len(c.Issues)
+		}
+	}
+
+	return result
+}
+
+func TestRun(t *testing.T) {
+	db := initCorruptedDB(t)
+
+	report, err := Run(db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := issuesByCheck(report)
+	for _, name := range []string{"orphan-comments-bda_posts", "duplicate-likes", "orphan-users-promo_id", "topic-posts-count", "orphan-follows-followed_id", "orphan-subscriptions-topics", "user-follow-counts", "tag-uses-count", "comment-replies-count", "comment-paths"} {
+		if got[name] == 0 {
+			t.Errorf("Run should report %s, got:%v", name, got)
+		}
+	}
+
+	if got["orphan-likes-comment_id"] != 0 {
+		t.Errorf("Run should not report likes of existing comments, got:%v", got)
+	}
+
+	// a dry run does not change anything
+	report, err = Run(db, Options{Repair: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Unrepaired() == 0 {
+		t.Error("Run in dry run mode should leave the issues")
+	}
+
+	report, err = Run(db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issuesByCheck(report)) == 0 {
+		t.Error("Run in dry run mode should roll back the repairs")
+	}
+
+	// likes of the deleted comments are repaired with them
+	report, err = Run(db, Options{Repair: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got = issuesByCheck(report)
+	if got["orphan-likes-comment_id"] != 2 {
+		t.Errorf("Run should repair likes of the deleted comments, got:%v", got)
+	}
+
+	report, err = Run(db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Issues != 0 {
+		t.Errorf("Run should have repaired all the issues, got:%v", issuesByCheck(report))
+	}
+}
+
+func TestRunOutdatedSchema(t *testing.T) {
+	db := initCorruptedDB(t)
+
+	err := db.Migrator().DropTable(&models.LinkPreview{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Run(db, Options{Repair: true, DryRun: true})
+	if !errors.Is(err, repository.ErrOutdatedSchema) {
+		t.Errorf("Run on an outdated database want:%v, got:%v", repository.ErrOutdatedSchema, err)
+	}
+
+	if db.Migrator().HasTable(&models.LinkPreview{}) {
+		t.Error("Run should not migrate the database")
+	}
+}
+
+func TestRunStaleSearchDocuments(t *testing.T) {
+	db := initCorruptedDB(t)
+
+	engine := search.NewLikeEngine()
+	_, err := engine.Migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the comments of the deleted bda post are indexed, they are deleted by the repair
+	_, err = search.Reindex(db, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := &models.Comment{}
+	db.First(deleted, "target_type = ? AND target_id NOT IN (SELECT id FROM bda_posts)", models.BdaPostComment)
+
+	report, err := Run(db, Options{Repair: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := issuesByCheck(report)
+	if got["stale-search-documents"] == 0 || got["stale-search-documents"] != got["orphan-comments-bda_posts"] {
+		t.Errorf("Run should remove the deleted comments from the search index, got:%v", got)
+	}
+
+	report, err = Run(db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Issues != 0 {
+		t.Errorf("Run should have repaired all the issues, got:%v", issuesByCheck(report))
+	}
+
+	hits, err := search.Search(db, engine, search.Query{Text: deleted.Content, Kinds: []search.Kind{search.CommentKind}, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hit := range hits[search.CommentKind] {
+		if hit.ID == deleted.ID.String() {
+			t.Errorf("Search should not find the deleted comment %s", hit.ID)
+		}
+	}
+}
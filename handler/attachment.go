@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	// errInvalidAttachment is returned when an attachment is not a media of the author
+	errInvalidAttachment = errors.New("the attachments must be media uploaded by the author")
+	// errTooManyAttachments is returned when a resource has too many attachments
+	errTooManyAttachments = fmt.Errorf("a resource can have at most %d attachments", models.MaxAttachments)
+)
+
+// attachmentExtensions are the extensions of the default names of the attachments by
+// content type
+var attachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// isAttachmentError check if an error is due to invalid attachments
+func isAttachmentError(err error) bool {
+	return errors.Is(err, errInvalidAttachment) || errors.Is(err, errTooManyAttachments)
+}
+
+// saveAttachments replace the attachments of a resource by the given ones, in their
+// order, with the metadata of their media. The existing attachments are kept when none
+// are given (nil). It returns the attachments of the resource.
+func saveAttachments(repositories *repository.Repositories, sourceType models.AttachmentSource, sourceID uuid.UUID, userID uuid.UUID, attachments []models.Attachment) ([]models.Attachment, error) {
+	if attachments == nil {
+		existing := &[]models.Attachment{}
+		err := repositories.Attachments.ListAttachmentsBySource(existing, sourceType, sourceID.String())
+
+		return *existing, err
+	}
+
+	if len(attachments) > models.MaxAttachments {
+		return nil, errTooManyAttachments
+	}
+
+	err := repositories.Attachments.DeleteAttachmentsBySource(sourceType, sourceID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	saved := []models.Attachment{}
+	for position, given := range attachments {
+		uploaded := &models.Media{}
+
+		err := repositories.Media.GetMediaByID(uploaded, given.MediaID.String())
+		if err != nil {
+			if errors.Is(err, repository.ErrMediaNotFound) {
+				return nil, errInvalidAttachment
+			}
+
+			return nil, err
+		}
+
+		if !uuid.Equal(uploaded.UserID, userID) {
+			return nil, errInvalidAttachment
+		}
+
+		attachment := models.Attachment{
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			UserID:      userID,
+			MediaID:     uploaded.ID,
+			Position:    position,
+			Name:        attachmentName(given.Name, uploaded.ContentType),
+			AltText:     strings.TrimSpace(given.AltText),
+			ContentType: uploaded.ContentType,
+			Size:        uploaded.Size,
+			Width:       uploaded.Width,
+			Height:      uploaded.Height,
+		}
+
+		err = repositories.Attachments.CreateAttachment(&attachment)
+		if err != nil {
+			return nil, err
+		}
+
+		saved = append(saved, attachment)
+	}
+
+	return saved, nil
+}
+
+// attachmentName returns the file name of an attachment without path separators nor
+// control characters, a default name is given when it is empty
+func attachmentName(name string, contentType string) string {
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+
+		return r
+	}, name))
+
+	if name == "" || strings.Trim(name, ".") == "" {
+		return "attachment" + attachmentExtensions[contentType]
+	}
+
+	return name
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestSaveAttachments(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := uuid.NewV4()
+	alan := uuid.NewV4()
+	picture := &models.Media{UserID: ada, Hash: "picture", ContentType: "image/png", Size: 42, Width: 40, Height: 20}
+	document := &models.Media{UserID: ada, Hash: "document", ContentType: "application/pdf", Size: 1024}
+	other := &models.Media{UserID: alan, Hash: "other", ContentType: "image/png"}
+	db.Create(picture)
+	db.Create(document)
+	db.Create(other)
+
+	postID := uuid.NewV4()
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	save := func(attachments []models.Attachment) ([]models.Attachment, error) {
+		var saved []models.Attachment
+		err := unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+			var err error
+			saved, err = saveAttachments(repositories, models.PostAttachment, postID, ada, attachments)
+			return err
+		})
+
+		return saved, err
+	}
+
+	saved, err := save([]models.Attachment{
+		{MediaID: document.ID, Name: "../report.pdf"},
+		{MediaID: picture.ID, AltText: " a red square "},
+	})
+	if err != nil {
+		t.Fatalf("saveAttachments = %v", err)
+	}
+
+	if len(saved) != 2 || saved[0].Position != 0 || saved[1].Position != 1 {
+		t.Fatalf("saveAttachments got %+v, want 2 attachments in order", saved)
+	}
+	if saved[0].Name != ".._report.pdf" || saved[0].ContentType != "application/pdf" || saved[0].Size != 1024 {
+		t.Errorf("saveAttachments got %+v, want the document metadata", saved[0])
+	}
+	if saved[1].Name != "attachment.png" || saved[1].AltText != "a red square" || saved[1].Width != 40 {
+		t.Errorf("saveAttachments got %+v, want the picture metadata with a default name", saved[1])
+	}
+
+	t.Run("keep", func(t *testing.T) {
+		kept, err := save(nil)
+		if err != nil || len(kept) != 2 || !uuid.Equal(kept[0].MediaID, document.ID) {
+			t.Errorf("saveAttachments without attachments got %+v %v, want the existing ones", kept, err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tooMany := make([]models.Attachment, models.MaxAttachments+1)
+		for i := range tooMany {
+			tooMany[i].MediaID = picture.ID
+		}
+
+		tests := []struct {
+			name        string
+			attachments []models.Attachment
+			want        error
+		}{
+			{name: "media of another user", attachments: []models.Attachment{{MediaID: other.ID}}, want: errInvalidAttachment},
+			{name: "unknown media", attachments: []models.Attachment{{MediaID: uuid.NewV4()}}, want: errInvalidAttachment},
+			{name: "too many", attachments: tooMany, want: errTooManyAttachments},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := save(test.attachments)
+				if !errors.Is(err, test.want) {
+					t.Errorf("saveAttachments = %v, want %v", err, test.want)
+				}
+			})
+		}
+
+		var count int64
+		db.Model(&models.Attachment{}).Where("source_id = ?", postID).Count(&count)
+		if count != 2 {
+			t.Errorf("saveAttachments with invalid attachments left %v attachments, want 2", count)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		replaced, err := save([]models.Attachment{})
+		if err != nil || len(replaced) != 0 {
+			t.Errorf("saveAttachments with no attachments got %+v %v, want none", replaced, err)
+		}
+
+		var count int64
+		db.Model(&models.Attachment{}).Where("source_id = ?", postID).Count(&count)
+		if count != 0 {
+			t.Errorf("saveAttachments kept %v attachments, want 0", count)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	httpError "github.com/ada-social-network/api/error"
 	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
 	"github.com/ada-social-network/api/repository"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
@@ -12,25 +13,44 @@ import (
 
 // BdaPostHandler is a struct to define bda post handler
 type BdaPostHandler struct {
-	repository *repository.BdaPostRepository
+	repository repository.BdaPostStore
+	unitOfWork repository.UnitOfWork
 }
 
 // NewBdaPostHandler is a factory for bda post handler
-func NewBdaPostHandler(repository *repository.BdaPostRepository) *BdaPostHandler {
-	return &BdaPostHandler{repository: repository}
+func NewBdaPostHandler(repository repository.BdaPostStore, unitOfWork repository.UnitOfWork) *BdaPostHandler {
+	return &BdaPostHandler{repository: repository, unitOfWork: unitOfWork}
 }
 
 // ListBdaPost respond a list of bda posts
 func (bp *BdaPostHandler) ListBdaPost(c *gin.Context) {
+	page, err := GetPage(c, models.BdaPostFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.BdaPostRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	bdaPosts := &[]models.BdaPost{}
 
-	err := bp.repository.ListAllBdaPosts(bdaPosts)
+	info, err := bp.repository.ListBdaPosts(bdaPosts, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, bdaPosts)
+	items, err := representation.Render(bp.repository, collectionItems(bdaPosts))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreateBdaPost create a bda post
@@ -51,21 +71,95 @@ func (bp *BdaPostHandler) CreateBdaPost(c *gin.Context) {
 
 	bdaPost.UserID = user.ID
 
-	err = bp.repository.CreateBdaPost(bdaPost)
+	err = bp.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.BdaPosts.CreateBdaPost(bdaPost)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.BdaPostMention, bdaPost.ID, bdaPost.UserID, bdaPost.Content)
+		if err != nil {
+			return err
+		}
+
+		err = syncTags(repositories, models.BdaPostTag, bdaPost.ID, bdaPost.Content, nil)
+		if err != nil {
+			return err
+		}
+
+		bdaPost.Attachments, err = saveAttachments(repositories, models.BdaPostAttachment, bdaPost.ID, bdaPost.UserID, bdaPost.Attachments)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.BdaFeedChannel, realtime.Created, bdaPostResource, bdaPost)
+	})
 	if err != nil {
+		if isAttachmentError(err) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, bdaPost)
+	RespondResource(c, bdaPost.Base, bdaPost)
 }
 
 // DeleteBdaPost delete a specific bda post
 func (bp *BdaPostHandler) DeleteBdaPost(c *gin.Context) {
 	id, _ := c.Params.Get("id")
 
-	err := bp.repository.DeleteBdaPostByID(id)
+	err := bp.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			bdaPost := &models.BdaPost{}
+			err := repositories.BdaPosts.GetBdaPostByID(bdaPost, id)
+
+			return bdaPost.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		err = repositories.BdaPosts.DeleteBdaPostByID(id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Mentions.DeleteMentionsBySource(models.BdaPostMention, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTargetComments(repositories, models.BdaPostComment, id)
+		if err != nil {
+			return err
+		}
+
+		err = removeTags(repositories, models.BdaPostTag, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Attachments.DeleteAttachmentsBySource(models.BdaPostAttachment, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Polls.DeletePollsByTarget(models.BdaPostPoll, id)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.BdaFeedChannel, realtime.Deleted, bdaPostResource, deletedData(id, nil))
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		if errors.Is(err, repository.ErrBdaPostNotFound) {
 			httpError.NotFound(c, "comment", id, err)
 			return
@@ -80,21 +174,33 @@ func (bp *BdaPostHandler) DeleteBdaPost(c *gin.Context) {
 
 // GetBdaPost get a specific bda post
 func (bp *BdaPostHandler) GetBdaPost(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.BdaPostRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	id, _ := c.Params.Get("id")
 
 	bdaPost := &models.BdaPost{}
 
-	err := bp.repository.GetBdaPostByID(bdaPost, id)
+	err = bp.repository.GetBdaPostByID(bdaPost, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrBdaPostNotFound) {
 			httpError.NotFound(c, "bdaPost", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, bdaPost)
+	resource, err := representation.RenderOne(bp.repository, bdaPost)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, bdaPost.Base, resource)
 }
 
 // UpdateBdaPost update a specific bda post
@@ -106,25 +212,67 @@ func (bp *BdaPostHandler) UpdateBdaPost(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, repository.ErrBdaPostNotFound) {
 			httpError.NotFound(c, "bdaPost", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
+	if !CheckIfMatch(c, bdaPost.Base) {
+		return
+	}
+
+	// the author of the bda post can not be updated
+	stored := *bdaPost
+
 	err = c.ShouldBindJSON(bdaPost)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = bp.repository.UpdateBdaPost(bdaPost)
+	bdaPost.Version = stored.Version
+	bdaPost.UserID = stored.UserID
+
+	err = bp.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.BdaPosts.UpdateBdaPost(bdaPost)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.BdaPostMention, bdaPost.ID, bdaPost.UserID, bdaPost.Content)
+		if err != nil {
+			return err
+		}
+
+		err = syncTags(repositories, models.BdaPostTag, bdaPost.ID, bdaPost.Content, nil)
+		if err != nil {
+			return err
+		}
+
+		bdaPost.Attachments, err = saveAttachments(repositories, models.BdaPostAttachment, bdaPost.ID, bdaPost.UserID, bdaPost.Attachments)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.BdaFeedChannel, realtime.Updated, bdaPostResource, bdaPost)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if isAttachmentError(err) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, bdaPost)
+	RespondResource(c, bdaPost.Base, bdaPost)
 }
 
 // LikeBdaPostResponse defines the Like response for a BdaPost
@@ -179,13 +327,41 @@ func (bp *BdaPostHandler) CreateBdaPostLike(c *gin.Context) {
 		return
 	}
 
-	err = bp.repository.CreateLike(like)
+	err = bp.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.BdaPosts.CreateLike(like)
+		if err != nil {
+			return err
+		}
+
+		err = publish(repositories, realtime.BdaFeedChannel, realtime.Created, likeResource, createBdaPostLikeResponse(*like))
+		if err != nil {
+			return err
+		}
+
+		bdaPost := &models.BdaPost{}
+		err = repositories.BdaPosts.GetBdaPostByID(bdaPost, bdaPostID)
+		if err != nil {
+			if errors.Is(err, repository.ErrBdaPostNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		return notify(repositories, &models.Notification{
+			UserID:     bdaPost.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.BdaPostTarget,
+			TargetID:   bdaPost.ID,
+			ActorID:    user.ID,
+		})
+	})
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, createBdaPostLikeResponse(*like))
+	RespondResource(c, like.Base, createBdaPostLikeResponse(*like))
 }
 
 // ListBdaPostLikes get likes of a bda post
@@ -198,7 +374,19 @@ func (bp *BdaPostHandler) ListBdaPostLikes(c *gin.Context) {
 		return
 	}
 
-	err = bp.repository.ListAllPostsByBdaPostID(likes, bdaPostID)
+	page, err := GetPage(c, models.LikeFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.LikeRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	info, err := bp.repository.ListLikesByBdaPostID(likes, bdaPostID, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
@@ -223,14 +411,55 @@ func (bp *BdaPostHandler) ListBdaPostLikes(c *gin.Context) {
 		likesResponse = append(likesResponse, createBdaPostLikeResponse(like))
 	}
 
-	c.JSON(200, NewLikeCollection(likesResponse, isLikedByCurrentUser))
+	items, err := representation.Render(bp.repository, likesResponse)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewLikeCollection(items, info, isLikedByCurrentUser))
 }
 
 // DeleteBdaPostLike delete a specific like
 func (bp *BdaPostHandler) DeleteBdaPostLike(c *gin.Context) {
+	bdaPostID, _ := c.Params.Get("id")
 	id, _ := c.Params.Get("likeId")
 
-	err := bp.repository.DeleteLikeByID(id)
+	err := bp.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		like := &models.Like{}
+		err := repositories.Likes.GetLikeByID(like, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.BdaPosts.DeleteLikeByID(id)
+		if err != nil {
+			return err
+		}
+
+		err = publish(repositories, realtime.BdaFeedChannel, realtime.Deleted, likeResource, deletedData(id, gin.H{"bdaPostId": bdaPostID}))
+		if err != nil {
+			return err
+		}
+
+		bdaPost := &models.BdaPost{}
+		err = repositories.BdaPosts.GetBdaPostByID(bdaPost, bdaPostID)
+		if err != nil {
+			if errors.Is(err, repository.ErrBdaPostNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		return unnotify(repositories, &models.Notification{
+			UserID:     bdaPost.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.BdaPostTarget,
+			TargetID:   bdaPost.ID,
+			ActorID:    like.UserID,
+		})
+	})
 	if err != nil {
 		if errors.Is(err, repository.ErrLikeNotFound) {
 			httpError.NotFound(c, "like", id, err)
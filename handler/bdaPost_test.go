@@ -2,7 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
@@ -21,22 +24,86 @@ func TestListBdaPostComments(t *testing.T) {
 		Value: "80a08d36-cfea-4898-aee3-6902fa562f1d"},
 	}
 
-	tx := db.Create(&models.Comment{BdaPostID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d")})
+	tx := db.Create(&models.Comment{TargetType: models.BdaPostComment, TargetID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d")})
 	if tx.Error != nil {
 		t.Error("ListBdaPostComments response should not have an error: %w", tx.Error)
 	}
 
 	commentRepository := repository.NewCommentRepository(db)
-	NewCommentHandler(commentRepository).ListBdaPostComments(ctx)
+	NewCommentHandler(commentRepository, repository.NewUnitOfWork(db), DefaultCommentMaxDepth).ListBdaPostComments(ctx)
 
-	got := &[]models.Comment{}
+	got := &Collection{}
 	_ = json.Unmarshal(res.Body.Bytes(), got)
 
-	if len(*got) == 0 {
+	if len(got.Items) == 0 {
 		t.Error("ListBdaPostComments response should not be empty")
 	}
 }
 
+func TestListBdaPostCommentsPages(t *testing.T) {
+	db := commonTesting.InitDB(&models.Comment{})
+	bdaPostID := uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f2e")
+	createdAt := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		// comments created at the same time are ordered by id
+		tx := db.Create(&models.Comment{Base: models.Base{CreatedAt: createdAt.Add(time.Duration(i/2) * time.Second)}, TargetType: models.BdaPostComment, TargetID: bdaPostID})
+		if tx.Error != nil {
+			t.Fatalf("ListBdaPostComments comment should be created: %v", tx.Error)
+		}
+	}
+
+	ids := map[string]bool{}
+	after := ""
+	for pages := 1; ; pages++ {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = []gin.Param{{Key: "id", Value: bdaPostID.String()}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?limit=2&after="+after, nil)
+
+		NewCommentHandler(repository.NewCommentRepository(db), repository.NewUnitOfWork(db), DefaultCommentMaxDepth).ListBdaPostComments(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("ListBdaPostComments code = %v, want 200", res.Code)
+		}
+
+		got := &Collection{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		if got.Total != 5 || got.Count != len(got.Items) {
+			t.Errorf("ListBdaPostComments got total = %v and count = %v", got.Total, got.Count)
+		}
+
+		for _, item := range got.Items {
+			ids[item.(map[string]interface{})["id"].(string)] = true
+		}
+
+		if got.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("ListBdaPostComments got %v pages, want 3", pages)
+			}
+			break
+		}
+
+		after = got.NextCursor
+	}
+
+	if len(ids) != 5 {
+		t.Errorf("ListBdaPostComments got %v comments, want 5", len(ids))
+	}
+}
+
+func TestListBdaPostCommentsInvalidCursor(t *testing.T) {
+	db := commonTesting.InitDB(&models.Comment{})
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?after=invalid", nil)
+
+	NewCommentHandler(repository.NewCommentRepository(db), repository.NewUnitOfWork(db), DefaultCommentMaxDepth).ListBdaPostComments(ctx)
+
+	if res.Code != 400 {
+		t.Errorf("ListBdaPostComments code = %v, want 400", res.Code)
+	}
+}
+
 func TestCreateBdaPostComment(t *testing.T) {
 	type args struct {
 		comment models.Comment
@@ -107,7 +174,12 @@ func TestCreateBdaPostComment(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			db := commonTesting.InitDB(&models.Comment{})
+			db := commonTesting.InitAllDB()
+
+			if tt.args.bdaPost != nil {
+				db.FirstOrCreate(tt.args.bdaPost)
+			}
+
 			res, ctx, _ := commonTesting.InitHTTPTest()
 
 			commonTesting.AddRequestWithBodyToContext(ctx, tt.args.comment)
@@ -119,7 +191,7 @@ func TestCreateBdaPostComment(t *testing.T) {
 			}
 
 			commentRepository := repository.NewCommentRepository(db)
-			NewCommentHandler(commentRepository).CreateBdaPostComment(ctx)
+			NewCommentHandler(commentRepository, repository.NewUnitOfWork(db), DefaultCommentMaxDepth).CreateBdaPostComment(ctx)
 
 			comment := &models.Comment{}
 			_ = json.Unmarshal(res.Body.Bytes(), comment)
@@ -137,18 +209,27 @@ func TestCreateBdaPostComment(t *testing.T) {
 }
 
 func TestDeleteBdaPostComment(t *testing.T) {
-	db := commonTesting.InitDB(&models.Comment{})
+	db := commonTesting.InitAllDB()
+
 	res, ctx, _ := commonTesting.InitHTTPTest()
 	id := uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b")
 
+	bdaPostID := uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d")
+
 	comment := &models.Comment{
-		Base:    models.Base{ID: id},
-		Content: "lorem ipsum",
+		Base:       models.Base{ID: id},
+		TargetType: models.BdaPostComment,
+		TargetID:   bdaPostID,
+		Content:    "lorem ipsum",
 	}
 
 	db.Create(comment)
 
 	ctx.Params = gin.Params{
+		{
+			Key:   "id",
+			Value: bdaPostID.String(),
+		},
 		{
 			Key:   "commentId",
 			Value: id.String(),
@@ -156,7 +237,7 @@ func TestDeleteBdaPostComment(t *testing.T) {
 	}
 
 	commentRepository := repository.NewCommentRepository(db)
-	NewCommentHandler(commentRepository).DeleteBdaPostComment(ctx)
+	NewCommentHandler(commentRepository, repository.NewUnitOfWork(db), DefaultCommentMaxDepth).DeleteComment(ctx)
 
 	if res.Code != 204 {
 		t.Errorf("DeleteComment want:%d, got:%d", 204, res.Code)
@@ -192,9 +273,15 @@ func TestGetBdaPostComment(t *testing.T) {
 					Base: models.Base{
 						ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b"),
 					},
-					Content: "Lorem ipsum",
+					TargetType: models.BdaPostComment,
+					TargetID:   uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d"),
+					Content:    "Lorem ipsum",
 				},
 				params: gin.Params{
+					{
+						Key:   "id",
+						Value: "80a08d36-cfea-4898-aee3-6902fa562f1d",
+					},
 					{
 						Key:   "commentId",
 						Value: "80a08d36-cfea-4898-aee3-6902fa562f0b",
@@ -205,6 +292,30 @@ func TestGetBdaPostComment(t *testing.T) {
 				code: 200,
 			},
 		},
+		{
+			name: "comment of another resource",
+			args: args{
+				comment: &models.Comment{
+					Base:       models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b")},
+					TargetType: models.BdaPostComment,
+					TargetID:   uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d"),
+					Content:    "Lorem ipsum",
+				},
+				params: gin.Params{
+					{
+						Key:   "id",
+						Value: "99999999-9999-9999-9999-999999999999",
+					},
+					{
+						Key:   "commentId",
+						Value: "80a08d36-cfea-4898-aee3-6902fa562f0b",
+					},
+				},
+			},
+			want: want{
+				code: 404,
+			},
+		},
 		{
 			name: "not found",
 			args: args{
@@ -234,7 +345,7 @@ func TestGetBdaPostComment(t *testing.T) {
 			ctx.Params = tt.args.params
 
 			commentRepository := repository.NewCommentRepository(db)
-			NewCommentHandler(commentRepository).GetBdaPostComment(ctx)
+			NewCommentHandler(commentRepository, repository.NewUnitOfWork(db), DefaultCommentMaxDepth).GetComment(ctx)
 
 			if res.Code != tt.want.code {
 				t.Errorf("GetCommentHandler want:%d, got:%d", tt.want.code, res.Code)
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	// errAlreadyBlocked is returned when the current user already blocked a user
+	errAlreadyBlocked = errors.New("user already blocked by this user")
+	// errBlockSelf is returned when the current user tries to block themselves
+	errBlockSelf = errors.New("you can not block yourself")
+)
+
+// BlockHandler is a struct to define block handler
+type BlockHandler struct {
+	repository repository.BlockStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewBlockHandler is a factory for block handler
+func NewBlockHandler(repository repository.BlockStore, unitOfWork repository.UnitOfWork) *BlockHandler {
+	return &BlockHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// ListBlocks respond a list of the users blocked by the current user
+func (b *BlockHandler) ListBlocks(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetPage(c, models.BlockFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.BlockRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	blocks := &[]models.Block{}
+
+	info, err := b.repository.ListBlocksByUserID(blocks, user.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(b.repository, collectionItems(blocks))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// BlockUser make the current user block a specific user
+func (b *BlockHandler) BlockUser(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	blockedID, _ := c.Params.Get("id")
+
+	blockedUUID, err := uuid.FromString(blockedID)
+	if err != nil {
+		httpError.NotFound(c, "user", blockedID, err)
+		return
+	}
+
+	if uuid.Equal(user.ID, blockedUUID) {
+		httpError.BadRequest(c, errBlockSelf)
+		return
+	}
+
+	block := &models.Block{UserID: user.ID, BlockedID: blockedUUID}
+
+	err = b.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Users.GetUserByID(&models.User{}, blockedID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Blocks.GetBlock(&models.Block{}, user.ID.String(), blockedID)
+		if err == nil {
+			return errAlreadyBlocked
+		}
+
+		if !errors.Is(err, repository.ErrBlockNotFound) {
+			return err
+		}
+
+		return repositories.Blocks.CreateBlock(block)
+	})
+	if err != nil {
+		if errors.Is(err, errAlreadyBlocked) {
+			httpError.Conflict(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrUserNotFound) {
+			httpError.NotFound(c, "user", blockedID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, block.Base, block)
+}
+
+// UnblockUser make the current user stop blocking a specific user
+func (b *BlockHandler) UnblockUser(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	blockedID, _ := c.Params.Get("id")
+
+	err = b.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		block := &models.Block{}
+
+		err := repositories.Blocks.GetBlock(block, user.ID.String(), blockedID)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Blocks.DeleteBlockByID(block.ID.String())
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrBlockNotFound) {
+			httpError.NotFound(c, "block", blockedID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
@@ -11,25 +11,44 @@ import (
 
 //CategoryHandler is a struct to define category handler
 type CategoryHandler struct {
-	repository *repository.CategoryRepository
+	repository repository.CategoryStore
+	unitOfWork repository.UnitOfWork
 }
 
 // NewCategoryHandler is a factory for category handler
-func NewCategoryHandler(repository *repository.CategoryRepository) *CategoryHandler {
-	return &CategoryHandler{repository: repository}
+func NewCategoryHandler(repository repository.CategoryStore, unitOfWork repository.UnitOfWork) *CategoryHandler {
+	return &CategoryHandler{repository: repository, unitOfWork: unitOfWork}
 }
 
 // ListCategories respond a list of categories
 func (ca *CategoryHandler) ListCategories(c *gin.Context) {
+	page, err := GetPage(c, models.CategoryFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.CategoryRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	categories := &[]models.Category{}
 
-	err := ca.repository.ListAllCategories(categories)
+	info, err := ca.repository.ListCategories(categories, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(ca.repository, collectionItems(categories))
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, categories)
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreateCategory create a category
@@ -48,15 +67,37 @@ func (ca *CategoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, category)
+	RespondResource(c, category.Base, category)
 }
 
 // DeleteCategory delete a specific category
 func (ca *CategoryHandler) DeleteCategory(c *gin.Context) {
 	id, _ := c.Params.Get("id")
 
-	err := ca.repository.DeleteCategoryByID(id)
+	err := ca.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			category := &models.Category{}
+			err := repositories.Categories.GetCategoryByID(category, id)
+
+			return category.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Categories.DeleteCategoryByID(id)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Subscriptions.DeleteSubscriptionsByTarget(models.CategorySubscription, id)
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		if errors.Is(err, repository.ErrCategoryNotFound) {
 			httpError.NotFound(c, "category", id, err)
 			return
@@ -71,21 +112,33 @@ func (ca *CategoryHandler) DeleteCategory(c *gin.Context) {
 
 // GetCategory get a specific category
 func (ca *CategoryHandler) GetCategory(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.CategoryRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	id, _ := c.Params.Get("id")
 
 	category := &models.Category{}
 
-	err := ca.repository.GetCategoryByID(category, id)
+	err = ca.repository.GetCategoryByID(category, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrCategoryNotFound) {
 			httpError.NotFound(c, "category", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, category)
+	resource, err := representation.RenderOne(ca.repository, category)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, category.Base, resource)
 }
 
 // UpdateCategory update a specific category
@@ -97,23 +150,36 @@ func (ca *CategoryHandler) UpdateCategory(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, repository.ErrCategoryNotFound) {
 			httpError.NotFound(c, "category", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
+	if !CheckIfMatch(c, category.Base) {
+		return
+	}
+
+	version := category.Version
+
 	err = c.ShouldBindJSON(category)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
+	category.Version = version
+
 	err = ca.repository.UpdateCategory(category)
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, category)
+	RespondResource(c, category.Base, category)
 }
@@ -2,35 +2,97 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 
 	httpError "github.com/ada-social-network/api/error"
 	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
 	"github.com/ada-social-network/api/repository"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
 )
 
+const (
+	// DefaultCommentMaxDepth is the default maximum depth of replies, deeper replies are
+	// attached to the comment at the maximum depth
+	DefaultCommentMaxDepth = 5
+	// DefaultTreeReplies is the number of replies of each comment of a tree when no
+	// replies limit is requested
+	DefaultTreeReplies = 3
+	// MaxTreeReplies is the maximum number of replies of each comment of a tree
+	MaxTreeReplies = 20
+)
+
+const (
+	// flatView lists comments without their replies
+	flatView = "flat"
+	// treeView lists comments with their first replies nested in them
+	treeView = "tree"
+	// threadView lists comments followed by their replies, depth first
+	threadView = "thread"
+)
+
+// errInvalidReply is an error when a comment can not reply to another comment
+var errInvalidReply = errors.New("invalid reply")
+
+// commentThreadOrder is the order of a thread, each comment followed by its replies
+var commentThreadOrder = []repository.Sort{{Field: models.Field{Column: "path", Type: models.StringField}}}
+
+// commentableTargets are the notification targets of the commented resources
+var commentableTargets = map[models.Commentable]models.NotificationTarget{
+	models.PostComment:    models.PostTarget,
+	models.TopicComment:   models.TopicTarget,
+	models.BdaPostComment: models.BdaPostTarget,
+}
+
+// commentLists are the lists of comments of each view
+type commentLists struct {
+	flat   func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error)
+	tree   func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error)
+	thread func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error)
+}
+
 // CommentHandler is a struct to define comment handler
 type CommentHandler struct {
-	repository *repository.CommentRepository
+	repository repository.CommentStore
+	unitOfWork repository.UnitOfWork
+	maxDepth   int
 }
 
-// NewCommentHandler is a factory comment handler
-func NewCommentHandler(repository *repository.CommentRepository) *CommentHandler {
-	return &CommentHandler{repository: repository}
+// NewCommentHandler is a factory comment handler, replies are nested at most maxDepth
+// levels deep
+func NewCommentHandler(repository repository.CommentStore, unitOfWork repository.UnitOfWork, maxDepth int) *CommentHandler {
+	return &CommentHandler{repository: repository, unitOfWork: unitOfWork, maxDepth: maxDepth}
 }
 
-// CreateBdaPostComment create a comment
+// CreateBdaPostComment create a comment of a bda post
 func (co *CommentHandler) CreateBdaPostComment(c *gin.Context) {
+	co.createComment(c, models.BdaPostComment)
+}
+
+// CreatePostComment create a comment of a forum post
+func (co *CommentHandler) CreatePostComment(c *gin.Context) {
+	co.createComment(c, models.PostComment)
+}
+
+// CreateTopicComment create a comment of a topic
+func (co *CommentHandler) CreateTopicComment(c *gin.Context) {
+	co.createComment(c, models.TopicComment)
+}
+
+// createComment create a comment of the resource of the id parameter
+func (co *CommentHandler) createComment(c *gin.Context, targetType models.Commentable) {
 	user, err := GetCurrentUser(c)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	bdapostID, _ := c.Params.Get("id")
+	targetID, _ := c.Params.Get("id")
 
-	bdaPostUUID, err := uuid.FromString(bdapostID)
+	targetUUID, err := uuid.FromString(targetID)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
@@ -43,54 +105,212 @@ func (co *CommentHandler) CreateBdaPostComment(c *gin.Context) {
 		return
 	}
 
-	comment.BdaPostID = bdaPostUUID
+	comment.TargetType = targetType
+	comment.TargetID = targetUUID
 	comment.UserID = user.ID
 
-	err = co.repository.CreateComment(comment)
+	repliedTo, parent, err := co.findParent(comment.ParentID, targetType, targetUUID)
 	if err != nil {
+		if errors.Is(err, errInvalidReply) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, comment)
-}
+	comment.SetParent(parent)
 
-// UpdateBdaPostComment update a specific comment
-func (co *CommentHandler) UpdateBdaPostComment(c *gin.Context) {
-	commentID, _ := c.Params.Get("commentId")
-	comment := &models.Comment{}
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		authorID, err := findCommentableAuthor(repositories, targetType, targetID)
+		if err != nil {
+			return err
+		}
 
-	err := co.repository.GetCommentByID(comment, commentID)
+		err = repositories.Comments.CreateComment(comment)
+		if err != nil {
+			return err
+		}
+
+		if parent != nil {
+			err = repositories.Comments.UpdateRepliesCount(parent.ID.String(), 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = syncMentions(repositories, models.CommentMention, comment.ID, comment.UserID, comment.Content)
+		if err != nil {
+			return err
+		}
+
+		comment.Attachments, err = saveAttachments(repositories, models.CommentAttachment, comment.ID, comment.UserID, comment.Attachments)
+		if err != nil {
+			return err
+		}
+
+		err = publishComment(repositories, comment, realtime.Created, commentResource, comment)
+		if err != nil {
+			return err
+		}
+
+		if repliedTo != nil {
+			err = notify(repositories, &models.Notification{
+				UserID:     repliedTo.UserID,
+				Type:       models.ReplyNotification,
+				TargetType: models.CommentTarget,
+				TargetID:   repliedTo.ID,
+				ActorID:    user.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// the author replied to is already notified
+		if repliedTo != nil && uuid.Equal(repliedTo.UserID, authorID) {
+			return nil
+		}
+
+		return notify(repositories, &models.Notification{
+			UserID:     authorID,
+			Type:       models.CommentNotification,
+			TargetType: commentableTargets[targetType],
+			TargetID:   targetUUID,
+			ActorID:    user.ID,
+		})
+	})
 	if err != nil {
-		if errors.Is(err, repository.ErrCommentNotFound) {
-			httpError.NotFound(c, "comment", commentID, err)
+		if errors.Is(err, repository.ErrPostNotFound) || errors.Is(err, repository.ErrTopicNotFound) || errors.Is(err, repository.ErrBdaPostNotFound) {
+			httpError.NotFound(c, string(targetType), targetID, err)
+			return
+		}
 
+		if isAttachmentError(err) {
+			httpError.BadRequest(c, err)
+			return
 		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = c.ShouldBindJSON(comment)
+	RespondResource(c, comment.Base, comment)
+}
+
+// UpdateComment update a specific comment of a resource
+func (co *CommentHandler) UpdateComment(c *gin.Context) {
+	comment := &models.Comment{}
+
+	if !co.findComment(c, comment) {
+		return
+	}
+
+	if comment.IsDeleted() {
+		httpError.NotFound(c, "comment", comment.ID.String(), repository.ErrCommentNotFound)
+		return
+	}
+
+	if !CheckIfMatch(c, comment.Base) {
+		return
+	}
+
+	// the place of the comment in its thread can not be updated
+	stored := *comment
+
+	err := c.ShouldBindJSON(comment)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = co.repository.UpdateComment(comment)
+	comment.Version = stored.Version
+	comment.TargetType = stored.TargetType
+	comment.TargetID = stored.TargetID
+	comment.ParentID = stored.ParentID
+	comment.Depth = stored.Depth
+	comment.RepliesCount = stored.RepliesCount
+	comment.Path = stored.Path
+
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Comments.UpdateComment(comment)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.CommentMention, comment.ID, comment.UserID, comment.Content)
+		if err != nil {
+			return err
+		}
+
+		comment.Attachments, err = saveAttachments(repositories, models.CommentAttachment, comment.ID, comment.UserID, comment.Attachments)
+		if err != nil {
+			return err
+		}
+
+		return publishComment(repositories, comment, realtime.Updated, commentResource, comment)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if isAttachmentError(err) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, comment)
+	RespondResource(c, comment.Base, comment)
 }
 
-// DeleteBdaPostComment delete a specific comment
-func (co *CommentHandler) DeleteBdaPostComment(c *gin.Context) {
-	commentID, _ := c.Params.Get("commentId")
+// DeleteComment delete a specific comment of a resource, a comment with replies is kept
+// in its thread as a deleted placeholder
+func (co *CommentHandler) DeleteComment(c *gin.Context) {
+	comment := &models.Comment{}
+	commentID := c.Param("commentId")
+
+	if !co.findComment(c, comment) {
+		return
+	}
+
+	if comment.IsDeleted() {
+		httpError.NotFound(c, "comment", commentID, repository.ErrCommentNotFound)
+		return
+	}
 
-	err := co.repository.DeleteCommentByID(commentID)
+	err := co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			current := &models.Comment{}
+			err := repositories.Comments.GetCommentByID(current, commentID)
+
+			return current.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		err = deleteComment(repositories, comment)
+		if err != nil {
+			return err
+		}
+
+		return publishComment(repositories, comment, realtime.Deleted, commentResource, deletedData(commentID, gin.H{
+			"targetType": comment.TargetType,
+			"targetId":   comment.TargetID,
+		}))
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		if errors.Is(err, repository.ErrCommentNotFound) {
 			httpError.NotFound(c, "comment", commentID, err)
 			return
@@ -103,37 +323,495 @@ func (co *CommentHandler) DeleteBdaPostComment(c *gin.Context) {
 	c.JSON(204, nil)
 }
 
-// GetBdaPostComment get a specific comment
-func (co *CommentHandler) GetBdaPostComment(c *gin.Context) {
-	commentID, _ := c.Params.Get("commentId")
+// GetComment get a specific comment of a resource
+func (co *CommentHandler) GetComment(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.CommentRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
 
 	comment := &models.Comment{}
 
+	if !co.findComment(c, comment) {
+		return
+	}
+
+	resource, err := representation.RenderOne(co.repository, comment)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, comment.Base, resource)
+}
+
+// findComment get the comment of the commentId parameter, it responds not found and
+// returns false when it is not a comment of the resource of the id parameter
+func (co *CommentHandler) findComment(c *gin.Context, comment *models.Comment) bool {
+	commentID, _ := c.Params.Get("commentId")
+	targetID, _ := c.Params.Get("id")
+
 	err := co.repository.GetCommentByID(comment, commentID)
+	if err == nil && comment.TargetID.String() != targetID {
+		err = repository.ErrCommentNotFound
+	}
 	if err != nil {
 		if errors.Is(err, repository.ErrCommentNotFound) {
 			httpError.NotFound(c, "comment", commentID, err)
-
+			return false
 		}
+
 		httpError.Internal(c, err)
-		return
+		return false
 	}
 
-	c.JSON(200, comment)
+	return true
 }
 
 // ListBdaPostComments get comments of a bda post
 func (co *CommentHandler) ListBdaPostComments(c *gin.Context) {
+	co.listTargetComments(c, models.BdaPostComment)
+}
+
+// ListPostComments get comments of a forum post
+func (co *CommentHandler) ListPostComments(c *gin.Context) {
+	co.listTargetComments(c, models.PostComment)
+}
+
+// ListTopicComments get comments of a topic
+func (co *CommentHandler) ListTopicComments(c *gin.Context) {
+	co.listTargetComments(c, models.TopicComment)
+}
+
+// listTargetComments get comments of the resource of the id parameter. All the comments
+// are listed by default, view=tree lists the comments which are not replies with their
+// first replies nested in them and view=thread lists all the comments each followed by
+// its replies.
+func (co *CommentHandler) listTargetComments(c *gin.Context, targetType models.Commentable) {
+	id, _ := c.Params.Get("id")
+
+	co.listComments(c, commentLists{
+		flat: func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error) {
+			return co.repository.ListCommentsByTarget(comments, targetType, id, page)
+		},
+		tree: func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error) {
+			return co.repository.ListTopLevelCommentsByTarget(comments, targetType, id, page)
+		},
+		thread: func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error) {
+			return co.repository.ListCommentThread(comments, targetType, id, nil, page)
+		},
+	})
+}
+
+// ListCommentReplies get the replies to a comment. The direct replies are listed by
+// default, view=tree nests their first replies in them and view=thread lists the replies
+// at any depth, each followed by its replies.
+func (co *CommentHandler) ListCommentReplies(c *gin.Context) {
 	id, _ := c.Params.Get("id")
+	parent := &models.Comment{}
+
+	err := co.repository.GetCommentByID(parent, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			httpError.NotFound(c, "comment", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	replies := func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error) {
+		return co.repository.ListCommentsByParentID(comments, id, page)
+	}
+
+	co.listComments(c, commentLists{
+		flat: replies,
+		tree: replies,
+		thread: func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error) {
+			return co.repository.ListCommentThread(comments, parent.TargetType, parent.TargetID.String(), parent, page)
+		},
+	})
+}
+
+// listComments respond the list of comments of the requested view
+func (co *CommentHandler) listComments(c *gin.Context, lists commentLists) {
+	view := c.DefaultQuery("view", flatView)
+
+	var page repository.Page
+	var err error
+	var list func(comments *[]models.Comment, page repository.Page) (repository.PageInfo, error)
+
+	switch view {
+	case flatView:
+		page, err = GetPage(c, models.CommentFields)
+		list = lists.flat
+	case treeView:
+		page, err = GetPage(c, models.CommentFields)
+		list = lists.tree
+	case threadView:
+		if c.Query("sort") != "" {
+			err = fmt.Errorf("a thread can not be sorted")
+			break
+		}
+
+		page, err = GetSortedPage(c, models.CommentFields, commentThreadOrder)
+		list = lists.thread
+	default:
+		err = fmt.Errorf("unknown view %q", view)
+	}
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	repliesLimit, err := getTreeRepliesLimit(c)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.CommentRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	comments := &[]models.Comment{}
 
-	err := co.repository.ListAllCommentsByBdaPostID(comments, id)
+	info, err := list(comments, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	if view != treeView {
+		items, err := representation.Render(co.repository, collectionItems(comments))
+		if err != nil {
+			httpError.Internal(c, err)
+			return
+		}
+
+		c.JSON(200, NewCollection(items, info))
+		return
+	}
+
+	roots := len(*comments)
+
+	err = co.listTreeReplies(comments, repliesLimit)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, comments)
+	items, err := representation.Render(co.repository, collectionItems(comments))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err = commentTree(*comments, items, roots)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// getTreeRepliesLimit get the number of replies of each comment of a tree from the
+// replies query parameter
+func getTreeRepliesLimit(c *gin.Context) (int, error) {
+	value := c.Query("replies")
+	if value == "" {
+		return DefaultTreeReplies, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("replies must be a positive number")
+	}
+
+	if limit > MaxTreeReplies {
+		return MaxTreeReplies, nil
+	}
+
+	return limit, nil
+}
+
+// listTreeReplies add to comments the first replies of each comment, and the first
+// replies of these replies until the deepest replies
+func (co *CommentHandler) listTreeReplies(comments *[]models.Comment, limit int) error {
+	if limit == 0 {
+		return nil
+	}
+
+	parents := *comments
+	for len(parents) > 0 {
+		parentIDs := []string{}
+		for _, parent := range parents {
+			if parent.RepliesCount > 0 {
+				parentIDs = append(parentIDs, parent.ID.String())
+			}
+		}
+
+		replies := &[]models.Comment{}
+		err := co.repository.ListFirstRepliesByParentIDs(replies, parentIDs, limit)
+		if err != nil {
+			return err
+		}
+
+		*comments = append(*comments, *replies...)
+		parents = *replies
+	}
+
+	return nil
+}
+
+// commentTree nest the rendered replies in the rendered comment they reply to, the
+// first roots comments are the top of the tree
+func commentTree(comments []models.Comment, rendered []interface{}, roots int) ([]interface{}, error) {
+	nodes := []map[string]interface{}{}
+	err := convert(rendered, &nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]map[string]interface{}{}
+	for i, comment := range comments {
+		nodes[i]["replies"] = []interface{}{}
+		byID[comment.ID.String()] = nodes[i]
+	}
+
+	tree := []interface{}{}
+	for i, comment := range comments {
+		if i < roots {
+			tree = append(tree, nodes[i])
+			continue
+		}
+
+		parent := byID[comment.ParentID.String()]
+		parent["replies"] = append(parent["replies"].([]interface{}), nodes[i])
+	}
+
+	return tree, nil
+}
+
+// findParent find the comment replied to and the parent of a reply to a resource. The
+// parent is the comment replied to, or its closest parent above the maximum depth.
+func (co *CommentHandler) findParent(parentID *uuid.UUID, targetType models.Commentable, targetID uuid.UUID) (*models.Comment, *models.Comment, error) {
+	if parentID == nil {
+		return nil, nil, nil
+	}
+
+	repliedTo := &models.Comment{}
+	err := co.repository.GetCommentByID(repliedTo, parentID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return nil, nil, fmt.Errorf("%w: comment %s does not exist", errInvalidReply, parentID)
+		}
+
+		return nil, nil, err
+	}
+
+	if repliedTo.TargetType != targetType || !uuid.Equal(repliedTo.TargetID, targetID) {
+		return nil, nil, fmt.Errorf("%w: comment %s is not a comment of %s %s", errInvalidReply, parentID, targetType, targetID)
+	}
+
+	if repliedTo.IsDeleted() {
+		return nil, nil, fmt.Errorf("%w: comment %s has been deleted", errInvalidReply, parentID)
+	}
+
+	parent := repliedTo
+	for parent.Depth >= co.maxDepth {
+		if parent.ParentID == nil {
+			return repliedTo, nil, nil
+		}
+
+		grandParent := &models.Comment{}
+		err = co.repository.GetCommentByID(grandParent, parent.ParentID.String())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parent = grandParent
+	}
+
+	return repliedTo, parent, nil
+}
+
+// findCommentableAuthor get the author of a commented resource, it returns the not found
+// error of the resource when it does not exist
+func findCommentableAuthor(repositories *repository.Repositories, targetType models.Commentable, targetID string) (uuid.UUID, error) {
+	switch targetType {
+	case models.PostComment:
+		post := &models.Post{}
+		err := repositories.Posts.GetPostByID(post, targetID)
+		return post.UserID, err
+	case models.TopicComment:
+		topic := &models.Topic{}
+		err := repositories.Topics.GetTopicByID(topic, targetID)
+		return topic.UserID, err
+	default:
+		bdaPost := &models.BdaPost{}
+		err := repositories.BdaPosts.GetBdaPostByID(bdaPost, targetID)
+		return bdaPost.UserID, err
+	}
+}
+
+// publishComment publish an event about a comment or its likes on the channel of the
+// commented resource: the bda feed or the topic of the post or topic commented
+func publishComment(repositories *repository.Repositories, comment *models.Comment, eventType realtime.EventType, resource string, data interface{}) error {
+	var channel string
+
+	switch comment.TargetType {
+	case models.BdaPostComment:
+		channel = realtime.BdaFeedChannel
+	case models.TopicComment:
+		channel = realtime.TopicChannel(comment.TargetID.String())
+	case models.PostComment:
+		post := &models.Post{}
+		err := repositories.Posts.GetPostByID(post, comment.TargetID.String())
+		if err != nil {
+			if errors.Is(err, repository.ErrPostNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		channel = realtime.TopicChannel(post.TopicID.String())
+	}
+
+	return publish(repositories, channel, eventType, resource, data)
+}
+
+// deleteTargetComments delete all the comments of a resource with their likes and mentions
+func deleteTargetComments(repositories *repository.Repositories, targetType models.Commentable, targetID string) error {
+	comments := &[]models.Comment{}
+	err := repositories.Comments.ListAllCommentsByTarget(comments, targetType, targetID)
+	if err != nil {
+		return err
+	}
+
+	for _, comment := range *comments {
+		err = repositories.Likes.DeleteLikesByCommentID(comment.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Mentions.DeleteMentionsBySource(models.CommentMention, comment.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Attachments.DeleteAttachmentsBySource(models.CommentAttachment, comment.ID.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return repositories.Comments.DeleteCommentsByTarget(targetType, targetID)
+}
+
+// deleteComment delete a comment with its likes, mentions, attachments and the
+// notifications about it. A comment with replies is kept as a deleted placeholder, it is
+// deleted with its last reply.
+func deleteComment(repositories *repository.Repositories, comment *models.Comment) error {
+	err := repositories.Likes.DeleteLikesByCommentID(comment.ID.String())
+	if err != nil {
+		return err
+	}
+
+	err = withdrawNotifications(repositories, models.CommentTarget, comment.ID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Mentions.DeleteMentionsBySource(models.CommentMention, comment.ID.String())
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Attachments.DeleteAttachmentsBySource(models.CommentAttachment, comment.ID.String())
+	if err != nil {
+		return err
+	}
+
+	if comment.RepliesCount > 0 {
+		return repositories.Comments.MarkCommentDeleted(comment.ID.String())
+	}
+
+	err = repositories.Comments.DeleteCommentByID(comment.ID.String())
+	if err != nil {
+		return err
+	}
+
+	if comment.ParentID == nil {
+		return nil
+	}
+
+	parent := &models.Comment{}
+	err = repositories.Comments.GetCommentByID(parent, comment.ParentID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	err = repositories.Comments.UpdateRepliesCount(parent.ID.String(), -1)
+	if err != nil {
+		return err
+	}
+
+	parent.RepliesCount--
+	if parent.IsDeleted() && parent.RepliesCount <= 0 {
+		return deleteComment(repositories, parent)
+	}
+
+	return nil
+}
+
+// deleteUserComments delete the comments of a user, the deepest replies first so the
+// comments replied to only by the user are not kept as placeholders
+func deleteUserComments(repositories *repository.Repositories, userID string) error {
+	comments := &[]models.Comment{}
+	err := repositories.Comments.ListAllCommentsByUserID(comments, userID)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(*comments, func(i, j int) bool {
+		return (*comments)[i].Depth > (*comments)[j].Depth
+	})
+
+	for _, comment := range *comments {
+		err = repositories.Likes.DeleteLikesByCommentID(comment.ID.String())
+		if err != nil {
+			return err
+		}
+
+		// the replies count changes when replies of the user are deleted
+		current := &models.Comment{}
+		err = repositories.Comments.GetCommentByID(current, comment.ID.String())
+		if err != nil {
+			if errors.Is(err, repository.ErrCommentNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		err = deleteComment(repositories, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // LikeCommentResponse defines the Like response for a BdaPost
@@ -189,13 +867,41 @@ func (co *CommentHandler) CreateCommentLike(c *gin.Context) {
 		return
 	}
 
-	err = co.repository.CreateLike(like)
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Comments.CreateLike(like)
+		if err != nil {
+			return err
+		}
+
+		comment := &models.Comment{}
+		err = repositories.Comments.GetCommentByID(comment, commentID)
+		if err != nil {
+			if errors.Is(err, repository.ErrCommentNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = publishComment(repositories, comment, realtime.Created, likeResource, createCommentLikeResponse(*like))
+		if err != nil {
+			return err
+		}
+
+		return notify(repositories, &models.Notification{
+			UserID:     comment.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.CommentTarget,
+			TargetID:   comment.ID,
+			ActorID:    user.ID,
+		})
+	})
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, createCommentLikeResponse(*like))
+	RespondResource(c, like.Base, createCommentLikeResponse(*like))
 }
 
 // ListCommentLikes get likes of a bda post
@@ -209,7 +915,19 @@ func (co *CommentHandler) ListCommentLikes(c *gin.Context) {
 		return
 	}
 
-	err = co.repository.ListAllPostsByCommentID(likes, commentID)
+	page, err := GetPage(c, models.LikeFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.LikeRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	info, err := co.repository.ListLikesByCommentID(likes, commentID, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
@@ -234,14 +952,55 @@ func (co *CommentHandler) ListCommentLikes(c *gin.Context) {
 		likesResponse = append(likesResponse, createCommentLikeResponse(like))
 	}
 
-	c.JSON(200, NewLikeCollection(likesResponse, isLikedByCurrentUser))
+	items, err := representation.Render(co.repository, likesResponse)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewLikeCollection(items, info, isLikedByCurrentUser))
 }
 
 // DeleteCommentLike delete a specific like
 func (co *CommentHandler) DeleteCommentLike(c *gin.Context) {
+	commentID, _ := c.Params.Get("id")
 	id, _ := c.Params.Get("likeId")
 
-	err := co.repository.DeleteLikeByID(id)
+	err := co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		like := &models.Like{}
+		err := repositories.Likes.GetLikeByID(like, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Comments.DeleteLikeByID(id)
+		if err != nil {
+			return err
+		}
+
+		comment := &models.Comment{}
+		err = repositories.Comments.GetCommentByID(comment, commentID)
+		if err != nil {
+			if errors.Is(err, repository.ErrCommentNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = publishComment(repositories, comment, realtime.Deleted, likeResource, deletedData(id, gin.H{"commentId": comment.ID}))
+		if err != nil {
+			return err
+		}
+
+		return unnotify(repositories, &models.Notification{
+			UserID:     comment.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.CommentTarget,
+			TargetID:   comment.ID,
+			ActorID:    like.UserID,
+		})
+	})
 	if err != nil {
 		if errors.Is(err, repository.ErrLikeNotFound) {
 			httpError.NotFound(c, "like", id, err)
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// commentNode is a comment of a tree view
+type commentNode struct {
+	models.Comment
+	Replies []commentNode `json:"replies"`
+}
+
+func TestCommentReplies(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := &models.User{FirstName: "Ada", LastName: "Byron", Email: "ada@replies.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Mathison", Email: "alan@replies.dev"}
+	db.Create(ada)
+	db.Create(alan)
+
+	bdaPost := &models.BdaPost{UserID: ada.ID, Title: "replies"}
+	other := &models.BdaPost{UserID: ada.ID, Title: "other replies"}
+	db.Create(bdaPost)
+	db.Create(other)
+
+	// replies are nested at most one level deep
+	handler := NewCommentHandler(repository.NewCommentRepository(db), repository.NewUnitOfWork(db), 1)
+
+	create := func(user *models.User, bdaPostID uuid.UUID, parentID *uuid.UUID) (models.Comment, int) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: bdaPostID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, models.Comment{Content: "lorem ipsum", ParentID: parentID})
+
+		handler.CreateBdaPostComment(ctx)
+
+		comment := models.Comment{}
+		_ = json.Unmarshal(res.Body.Bytes(), &comment)
+
+		return comment, res.Code
+	}
+
+	list := func(action gin.HandlerFunc, id string, query string) (*Collection, int) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: id}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+
+		action(ctx)
+
+		got := &Collection{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		return got, res.Code
+	}
+
+	ids := func(collection *Collection) []string {
+		result := []string{}
+		for _, item := range collection.Items {
+			result = append(result, fmt.Sprint(item.(map[string]interface{})["id"]))
+		}
+
+		return result
+	}
+
+	first, _ := create(ada, bdaPost.ID, nil)
+	if first.ContentHTML != "<p>lorem ipsum</p>\n" {
+		t.Errorf("CreateBdaPostComment content html = %q, want the rendered content", first.ContentHTML)
+	}
+
+	reply, code := create(alan, bdaPost.ID, &first.ID)
+	if code != 200 || reply.ParentID == nil || !uuid.Equal(*reply.ParentID, first.ID) || reply.Depth != 1 {
+		t.Fatalf("CreateBdaPostComment reply = %v %v, want a reply to %v", code, reply, first.ID)
+	}
+
+	// a reply deeper than the maximum depth is attached to the parent of the comment replied to
+	deep, _ := create(ada, bdaPost.ID, &reply.ID)
+	if deep.ParentID == nil || !uuid.Equal(*deep.ParentID, first.ID) || deep.Depth != 1 {
+		t.Errorf("CreateBdaPostComment deep reply = %v, want a reply to %v", deep, first.ID)
+	}
+
+	// the author replied to is notified even when the reply is attached to another comment
+	var notifications int64
+	db.Model(&models.Notification{}).Where("user_id = ? AND type = ? AND target_id = ?", alan.ID, models.ReplyNotification, reply.ID).Count(&notifications)
+	if notifications != 1 {
+		t.Errorf("CreateBdaPostComment reply notifications = %v, want 1", notifications)
+	}
+
+	stored := &models.Comment{}
+	db.First(stored, "id = ?", first.ID)
+	if stored.RepliesCount != 2 {
+		t.Errorf("CreateBdaPostComment replies count = %v, want 2", stored.RepliesCount)
+	}
+
+	if _, code = create(alan, other.ID, &first.ID); code != 400 {
+		t.Errorf("CreateBdaPostComment reply in another bda post code = %v, want 400", code)
+	}
+
+	second, _ := create(alan, bdaPost.ID, nil)
+
+	got, _ := list(handler.ListBdaPostComments, bdaPost.ID.String(), "view=thread")
+	want := []string{first.ID.String(), reply.ID.String(), deep.ID.String(), second.ID.String()}
+	if fmt.Sprint(ids(got)) != fmt.Sprint(want) {
+		t.Errorf("ListBdaPostComments thread = %v, want %v", ids(got), want)
+	}
+
+	if _, code = list(handler.ListBdaPostComments, bdaPost.ID.String(), "view=thread&sort=createdAt"); code != 400 {
+		t.Errorf("ListBdaPostComments sorted thread code = %v, want 400", code)
+	}
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Params = gin.Params{{Key: "id", Value: bdaPost.ID.String()}}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?view=tree&replies=1", nil)
+	handler.ListBdaPostComments(ctx)
+
+	tree := &struct {
+		Items []commentNode `json:"items"`
+	}{}
+	_ = json.Unmarshal(res.Body.Bytes(), tree)
+	if len(tree.Items) != 2 || len(tree.Items[0].Replies) != 1 || tree.Items[0].RepliesCount != 2 || !uuid.Equal(tree.Items[0].Replies[0].ID, reply.ID) {
+		t.Errorf("ListBdaPostComments tree = %s, want the first reply in the first comment", res.Body.String())
+	}
+
+	got, _ = list(handler.ListCommentReplies, first.ID.String(), "limit=1")
+	if len(got.Items) != 1 || got.Total != 2 || got.NextCursor == "" {
+		t.Errorf("ListCommentReplies = %v, want the first page of 2 replies", got)
+	}
+
+	got, _ = list(handler.ListCommentReplies, first.ID.String(), "limit=1&after="+got.NextCursor)
+	if fmt.Sprint(ids(got)) != fmt.Sprint([]string{deep.ID.String()}) {
+		t.Errorf("ListCommentReplies second page = %v, want %v", ids(got), deep.ID)
+	}
+
+	// the content is rendered again when it is edited
+	res, ctx, _ = commonTesting.InitHTTPTest()
+	ctx.Params = gin.Params{{Key: "id", Value: bdaPost.ID.String()}, {Key: "commentId", Value: first.ID.String()}}
+	commonTesting.AddRequestWithBodyToContext(ctx, models.Comment{Content: "**lorem** ipsum", ContentHTML: "<script></script>"})
+	handler.UpdateComment(ctx)
+
+	updated := models.Comment{}
+	_ = json.Unmarshal(res.Body.Bytes(), &updated)
+	if res.Code != 200 || updated.ContentHTML != "<p><strong>lorem</strong> ipsum</p>\n" {
+		t.Errorf("UpdateComment = %v %q, want the edited content rendered", res.Code, updated.ContentHTML)
+	}
+
+	remove := func(comment models.Comment) int {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: comment.TargetID.String()}, {Key: "commentId", Value: comment.ID.String()}}
+		ctx.Request = httptest.NewRequest(http.MethodDelete, "/", strings.NewReader(""))
+
+		handler.DeleteComment(ctx)
+
+		return res.Code
+	}
+
+	db.Create(&models.Like{UserID: alan.ID, CommentID: first.ID})
+
+	// a comment with replies is kept as a placeholder
+	if code = remove(first); code != 204 {
+		t.Fatalf("DeleteComment code = %v, want 204", code)
+	}
+
+	stored = &models.Comment{}
+	db.First(stored, "id = ?", first.ID)
+	if !stored.IsDeleted() || stored.Content != "" || stored.ContentHTML != "" || !uuid.Equal(stored.UserID, uuid.Nil) {
+		t.Errorf("DeleteComment comment = %v, want a deleted placeholder", stored)
+	}
+
+	// the likes of the comment and the notifications about it are deleted with it
+	var likes int64
+	db.Model(&models.Like{}).Where("comment_id = ?", first.ID).Count(&likes)
+	db.Model(&models.Notification{}).Where("target_type = ? AND target_id = ?", models.CommentTarget, first.ID).Count(&notifications)
+	if likes != 0 || notifications != 0 {
+		t.Errorf("DeleteComment left %v likes and %v notifications of the placeholder", likes, notifications)
+	}
+
+	if code = remove(first); code != 404 {
+		t.Errorf("DeleteComment placeholder code = %v, want 404", code)
+	}
+
+	if _, code = create(alan, bdaPost.ID, &first.ID); code != 400 {
+		t.Errorf("CreateBdaPostComment reply to a placeholder code = %v, want 400", code)
+	}
+
+	// the placeholder is removed with its last reply
+	remove(reply)
+	remove(deep)
+
+	var count int64
+	db.Model(&models.Comment{}).Where("id IN ?", []string{first.ID.String(), reply.ID.String(), deep.ID.String()}).Count(&count)
+	if count != 0 {
+		t.Errorf("DeleteComment should remove the placeholder with its replies, got %v comments", count)
+	}
+
+	db.Model(&models.Notification{}).Where("target_type = ? AND target_id = ?", models.CommentTarget, reply.ID).Count(&notifications)
+	if notifications != 0 {
+		t.Errorf("DeleteComment left %v notifications of the reply", notifications)
+	}
+}
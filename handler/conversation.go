@@ -0,0 +1,567 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// MaxConversationMembers is the maximum number of members of a group conversation, the
+// user starting it included
+const MaxConversationMembers = 10
+
+var (
+	// errConversationWithSelf is returned when the current user starts a conversation only with themselves
+	errConversationWithSelf = errors.New("you can not start a conversation with yourself")
+	// errTooManyMembers is returned when a conversation is started with too many users
+	errTooManyMembers = fmt.Errorf("a conversation can not have more than %d members", MaxConversationMembers)
+	// errBlockedUser is returned when a user of a conversation blocked the current user or has been blocked by them
+	errBlockedUser = errors.New("a user of the conversation blocked you or has been blocked by you")
+	// errLeaveDirectConversation is returned when the current user leaves a conversation which is not a group
+	errLeaveDirectConversation = errors.New("only a group conversation can be left")
+)
+
+// conversationOrder is the default order of conversations, the most recently active first
+var conversationOrder = []repository.Sort{{Field: models.ConversationFields["lastMessageAt"], Desc: true}}
+
+// messageOrder is the default order of messages, the most recent first
+var messageOrder = []repository.Sort{{Field: models.MessageFields["createdAt"], Desc: true}}
+
+// ConversationHandler is a struct to define conversation handler
+type ConversationHandler struct {
+	repository repository.ConversationStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewConversationHandler is a factory for conversation handler
+func NewConversationHandler(repository repository.ConversationStore, unitOfWork repository.UnitOfWork) *ConversationHandler {
+	return &ConversationHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// ConversationRequest defines the users a conversation is started with
+type ConversationRequest struct {
+	UserIDs []uuid.UUID `json:"userIds" binding:"required,min=1"`
+	Title   string      `json:"title" binding:"max=128"`
+}
+
+// ConversationResponse defines a conversation with its members and the number of messages
+// the current user has not read
+type ConversationResponse struct {
+	models.Conversation
+	Members     []models.ConversationMember `json:"members"`
+	UnreadCount int64                       `json:"unreadCount"`
+}
+
+// ListConversations respond a list of the conversations of the current user
+func (co *ConversationHandler) ListConversations(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetSortedPage(c, models.ConversationFields, conversationOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.ConversationRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	conversations := &[]models.Conversation{}
+
+	info, err := co.repository.ListConversationsByUserID(conversations, user.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	responses, err := createConversationsResponse(co.repository, user.ID.String(), *conversations)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(co.repository, responses)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// GetConversation get a specific conversation of the current user
+func (co *ConversationHandler) GetConversation(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	conversation := &models.Conversation{}
+
+	err = findConversation(co.repository, conversation, &models.ConversationMember{}, id, user.ID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			httpError.NotFound(c, "conversation", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	co.respondConversation(c, user.ID.String(), conversation)
+}
+
+// CreateConversation start a conversation of the current user with other users. A
+// conversation with a single user is a direct conversation, the existing direct
+// conversation with this user is responded if any.
+func (co *ConversationHandler) CreateConversation(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	request := &ConversationRequest{}
+	err = c.ShouldBindJSON(request)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	userIDs := []string{}
+	seen := map[uuid.UUID]bool{user.ID: true}
+	for _, userID := range request.UserIDs {
+		if seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+		userIDs = append(userIDs, userID.String())
+	}
+
+	if len(userIDs) == 0 {
+		httpError.BadRequest(c, errConversationWithSelf)
+		return
+	}
+
+	if len(userIDs)+1 > MaxConversationMembers {
+		httpError.BadRequest(c, errTooManyMembers)
+		return
+	}
+
+	conversation := &models.Conversation{
+		UserID:  user.ID,
+		Title:   request.Title,
+		IsGroup: len(userIDs) > 1,
+	}
+
+	var missingUserID string
+
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		for _, userID := range userIDs {
+			err := repositories.Users.GetUserByID(&models.User{}, userID)
+			if err != nil {
+				missingUserID = userID
+				return err
+			}
+		}
+
+		blocked, err := repositories.Blocks.HasBlockBetween(user.ID.String(), userIDs)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return errBlockedUser
+		}
+
+		if !conversation.IsGroup {
+			err = repositories.Conversations.GetDirectConversation(conversation, user.ID.String(), userIDs[0])
+			if err == nil {
+				return nil
+			}
+
+			if !errors.Is(err, repository.ErrConversationNotFound) {
+				return err
+			}
+		}
+
+		conversation.CreatedAt = time.Now()
+		conversation.LastMessageAt = conversation.CreatedAt
+
+		err = repositories.Conversations.CreateConversation(conversation)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range append([]string{user.ID.String()}, userIDs...) {
+			err = repositories.Conversations.CreateConversationMember(&models.ConversationMember{
+				ConversationID: conversation.ID,
+				UserID:         uuid.FromStringOrNil(userID),
+			})
+			if err != nil {
+				return err
+			}
+
+			// the members are told on their notifications channel to join the new conversation
+			err = publish(repositories, realtime.NotificationsChannel(userID), realtime.Created, conversationResource, conversation)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			httpError.NotFound(c, "user", missingUserID, err)
+			return
+		}
+
+		if errors.Is(err, errBlockedUser) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	co.respondConversation(c, user.ID.String(), conversation)
+}
+
+// LeaveConversation remove the current user from a group conversation, the conversation
+// is deleted with its last member
+func (co *ConversationHandler) LeaveConversation(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		conversation := &models.Conversation{}
+		member := &models.ConversationMember{}
+
+		err := findConversation(repositories.Conversations, conversation, member, id, user.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if !conversation.IsGroup {
+			return errLeaveDirectConversation
+		}
+
+		err = repositories.Conversations.DeleteConversationMemberByID(member.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = publish(repositories, realtime.ConversationChannel(id), realtime.Deleted, conversationMemberResource, deletedData(member.ID.String(), gin.H{
+			"conversationId": member.ConversationID,
+			"userId":         member.UserID,
+		}))
+		if err != nil {
+			return err
+		}
+
+		count, err := repositories.Conversations.CountConversationMembers(id)
+		if err != nil || count > 0 {
+			return err
+		}
+
+		return repositories.Conversations.DeleteConversationByID(id)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			httpError.NotFound(c, "conversation", id, err)
+			return
+		}
+
+		if errors.Is(err, errLeaveDirectConversation) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// ReadConversation mark all the messages of a conversation as read by the current user
+func (co *ConversationHandler) ReadConversation(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	member := &models.ConversationMember{}
+
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		conversation := &models.Conversation{}
+
+		err := findConversation(repositories.Conversations, conversation, member, id, user.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Conversations.MarkConversationRead(member, conversation.LastMessageAt)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.ConversationChannel(id), realtime.Updated, conversationMemberResource, member)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			httpError.NotFound(c, "conversation", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, member)
+}
+
+// ListMessages respond a page of the messages of a conversation of the current user, the
+// most recent first
+func (co *ConversationHandler) ListMessages(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetSortedPage(c, models.MessageFields, messageOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.MessageRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	err = findConversation(co.repository, &models.Conversation{}, &models.ConversationMember{}, id, user.ID.String())
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			httpError.NotFound(c, "conversation", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	messages := &[]models.Message{}
+
+	info, err := co.repository.ListMessagesByConversationID(messages, id, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(co.repository, collectionItems(messages))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// CreateMessage send a message of the current user in a conversation, the messages of a
+// direct conversation can not be sent while one of the users blocked the other
+func (co *ConversationHandler) CreateMessage(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	message := &models.Message{}
+	err = c.ShouldBindJSON(message)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	message.UserID = user.ID
+
+	err = co.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		conversation := &models.Conversation{}
+		member := &models.ConversationMember{}
+
+		err := findConversation(repositories.Conversations, conversation, member, id, user.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if !conversation.IsGroup {
+			members := &[]models.ConversationMember{}
+			err = repositories.Conversations.ListConversationMembers(members, []string{id})
+			if err != nil {
+				return err
+			}
+
+			otherIDs := []string{}
+			for _, other := range *members {
+				if !uuid.Equal(other.UserID, user.ID) {
+					otherIDs = append(otherIDs, other.UserID.String())
+				}
+			}
+
+			blocked, err := repositories.Blocks.HasBlockBetween(user.ID.String(), otherIDs)
+			if err != nil {
+				return err
+			}
+
+			if blocked {
+				return errBlockedUser
+			}
+		}
+
+		message.ConversationID = conversation.ID
+		err = repositories.Conversations.CreateMessage(message)
+		if err != nil {
+			return err
+		}
+
+		message.Attachments, err = saveAttachments(repositories, models.MessageAttachment, message.ID, message.UserID, message.Attachments)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Conversations.UpdateLastMessageAt(id, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		// the messages of the sender are read
+		err = repositories.Conversations.MarkConversationRead(member, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.ConversationChannel(id), realtime.Created, messageResource, message)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationNotFound) {
+			httpError.NotFound(c, "conversation", id, err)
+			return
+		}
+
+		if errors.Is(err, errBlockedUser) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if isAttachmentError(err) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, message.Base, message)
+}
+
+// respondConversation respond a conversation with its members and unread count, the
+// response is not cached as it changes with each message
+func (co *ConversationHandler) respondConversation(c *gin.Context, userID string, conversation *models.Conversation) {
+	responses, err := createConversationsResponse(co.repository, userID, []models.Conversation{*conversation})
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, responses[0])
+}
+
+// findConversation get a conversation and the membership of a user in it, a conversation
+// the user is not a member of is not found
+func findConversation(store repository.ConversationStore, conversation *models.Conversation, member *models.ConversationMember, conversationID string, userID string) error {
+	err := store.GetConversationMember(member, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrConversationMemberNotFound) {
+			return repository.ErrConversationNotFound
+		}
+
+		return err
+	}
+
+	return store.GetConversationByID(conversation, conversationID)
+}
+
+// createConversationsResponse add the members and the unread count of a user to each
+// conversation, they are loaded with a single query each
+func createConversationsResponse(store repository.ConversationStore, userID string, conversations []models.Conversation) ([]interface{}, error) {
+	conversationIDs := []string{}
+	for _, conversation := range conversations {
+		conversationIDs = append(conversationIDs, conversation.ID.String())
+	}
+
+	members := &[]models.ConversationMember{}
+	err := store.ListConversationMembers(members, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byConversation := map[uuid.UUID][]models.ConversationMember{}
+	for _, member := range *members {
+		byConversation[member.ConversationID] = append(byConversation[member.ConversationID], member)
+	}
+
+	unreadCounts, err := store.CountUnreadMessages(userID, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []interface{}{}
+	for _, conversation := range conversations {
+		conversationMembers := byConversation[conversation.ID]
+		if conversationMembers == nil {
+			conversationMembers = []models.ConversationMember{}
+		}
+
+		responses = append(responses, ConversationResponse{
+			Conversation: conversation,
+			Members:      conversationMembers,
+			UnreadCount:  unreadCounts[conversation.ID.String()],
+		})
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestConversations(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@conversations.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@conversations.dev"}
+	grace := &models.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@conversations.dev"}
+	db.Create(ada)
+	db.Create(alan)
+	db.Create(grace)
+
+	unitOfWork := repository.NewUnitOfWork(db)
+	handler := NewConversationHandler(repository.NewConversationRepository(db), unitOfWork)
+	blockHandler := NewBlockHandler(repository.NewBlockRepository(db), unitOfWork)
+
+	call := func(action gin.HandlerFunc, user *models.User, id string, body string) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: id}}
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+		action(ctx)
+
+		return res
+	}
+
+	start := func(user *models.User, body string) (ConversationResponse, int) {
+		res := call(handler.CreateConversation, user, "", body)
+
+		conversation := ConversationResponse{}
+		_ = json.Unmarshal(res.Body.Bytes(), &conversation)
+
+		return conversation, res.Code
+	}
+
+	send := func(user *models.User, conversationID uuid.UUID, content string) int {
+		return call(handler.CreateMessage, user, conversationID.String(), `{"content": "`+content+`"}`).Code
+	}
+
+	list := func(user *models.User) []ConversationResponse {
+		res := call(handler.ListConversations, user, "", "")
+
+		got := &struct {
+			Items []ConversationResponse `json:"items"`
+		}{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		return got.Items
+	}
+
+	direct, code := start(ada, `{"userIds": ["`+alan.ID.String()+`"]}`)
+	if code != 200 || direct.IsGroup || len(direct.Members) != 2 {
+		t.Fatalf("CreateConversation = %v %+v, want a direct conversation with 2 members", code, direct)
+	}
+
+	// the direct conversation between two users is reused
+	again, _ := start(alan, `{"userIds": ["`+ada.ID.String()+`"]}`)
+	if !uuid.Equal(again.ID, direct.ID) {
+		t.Errorf("CreateConversation got %v, want the existing conversation %v", again.ID, direct.ID)
+	}
+
+	group, code := start(ada, `{"userIds": ["`+alan.ID.String()+`", "`+grace.ID.String()+`"], "title": "pair programming"}`)
+	if code != 200 || !group.IsGroup || len(group.Members) != 3 {
+		t.Fatalf("CreateConversation group = %v %+v, want a group with 3 members", code, group)
+	}
+
+	if _, code = start(ada, `{"userIds": ["`+ada.ID.String()+`"]}`); code != 400 {
+		t.Errorf("CreateConversation with yourself code = %v, want 400", code)
+	}
+
+	if _, code = start(ada, `{"userIds": ["`+uuid.NewV4().String()+`"]}`); code != 404 {
+		t.Errorf("CreateConversation with an unknown user code = %v, want 404", code)
+	}
+
+	send(alan, direct.ID, "hello")
+	send(alan, direct.ID, "are you there?")
+	send(ada, direct.ID, "yes")
+	send(grace, group.ID, "hi all")
+
+	conversations := list(ada)
+	if len(conversations) != 2 || !uuid.Equal(conversations[0].ID, group.ID) {
+		t.Fatalf("ListConversations got %+v, want the group first", conversations)
+	}
+
+	// the messages sent before their own message are read by the sender
+	if conversations[0].UnreadCount != 1 || conversations[1].UnreadCount != 0 {
+		t.Errorf("ListConversations unread counts = %v and %v, want 1 and 0", conversations[0].UnreadCount, conversations[1].UnreadCount)
+	}
+
+	if unread := list(alan); unread[1].UnreadCount != 1 {
+		t.Errorf("ListConversations unread count of the direct conversation = %v, want 1", unread[1].UnreadCount)
+	}
+
+	if code = call(handler.ReadConversation, ada, group.ID.String(), "").Code; code != 200 {
+		t.Errorf("ReadConversation code = %v, want 200", code)
+	}
+
+	if conversations = list(ada); conversations[0].UnreadCount != 0 {
+		t.Errorf("ListConversations unread count after read = %v, want 0", conversations[0].UnreadCount)
+	}
+
+	res := call(handler.ListMessages, ada, direct.ID.String(), "")
+	messages := &Collection{}
+	_ = json.Unmarshal(res.Body.Bytes(), messages)
+	if res.Code != 200 || messages.Total != 3 || messages.Items[0].(map[string]interface{})["content"] != "yes" {
+		t.Errorf("ListMessages = %v %s, want the 3 messages, the most recent first", res.Code, res.Body.String())
+	}
+
+	if code = call(handler.ListMessages, grace, direct.ID.String(), "").Code; code != 404 {
+		t.Errorf("ListMessages of another conversation code = %v, want 404", code)
+	}
+
+	// a blocked user can not start a conversation nor send direct messages
+	if code = call(blockHandler.BlockUser, grace, ada.ID.String(), "").Code; code != 200 {
+		t.Fatalf("BlockUser code = %v, want 200", code)
+	}
+
+	if _, code = start(ada, `{"userIds": ["`+grace.ID.String()+`"]}`); code != 403 {
+		t.Errorf("CreateConversation with a user who blocked you code = %v, want 403", code)
+	}
+
+	if code = call(blockHandler.BlockUser, alan, ada.ID.String(), "").Code; code != 200 {
+		t.Fatalf("BlockUser code = %v, want 200", code)
+	}
+
+	if code = send(ada, direct.ID, "hello?"); code != 403 {
+		t.Errorf("CreateMessage to a user who blocked you code = %v, want 403", code)
+	}
+
+	if code = call(blockHandler.UnblockUser, alan, ada.ID.String(), "").Code; code != 204 {
+		t.Errorf("UnblockUser code = %v, want 204", code)
+	}
+
+	if code = send(ada, direct.ID, "hello?"); code != 200 {
+		t.Errorf("CreateMessage after unblock code = %v, want 200", code)
+	}
+
+	if code = call(handler.LeaveConversation, ada, direct.ID.String(), "").Code; code != 400 {
+		t.Errorf("LeaveConversation of a direct conversation code = %v, want 400", code)
+	}
+
+	message := &models.Message{}
+	db.First(message, "conversation_id = ?", group.ID)
+	db.Create(&models.Attachment{SourceType: models.MessageAttachment, SourceID: message.ID, UserID: message.UserID, MediaID: uuid.NewV4()})
+
+	for _, user := range []*models.User{ada, alan, grace} {
+		if code = call(handler.LeaveConversation, user, group.ID.String(), "").Code; code != 204 {
+			t.Errorf("LeaveConversation code = %v, want 204", code)
+		}
+	}
+
+	var count int64
+	db.Model(&models.Message{}).Where("conversation_id = ?", group.ID).Count(&count)
+	if err := db.First(&models.Conversation{}, "id = ?", group.ID).Error; err == nil || count != 0 {
+		t.Errorf("LeaveConversation should delete the group and its messages with its last member")
+	}
+
+	db.Model(&models.Attachment{}).Where("source_type = ? AND source_id = ?", models.MessageAttachment, message.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("LeaveConversation should delete the attachments of the messages of the group, got %v", count)
+	}
+}
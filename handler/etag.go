@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+)
+
+// errPreconditionFailed is an error when the If-Match header does not match the resource
+var errPreconditionFailed = errors.New("if-match header does not match the resource")
+
+// ETag returns the entity tag of a resource, it changes each time the resource is updated
+func ETag(base models.Base) string {
+	return `"` + strconv.Itoa(base.Version) + `"`
+}
+
+// representationETag returns the entity tag of the representation of a resource responded
+// to a request. The query parameters, e.g. ?include= and ?fields=, change the
+// representation of a same version, so their hash follows the version: "3-9f2c01ab".
+func representationETag(c *gin.Context, base models.Base) string {
+	query := c.Request.URL.Query().Encode()
+	if query == "" {
+		return ETag(base)
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(query))
+
+	return fmt.Sprintf(`"%d-%08x"`, base.Version, hash.Sum32())
+}
+
+// matchETag check if an If-None-Match header value contains etag, the comparison is
+// weak and ignores the W/ prefix
+func matchETag(header, etag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			return true
+		}
+
+		if strings.TrimPrefix(value, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchVersion check if an If-Match header value contains an entity tag of the current
+// version of a resource, whatever the representation the client read
+func matchVersion(header string, base models.Base) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			return true
+		}
+
+		// a weak entity tag never matches an If-Match header
+		if !strings.HasPrefix(value, `"`) {
+			continue
+		}
+
+		version := strings.SplitN(strings.Trim(value, `"`), "-", 2)[0]
+		if version == strconv.Itoa(base.Version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckIfMatch check the If-Match header of an update or a delete against the current
+// version of the resource, it responds 412 Precondition Failed and returns false when
+// the resource has been modified since the client read it
+func CheckIfMatch(c *gin.Context, base models.Base) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchVersion(header, base) {
+		return true
+	}
+
+	httpError.PreconditionFailed(c, errPreconditionFailed)
+	return false
+}
+
+// checkPrecondition check the If-Match header of a delete in its transaction, current
+// loads the resource and is only called when the header is given. It returns
+// errPreconditionFailed when the resource has been modified since the client read it.
+func checkPrecondition(c *gin.Context, current func() (models.Base, error)) error {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+
+	base, err := current()
+	if err != nil {
+		return err
+	}
+
+	if !matchVersion(header, base) {
+		return errPreconditionFailed
+	}
+
+	return nil
+}
+
+// RespondResource respond a single resource with its ETag, or 304 Not Modified when
+// the If-None-Match header of a GET request matches the resource
+func RespondResource(c *gin.Context, base models.Base, resource interface{}) {
+	etag := representationETag(c, base)
+	c.Header("ETag", etag)
+
+	header := c.GetHeader("If-None-Match")
+	if header != "" && c.Request.Method == http.MethodGet && matchETag(header, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(200, resource)
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		version  int
+		want     bool
+		wantCode int
+	}{
+		{
+			name:     "without header",
+			version:  2,
+			want:     true,
+			wantCode: 200,
+		},
+		{
+			name:     "same version",
+			ifMatch:  `"2"`,
+			version:  2,
+			want:     true,
+			wantCode: 200,
+		},
+		{
+			name:     "one of the versions",
+			ifMatch:  `"1", "2"`,
+			version:  2,
+			want:     true,
+			wantCode: 200,
+		},
+		{
+			name:     "any version",
+			ifMatch:  `*`,
+			version:  2,
+			want:     true,
+			wantCode: 200,
+		},
+		{
+			name:     "representation of the version",
+			ifMatch:  `"2-0a1b2c3d"`,
+			version:  2,
+			want:     true,
+			wantCode: 200,
+		},
+		{
+			name:     "outdated version",
+			ifMatch:  `"1"`,
+			version:  2,
+			want:     false,
+			wantCode: 412,
+		},
+		{
+			name:     "weak etag",
+			ifMatch:  `W/"2"`,
+			version:  2,
+			want:     false,
+			wantCode: 412,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.ifMatch != "" {
+				ctx.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got := CheckIfMatch(ctx, models.Base{Version: tt.version})
+
+			if got != tt.want {
+				t.Errorf("CheckIfMatch() got = %v, want %v", got, tt.want)
+			}
+
+			if res.Code != tt.wantCode {
+				t.Errorf("CheckIfMatch() code = %v, want %v", res.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRespondResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		ifNoneMatch string
+		wantCode    int
+		wantETag    string
+	}{
+		{
+			name:     "without header",
+			method:   http.MethodGet,
+			wantCode: 200,
+			wantETag: `"3"`,
+		},
+		{
+			name:        "not modified",
+			method:      http.MethodGet,
+			ifNoneMatch: `"3"`,
+			wantCode:    304,
+			wantETag:    `"3"`,
+		},
+		{
+			name:        "weak not modified",
+			method:      http.MethodGet,
+			ifNoneMatch: `W/"3"`,
+			wantCode:    304,
+			wantETag:    `"3"`,
+		},
+		{
+			name:        "modified",
+			method:      http.MethodGet,
+			ifNoneMatch: `"2"`,
+			wantCode:    200,
+			wantETag:    `"3"`,
+		},
+		{
+			name:        "not a read",
+			method:      http.MethodPatch,
+			ifNoneMatch: `"3"`,
+			wantCode:    200,
+			wantETag:    `"3"`,
+		},
+		{
+			name:        "other representation",
+			method:      http.MethodGet,
+			url:         "/?include=user",
+			ifNoneMatch: `"3"`,
+			wantCode:    200,
+			wantETag:    `"3-8957e9c1"`,
+		},
+		{
+			name:        "same representation",
+			method:      http.MethodGet,
+			url:         "/?include=user",
+			ifNoneMatch: `"3-8957e9c1"`,
+			wantCode:    304,
+			wantETag:    `"3-8957e9c1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			url := tt.url
+			if url == "" {
+				url = "/"
+			}
+
+			ctx.Request = httptest.NewRequest(tt.method, url, nil)
+			if tt.ifNoneMatch != "" {
+				ctx.Request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			RespondResource(ctx, models.Base{Version: 3}, map[string]string{})
+			ctx.Writer.WriteHeaderNow()
+
+			if res.Code != tt.wantCode {
+				t.Errorf("RespondResource() code = %v, want %v", res.Code, tt.wantCode)
+			}
+
+			if res.Header().Get("ETag") != tt.wantETag {
+				t.Errorf("RespondResource() etag = %v, want %v", res.Header().Get("ETag"), tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestDeleteIfMatch(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	unitOfWork := repository.NewUnitOfWork(db)
+	promoHandler := NewPromoHandler(repository.NewPromoRepository(db), unitOfWork)
+	categoryHandler := NewCategoryHandler(repository.NewCategoryRepository(db), unitOfWork)
+	mediaHandler := NewMediaHandler(repository.NewMediaRepository(db), unitOfWork, nil, 0)
+	pollHandler := NewPollHandler(repository.NewPollRepository(db), unitOfWork)
+
+	user := &models.User{Email: "if-match@users.dev"}
+	db.Create(user)
+
+	promo := &models.Promo{Name: "Hamilton", StartDate: "2022-01-01", EndDate: "2023-01-01"}
+	category := &models.Category{Name: "Concurrency"}
+	uploaded := &models.Media{UserID: user.ID, Hash: "if-match"}
+	poll := &models.Poll{TargetType: models.TopicPoll, TargetID: uuid.NewV4(), UserID: user.ID, Question: "?"}
+	db.Create(promo)
+	db.Create(category)
+	db.Create(uploaded)
+	db.Create(poll)
+	vote := &models.PollVote{PollID: poll.ID, UserID: user.ID}
+	db.Create(vote)
+
+	// the resources are updated after their etag has been read
+	for _, model := range []interface{}{promo, category, uploaded, vote, poll} {
+		db.Model(model).UpdateColumn("version", 2)
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		ifMatch  string
+		handle   gin.HandlerFunc
+		wantCode int
+	}{
+		{name: "outdated promo", id: promo.ID.String(), ifMatch: `"1"`, handle: promoHandler.DeletePromo, wantCode: 412},
+		{name: "promo", id: promo.ID.String(), ifMatch: `"2"`, handle: promoHandler.DeletePromo, wantCode: 204},
+		{name: "outdated category", id: category.ID.String(), ifMatch: `"1"`, handle: categoryHandler.DeleteCategory, wantCode: 412},
+		{name: "category", id: category.ID.String(), ifMatch: `"2"`, handle: categoryHandler.DeleteCategory, wantCode: 204},
+		{name: "deleted category", id: category.ID.String(), ifMatch: `"2"`, handle: categoryHandler.DeleteCategory, wantCode: 404},
+		{name: "outdated media", id: uploaded.ID.String(), ifMatch: `"1"`, handle: mediaHandler.DeleteMedia, wantCode: 412},
+		{name: "media", id: uploaded.ID.String(), ifMatch: `"2"`, handle: mediaHandler.DeleteMedia, wantCode: 204},
+		{name: "outdated poll vote", id: poll.ID.String(), ifMatch: `"1"`, handle: pollHandler.DeletePollVote, wantCode: 412},
+		{name: "poll vote", id: poll.ID.String(), ifMatch: `"2"`, handle: pollHandler.DeletePollVote, wantCode: 204},
+		{name: "outdated poll", id: poll.ID.String(), ifMatch: `"1"`, handle: pollHandler.DeletePoll, wantCode: 412},
+		{name: "poll", id: poll.ID.String(), ifMatch: `"2"`, handle: pollHandler.DeletePoll, wantCode: 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Request = httptest.NewRequest(http.MethodDelete, "/", nil)
+			ctx.Request.Header.Set("If-Match", tt.ifMatch)
+			ctx.Params = gin.Params{{Key: "id", Value: tt.id}}
+			ctx.Set(middleware.IdentityKey, user)
+
+			tt.handle(ctx)
+			ctx.Writer.WriteHeaderNow()
+
+			if res.Code != tt.wantCode {
+				t.Errorf("Delete code = %v, want %v", res.Code, tt.wantCode)
+			}
+		})
+	}
+}
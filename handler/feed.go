@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"reflect"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// feedOrder is the order of a feed, the newest items first
+var feedOrder = []repository.Sort{{Field: models.FeedFields["createdAt"], Desc: true}}
+
+// feedResources returns an empty list of the model of each type of feed item
+var feedResources = map[string]func() interface{}{
+	string(models.PostFeedItem):    func() interface{} { return &[]models.Post{} },
+	string(models.TopicFeedItem):   func() interface{} { return &[]models.Topic{} },
+	string(models.BdaPostFeedItem): func() interface{} { return &[]models.BdaPost{} },
+	string(models.CommentFeedItem): func() interface{} { return &[]models.Comment{} },
+}
+
+// FeedHandler is a struct to define feed handler
+type FeedHandler struct {
+	repository repository.FeedStore
+}
+
+// NewFeedHandler is a factory for feed handler
+func NewFeedHandler(repository repository.FeedStore) *FeedHandler {
+	return &FeedHandler{repository: repository}
+}
+
+// FeedItemResponse defines an item of a feed with the resource it is about
+type FeedItemResponse struct {
+	Type      models.FeedItemType `json:"type"`
+	CreatedAt time.Time           `json:"createdAt"`
+	Resource  interface{}         `json:"resource"`
+}
+
+// GetFeed respond the feed of the current user, the newest items first
+func (f *FeedHandler) GetFeed(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetSortedPage(c, models.FeedFields, feedOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	feedItems := &[]models.FeedItem{}
+
+	info, err := f.repository.ListFeed(feedItems, user.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	resources, err := f.loadResources(*feedItems)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items := []interface{}{}
+	for _, item := range *feedItems {
+		// the resource may have been deleted since the feed has been read
+		resource, ok := resources[item.ID]
+		if !ok {
+			continue
+		}
+
+		items = append(items, FeedItemResponse{Type: item.Type, CreatedAt: item.CreatedAt, Resource: resource})
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// loadResources load the resources of feed items with one query by type, by id
+func (f *FeedHandler) loadResources(items []models.FeedItem) (map[uuid.UUID]interface{}, error) {
+	keys := map[string][]interface{}{}
+	for _, item := range items {
+		keys[string(item.Type)] = append(keys[string(item.Type)], item.ID.String())
+	}
+
+	return loadResources(f.repository, feedResources, keys)
+}
+
+// loadResources load resources of several types with one query by type, by id. lists
+// returns an empty list of the model of each type, unknown types are skipped.
+func loadResources(store repository.RelationStore, lists map[string]func() interface{}, keys map[string][]interface{}) (map[uuid.UUID]interface{}, error) {
+	resources := map[uuid.UUID]interface{}{}
+	for resourceType, ids := range keys {
+		newList, ok := lists[resourceType]
+		if !ok {
+			continue
+		}
+
+		list := newList()
+		err := store.ListRelated(list, "id", ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range collectionItems(list) {
+			id := reflect.ValueOf(resource).FieldByName("ID").Interface().(uuid.UUID)
+			resources[id] = resource
+		}
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestGetFeed(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	me, friend, other := uuid.NewV4(), uuid.NewV4(), uuid.NewV4()
+	topic, category := uuid.NewV4(), uuid.NewV4()
+	now := time.Now()
+	base := func(minutes int) models.Base {
+		return models.Base{ID: uuid.NewV4(), CreatedAt: now.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	rows := []interface{}{
+		&models.Follow{UserID: me, FollowedID: friend},
+		&models.Subscription{UserID: me, TargetType: models.TopicSubscription, TargetID: topic},
+		&models.Subscription{UserID: me, TargetType: models.CategorySubscription, TargetID: category},
+		// in the feed
+		&models.Post{Base: base(1), UserID: other, TopicID: topic, Content: "subscribed topic"},
+		&models.Topic{Base: base(2), UserID: other, CategoryID: category, Name: "subscribed category"},
+		&models.Comment{Base: base(3), UserID: friend, Content: "followed user"},
+		&models.BdaPost{Base: base(4), UserID: friend, Title: "followed user"},
+		// not in the feed
+		&models.Post{Base: base(5), UserID: other, TopicID: uuid.NewV4(), Content: "other topic"},
+		&models.Post{Base: base(6), UserID: me, TopicID: topic, Content: "my own post"},
+		&models.BdaPost{Base: base(7), UserID: other, Title: "bda feed not subscribed"},
+	}
+
+	for _, row := range rows {
+		err := db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantTypes []models.FeedItemType
+	}{
+		{name: "newest first", query: "", wantTypes: []models.FeedItemType{models.BdaPostFeedItem, models.CommentFeedItem, models.TopicFeedItem, models.PostFeedItem}},
+		{name: "filtered by type", query: "filter[type]=post", wantTypes: []models.FeedItemType{models.PostFeedItem}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types := []models.FeedItemType{}
+			after := ""
+			for {
+				res, ctx, _ := commonTesting.InitHTTPTest()
+				ctx.Request = httptest.NewRequest(http.MethodGet, "/?limit=3&"+tt.query+"&after="+after, nil)
+				ctx.Set(middleware.IdentityKey, &models.User{Base: models.Base{ID: me}})
+
+				NewFeedHandler(repository.NewFeedRepository(db)).GetFeed(ctx)
+
+				if res.Code != 200 {
+					t.Fatalf("GetFeed code = %v, want 200: %s", res.Code, res.Body.String())
+				}
+
+				got := &struct {
+					Collection
+					Items []FeedItemResponse `json:"items"`
+				}{}
+				_ = json.Unmarshal(res.Body.Bytes(), got)
+
+				for _, item := range got.Items {
+					if item.Resource == nil {
+						t.Errorf("GetFeed item %s should embed its resource", item.Type)
+					}
+
+					types = append(types, item.Type)
+				}
+
+				if got.NextCursor == "" {
+					break
+				}
+
+				after = got.NextCursor
+			}
+
+			if len(types) != len(tt.wantTypes) {
+				t.Fatalf("GetFeed got %v, want %v", types, tt.wantTypes)
+			}
+
+			for i := range types {
+				if types[i] != tt.wantTypes[i] {
+					t.Fatalf("GetFeed got %v, want %v", types, tt.wantTypes)
+				}
+			}
+		})
+	}
+}
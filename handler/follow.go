@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	// errAlreadyFollowed is returned when the current user already follows a user
+	errAlreadyFollowed = errors.New("user already followed by this user")
+	// errFollowSelf is returned when the current user tries to follow themselves
+	errFollowSelf = errors.New("you can not follow yourself")
+)
+
+// FollowHandler is a struct to define follow handler
+type FollowHandler struct {
+	repository repository.FollowStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewFollowHandler is a factory for follow handler
+func NewFollowHandler(repository repository.FollowStore, unitOfWork repository.UnitOfWork) *FollowHandler {
+	return &FollowHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// FollowUser make the current user follow a specific user
+func (f *FollowHandler) FollowUser(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	followedID, _ := c.Params.Get("id")
+
+	followedUUID, err := uuid.FromString(followedID)
+	if err != nil {
+		httpError.NotFound(c, "user", followedID, err)
+		return
+	}
+
+	if uuid.Equal(user.ID, followedUUID) {
+		httpError.BadRequest(c, errFollowSelf)
+		return
+	}
+
+	follow := &models.Follow{UserID: user.ID, FollowedID: followedUUID}
+
+	err = f.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Follows.GetFollow(&models.Follow{}, user.ID.String(), followedID)
+		if err == nil {
+			return errAlreadyFollowed
+		}
+
+		if !errors.Is(err, repository.ErrFollowNotFound) {
+			return err
+		}
+
+		err = repositories.Users.UpdateFollowersCount(followedID, 1)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Users.UpdateFollowingCount(user.ID.String(), 1)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Follows.CreateFollow(follow)
+	})
+	if err != nil {
+		if errors.Is(err, errAlreadyFollowed) {
+			httpError.Conflict(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrUserNotFound) {
+			httpError.NotFound(c, "user", followedID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, follow.Base, follow)
+}
+
+// UnfollowUser make the current user stop following a specific user
+func (f *FollowHandler) UnfollowUser(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	followedID, _ := c.Params.Get("id")
+
+	err = f.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		follow := &models.Follow{}
+
+		err := repositories.Follows.GetFollow(follow, user.ID.String(), followedID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Follows.DeleteFollowByID(follow.ID.String())
+		if err != nil {
+			return err
+		}
+
+		return updateFollowCounts(repositories, follow, -1)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrFollowNotFound) {
+			httpError.NotFound(c, "follow", followedID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// ListFollowers respond a list of the users following a specific user
+func (f *FollowHandler) ListFollowers(c *gin.Context) {
+	f.listUsers(c, f.repository.ListFollowers)
+}
+
+// ListFollowing respond a list of the users followed by a specific user
+func (f *FollowHandler) ListFollowing(c *gin.Context) {
+	f.listUsers(c, f.repository.ListFollowing)
+}
+
+// listUsers respond a list of users related to the user of the id param
+func (f *FollowHandler) listUsers(c *gin.Context, list func(users *[]models.User, userID string, page repository.Page) (repository.PageInfo, error)) {
+	page, err := GetPage(c, models.UserFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.UserRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	userID, _ := c.Params.Get("id")
+	users := &[]models.User{}
+
+	info, err := list(users, userID, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(f.repository, createUsersResponse(users))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// updateFollowCounts add delta to the counters of the two users of a follow, a
+// user which does not exist anymore is ignored
+func updateFollowCounts(repositories *repository.Repositories, follow *models.Follow, delta int) error {
+	err := repositories.Users.UpdateFollowersCount(follow.FollowedID.String(), delta)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+
+	err = repositories.Users.UpdateFollowingCount(follow.UserID.String(), delta)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestFollowUser(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	follower := &models.User{Email: "follower@ada.dev"}
+	followed := &models.User{Email: "followed@ada.dev"}
+	db.Create(follower)
+	db.Create(followed)
+
+	handler := NewFollowHandler(repository.NewFollowRepository(db), repository.NewUnitOfWork(db))
+
+	call := func(action gin.HandlerFunc, userID string) int {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, follower)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		action(ctx)
+
+		return res.Code
+	}
+
+	counts := func() (int, int) {
+		db.First(follower, "id = ?", follower.ID)
+		db.First(followed, "id = ?", followed.ID)
+
+		return follower.FollowingCount, followed.FollowersCount
+	}
+
+	tests := []struct {
+		name          string
+		action        gin.HandlerFunc
+		userID        string
+		wantCode      int
+		wantFollowing int
+	}{
+		{name: "follow", action: handler.FollowUser, userID: followed.ID.String(), wantCode: 200, wantFollowing: 1},
+		{name: "follow twice", action: handler.FollowUser, userID: followed.ID.String(), wantCode: 409, wantFollowing: 1},
+		{name: "follow yourself", action: handler.FollowUser, userID: follower.ID.String(), wantCode: 400, wantFollowing: 1},
+		{name: "follow unknown user", action: handler.FollowUser, userID: uuid.NewV4().String(), wantCode: 404, wantFollowing: 1},
+		{name: "unfollow", action: handler.UnfollowUser, userID: followed.ID.String(), wantCode: 204, wantFollowing: 0},
+		{name: "unfollow twice", action: handler.UnfollowUser, userID: followed.ID.String(), wantCode: 404, wantFollowing: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := call(tt.action, tt.userID)
+			if code != tt.wantCode {
+				t.Errorf("%s code = %v, want %v", tt.name, code, tt.wantCode)
+			}
+
+			following, followers := counts()
+			if following != tt.wantFollowing || followers != tt.wantFollowing {
+				t.Errorf("%s got following = %v and followers = %v, want %v", tt.name, following, followers, tt.wantFollowing)
+			}
+		})
+	}
+}
+
+func TestListFollowers(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	follower := &models.User{Email: "list-follower@ada.dev", Password: "secret"}
+	followed := &models.User{Email: "list-followed@ada.dev"}
+	db.Create(follower)
+	db.Create(followed)
+	db.Create(&models.Follow{UserID: follower.ID, FollowedID: followed.ID})
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Params = gin.Params{{Key: "id", Value: followed.ID.String()}}
+
+	NewFollowHandler(repository.NewFollowRepository(db), nil).ListFollowers(ctx)
+
+	got := &Collection{}
+	_ = json.Unmarshal(res.Body.Bytes(), got)
+
+	if res.Code != 200 || got.Total != 1 {
+		t.Fatalf("ListFollowers got code = %v and total = %v, want 200 and 1: %s", res.Code, got.Total, res.Body.String())
+	}
+
+	item := got.Items[0].(map[string]interface{})
+	if item["id"] != follower.ID.String() {
+		t.Errorf("ListFollowers got %v, want the follower", item["id"])
+	}
+
+	if _, ok := item["password"]; ok {
+		t.Error("ListFollowers should not respond the password of the followers")
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+)
+
+// Representation defines how resources are rendered: the related resources included
+// with them and the fields kept
+type Representation struct {
+	relations models.Relations
+	include   []string
+	fields    map[string]bool
+}
+
+// GetRepresentation get the representation requested by the query parameters:
+//
+//   - include: comma separated relations to include, e.g. include=author,likes
+//   - fields: comma separated fields to keep, e.g. fields=id,content,createdAt
+//
+// Only the given relations of the resource can be included.
+func GetRepresentation(c *gin.Context, relations models.Relations) (*Representation, error) {
+	representation := &Representation{relations: relations}
+
+	if include := c.Query("include"); include != "" {
+		for _, name := range strings.Split(include, ",") {
+			if _, ok := relations[name]; !ok {
+				return nil, fmt.Errorf("can not include %q", name)
+			}
+
+			representation.include = append(representation.include, name)
+		}
+	}
+
+	if fields := c.Query("fields"); fields != "" {
+		representation.fields = map[string]bool{}
+		for _, name := range strings.Split(fields, ",") {
+			representation.fields[name] = true
+		}
+
+		// included relations are always kept
+		for _, name := range representation.include {
+			representation.fields[name] = true
+		}
+	}
+
+	return representation, nil
+}
+
+// Render returns the items with their related resources and the requested fields only.
+// Each relation is loaded with a single query for all the items.
+func (r *Representation) Render(store repository.RelationStore, items []interface{}) ([]interface{}, error) {
+	if len(r.include) == 0 && r.fields == nil {
+		return items, nil
+	}
+
+	resources := []map[string]interface{}{}
+	err := convert(items, &resources)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range r.include {
+		err = r.includeRelation(store, name, resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rendered := make([]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		if r.fields != nil {
+			for field := range resource {
+				if !r.fields[field] {
+					delete(resource, field)
+				}
+			}
+		}
+
+		rendered = append(rendered, resource)
+	}
+
+	return rendered, nil
+}
+
+// RenderOne returns a single item with its related resources and the requested fields only
+func (r *Representation) RenderOne(store repository.RelationStore, item interface{}) (interface{}, error) {
+	rendered, err := r.Render(store, []interface{}{item})
+	if err != nil {
+		return nil, err
+	}
+
+	return rendered[0], nil
+}
+
+// includeRelation add to the resources the related resources of a relation
+func (r *Representation) includeRelation(store repository.RelationStore, name string, resources []map[string]interface{}) error {
+	relation := r.relations[name]
+
+	keys := []interface{}{}
+	seen := map[string]bool{}
+	for _, resource := range resources {
+		key, ok := resource[relation.Key].(string)
+		if !ok || key == "" || key == uuid.Nil.String() || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	related := relation.New()
+	err := store.ListRelated(related, relation.Column, keys)
+	if err != nil {
+		return err
+	}
+
+	relatedResources := []map[string]interface{}{}
+	err = convert(related, &relatedResources)
+	if err != nil {
+		return err
+	}
+
+	byKey := map[string][]map[string]interface{}{}
+	for _, relatedResource := range relatedResources {
+		for _, hidden := range relation.Hidden {
+			delete(relatedResource, hidden)
+		}
+
+		key := fmt.Sprint(relatedResource[relation.RelatedKey])
+		byKey[key] = append(byKey[key], relatedResource)
+	}
+
+	for _, resource := range resources {
+		matches := byKey[fmt.Sprint(resource[relation.Key])]
+
+		if relation.Many {
+			if matches == nil {
+				matches = []map[string]interface{}{}
+			}
+			resource[name] = matches
+		} else if len(matches) > 0 {
+			resource[name] = matches[0]
+		} else {
+			resource[name] = nil
+		}
+	}
+
+	return nil
+}
+
+// convert copy the JSON representation of from to to
+func convert(from interface{}, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	return decoder.Decode(to)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+)
+
+// countingPostStore counts the queries of related resources
+type countingPostStore struct {
+	repository.PostStore
+	queries int
+}
+
+func (s *countingPostStore) ListRelated(related interface{}, column string, keys []interface{}) error {
+	s.queries++
+	return s.PostStore.ListRelated(related, column, keys)
+}
+
+func TestListPostInclude(t *testing.T) {
+	commonTesting.InitDB(&models.User{})
+	commonTesting.InitDB(&models.Like{})
+	db := commonTesting.InitDB(&models.Post{})
+	topicID := uuid.NewV4()
+
+	users := []*models.User{
+		{FirstName: "Ada", LastName: "Lovelace", Email: uuid.NewV4().String() + "@ada.dev", Password: "secret"},
+		{FirstName: "Grace", LastName: "Hopper", Email: uuid.NewV4().String() + "@ada.dev", Password: "secret"},
+	}
+	for _, user := range users {
+		db.Create(user)
+	}
+
+	for i := 0; i < 3; i++ {
+		post := &models.Post{Content: "lorem ipsum", UserID: users[i%2].ID, TopicID: topicID}
+		db.Create(post)
+		db.Create(&models.Like{PostID: post.ID, UserID: users[0].ID})
+	}
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Params = []gin.Param{{Key: "id", Value: topicID.String()}}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?include=author,likes&fields=id,content", nil)
+
+	store := &countingPostStore{PostStore: repository.NewPostRepository(db)}
+	NewPostHandler(store, nil).ListPost(ctx)
+
+	if res.Code != 200 {
+		t.Fatalf("ListPost code = %v, want 200: %s", res.Code, res.Body.String())
+	}
+
+	got := &struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	_ = json.Unmarshal(res.Body.Bytes(), got)
+
+	if len(got.Items) != 3 {
+		t.Fatalf("ListPost got %v posts, want 3", len(got.Items))
+	}
+
+	if store.queries != 2 {
+		t.Errorf("ListPost got %v queries of related resources, want 2", store.queries)
+	}
+
+	for _, item := range got.Items {
+		keys := []string{}
+		for key := range item {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if len(keys) != 4 || keys[0] != "author" || keys[1] != "content" || keys[2] != "id" || keys[3] != "likes" {
+			t.Errorf("ListPost got fields %v, want [author content id likes]", keys)
+		}
+
+		author, ok := item["author"].(map[string]interface{})
+		if !ok || author["firstName"] == nil {
+			t.Errorf("ListPost author should be included, got %v", item["author"])
+		}
+
+		if _, ok := author["password"]; ok {
+			t.Error("ListPost author password should not be included")
+		}
+
+		likes, ok := item["likes"].([]interface{})
+		if !ok || len(likes) != 1 {
+			t.Errorf("ListPost likes should be included, got %v", item["likes"])
+		}
+	}
+}
+
+func TestListPostUnknownInclude(t *testing.T) {
+	db := commonTesting.InitDB(&models.Post{})
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?include=password", nil)
+
+	NewPostHandler(repository.NewPostRepository(db), nil).ListPost(ctx)
+
+	if res.Code != 400 {
+		t.Errorf("ListPost code = %v, want 400", res.Code)
+	}
+}
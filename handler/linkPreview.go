@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	uuid "github.com/satori/go.uuid"
+)
+
+// syncLinkPreviews save the links of the content of a post to be previewed: the new links
+// are created pending and fetched in background, the links removed from the content are
+// deleted and the others keep their preview in their new position
+func syncLinkPreviews(repositories *repository.Repositories, postID uuid.UUID, content string) error {
+	previews := &[]models.LinkPreview{}
+	err := repositories.LinkPreviews.ListLinkPreviewsByPostID(previews, postID.String())
+	if err != nil {
+		return err
+	}
+
+	positions := map[string]int{}
+	for position, link := range models.ParseLinks(content) {
+		positions[link] = position
+	}
+
+	for _, preview := range *previews {
+		position, ok := positions[preview.URL]
+		delete(positions, preview.URL)
+
+		if !ok {
+			err = repositories.LinkPreviews.DeleteLinkPreviewByID(preview.ID.String())
+		} else if position != preview.Position {
+			err = repositories.LinkPreviews.UpdateLinkPreviewPosition(preview.ID.String(), position)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for link, position := range positions {
+		err = repositories.LinkPreviews.CreateLinkPreview(&models.LinkPreview{
+			PostID:   postID,
+			URL:      link,
+			Position: position,
+			Status:   models.PendingPreview,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
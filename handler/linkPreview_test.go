@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/ada-social-network/api/unfurl"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLinkPreviews(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><head><meta property="og:title" content="Page %s"><meta property="og:image" content="/cover.png"></head></html>`, r.URL.Path)
+	}))
+	defer stub.Close()
+
+	author := &models.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@previews.dev"}
+	db.Create(author)
+
+	topic := &models.Topic{Name: "previews", UserID: author.ID}
+	db.Create(topic)
+
+	handler := NewPostHandler(repository.NewPostRepository(db), repository.NewUnitOfWork(db))
+	store := repository.NewLinkPreviewRepository(db)
+	worker := unfurl.NewWorker(store, unfurl.NewFetcher(time.Second, unfurl.DefaultMaxSize).WithPrivateNetworks(), time.Second, time.Hour)
+	post := &models.Post{}
+
+	save := func(action gin.HandlerFunc, content string) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, author)
+		ctx.Params = gin.Params{{Key: "id", Value: topic.ID.String()}, {Key: "postId", Value: post.ID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, map[string]string{"content": content})
+
+		action(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("save post code = %v, want 200: %s", res.Code, res.Body.String())
+		}
+
+		_ = json.Unmarshal(res.Body.Bytes(), post)
+	}
+
+	// previews returns the previews included with the post, by position
+	previews := func() []models.LinkPreview {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: topic.ID.String()}, {Key: "postId", Value: post.ID.String()}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?include=previews", nil)
+
+		handler.GetPost(ctx)
+
+		included := &struct {
+			Previews []models.LinkPreview `json:"previews"`
+		}{}
+		_ = json.Unmarshal(res.Body.Bytes(), included)
+
+		sort.Slice(included.Previews, func(i, j int) bool {
+			return included.Previews[i].Position < included.Previews[j].Position
+		})
+
+		return included.Previews
+	}
+
+	save(handler.CreatePost, fmt.Sprintf("Read %s/first and [this](%s/missing).", stub.URL, stub.URL))
+
+	got := previews()
+	if len(got) != 2 || got[0].Status != models.PendingPreview || got[1].URL != stub.URL+"/missing" {
+		t.Fatalf("previews after create = %+v, want 2 pending previews", got)
+	}
+
+	count, err := worker.RunOnce(context.Background())
+	if err != nil || count != 2 {
+		t.Fatalf("RunOnce = %v %v, want 2 previews done", count, err)
+	}
+
+	got = previews()
+	if got[0].Status != models.FetchedPreview || got[0].Title != "Page /first" || got[0].ImageURL != stub.URL+"/cover.png" {
+		t.Errorf("fetched preview = %+v, want the metadata of the page", got[0])
+	}
+	if got[1].Status != models.FailedPreview || got[1].Title != "" {
+		t.Errorf("preview of a missing page = %+v, want a failed preview", got[1])
+	}
+
+	save(handler.UpdatePost, fmt.Sprintf("Now %s/second, then %s/first", stub.URL, stub.URL))
+
+	got = previews()
+	if len(got) != 2 || got[0].Status != models.PendingPreview || got[1].Status != models.FetchedPreview || got[1].Position != 1 {
+		t.Fatalf("previews after update = %+v, want a new pending preview and the fetched one moved", got)
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		stub.Close()
+
+		other := &models.LinkPreview{PostID: topic.ID, URL: stub.URL + "/first", Status: models.PendingPreview}
+		db.Create(other)
+
+		_, _ = worker.RunOnce(context.Background())
+
+		cached := &models.LinkPreview{}
+		db.First(cached, "id = ?", other.ID)
+		if cached.Status != models.FetchedPreview || cached.Title != "Page /first" {
+			t.Errorf("preview of a fetched link = %+v, want the cached metadata", cached)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: topic.ID.String()}, {Key: "postId", Value: post.ID.String()}}
+		ctx.Request = httptest.NewRequest(http.MethodDelete, "/", nil)
+
+		handler.DeletePost(ctx)
+
+		var count int64
+		db.Model(&models.LinkPreview{}).Where("post_id = ?", post.ID).Count(&count)
+		if res.Code != 204 || count != 0 {
+			t.Errorf("DeletePost = %v and kept %v previews, want 204 and no preview", res.Code, count)
+		}
+	})
+}
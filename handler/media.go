@@ -0,0 +1,426 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/media"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/storage"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// MediaCacheControl is the cache policy of the files of the media, their content never
+// changes
+const MediaCacheControl = "public, max-age=31536000, immutable"
+
+// presignedURLExpiry is the duration during which the clients are redirected to the same
+// presigned URL of a file, the URLs are valid twice as long
+const presignedURLExpiry = time.Hour
+
+// multipartOverhead is the size allowed for the multipart encoding of an upload
+const multipartOverhead = 64 << 10
+
+var (
+	// errFileRequired is returned when an upload has no file
+	errFileRequired = errors.New("a file is required in the file field")
+	// errUnknownThumbnail is returned when a file is requested with an unknown size
+	errUnknownThumbnail = errors.New("unknown size")
+	// errNoThumbnail is returned when a thumbnail of a document is requested
+	errNoThumbnail = errors.New("only the images have thumbnails")
+	// errNotMediaOwner is returned when a user deletes the media of another user
+	errNotMediaOwner = errors.New("only the user who uploaded a media can delete it")
+	// errInvalidMedia is returned when a picture is not an uploaded media
+	errInvalidMedia = errors.New("the pictures must be images uploaded by the user")
+)
+
+// MediaHandler is a struct to define media handler
+type MediaHandler struct {
+	repository repository.MediaStore
+	unitOfWork repository.UnitOfWork
+	files      storage.Store
+	maxSize    int64
+}
+
+// NewMediaHandler is a factory media handler, maxSize is the maximum size of an uploaded
+// file in bytes
+func NewMediaHandler(repository repository.MediaStore, unitOfWork repository.UnitOfWork, files storage.Store, maxSize int64) *MediaHandler {
+	return &MediaHandler{repository: repository, unitOfWork: unitOfWork, files: files, maxSize: maxSize}
+}
+
+// UploadMedia store the image or document of the file field of a multipart request, with
+// the thumbnails of an image. The media of the current user with the same content is
+// responded if any.
+func (m *MediaHandler) UploadMedia(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, m.maxSize+multipartOverhead)
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		// the error of http.MaxBytesReader has no type before Go 1.19
+		if strings.Contains(err.Error(), "request body too large") {
+			httpError.TooLarge(c, media.ErrTooLarge)
+			return
+		}
+
+		httpError.BadRequest(c, errFileRequired)
+		return
+	}
+	defer file.Close()
+
+	processed, err := media.Process(file, m.maxSize)
+	if err != nil {
+		if errors.Is(err, media.ErrTooLarge) {
+			httpError.TooLarge(c, err)
+			return
+		}
+
+		if errors.Is(err, media.ErrUnsupportedType) || errors.Is(err, media.ErrTooManyPixels) {
+			httpError.UnsupportedMediaType(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	hash := processed.Hash()
+	uploaded := &models.Media{}
+
+	err = m.repository.GetMediaByUserAndHash(uploaded, user.ID.String(), hash)
+	if err == nil {
+		RespondResource(c, uploaded.Base, uploaded)
+		return
+	}
+
+	if !errors.Is(err, repository.ErrMediaNotFound) {
+		httpError.Internal(c, err)
+		return
+	}
+
+	// the files are stored first so a media never references missing files
+	err = m.files.Put(media.FileKey(hash, ""), processed.Data, processed.ContentType)
+	for name, data := range processed.Thumbnails {
+		if err == nil {
+			err = m.files.Put(media.FileKey(hash, name), data, processed.ContentType)
+		}
+	}
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	uploaded = &models.Media{
+		UserID:      user.ID,
+		Hash:        hash,
+		ContentType: processed.ContentType,
+		Size:        int64(len(processed.Data)),
+		Width:       processed.Width,
+		Height:      processed.Height,
+	}
+
+	err = m.repository.CreateMedia(uploaded)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, uploaded.Base, uploaded)
+}
+
+// ListMedia respond a page of the media uploaded by the current user
+func (m *MediaHandler) ListMedia(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetPage(c, models.MediaFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.MediaRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	uploaded := &[]models.Media{}
+
+	info, err := m.repository.ListMediaByUserID(uploaded, user.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(m.repository, collectionItems(uploaded))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// GetMedia get a specific media
+func (m *MediaHandler) GetMedia(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.MediaRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	uploaded := &models.Media{}
+
+	err = m.repository.GetMediaByID(uploaded, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrMediaNotFound) {
+			httpError.NotFound(c, "media", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	resource, err := representation.RenderOne(m.repository, uploaded)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, uploaded.Base, resource)
+}
+
+// ServeMediaFile respond the file of a media or one of its thumbnails with the size
+// query parameter, the files are cached by the clients as they never change. The documents
+// are downloaded rather than displayed. When the storage gives presigned URLs the clients
+// are redirected to download the file from it.
+func (m *MediaHandler) ServeMediaFile(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	size := c.Query("size")
+
+	if size != "" && !validThumbnail(size) {
+		httpError.BadRequest(c, errUnknownThumbnail)
+		return
+	}
+
+	uploaded := &models.Media{}
+
+	err := m.repository.GetMediaByID(uploaded, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrMediaNotFound) {
+			httpError.NotFound(c, "media", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	if size != "" && !media.IsImage(uploaded.ContentType) {
+		httpError.BadRequest(c, errNoThumbnail)
+		return
+	}
+
+	key := media.FileKey(uploaded.Hash, size)
+
+	if presigner, ok := m.files.(storage.Presigner); ok {
+		url, err := presigner.PresignGet(key, presignedURLExpiry)
+		if err != nil {
+			httpError.Internal(c, err)
+			return
+		}
+
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(presignedURLExpiry.Seconds())))
+		c.Redirect(http.StatusFound, url)
+		return
+	}
+
+	file, err := m.files.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			httpError.NotFound(c, "media", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", uploaded.ContentType)
+	c.Header("Cache-Control", MediaCacheControl)
+	c.Header("ETag", `"`+key+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	if !media.IsImage(uploaded.ContentType) {
+		c.Header("Content-Disposition", "attachment")
+	}
+
+	http.ServeContent(c.Writer, c.Request, "", uploaded.CreatedAt, file)
+}
+
+// DeleteMedia delete a media of the current user, it is removed from the pictures and the
+// attachments referencing it and its files are deleted when no other media shares them
+func (m *MediaHandler) DeleteMedia(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	var unused []string
+
+	err = m.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		uploaded := &models.Media{}
+
+		err := repositories.Media.GetMediaByID(uploaded, id)
+		if err != nil {
+			return err
+		}
+
+		if !uuid.Equal(uploaded.UserID, user.ID) {
+			return errNotMediaOwner
+		}
+
+		err = checkPrecondition(c, func() (models.Base, error) { return uploaded.Base, nil })
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Media.ClearMediaReferences(id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Attachments.DeleteAttachmentsByMediaID(id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Media.DeleteMediaByID(id)
+		if err != nil {
+			return err
+		}
+
+		unused, err = unusedMediaHashes(repositories, []string{uploaded.Hash})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrMediaNotFound) {
+			httpError.NotFound(c, "media", id, err)
+			return
+		}
+
+		if errors.Is(err, errNotMediaOwner) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	deleteMediaFiles(m.files, unused)
+
+	c.JSON(204, nil)
+}
+
+// validThumbnail check if a thumbnail size exists
+func validThumbnail(name string) bool {
+	for _, thumbnail := range media.Thumbnails {
+		if thumbnail.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unusedMediaHashes returns the hashes of deleted media which are not shared by other media
+func unusedMediaHashes(repositories *repository.Repositories, hashes []string) ([]string, error) {
+	unused := []string{}
+	for _, hash := range hashes {
+		count, err := repositories.Media.CountMediaByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if count == 0 {
+			unused = append(unused, hash)
+		}
+	}
+
+	return unused, nil
+}
+
+// deleteMediaFiles delete the files of media hashes once their media are deleted, a file
+// which can not be deleted is logged as the media are already gone
+func deleteMediaFiles(files storage.Store, hashes []string) {
+	if files == nil {
+		return
+	}
+
+	for _, hash := range hashes {
+		keys := []string{media.FileKey(hash, "")}
+		for _, thumbnail := range media.Thumbnails {
+			keys = append(keys, media.FileKey(hash, thumbnail.Name))
+		}
+
+		for _, key := range keys {
+			err := files.Delete(key)
+			if err != nil {
+				log.Printf("can not delete media file %s: %s", key, err)
+			}
+		}
+	}
+}
+
+// checkPictures check the pictures of a resource are uploaded media, when ownerID is
+// given they must have been uploaded by this user
+func checkPictures(repositories *repository.Repositories, ownerID *uuid.UUID, pictureIDs ...*uuid.UUID) error {
+	for _, pictureID := range pictureIDs {
+		if pictureID == nil {
+			continue
+		}
+
+		picture := &models.Media{}
+
+		err := repositories.Media.GetMediaByID(picture, pictureID.String())
+		if err != nil {
+			if errors.Is(err, repository.ErrMediaNotFound) {
+				return errInvalidMedia
+			}
+
+			return err
+		}
+
+		if !media.IsImage(picture.ContentType) || (ownerID != nil && !uuid.Equal(picture.UserID, *ownerID)) {
+			return errInvalidMedia
+		}
+	}
+
+	return nil
+}
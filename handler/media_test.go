@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/media"
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/storage"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// pngImage returns a png image of a size filled with a color
+func pngImage(t *testing.T, width, height int, fill color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// multipartFile returns a multipart body with a file field and its content type
+func multipartFile(t *testing.T, field string, data []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile(field, "image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(data)
+	_ = writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+// presignedStore is a file store giving presigned URLs
+type presignedStore struct {
+	*storage.FileStore
+}
+
+func (p presignedStore) PresignGet(key string, expires time.Duration) (string, error) {
+	return "https://storage.test/" + key, nil
+}
+
+func TestMedia(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@media.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@media.dev"}
+	db.Create(ada)
+	db.Create(alan)
+
+	files, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unitOfWork := repository.NewUnitOfWork(db)
+	handler := NewMediaHandler(repository.NewMediaRepository(db), unitOfWork, files, 64<<10)
+
+	upload := func(user *models.User, field string, data []byte) (*models.Media, int) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+
+		body, contentType := multipartFile(t, field, data)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/media", body)
+		ctx.Request.Header.Set("Content-Type", contentType)
+
+		handler.UploadMedia(ctx)
+
+		uploaded := &models.Media{}
+		_ = json.Unmarshal(res.Body.Bytes(), uploaded)
+
+		return uploaded, res.Code
+	}
+
+	serve := func(id string, query string, header http.Header) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: id}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/media/"+id+"/file"+query, nil)
+		for key, values := range header {
+			ctx.Request.Header[key] = values
+		}
+
+		handler.ServeMediaFile(ctx)
+		// the status of a response without body is written by the router
+		ctx.Writer.WriteHeaderNow()
+
+		return res
+	}
+
+	remove := func(user *models.User, id string) int {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: id}}
+		ctx.Request = httptest.NewRequest(http.MethodDelete, "/media/"+id, nil)
+
+		handler.DeleteMedia(ctx)
+
+		return res.Code
+	}
+
+	red := pngImage(t, 400, 200, color.NRGBA{R: 255, A: 255})
+
+	uploaded, code := upload(ada, "file", red)
+	if code != 200 {
+		t.Fatalf("UploadMedia = %v, want 200", code)
+	}
+	if uploaded.ContentType != "image/png" || uploaded.Width != 400 || uploaded.Height != 200 || !uuid.Equal(uploaded.UserID, ada.ID) {
+		t.Errorf("UploadMedia got %+v, want a 400x200 png of the user", uploaded)
+	}
+
+	again, _ := upload(ada, "file", red)
+	if !uuid.Equal(again.ID, uploaded.ID) {
+		t.Errorf("UploadMedia got %v, want the existing media %v", again.ID, uploaded.ID)
+	}
+
+	shared, _ := upload(alan, "file", red)
+	if uuid.Equal(shared.ID, uploaded.ID) || shared.Hash != uploaded.Hash {
+		t.Errorf("UploadMedia of another user got %+v, want a new media sharing the files", shared)
+	}
+
+	t.Run("invalid uploads", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			field string
+			data  []byte
+			want  int
+		}{
+			{name: "no file", field: "other", data: red, want: 400},
+			{name: "not an image", field: "file", data: []byte("just some text"), want: 415},
+			{name: "too large", field: "file", data: bytes.Repeat([]byte{0}, 65<<10), want: 413},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if _, code := upload(ada, test.field, test.data); code != test.want {
+					t.Errorf("UploadMedia = %v, want %v", code, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("serve files", func(t *testing.T) {
+		res := serve(uploaded.ID.String(), "", nil)
+		if res.Code != 200 || res.Header().Get("Content-Type") != "image/png" || res.Header().Get("Cache-Control") != MediaCacheControl {
+			t.Fatalf("ServeMediaFile = %v %v, want a cached png", res.Code, res.Header())
+		}
+
+		etag := res.Header().Get("ETag")
+		res = serve(uploaded.ID.String(), "", http.Header{"If-None-Match": {etag}})
+		if res.Code != 304 {
+			t.Errorf("ServeMediaFile with If-None-Match = %v, want 304", res.Code)
+		}
+
+		res = serve(uploaded.ID.String(), "?size=small", nil)
+		thumbnail, err := png.DecodeConfig(res.Body)
+		if err != nil || thumbnail.Width != 96 || thumbnail.Height != 48 {
+			t.Errorf("ServeMediaFile small got %+v %v, want a 96x48 thumbnail", thumbnail, err)
+		}
+
+		// an image is never enlarged
+		res = serve(uploaded.ID.String(), "?size=large", nil)
+		thumbnail, err = png.DecodeConfig(res.Body)
+		if err != nil || thumbnail.Width != 400 {
+			t.Errorf("ServeMediaFile large got %+v %v, want the original width", thumbnail, err)
+		}
+
+		presigned := NewMediaHandler(repository.NewMediaRepository(db), unitOfWork, presignedStore{files}, 64<<10)
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Params = gin.Params{{Key: "id", Value: uploaded.ID.String()}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/media/"+uploaded.ID.String()+"/file?size=medium", nil)
+		presigned.ServeMediaFile(ctx)
+		if res.Code != 302 || res.Header().Get("Location") != "https://storage.test/"+media.FileKey(uploaded.Hash, "medium") {
+			t.Errorf("ServeMediaFile with presigned URLs = %v %v, want a redirect to the thumbnail", res.Code, res.Header())
+		}
+
+		if res := serve(uploaded.ID.String(), "?size=huge", nil); res.Code != 400 {
+			t.Errorf("ServeMediaFile with an unknown size = %v, want 400", res.Code)
+		}
+		if res := serve(uuid.NewV4().String(), "", nil); res.Code != 404 {
+			t.Errorf("ServeMediaFile of an unknown media = %v, want 404", res.Code)
+		}
+	})
+
+	document, code := upload(ada, "file", []byte("%PDF-1.4\n"))
+	if code != 200 || document.ContentType != "application/pdf" {
+		t.Fatalf("UploadMedia of a document = %v %+v, want a pdf", code, document)
+	}
+
+	t.Run("serve documents", func(t *testing.T) {
+		res := serve(document.ID.String(), "", nil)
+		if res.Code != 200 || res.Header().Get("Content-Disposition") != "attachment" {
+			t.Errorf("ServeMediaFile of a document = %v %v, want a download", res.Code, res.Header())
+		}
+
+		if res := serve(document.ID.String(), "?size=small", nil); res.Code != 400 {
+			t.Errorf("ServeMediaFile of a document thumbnail = %v, want 400", res.Code)
+		}
+	})
+
+	t.Run("check pictures", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			ownerID *uuid.UUID
+			picture uuid.UUID
+			want    error
+		}{
+			{name: "own picture", ownerID: &ada.ID, picture: uploaded.ID},
+			{name: "picture of another user", ownerID: &alan.ID, picture: uploaded.ID, want: errInvalidMedia},
+			{name: "any picture", picture: uploaded.ID},
+			{name: "unknown picture", picture: uuid.NewV4(), want: errInvalidMedia},
+			{name: "document", picture: document.ID, want: errInvalidMedia},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				picture := test.picture
+				err := unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+					return checkPictures(repositories, test.ownerID, nil, &picture)
+				})
+				if !errors.Is(err, test.want) {
+					t.Errorf("checkPictures = %v, want %v", err, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		db.Model(ada).UpdateColumn("profil_pic_id", uploaded.ID)
+
+		if code := remove(alan, uploaded.ID.String()); code != 403 {
+			t.Errorf("DeleteMedia by another user = %v, want 403", code)
+		}
+
+		if code := remove(ada, uploaded.ID.String()); code != 204 {
+			t.Fatalf("DeleteMedia = %v, want 204", code)
+		}
+
+		user := &models.User{}
+		db.First(user, "id = ?", ada.ID)
+		if user.ProfilPicID != nil {
+			t.Errorf("DeleteMedia kept the profil pic %v", user.ProfilPicID)
+		}
+
+		// the files are kept while another media shares them
+		if res := serve(shared.ID.String(), "", nil); res.Code != 200 {
+			t.Errorf("ServeMediaFile of a shared media = %v, want 200", res.Code)
+		}
+
+		if code := remove(alan, shared.ID.String()); code != 204 {
+			t.Fatalf("DeleteMedia = %v, want 204", code)
+		}
+		if _, err := files.Open(media.FileKey(shared.Hash, "")); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Open after the last media is deleted = %v, want %v", err, storage.ErrNotFound)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DefaultMentionSuggestionsLimit is the number of suggestions when the limit is not given
+	DefaultMentionSuggestionsLimit = 8
+	// MaxMentionSuggestionsLimit is the maximum number of suggestions
+	MaxMentionSuggestionsLimit = 20
+)
+
+// MentionSuggestion defines a user who can be mentioned
+type MentionSuggestion struct {
+	ID          uuid.UUID  `json:"id"`
+	FirstName   string     `json:"firstName"`
+	LastName    string     `json:"lastName"`
+	ProfilPicID *uuid.UUID `json:"profilPicId"`
+	Handle      string     `json:"handle"`
+}
+
+// ListMentionSuggestions respond the users whose handle or last name starts with the q
+// query parameter, the most followed first
+func (u *UserHandler) ListMentionSuggestions(c *gin.Context) {
+	query := strings.TrimPrefix(strings.TrimSpace(c.Query("q")), "@")
+
+	limit := DefaultMentionSuggestionsLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxMentionSuggestionsLimit {
+			httpError.BadRequest(c, fmt.Errorf("limit must be a number between 1 and %d", MaxMentionSuggestionsLimit))
+			return
+		}
+	}
+
+	users := &[]models.User{}
+
+	err := u.repository.ListMentionSuggestions(users, query, limit)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	suggestions := []MentionSuggestion{}
+	for _, user := range *users {
+		suggestions = append(suggestions, MentionSuggestion{
+			ID:          user.ID,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			ProfilPicID: user.ProfilPicID,
+			Handle:      models.MentionHandle(user),
+		})
+	}
+
+	c.JSON(200, suggestions)
+}
+
+// syncMentions save the users mentioned by the content of a resource: new mentions are
+// created and notified, the mentions removed from the content are deleted
+func syncMentions(repositories *repository.Repositories, sourceType models.MentionSource, sourceID uuid.UUID, authorID uuid.UUID, content string) error {
+	users := &[]models.User{}
+	err := repositories.Users.ListUsersByMentionHandles(users, models.ParseMentions(content))
+	if err != nil {
+		return err
+	}
+
+	mentions := &[]models.Mention{}
+	err = repositories.Mentions.ListMentionsBySource(mentions, sourceType, sourceID.String())
+	if err != nil {
+		return err
+	}
+
+	mentioned := map[uuid.UUID]bool{}
+	for _, mention := range *mentions {
+		mentioned[mention.UserID] = true
+	}
+
+	kept := map[uuid.UUID]bool{}
+	for _, user := range *users {
+		kept[user.ID] = true
+		if mentioned[user.ID] {
+			continue
+		}
+
+		err = repositories.Mentions.CreateMention(&models.Mention{
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			UserID:     user.ID,
+			Handle:     models.MentionHandle(user),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = notify(repositories, &models.Notification{
+			UserID:     user.ID,
+			Type:       models.MentionNotification,
+			TargetType: models.NotificationTarget(sourceType),
+			TargetID:   sourceID,
+			ActorID:    authorID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	removed := []string{}
+	for _, mention := range *mentions {
+		if !kept[mention.UserID] {
+			removed = append(removed, mention.ID.String())
+		}
+	}
+
+	return repositories.Mentions.DeleteMentionsByIDs(removed)
+}
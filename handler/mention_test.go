@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncMentions(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	author := &models.User{FirstName: "Katherine", LastName: "Johnson", Email: "katherine@mentions.dev"}
+	margaret := &models.User{FirstName: "Margaret", LastName: "Hamilton", Email: "margaret@mentions.dev"}
+	dorothy := &models.User{FirstName: "Dorothy", LastName: "Vaughan Jr", Email: "dorothy@mentions.dev"}
+	db.Create(author)
+	db.Create(margaret)
+	db.Create(dorothy)
+
+	topic := &models.Topic{Name: "mentions", UserID: author.ID}
+	db.Create(topic)
+
+	handler := NewPostHandler(repository.NewPostRepository(db), repository.NewUnitOfWork(db))
+	post := &models.Post{}
+
+	save := func(action gin.HandlerFunc, content string) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, author)
+		ctx.Params = gin.Params{{Key: "id", Value: topic.ID.String()}, {Key: "postId", Value: post.ID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, map[string]string{"content": content})
+
+		action(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("save post code = %v, want 200: %s", res.Code, res.Body.String())
+		}
+
+		_ = json.Unmarshal(res.Body.Bytes(), post)
+	}
+
+	mentioned := func() map[string]bool {
+		mentions := []models.Mention{}
+		db.Where("source_type = ? AND source_id = ?", models.PostMention, post.ID).Find(&mentions)
+
+		users := map[string]bool{}
+		for _, mention := range mentions {
+			users[mention.Handle] = true
+		}
+
+		return users
+	}
+
+	notified := func(user *models.User) int64 {
+		var count int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", user.ID, models.MentionNotification).Count(&count)
+
+		return count
+	}
+
+	save(handler.CreatePost, "Thanks @Margaret.Hamilton and @margaret.hamilton, not margaret@hamilton.dev nor @unknown.user")
+
+	if got := mentioned(); len(got) != 1 || !got["margaret.hamilton"] {
+		t.Errorf("mentions after create = %v, want margaret.hamilton", got)
+	}
+
+	save(handler.UpdatePost, "Thanks @dorothy.vaughan-jr and @margaret.hamilton.")
+
+	if got := mentioned(); len(got) != 2 || !got["dorothy.vaughan-jr"] {
+		t.Errorf("mentions after adding a mention = %v, want margaret.hamilton and dorothy.vaughan-jr", got)
+	}
+
+	save(handler.UpdatePost, "Thanks @dorothy.vaughan-jr")
+
+	if got := mentioned(); len(got) != 1 || !got["dorothy.vaughan-jr"] {
+		t.Errorf("mentions after removing a mention = %v, want dorothy.vaughan-jr", got)
+	}
+
+	if notified(margaret) != 1 || notified(dorothy) != 1 {
+		t.Errorf("got %d and %d mention notifications, want 1 each", notified(margaret), notified(dorothy))
+	}
+}
+
+func TestListMentionSuggestions(t *testing.T) {
+	db := commonTesting.InitDB(&models.User{})
+
+	db.Create(&models.User{FirstName: "Radia", LastName: "Brewster", Email: "radia.brewster@suggestions.dev"})
+	db.Create(&models.User{FirstName: "Radia", LastName: "Perlman", Email: "radia.perlman@suggestions.dev", FollowersCount: 3})
+	db.Create(&models.User{FirstName: "Emmy", LastName: "Radiant", Email: "emmy@suggestions.dev"})
+
+	tests := []struct {
+		name        string
+		query       string
+		wantCode    int
+		wantHandles []string
+	}{
+		{name: "first or last name prefix", query: "?q=@radia", wantCode: 200, wantHandles: []string{"radia.perlman", "emmy.radiant", "radia.brewster"}},
+		{name: "handle prefix", query: "?q=radia.b", wantCode: 200, wantHandles: []string{"radia.brewster"}},
+		{name: "limit", query: "?q=radia&limit=1", wantCode: 200, wantHandles: []string{"radia.perlman"}},
+		{name: "invalid limit", query: "?q=radia&limit=100", wantCode: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+
+			NewUserHandler(repository.NewUserRepository(db), nil, nil).ListMentionSuggestions(ctx)
+
+			if res.Code != tt.wantCode {
+				t.Fatalf("ListMentionSuggestions code = %v, want %v", res.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != 200 {
+				return
+			}
+
+			suggestions := []MentionSuggestion{}
+			_ = json.Unmarshal(res.Body.Bytes(), &suggestions)
+
+			handles := []string{}
+			for _, suggestion := range suggestions {
+				handles = append(handles, suggestion.Handle)
+			}
+
+			if len(handles) != len(tt.wantHandles) {
+				t.Fatalf("ListMentionSuggestions handles = %v, want %v", handles, tt.wantHandles)
+			}
+
+			for i := range handles {
+				if handles[i] != tt.wantHandles[i] {
+					t.Errorf("ListMentionSuggestions handles = %v, want %v", handles, tt.wantHandles)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// targetNames are the names of the targets of the notifications used in the messages
+var targetNames = map[models.NotificationTarget]string{
+	models.PostTarget:    "post",
+	models.BdaPostTarget: "bda post",
+	models.CommentTarget: "comment",
+	models.TopicTarget:   "topic",
+}
+
+// notificationOrder is the default order of notifications, the most recently updated first
+var notificationOrder = []repository.Sort{{Field: models.NotificationFields["updatedAt"], Desc: true}}
+
+// NotificationHandler is a struct to define notification handler
+type NotificationHandler struct {
+	repository repository.NotificationStore
+}
+
+// NewNotificationHandler is a factory for notification handler
+func NewNotificationHandler(repository repository.NotificationStore) *NotificationHandler {
+	return &NotificationHandler{repository: repository}
+}
+
+// NotificationResponse defines a notification with a message describing it
+type NotificationResponse struct {
+	models.Notification
+	Message string `json:"message"`
+}
+
+// NotificationCollection defines a collection of notifications and the number of
+// unread notifications of the current user
+type NotificationCollection struct {
+	Collection
+	UnreadCount int64 `json:"unreadCount"`
+}
+
+// ListNotifications respond a list of the notifications of the current user, only the
+// unread notifications are listed with unread=true
+func (n *NotificationHandler) ListNotifications(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetSortedPage(c, models.NotificationFields, notificationOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.NotificationRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	notifications := &[]models.Notification{}
+
+	info, err := n.repository.ListNotificationsByUserID(notifications, user.ID.String(), c.Query("unread") == "true", page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	unreadCount, err := n.repository.CountUnreadNotifications(user.ID.String())
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	responses, err := n.createNotificationsResponse(*notifications)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(n.repository, responses)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NotificationCollection{Collection: *NewCollection(items, info), UnreadCount: unreadCount})
+}
+
+// ReadNotification mark a specific notification of the current user as read
+func (n *NotificationHandler) ReadNotification(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	notification := &models.Notification{}
+
+	err = n.repository.MarkNotificationRead(notification, user.ID.String(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			httpError.NotFound(c, "notification", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	responses, err := n.createNotificationsResponse([]models.Notification{*notification})
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, responses[0])
+}
+
+// ReadAllNotifications mark all the notifications of the current user as read
+func (n *NotificationHandler) ReadAllNotifications(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	err = n.repository.MarkAllNotificationsRead(user.ID.String())
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// GetNotificationPreferences respond if each type of notifications is enabled for the
+// current user
+func (n *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	n.respondPreferences(c, user.ID.String())
+}
+
+// UpdateNotificationPreferences enable or disable types of notifications for the
+// current user, the types which are not given are left unchanged
+func (n *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	request := map[models.NotificationType]bool{}
+	err = c.ShouldBindJSON(&request)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	for notificationType := range request {
+		if !validNotificationType(notificationType) {
+			httpError.BadRequest(c, fmt.Errorf("unknown notification type %q", notificationType))
+			return
+		}
+	}
+
+	for _, notificationType := range models.NotificationTypes {
+		enabled, ok := request[notificationType]
+		if !ok {
+			continue
+		}
+
+		err = n.repository.SaveNotificationPreference(&models.NotificationPreference{
+			UserID:  user.ID,
+			Type:    notificationType,
+			Enabled: enabled,
+		})
+		if err != nil {
+			httpError.Internal(c, err)
+			return
+		}
+	}
+
+	n.respondPreferences(c, user.ID.String())
+}
+
+// respondPreferences respond the notification preferences of a user, by type
+func (n *NotificationHandler) respondPreferences(c *gin.Context, userID string) {
+	preferences := &[]models.NotificationPreference{}
+
+	err := n.repository.ListNotificationPreferences(preferences, userID)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	response := map[models.NotificationType]bool{}
+	for _, notificationType := range models.NotificationTypes {
+		response[notificationType] = true
+	}
+
+	for _, preference := range *preferences {
+		response[preference.Type] = preference.Enabled
+	}
+
+	c.JSON(200, response)
+}
+
+// createNotificationsResponse add the message of each notification, the actors are
+// loaded with a single query
+func (n *NotificationHandler) createNotificationsResponse(notifications []models.Notification) ([]interface{}, error) {
+	actorIDs := []interface{}{}
+	for _, notification := range notifications {
+		actorIDs = append(actorIDs, notification.ActorID.String())
+	}
+
+	actors := &[]models.User{}
+	if len(actorIDs) > 0 {
+		err := n.repository.ListRelated(actors, "id", actorIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names := map[uuid.UUID]string{}
+	for _, actor := range *actors {
+		names[actor.ID] = actor.FirstName + " " + actor.LastName
+	}
+
+	responses := []interface{}{}
+	for _, notification := range notifications {
+		name, ok := names[notification.ActorID]
+		if !ok {
+			name = "Someone"
+		}
+
+		responses = append(responses, NotificationResponse{
+			Notification: notification,
+			Message:      notificationMessage(notification, name),
+		})
+	}
+
+	return responses, nil
+}
+
+// notificationMessage describe a notification, grouped events are summed up
+func notificationMessage(notification models.Notification, actor string) string {
+	target := targetNames[notification.TargetType]
+	count := notification.Count
+
+	switch notification.Type {
+	case models.LikeNotification:
+		// a user can like, unlike and like again, only the distinct users are counted
+		if notification.ActorsCount > 1 {
+			return fmt.Sprintf("%s and %s liked your %s", actor, others(notification.ActorsCount-1), target)
+		}
+		return fmt.Sprintf("%s liked your %s", actor, target)
+	case models.CommentNotification:
+		if count > 1 {
+			return fmt.Sprintf("%d new comments on your %s", count, target)
+		}
+		return fmt.Sprintf("%s commented on your %s", actor, target)
+	case models.ReplyNotification:
+		if count > 1 {
+			return fmt.Sprintf("%d new replies to your %s", count, target)
+		}
+		return fmt.Sprintf("%s replied to your %s", actor, target)
+	case models.MentionNotification:
+		if count > 1 {
+			return fmt.Sprintf("You have been mentioned %d times in a %s", count, target)
+		}
+		return fmt.Sprintf("%s mentioned you in a %s", actor, target)
+	case models.PostNotification:
+		if count > 1 {
+			return fmt.Sprintf("%d new posts in a %s you subscribed to", count, target)
+		}
+		return fmt.Sprintf("%s posted in a %s you subscribed to", actor, target)
+	default:
+		return ""
+	}
+}
+
+// others describe a number of other users
+func others(count int) string {
+	if count == 1 {
+		return "1 other person"
+	}
+
+	return fmt.Sprintf("%d other people", count)
+}
+
+// validNotificationType check if a type of notifications exists
+func validNotificationType(notificationType models.NotificationType) bool {
+	for _, t := range models.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notify send a notification to the user owning its target and publish it on their
+// notifications channel, users are not notified of their own actions
+func notify(repositories *repository.Repositories, notification *models.Notification) error {
+	if uuid.Equal(notification.UserID, uuid.Nil) || uuid.Equal(notification.UserID, notification.ActorID) {
+		return nil
+	}
+
+	err := repositories.Notifications.Notify(notification)
+	if err != nil || uuid.Equal(notification.ID, uuid.Nil) {
+		// the notification is disabled by the user
+		return err
+	}
+
+	eventType := realtime.Created
+	if notification.Count > 1 {
+		eventType = realtime.Updated
+	}
+
+	return publish(repositories, realtime.NotificationsChannel(notification.UserID.String()), eventType, notificationResource, notification)
+}
+
+// unnotify withdraw the event of an actor from the unread notification of the user owning
+// its target, e.g. when a like is removed, and publish the change on their notifications
+// channel. Read notifications are kept as they were.
+func unnotify(repositories *repository.Repositories, notification *models.Notification) error {
+	if uuid.Equal(notification.UserID, uuid.Nil) || uuid.Equal(notification.UserID, notification.ActorID) {
+		return nil
+	}
+
+	err := repositories.Notifications.Unnotify(notification)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	channel := realtime.NotificationsChannel(notification.UserID.String())
+	if notification.ActorsCount == 0 {
+		return publish(repositories, channel, realtime.Deleted, notificationResource, deletedData(notification.ID.String(), nil))
+	}
+
+	return publish(repositories, channel, realtime.Updated, notificationResource, notification)
+}
+
+// withdrawNotifications delete the notifications about a resource, e.g. the replies to a
+// deleted comment, and publish their deletion on the notifications channel of their users
+func withdrawNotifications(repositories *repository.Repositories, targetType models.NotificationTarget, targetID uuid.UUID) error {
+	notifications := &[]models.Notification{}
+	err := repositories.Notifications.DeleteNotificationsByTarget(notifications, targetType, targetID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range *notifications {
+		channel := realtime.NotificationsChannel(notification.UserID.String())
+		err = publish(repositories, channel, realtime.Deleted, notificationResource, deletedData(notification.ID.String(), nil))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifySubscribers send a notification to each subscriber of a target
+func notifySubscribers(repositories *repository.Repositories, targetType models.SubscriptionTarget, targetID uuid.UUID, notification *models.Notification) error {
+	subscriberIDs, err := repositories.Subscriptions.ListSubscriberIDs(targetType, targetID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, subscriberID := range subscriberIDs {
+		subscriberNotification := *notification
+		subscriberNotification.UserID = uuid.FromStringOrNil(subscriberID)
+
+		err = notify(repositories, &subscriberNotification)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotifications(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	author := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@notifications.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@notifications.dev"}
+	grace := &models.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@notifications.dev"}
+	db.Create(author)
+	db.Create(alan)
+	db.Create(grace)
+
+	bdaPost := &models.BdaPost{UserID: author.ID, Title: "notified"}
+	db.Create(bdaPost)
+
+	bdaPostHandler := NewBdaPostHandler(repository.NewBdaPostRepository(db), repository.NewUnitOfWork(db))
+	handler := NewNotificationHandler(repository.NewNotificationRepository(db))
+
+	like := func(user *models.User) {
+		db.Where("user_id = ?", user.ID).Delete(&models.Like{})
+
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: bdaPost.ID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, models.Like{})
+
+		bdaPostHandler.CreateBdaPostLike(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("CreateBdaPostLike code = %v, want 200", res.Code)
+		}
+	}
+
+	call := func(action gin.HandlerFunc, method string, body string, params gin.Params) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, author)
+		ctx.Params = params
+		ctx.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+
+		action(ctx)
+
+		return res
+	}
+
+	list := func() ([]NotificationResponse, int64) {
+		res := call(handler.ListNotifications, http.MethodGet, "", nil)
+		if res.Code != 200 {
+			t.Fatalf("ListNotifications code = %v, want 200", res.Code)
+		}
+
+		got := &struct {
+			Items       []NotificationResponse `json:"items"`
+			UnreadCount int64                  `json:"unreadCount"`
+		}{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		return got.Items, got.UnreadCount
+	}
+
+	like(alan)
+	like(grace)
+	// the author is not notified of their own like
+	like(author)
+
+	notifications, unread := list()
+	if len(notifications) != 1 || unread != 1 {
+		t.Fatalf("likes should be grouped in 1 unread notification, got %d notifications and %d unread", len(notifications), unread)
+	}
+
+	want := "Grace Hopper and 1 other person liked your bda post"
+	if notifications[0].Count != 2 || notifications[0].Message != want {
+		t.Errorf("grouped notification got count = %d and message = %q, want 2 and %q", notifications[0].Count, notifications[0].Message, want)
+	}
+
+	res := call(handler.ReadNotification, http.MethodPost, "", gin.Params{{Key: "id", Value: notifications[0].ID.String()}})
+	if res.Code != 200 {
+		t.Errorf("ReadNotification code = %v, want 200", res.Code)
+	}
+
+	// a like after the notification has been read starts a new notification
+	like(alan)
+
+	notifications, unread = list()
+	if len(notifications) != 2 || unread != 1 {
+		t.Errorf("got %d notifications and %d unread, want 2 notifications and 1 unread", len(notifications), unread)
+	}
+
+	res = call(handler.ReadAllNotifications, http.MethodPost, "", nil)
+	if res.Code != 204 {
+		t.Errorf("ReadAllNotifications code = %v, want 204", res.Code)
+	}
+
+	res = call(handler.UpdateNotificationPreferences, http.MethodPatch, `{"like": false}`, nil)
+	preferences := map[models.NotificationType]bool{}
+	_ = json.Unmarshal(res.Body.Bytes(), &preferences)
+	if res.Code != 200 || preferences[models.LikeNotification] || !preferences[models.CommentNotification] {
+		t.Errorf("UpdateNotificationPreferences got code = %v and preferences = %v", res.Code, preferences)
+	}
+
+	res = call(handler.UpdateNotificationPreferences, http.MethodPatch, `{"unknown": false}`, nil)
+	if res.Code != 400 {
+		t.Errorf("UpdateNotificationPreferences of an unknown type code = %v, want 400", res.Code)
+	}
+
+	// disabled types are not notified
+	like(grace)
+
+	_, unread = list()
+	if unread != 0 {
+		t.Errorf("disabled notifications got %d unread, want 0", unread)
+	}
+}
+
+func TestNotificationsOfRemovedLikes(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	author := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@unlikes.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@unlikes.dev"}
+	grace := &models.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@unlikes.dev"}
+	db.Create(author)
+	db.Create(alan)
+	db.Create(grace)
+
+	bdaPost := &models.BdaPost{UserID: author.ID, Title: "unliked"}
+	db.Create(bdaPost)
+
+	bdaPostHandler := NewBdaPostHandler(repository.NewBdaPostRepository(db), repository.NewUnitOfWork(db))
+
+	like := func(user *models.User) string {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: bdaPost.ID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, models.Like{})
+
+		bdaPostHandler.CreateBdaPostLike(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("CreateBdaPostLike code = %v, want 200", res.Code)
+		}
+
+		got := &models.Like{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		return got.ID.String()
+	}
+
+	unlike := func(user *models.User, likeID string) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: bdaPost.ID.String()}, {Key: "likeId", Value: likeID}}
+
+		bdaPostHandler.DeleteBdaPostLike(ctx)
+
+		if res.Code != 204 {
+			t.Fatalf("DeleteBdaPostLike code = %v, want 204", res.Code)
+		}
+	}
+
+	notification := func() *models.Notification {
+		notifications := []models.Notification{}
+		db.Where("user_id = ? AND target_id = ?", author.ID, bdaPost.ID).Find(&notifications)
+		if len(notifications) > 1 {
+			t.Fatalf("got %d notifications, want at most 1", len(notifications))
+		}
+
+		if len(notifications) == 0 {
+			return nil
+		}
+
+		return &notifications[0]
+	}
+
+	// a user liking, unliking and liking again is counted once
+	unlike(alan, like(alan))
+	if got := notification(); got != nil {
+		t.Errorf("the notification of a removed like should be deleted, got %+v", got)
+	}
+
+	like(alan)
+	unlike(grace, like(grace))
+	graceLike := like(grace)
+
+	got := notification()
+	if got == nil || got.ActorsCount != 2 || got.Count != 2 || got.ActorID != grace.ID {
+		t.Fatalf("notification got %+v, want 2 actors, 2 events and Grace as actor", got)
+	}
+
+	want := "Grace Hopper and 1 other person liked your bda post"
+	if message := notificationMessage(*got, "Grace Hopper"); message != want {
+		t.Errorf("notification message got %q, want %q", message, want)
+	}
+
+	// the previous actor is back when the last one is removed
+	unlike(grace, graceLike)
+
+	got = notification()
+	if got == nil || got.ActorsCount != 1 || got.Count != 1 || got.ActorID != alan.ID {
+		t.Errorf("notification got %+v, want 1 actor, 1 event and Alan as actor", got)
+	}
+}
@@ -0,0 +1,539 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	// errNotPollTargetAuthor is returned when a user adds a poll to the resource of another user
+	errNotPollTargetAuthor = errors.New("only the author of a resource can add a poll to it")
+	// errNotPollAuthor is returned when a user closes or deletes the poll of another user
+	errNotPollAuthor = errors.New("only the author of a poll can close or delete it")
+	// errPollClosed is returned when a user votes in a closed poll
+	errPollClosed = errors.New("the poll is closed")
+	// errTooManyPollOptions is returned when a poll is created with too many options
+	errTooManyPollOptions = fmt.Errorf("a poll can have at most %d options", models.MaxPollOptions)
+	// errInvalidDeadline is returned when a poll is created with a past deadline
+	errInvalidDeadline = errors.New("the deadline of the poll must be in the future")
+	// errInvalidChoice is returned when the options of a vote are not options of the poll,
+	// or several options are chosen in a single choice poll
+	errInvalidChoice = errors.New("the chosen options are not valid for the poll")
+)
+
+// PollResults are the votes of a poll by option
+type PollResults struct {
+	PollID      uuid.UUID           `json:"pollId"`
+	Closed      bool                `json:"closed"`
+	VotersCount int                 `json:"votersCount"`
+	Options     []PollOptionResults `json:"options"`
+	// Choices are the options chosen by the current user
+	Choices []uuid.UUID `json:"choices"`
+}
+
+// PollOptionResults are the votes of an option of a poll
+type PollOptionResults struct {
+	OptionID   uuid.UUID `json:"optionId"`
+	Text       string    `json:"text"`
+	VotesCount int       `json:"votesCount"`
+	// Voters are the users who chose the option, they are hidden in the anonymous polls
+	Voters []uuid.UUID `json:"voters,omitempty"`
+}
+
+// PollHandler is a struct to define poll handler
+type PollHandler struct {
+	repository repository.PollStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewPollHandler is a factory for poll handler
+func NewPollHandler(repository repository.PollStore, unitOfWork repository.UnitOfWork) *PollHandler {
+	return &PollHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// CreateTopicPoll create the poll of a topic
+func (p *PollHandler) CreateTopicPoll(c *gin.Context) {
+	p.createPoll(c, models.TopicPoll)
+}
+
+// CreateBdaPostPoll create the poll of a bda post
+func (p *PollHandler) CreateBdaPostPoll(c *gin.Context) {
+	p.createPoll(c, models.BdaPostPoll)
+}
+
+// createPoll create the poll of the resource of the id parameter, only its author can
+// add a poll
+func (p *PollHandler) createPoll(c *gin.Context, targetType models.PollTarget) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	targetID, _ := c.Params.Get("id")
+
+	targetUUID, err := uuid.FromString(targetID)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	poll := &models.Poll{}
+	err = c.ShouldBindJSON(poll)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	if len(poll.Options) > models.MaxPollOptions {
+		httpError.BadRequest(c, errTooManyPollOptions)
+		return
+	}
+
+	if poll.Closed(time.Now()) {
+		httpError.BadRequest(c, errInvalidDeadline)
+		return
+	}
+
+	poll.TargetType = targetType
+	poll.TargetID = targetUUID
+	poll.UserID = user.ID
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		authorID, err := findPollTargetAuthor(repositories, targetType, targetID)
+		if err != nil {
+			return err
+		}
+
+		if !uuid.Equal(authorID, user.ID) {
+			return errNotPollTargetAuthor
+		}
+
+		return repositories.Polls.CreatePoll(poll)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTopicNotFound) || errors.Is(err, repository.ErrBdaPostNotFound) {
+			httpError.NotFound(c, string(targetType), targetID, err)
+			return
+		}
+
+		if errors.Is(err, errNotPollTargetAuthor) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrPollExists) {
+			httpError.Conflict(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, poll.Base, poll)
+}
+
+// GetTopicPoll get the poll of a topic
+func (p *PollHandler) GetTopicPoll(c *gin.Context) {
+	p.getTargetPoll(c, models.TopicPoll)
+}
+
+// GetBdaPostPoll get the poll of a bda post
+func (p *PollHandler) GetBdaPostPoll(c *gin.Context) {
+	p.getTargetPoll(c, models.BdaPostPoll)
+}
+
+// getTargetPoll get the poll of the resource of the id parameter
+func (p *PollHandler) getTargetPoll(c *gin.Context, targetType models.PollTarget) {
+	targetID, _ := c.Params.Get("id")
+	poll := &models.Poll{}
+
+	err := p.repository.GetPollByTarget(poll, targetType, targetID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", targetID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, poll.Base, poll)
+}
+
+// GetPoll get a specific poll
+func (p *PollHandler) GetPoll(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	poll := &models.Poll{}
+
+	err := p.repository.GetPollByID(poll, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, poll.Base, poll)
+}
+
+// ClosePoll close the votes of a poll of the current user now, a closed poll is kept
+// as it is
+func (p *PollHandler) ClosePoll(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	poll := &models.Poll{}
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Polls.GetPollByID(poll, id)
+		if err != nil {
+			return err
+		}
+
+		if !uuid.Equal(poll.UserID, user.ID) {
+			return errNotPollAuthor
+		}
+
+		now := time.Now()
+		if poll.Closed(now) {
+			return nil
+		}
+
+		return repositories.Polls.ClosePoll(poll, now)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		if errors.Is(err, errNotPollAuthor) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, poll.Base, poll)
+}
+
+// DeletePoll delete a poll of the current user with its votes
+func (p *PollHandler) DeletePoll(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		poll := &models.Poll{}
+
+		err := repositories.Polls.GetPollByID(poll, id)
+		if err != nil {
+			return err
+		}
+
+		if !uuid.Equal(poll.UserID, user.ID) {
+			return errNotPollAuthor
+		}
+
+		err = checkPrecondition(c, func() (models.Base, error) { return poll.Base, nil })
+		if err != nil {
+			return err
+		}
+
+		return repositories.Polls.DeletePollByID(id)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		if errors.Is(err, errNotPollAuthor) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// VotePoll save the vote of the current user in a poll, a previous vote of the user is
+// replaced until the poll is closed
+func (p *PollHandler) VotePoll(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	vote := &models.PollVote{}
+	err = c.ShouldBindJSON(vote)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		poll := &models.Poll{}
+
+		err := repositories.Polls.GetPollByID(poll, id)
+		if err != nil {
+			return err
+		}
+
+		if poll.Closed(time.Now()) {
+			return errPollClosed
+		}
+
+		err = checkChoices(poll, vote.OptionIDs)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Polls.DeletePollVote(id, user.ID.String())
+		if err != nil && !errors.Is(err, repository.ErrPollVoteNotFound) {
+			return err
+		}
+
+		vote.PollID = poll.ID
+		vote.UserID = user.ID
+
+		return repositories.Polls.CreatePollVote(vote)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		if errors.Is(err, errPollClosed) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, errInvalidChoice) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
+		// another vote of the user has been saved in the meantime
+		if errors.Is(err, repository.ErrPollVoteExists) {
+			httpError.Conflict(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, vote.Base, vote)
+}
+
+// DeletePollVote delete the vote of the current user in a poll until it is closed
+func (p *PollHandler) DeletePollVote(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		poll := &models.Poll{}
+
+		err := repositories.Polls.GetPollByID(poll, id)
+		if err != nil {
+			return err
+		}
+
+		if poll.Closed(time.Now()) {
+			return errPollClosed
+		}
+
+		err = checkPrecondition(c, func() (models.Base, error) {
+			vote := &models.PollVote{}
+			err := repositories.Polls.GetPollVote(vote, id, user.ID.String())
+
+			return vote.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		return repositories.Polls.DeletePollVote(id, user.ID.String())
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrPollVoteNotFound) {
+			httpError.NotFound(c, "poll vote", id, err)
+			return
+		}
+
+		if errors.Is(err, errPollClosed) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// GetPollResults respond the number of votes of each option of a poll and the choices of
+// the current user, with the voters of each option when the poll is public
+func (p *PollHandler) GetPollResults(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	poll := &models.Poll{}
+
+	err = p.repository.GetPollByID(poll, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPollNotFound) {
+			httpError.NotFound(c, "poll", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	choices := &[]models.PollChoice{}
+	err = p.repository.ListPollChoices(choices, id)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, newPollResults(poll, *choices, user.ID, time.Now()))
+}
+
+// findPollTargetAuthor returns the author of a resource a poll can be attached to
+func findPollTargetAuthor(repositories *repository.Repositories, targetType models.PollTarget, targetID string) (uuid.UUID, error) {
+	if targetType == models.TopicPoll {
+		topic := &models.Topic{}
+		err := repositories.Topics.GetTopicByID(topic, targetID)
+		return topic.UserID, err
+	}
+
+	bdaPost := &models.BdaPost{}
+	err := repositories.BdaPosts.GetBdaPostByID(bdaPost, targetID)
+	return bdaPost.UserID, err
+}
+
+// checkChoices check the options of a vote are distinct options of a poll, and a single
+// option is chosen in a single choice poll
+func checkChoices(poll *models.Poll, optionIDs []uuid.UUID) error {
+	if len(optionIDs) > models.MaxPollOptions || (!poll.Multiple && len(optionIDs) != 1) {
+		return errInvalidChoice
+	}
+
+	options := map[uuid.UUID]bool{}
+	for _, option := range poll.Options {
+		options[option.ID] = true
+	}
+
+	chosen := map[uuid.UUID]bool{}
+	for _, optionID := range optionIDs {
+		if !options[optionID] || chosen[optionID] {
+			return errInvalidChoice
+		}
+
+		chosen[optionID] = true
+	}
+
+	return nil
+}
+
+// newPollResults returns the results of a poll from the choices of its voters
+func newPollResults(poll *models.Poll, choices []models.PollChoice, userID uuid.UUID, at time.Time) *PollResults {
+	results := &PollResults{
+		PollID:  poll.ID,
+		Closed:  poll.Closed(at),
+		Options: make([]PollOptionResults, len(poll.Options)),
+		Choices: []uuid.UUID{},
+	}
+
+	positions := map[uuid.UUID]int{}
+	for i, option := range poll.Options {
+		positions[option.ID] = i
+		results.Options[i] = PollOptionResults{OptionID: option.ID, Text: option.Text}
+	}
+
+	voters := map[uuid.UUID]bool{}
+	for _, choice := range choices {
+		position, ok := positions[choice.OptionID]
+		if !ok {
+			continue
+		}
+
+		voters[choice.UserID] = true
+		results.Options[position].VotesCount++
+
+		if !poll.Anonymous {
+			results.Options[position].Voters = append(results.Options[position].Voters, choice.UserID)
+		}
+
+		if uuid.Equal(choice.UserID, userID) {
+			results.Choices = append(results.Choices, choice.OptionID)
+		}
+	}
+
+	results.VotersCount = len(voters)
+
+	return results
+}
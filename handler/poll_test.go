@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestPolls(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	bda := &models.User{FirstName: "Hedy", LastName: "Lamarr", Email: "hedy@polls.dev"}
+	ada := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@polls.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@polls.dev"}
+	db.Create(bda)
+	db.Create(ada)
+	db.Create(alan)
+
+	bdaPost := &models.BdaPost{Title: "Party", Content: "Where do we go?", UserID: bda.ID}
+	topic := &models.Topic{Name: "Languages", Content: "Which ones?", UserID: ada.ID}
+	db.Create(bdaPost)
+	db.Create(topic)
+
+	handler := NewPollHandler(repository.NewPollRepository(db), repository.NewUnitOfWork(db))
+
+	request := func(user *models.User, id string, body string, handle gin.HandlerFunc) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: id}}
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+		handle(ctx)
+
+		return res
+	}
+
+	results := func(user *models.User, id string) *PollResults {
+		res := request(user, id, "", handler.GetPollResults)
+		if res.Code != 200 {
+			t.Fatalf("GetPollResults = %v, want 200", res.Code)
+		}
+
+		got := &PollResults{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		return got
+	}
+
+	t.Run("create", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+		tests := []struct {
+			name string
+			user *models.User
+			id   string
+			body string
+			want int
+		}{
+			{name: "not the author", user: ada, id: bdaPost.ID.String(), body: `{"question": "Where?", "options": [{"text": "Beach"}, {"text": "Mountain"}]}`, want: 403},
+			{name: "unknown bda post", user: bda, id: uuid.NewV4().String(), body: `{"question": "Where?", "options": [{"text": "Beach"}, {"text": "Mountain"}]}`, want: 404},
+			{name: "too many options", user: bda, id: bdaPost.ID.String(), body: `{"question": "Which?", "options": [{"text": "0"},{"text": "1"},{"text": "2"},{"text": "3"},{"text": "4"},{"text": "5"},{"text": "6"},{"text": "7"},{"text": "8"},{"text": "9"},{"text": "10"},{"text": "11"},{"text": "12"},{"text": "13"},{"text": "14"},{"text": "15"},{"text": "16"},{"text": "17"},{"text": "18"},{"text": "19"},{"text": "20"}]}`, want: 400},
+			{name: "past deadline", user: bda, id: bdaPost.ID.String(), body: `{"question": "Where?", "closesAt": "` + past + `", "options": [{"text": "Beach"}, {"text": "Mountain"}]}`, want: 400},
+			{name: "nominal", user: bda, id: bdaPost.ID.String(), body: `{"question": "Where?", "options": [{"text": "Beach"}, {"text": "Mountain"}, {"text": "City"}]}`, want: 200},
+			{name: "second poll", user: bda, id: bdaPost.ID.String(), body: `{"question": "When?", "options": [{"text": "Friday"}, {"text": "Saturday"}]}`, want: 409},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				res := request(test.user, test.id, test.body, handler.CreateBdaPostPoll)
+				if res.Code != test.want {
+					t.Errorf("CreateBdaPostPoll = %v, want %v: %s", res.Code, test.want, res.Body.String())
+				}
+			})
+		}
+	})
+
+	poll := &models.Poll{}
+	res := request(alan, bdaPost.ID.String(), "", handler.GetBdaPostPoll)
+	_ = json.Unmarshal(res.Body.Bytes(), poll)
+	if res.Code != 200 || len(poll.Options) != 3 || poll.Options[2].Text != "City" || poll.Options[2].Position != 2 {
+		t.Fatalf("GetBdaPostPoll = %v %+v, want the poll with its 3 options", res.Code, poll)
+	}
+
+	beach, mountain, city := poll.Options[0].ID, poll.Options[1].ID, poll.Options[2].ID
+	pollID := poll.ID.String()
+
+	t.Run("single choice", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			user    *models.User
+			options []uuid.UUID
+			want    int
+		}{
+			{name: "several options", user: ada, options: []uuid.UUID{beach, city}, want: 400},
+			{name: "unknown option", user: ada, options: []uuid.UUID{uuid.NewV4()}, want: 400},
+			{name: "vote", user: ada, options: []uuid.UUID{beach}, want: 200},
+			{name: "change", user: ada, options: []uuid.UUID{city}, want: 200},
+			{name: "other voter", user: alan, options: []uuid.UUID{city}, want: 200},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				body, _ := json.Marshal(map[string][]uuid.UUID{"optionIds": test.options})
+				res := request(test.user, pollID, string(body), handler.VotePoll)
+				if res.Code != test.want {
+					t.Errorf("VotePoll = %v, want %v: %s", res.Code, test.want, res.Body.String())
+				}
+			})
+		}
+
+		got := results(ada, pollID)
+		if got.VotersCount != 2 || got.Options[0].VotesCount != 0 || got.Options[2].VotesCount != 2 || len(got.Options[2].Voters) != 2 {
+			t.Errorf("GetPollResults = %+v, want 2 public votes for city", got)
+		}
+		if len(got.Choices) != 1 || !uuid.Equal(got.Choices[0], city) {
+			t.Errorf("GetPollResults choices = %v, want city", got.Choices)
+		}
+
+		var votes int64
+		db.Model(&models.PollVote{}).Where("poll_id = ? AND user_id = ?", pollID, ada.ID).Count(&votes)
+		if votes != 1 {
+			t.Errorf("got %v votes of the user, want 1", votes)
+		}
+	})
+
+	t.Run("one vote by user", func(t *testing.T) {
+		err := repository.NewPollRepository(db).CreatePollVote(&models.PollVote{PollID: poll.ID, UserID: ada.ID, OptionIDs: []uuid.UUID{mountain}})
+		if !errors.Is(err, repository.ErrPollVoteExists) {
+			t.Errorf("CreatePollVote of a user who voted = %v, want %v", err, repository.ErrPollVoteExists)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		res := request(ada, pollID, "", handler.ClosePoll)
+		if res.Code != 403 {
+			t.Errorf("ClosePoll of another user = %v, want 403", res.Code)
+		}
+
+		res = request(bda, pollID, "", handler.ClosePoll)
+		if res.Code != 200 {
+			t.Fatalf("ClosePoll = %v, want 200", res.Code)
+		}
+
+		body := fmt.Sprintf(`{"optionIds": ["%s"]}`, mountain)
+		if res := request(ada, pollID, body, handler.VotePoll); res.Code != 403 {
+			t.Errorf("VotePoll in a closed poll = %v, want 403", res.Code)
+		}
+		if res := request(alan, pollID, "", handler.DeletePollVote); res.Code != 403 {
+			t.Errorf("DeletePollVote in a closed poll = %v, want 403", res.Code)
+		}
+		if got := results(alan, pollID); !got.Closed || got.Options[2].VotesCount != 2 {
+			t.Errorf("GetPollResults of a closed poll = %+v, want the closed results", got)
+		}
+	})
+
+	t.Run("anonymous multiple choice", func(t *testing.T) {
+		deadline := time.Now().Add(time.Hour).Format(time.RFC3339)
+		body := `{"question": "Which?", "multiple": true, "anonymous": true, "closesAt": "` + deadline + `", "options": [{"text": "Go"}, {"text": "Rust"}, {"text": "Zig"}]}`
+
+		res := request(ada, topic.ID.String(), body, handler.CreateTopicPoll)
+		if res.Code != 200 {
+			t.Fatalf("CreateTopicPoll = %v, want 200: %s", res.Code, res.Body.String())
+		}
+
+		languages := &models.Poll{}
+		_ = json.Unmarshal(res.Body.Bytes(), languages)
+		id := languages.ID.String()
+		golang, rust := languages.Options[0].ID, languages.Options[1].ID
+
+		tests := []struct {
+			name    string
+			user    *models.User
+			options []uuid.UUID
+			want    int
+		}{
+			{name: "duplicate option", user: alan, options: []uuid.UUID{golang, golang}, want: 400},
+			{name: "several options", user: alan, options: []uuid.UUID{golang, rust}, want: 200},
+			{name: "one option", user: bda, options: []uuid.UUID{rust}, want: 200},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				body, _ := json.Marshal(map[string][]uuid.UUID{"optionIds": test.options})
+				res := request(test.user, id, string(body), handler.VotePoll)
+				if res.Code != test.want {
+					t.Errorf("VotePoll = %v, want %v: %s", res.Code, test.want, res.Body.String())
+				}
+			})
+		}
+
+		got := results(alan, id)
+		if got.VotersCount != 2 || got.Options[0].VotesCount != 1 || got.Options[1].VotesCount != 2 || got.Options[1].Voters != nil {
+			t.Errorf("GetPollResults = %+v, want anonymous counts", got)
+		}
+		if len(got.Choices) != 2 {
+			t.Errorf("GetPollResults choices = %v, want the 2 options of the user", got.Choices)
+		}
+
+		if res := request(bda, id, "", handler.DeletePollVote); res.Code != 204 {
+			t.Errorf("DeletePollVote = %v, want 204", res.Code)
+		}
+		if res := request(bda, id, "", handler.DeletePollVote); res.Code != 404 {
+			t.Errorf("DeletePollVote without vote = %v, want 404", res.Code)
+		}
+		if got := results(alan, id); got.VotersCount != 1 || got.Options[1].VotesCount != 1 {
+			t.Errorf("GetPollResults after a withdrawn vote = %+v, want 1 voter", got)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		res := request(alan, pollID, "", handler.DeletePoll)
+		if res.Code != 403 {
+			t.Errorf("DeletePoll of another user = %v, want 403", res.Code)
+		}
+
+		res = request(bda, pollID, "", handler.DeletePoll)
+		if res.Code != 204 {
+			t.Fatalf("DeletePoll = %v, want 204", res.Code)
+		}
+
+		var choices int64
+		db.Model(&models.PollChoice{}).Where("poll_id = ?", pollID).Count(&choices)
+		if choices != 0 {
+			t.Errorf("DeletePoll kept %v choices, want 0", choices)
+		}
+
+		if res := request(bda, pollID, "", handler.GetPoll); res.Code != 404 {
+			t.Errorf("GetPoll of a deleted poll = %v, want 404", res.Code)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	httpError "github.com/ada-social-network/api/error"
 	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
 	"github.com/ada-social-network/api/repository"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
@@ -12,26 +13,45 @@ import (
 
 // PostHandler is a struct to define post handler
 type PostHandler struct {
-	repository *repository.PostRepository
+	repository repository.PostStore
+	unitOfWork repository.UnitOfWork
 }
 
 // NewPostHandler is a factory post handler
-func NewPostHandler(repository *repository.PostRepository) *PostHandler {
-	return &PostHandler{repository: repository}
+func NewPostHandler(repository repository.PostStore, unitOfWork repository.UnitOfWork) *PostHandler {
+	return &PostHandler{repository: repository, unitOfWork: unitOfWork}
 }
 
 // ListPost get posts of a topic
 func (p *PostHandler) ListPost(c *gin.Context) {
+	page, err := GetPage(c, models.PostFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.PostRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	id, _ := c.Params.Get("id")
 	posts := &[]models.Post{}
 
-	err := p.repository.ListAllPostsByTopicID(posts, id)
+	info, err := p.repository.ListPostsByTopicID(posts, id, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, posts)
+	items, err := representation.Render(p.repository, collectionItems(posts))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreatePost create a post
@@ -60,21 +80,137 @@ func (p *PostHandler) CreatePost(c *gin.Context) {
 	post.UserID = user.ID
 	post.TopicID = topicUUID
 
-	err = p.repository.CreatePost(post)
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkSnippet(repositories, post.SnippetID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Posts.CreatePost(post)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.PostMention, post.ID, post.UserID, post.Content)
+		if err != nil {
+			return err
+		}
+
+		err = syncTags(repositories, models.PostTag, post.ID, post.Content, nil)
+		if err != nil {
+			return err
+		}
+
+		err = syncLinkPreviews(repositories, post.ID, post.Content)
+		if err != nil {
+			return err
+		}
+
+		post.Attachments, err = saveAttachments(repositories, models.PostAttachment, post.ID, post.UserID, post.Attachments)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Topics.UpdatePostsCount(topicID, 1)
+		if err != nil {
+			return err
+		}
+
+		err = publish(repositories, realtime.TopicChannel(topicID), realtime.Created, postResource, post)
+		if err != nil {
+			return err
+		}
+
+		return notifySubscribers(repositories, models.TopicSubscription, post.TopicID, &models.Notification{
+			Type:       models.PostNotification,
+			TargetType: models.TopicTarget,
+			TargetID:   post.TopicID,
+			ActorID:    user.ID,
+		})
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrTopicNotFound) {
+			httpError.NotFound(c, "topic", topicID, err)
+			return
+		}
+
+		if isAttachmentError(err) || errors.Is(err, errInvalidSnippet) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, post)
+	RespondResource(c, post.Base, post)
 }
 
 // DeletePost delete a specific post
 func (p *PostHandler) DeletePost(c *gin.Context) {
 	postID, _ := c.Params.Get("postId")
 
-	err := p.repository.DeletePostByID(postID)
+	err := p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		post := &models.Post{}
+
+		err := repositories.Posts.GetPostByID(post, postID)
+		if err != nil {
+			return err
+		}
+
+		err = checkPrecondition(c, func() (models.Base, error) { return post.Base, nil })
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Likes.DeleteLikesByPostID(postID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Posts.DeletePostByID(postID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Mentions.DeleteMentionsBySource(models.PostMention, postID)
+		if err != nil {
+			return err
+		}
+
+		err = removeTags(repositories, models.PostTag, postID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Attachments.DeleteAttachmentsBySource(models.PostAttachment, postID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.LinkPreviews.DeleteLinkPreviewsByPostID(postID)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTargetComments(repositories, models.PostComment, postID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Topics.UpdatePostsCount(post.TopicID.String(), -1)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.TopicChannel(post.TopicID.String()), realtime.Deleted, postResource, deletedData(postID, gin.H{"topicId": post.TopicID}))
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		if errors.Is(err, repository.ErrPostNotFound) {
 			httpError.NotFound(c, "post", postID, err)
 			return
@@ -89,49 +225,118 @@ func (p *PostHandler) DeletePost(c *gin.Context) {
 
 // GetPost get a specific post
 func (p *PostHandler) GetPost(c *gin.Context) {
-	postID, _ := c.Params.Get("postID")
+	representation, err := GetRepresentation(c, models.PostRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	postID, _ := c.Params.Get("postId")
 
 	post := &models.Post{}
 
-	err := p.repository.GetPostByID(post, postID)
+	err = p.repository.GetPostByID(post, postID)
 	if err != nil {
 		if errors.Is(err, repository.ErrPostNotFound) {
 			httpError.NotFound(c, "post", postID, err)
+			return
 		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	resource, err := representation.RenderOne(p.repository, post)
+	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, post)
+	RespondResource(c, post.Base, resource)
 }
 
 // UpdatePost update a specific post
 func (p *PostHandler) UpdatePost(c *gin.Context) {
-	postID, _ := c.Params.Get("postID")
+	postID, _ := c.Params.Get("postId")
 	post := &models.Post{}
 
 	err := p.repository.GetPostByID(post, postID)
 	if err != nil {
 		if errors.Is(err, repository.ErrPostNotFound) {
 			httpError.NotFound(c, "post", postID, err)
+			return
 		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
+	if !CheckIfMatch(c, post.Base) {
+		return
+	}
+
+	// the topic and the author of the post can not be updated
+	stored := *post
+
 	err = c.ShouldBindJSON(post)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = p.repository.UpdatePost(post)
+	post.Version = stored.Version
+	post.TopicID = stored.TopicID
+	post.UserID = stored.UserID
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkSnippet(repositories, post.SnippetID)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Posts.UpdatePost(post)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.PostMention, post.ID, post.UserID, post.Content)
+		if err != nil {
+			return err
+		}
+
+		err = syncTags(repositories, models.PostTag, post.ID, post.Content, nil)
+		if err != nil {
+			return err
+		}
+
+		err = syncLinkPreviews(repositories, post.ID, post.Content)
+		if err != nil {
+			return err
+		}
+
+		post.Attachments, err = saveAttachments(repositories, models.PostAttachment, post.ID, post.UserID, post.Attachments)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.TopicChannel(post.TopicID.String()), realtime.Updated, postResource, post)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if isAttachmentError(err) || errors.Is(err, errInvalidSnippet) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, post)
+	RespondResource(c, post.Base, post)
 }
 
 // LikePostResponse defines the Like response for a Post
@@ -181,13 +386,41 @@ func (p *PostHandler) CreatePostLike(c *gin.Context) {
 		return
 	}
 
-	err = p.repository.CreateLike(like)
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Posts.CreateLike(like)
+		if err != nil {
+			return err
+		}
+
+		post := &models.Post{}
+		err = repositories.Posts.GetPostByID(post, postID)
+		if err != nil {
+			if errors.Is(err, repository.ErrPostNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = publish(repositories, realtime.TopicChannel(post.TopicID.String()), realtime.Created, likeResource, createPostLikeResponse(*like))
+		if err != nil {
+			return err
+		}
+
+		return notify(repositories, &models.Notification{
+			UserID:     post.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.PostTarget,
+			TargetID:   post.ID,
+			ActorID:    user.ID,
+		})
+	})
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, createPostLikeResponse(*like))
+	RespondResource(c, like.Base, createPostLikeResponse(*like))
 }
 
 // ListPostLikes get likes of a post
@@ -201,7 +434,19 @@ func (p *PostHandler) ListPostLikes(c *gin.Context) {
 		return
 	}
 
-	err = p.repository.ListAllPostsByPostID(likes, postID)
+	page, err := GetPage(c, models.LikeFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.LikeRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	info, err := p.repository.ListLikesByPostID(likes, postID, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
@@ -226,14 +471,55 @@ func (p *PostHandler) ListPostLikes(c *gin.Context) {
 		likesResponse = append(likesResponse, createPostLikeResponse(like))
 	}
 
-	c.JSON(200, NewLikeCollection(likesResponse, isLikedByCurrentUser))
+	items, err := representation.Render(p.repository, likesResponse)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewLikeCollection(items, info, isLikedByCurrentUser))
 }
 
 // DeletePostLike delete a specific like
 func (p *PostHandler) DeletePostLike(c *gin.Context) {
+	postID, _ := c.Params.Get("id")
 	id, _ := c.Params.Get("likeId")
 
-	err := p.repository.DeleteLikeByID(id)
+	err := p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		like := &models.Like{}
+		err := repositories.Likes.GetLikeByID(like, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Posts.DeleteLikeByID(id)
+		if err != nil {
+			return err
+		}
+
+		post := &models.Post{}
+		err = repositories.Posts.GetPostByID(post, postID)
+		if err != nil {
+			if errors.Is(err, repository.ErrPostNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		err = publish(repositories, realtime.TopicChannel(post.TopicID.String()), realtime.Deleted, likeResource, deletedData(id, gin.H{"postId": post.ID}))
+		if err != nil {
+			return err
+		}
+
+		return unnotify(repositories, &models.Notification{
+			UserID:     post.UserID,
+			Type:       models.LikeNotification,
+			TargetType: models.PostTarget,
+			TargetID:   post.ID,
+			ActorID:    like.UserID,
+		})
+	})
 	if err != nil {
 		if errors.Is(err, repository.ErrLikeNotFound) {
 			httpError.NotFound(c, "like", id, err)
@@ -1,5 +1,17 @@
 package handler
 
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+)
+
 /* func TestListPostHandler(t *testing.T) {
 	db := commonTesting.InitDB(&models.Post{})
 	res, ctx, _ := commonTesting.InitHTTPTest()
@@ -209,3 +221,222 @@ func TestGetPostHandler(t *testing.T) {
 	}
 }
 */
+
+// fakePostCreator is an in-memory post store
+type fakePostCreator struct {
+	repository.PostStore
+	posts []*models.Post
+}
+
+func (f *fakePostCreator) CreatePost(post *models.Post) error {
+	f.posts = append(f.posts, post)
+	return nil
+}
+
+// fakeTopicCounter is an in-memory topic store keeping posts counters
+type fakeTopicCounter struct {
+	repository.TopicStore
+	counts map[string]int
+}
+
+func (f *fakeTopicCounter) UpdatePostsCount(topicID string, delta int) error {
+	if _, ok := f.counts[topicID]; !ok {
+		return repository.ErrTopicNotFound
+	}
+
+	f.counts[topicID] += delta
+	return nil
+}
+
+// fakeSubscribers is an in-memory subscription store of the subscribers of topics
+type fakeSubscribers struct {
+	repository.SubscriptionStore
+	subscribers map[string][]string
+}
+
+func (f *fakeSubscribers) ListSubscriberIDs(targetType models.SubscriptionTarget, targetID string) ([]string, error) {
+	return f.subscribers[targetID], nil
+}
+
+// fakeMentions is an in-memory mention store of a post without mentions
+type fakeMentions struct {
+	repository.MentionStore
+}
+
+func (f *fakeMentions) ListMentionsBySource(mentions *[]models.Mention, sourceType models.MentionSource, sourceID string) error {
+	return nil
+}
+
+func (f *fakeMentions) DeleteMentionsByIDs(mentionIDs []string) error {
+	return nil
+}
+
+// fakeMentionedUsers is an in-memory user store without mentioned users
+type fakeMentionedUsers struct {
+	repository.UserStore
+}
+
+func (f *fakeMentionedUsers) ListUsersByMentionHandles(users *[]models.User, handles []string) error {
+	return nil
+}
+
+// fakeTags is an in-memory tag store of a post without tags
+type fakeTags struct {
+	repository.TagStore
+}
+
+func (f *fakeTags) ListTaggingsBySource(taggings *[]models.Tagging, sourceType models.TagSource, sourceID string) error {
+	return nil
+}
+
+func (f *fakeTags) DeleteTaggingsByIDs(taggingIDs []string) error {
+	return nil
+}
+
+// fakeAttachments is an in-memory attachment store of a post without attachments
+type fakeAttachments struct {
+	repository.AttachmentStore
+}
+
+func (f *fakeAttachments) ListAttachmentsBySource(attachments *[]models.Attachment, sourceType models.AttachmentSource, sourceID string) error {
+	return nil
+}
+
+// fakeLinkPreviews is an in-memory link preview store of a post without links
+type fakeLinkPreviews struct {
+	repository.LinkPreviewStore
+}
+
+func (f *fakeLinkPreviews) ListLinkPreviewsByPostID(previews *[]models.LinkPreview, postID string) error {
+	return nil
+}
+
+// fakeNotifier is an in-memory notification store recording the notifications
+type fakeNotifier struct {
+	repository.NotificationStore
+	notifications []*models.Notification
+}
+
+func (f *fakeNotifier) Notify(notification *models.Notification) error {
+	f.notifications = append(f.notifications, notification)
+	return nil
+}
+
+func TestCreatePost(t *testing.T) {
+	type want struct {
+		code          int
+		count         int
+		notifications int
+	}
+
+	tests := []struct {
+		name    string
+		topicID string
+		want    want
+	}{
+		{
+			name:    "nominal",
+			topicID: "80a08d36-cfea-4898-aee3-6902fa562f0b",
+			want: want{
+				code:          200,
+				count:         1,
+				notifications: 1,
+			},
+		},
+		{
+			name:    "topic not found",
+			topicID: "99999999-9999-9999-9999-999999999999",
+			want: want{
+				code:          404,
+				count:         0,
+				notifications: 0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+
+			posts := &fakePostCreator{}
+			topics := &fakeTopicCounter{counts: map[string]int{"80a08d36-cfea-4898-aee3-6902fa562f0b": 0}}
+			// the author subscribed to the topic is not notified of their own post
+			subscriptions := &fakeSubscribers{subscribers: map[string][]string{
+				"80a08d36-cfea-4898-aee3-6902fa562f0b": {"80a08d36-cfea-4898-aee3-6902fa562f1d", "80a08d36-cfea-4898-aee3-6902fa562f2e"},
+			}}
+			notifications := &fakeNotifier{}
+			unitOfWork := &commonTesting.UnitOfWork{Repositories: &repository.Repositories{
+				Posts:         posts,
+				Topics:        topics,
+				Subscriptions: subscriptions,
+				Notifications: notifications,
+				Mentions:      &fakeMentions{},
+				Tags:          &fakeTags{},
+				Users:         &fakeMentionedUsers{},
+				Attachments:   &fakeAttachments{},
+				LinkPreviews:  &fakeLinkPreviews{},
+			}}
+
+			ctx.Set(middleware.IdentityKey, &models.User{Base: models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d")}})
+			ctx.Params = gin.Params{{Key: "id", Value: tt.topicID}}
+			commonTesting.AddRequestWithBodyToContext(ctx, models.Post{Content: "lorem ipsum"})
+
+			NewPostHandler(posts, unitOfWork).CreatePost(ctx)
+
+			if res.Code != tt.want.code {
+				t.Errorf("CreatePost want:%d, got:%d", tt.want.code, res.Code)
+			}
+
+			if topics.counts[tt.topicID] != tt.want.count {
+				t.Errorf("CreatePost posts count want:%d, got:%d", tt.want.count, topics.counts[tt.topicID])
+			}
+
+			if len(notifications.notifications) != tt.want.notifications {
+				t.Errorf("CreatePost notifications want:%d, got:%d", tt.want.notifications, len(notifications.notifications))
+			}
+		})
+	}
+}
+
+func TestUpdatePost(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	user := &models.User{Email: "update-post@users.dev"}
+	other := &models.User{Email: "update-post-other@users.dev"}
+	db.Create(user)
+	db.Create(other)
+
+	topic := &models.Topic{Name: "Update post", UserID: user.ID}
+	moved := &models.Topic{Name: "Moved post", UserID: other.ID}
+	db.Create(topic)
+	db.Create(moved)
+
+	post := &models.Post{Content: "lorem ipsum", TopicID: topic.ID, UserID: user.ID}
+	db.Create(post)
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Set(middleware.IdentityKey, user)
+	ctx.Params = gin.Params{{Key: "postId", Value: post.ID.String()}}
+	commonTesting.AddRequestWithBodyToContext(ctx, models.Post{Content: "dolor sit amet", TopicID: moved.ID, UserID: other.ID})
+
+	NewPostHandler(repository.NewPostRepository(db), repository.NewUnitOfWork(db)).UpdatePost(ctx)
+
+	if res.Code != 200 {
+		t.Fatalf("UpdatePost want:%d, got:%d", 200, res.Code)
+	}
+
+	got := &models.Post{}
+	db.First(got, "id = ?", post.ID)
+
+	if got.Content != "dolor sit amet" {
+		t.Errorf("UpdatePost content want:%s, got:%s", "dolor sit amet", got.Content)
+	}
+
+	if got.TopicID != topic.ID {
+		t.Errorf("UpdatePost topic want:%s, got:%s", topic.ID, got.TopicID)
+	}
+
+	if got.UserID != user.ID {
+		t.Errorf("UpdatePost author want:%s, got:%s", user.ID, got.UserID)
+	}
+}
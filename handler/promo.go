@@ -11,41 +11,76 @@ import (
 
 // PromoHandler is a struct to define promo handler
 type PromoHandler struct {
-	repository *repository.PromoRepository
+	repository repository.PromoStore
+	unitOfWork repository.UnitOfWork
 }
 
 // NewPromoHandler is a factory promo handler
-func NewPromoHandler(repository *repository.PromoRepository) *PromoHandler {
-	return &PromoHandler{repository: repository}
+func NewPromoHandler(repository repository.PromoStore, unitOfWork repository.UnitOfWork) *PromoHandler {
+	return &PromoHandler{repository: repository, unitOfWork: unitOfWork}
 }
 
 // ListPromoUsers get users from the same promo
 func (p *PromoHandler) ListPromoUsers(c *gin.Context) {
+	page, err := GetPage(c, models.UserFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.UserRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	id, _ := c.Params.Get("id")
-	promo := &[]models.User{}
+	users := &[]models.User{}
 
-	err := p.repository.ListAllUsersByPromoID(promo, id)
+	info, err := p.repository.ListUsersByPromoID(users, id, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, promo)
+	items, err := representation.Render(p.repository, createUsersResponse(users))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
 
+	c.JSON(200, NewCollection(items, info))
 }
 
 // ListPromos respond the list of all promos
 func (p *PromoHandler) ListPromos(c *gin.Context) {
+	page, err := GetPage(c, models.PromoFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.PromoRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	promos := &[]models.Promo{}
 
-	err := p.repository.ListAllPromos(promos)
+	info, err := p.repository.ListPromos(promos, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, promos)
+	items, err := representation.Render(p.repository, collectionItems(promos))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
 
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreatePromo create a promo
@@ -58,13 +93,25 @@ func (p *PromoHandler) CreatePromo(c *gin.Context) {
 		return
 	}
 
-	err = p.repository.CreatePromo(promo)
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPictures(repositories, nil, promo.ProfilePicID)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Promos.CreatePromo(promo)
+	})
 	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, promo)
+	RespondResource(c, promo.Base, promo)
 
 }
 
@@ -72,8 +119,30 @@ func (p *PromoHandler) CreatePromo(c *gin.Context) {
 func (p *PromoHandler) DeletePromo(c *gin.Context) {
 	promoID, _ := c.Params.Get("id")
 
-	err := p.repository.DeleteByPromoID(promoID)
+	err := p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			promo := &models.Promo{}
+			err := repositories.Promos.GetPromoByID(promo, promoID)
+
+			return promo.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		return repositories.Promos.DeleteByPromoID(promoID)
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrPromoNotFound) {
+			httpError.NotFound(c, "Promo", promoID, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
@@ -97,17 +166,42 @@ func (p *PromoHandler) UpdatePromo(c *gin.Context) {
 		return
 	}
 
+	if !CheckIfMatch(c, promo.Base) {
+		return
+	}
+
+	version := promo.Version
+
 	err = c.ShouldBindJSON(promo)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = p.repository.UpdatePromo(promo)
+	promo.Version = version
+
+	err = p.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPictures(repositories, nil, promo.ProfilePicID)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Promos.UpdatePromo(promo)
+	})
 	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, promo)
+	RespondResource(c, promo.Base, promo)
 }
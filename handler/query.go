@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+)
+
+// filterParameter matches the filter query parameters, e.g. filter[userId] or filter[createdAt][gte]
+var filterParameter = regexp.MustCompile(`^filter\[([A-Za-z]+)\](?:\[([a-z]+)\])?$`)
+
+// GetPage get the requested page of a list from the query parameters:
+//
+//   - limit: the maximum number of items
+//   - after: the cursor of the previous page
+//   - sort: comma separated fields, prefixed by - for a descending order, e.g. sort=-createdAt
+//   - filter[field][operator]: a filter on a field, the operator is eq when omitted
+//
+// Only the given fields of the resource can be used to filter and sort the list.
+func GetPage(c *gin.Context, fields models.Fields) (repository.Page, error) {
+	return GetSortedPage(c, fields, nil)
+}
+
+// GetSortedPage get the requested page of a list like GetPage, the list is sorted by
+// defaultSort when the sort query parameter is not given
+func GetSortedPage(c *gin.Context, fields models.Fields, defaultSort []repository.Sort) (repository.Page, error) {
+	page := repository.Page{}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 {
+			return page, fmt.Errorf("limit must be a positive number")
+		}
+
+		page.Limit = l
+	}
+
+	sorts, err := parseSort(c.Query("sort"), fields)
+	if err != nil {
+		return page, err
+	}
+	page.Sort = sorts
+	if len(page.Sort) == 0 {
+		page.Sort = defaultSort
+	}
+
+	filters, err := parseFilters(c.Request.URL.Query(), fields)
+	if err != nil {
+		return page, err
+	}
+	page.Filters = filters
+
+	if after := c.Query("after"); after != "" {
+		cursor, err := repository.DecodeCursor(after, page.Sort)
+		if err != nil {
+			return page, err
+		}
+
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+// parseSort parse the sort query parameter
+func parseSort(value string, fields models.Fields) ([]repository.Sort, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	sorts := []repository.Sort{}
+	for _, name := range strings.Split(value, ",") {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := fields[name]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("can not sort by %q", name)
+		}
+
+		sorts = append(sorts, repository.Sort{Field: field, Desc: desc})
+	}
+
+	return sorts, nil
+}
+
+// parseFilters parse the filter query parameters
+func parseFilters(query map[string][]string, fields models.Fields) ([]repository.Filter, error) {
+	// query parameters are sorted to always build the same query
+	parameters := make([]string, 0, len(query))
+	for parameter := range query {
+		if strings.HasPrefix(parameter, "filter") {
+			parameters = append(parameters, parameter)
+		}
+	}
+	sort.Strings(parameters)
+
+	filters := []repository.Filter{}
+	for _, parameter := range parameters {
+		match := filterParameter.FindStringSubmatch(parameter)
+		if match == nil {
+			return nil, fmt.Errorf("invalid filter %q", parameter)
+		}
+
+		field, ok := fields[match[1]]
+		if !ok {
+			return nil, fmt.Errorf("can not filter by %q", match[1])
+		}
+
+		operator := repository.Equal
+		if match[2] != "" {
+			operator = repository.Operator(match[2])
+		}
+
+		if !allowedOperator(operator, field.Type) {
+			return nil, fmt.Errorf("can not filter %q with operator %q", match[1], operator)
+		}
+
+		for _, value := range query[parameter] {
+			filter := repository.Filter{Field: field, Operator: operator}
+
+			if operator == repository.In {
+				values := []interface{}{}
+				for _, v := range strings.Split(value, ",") {
+					parsed, err := parseValue(v, field.Type)
+					if err != nil {
+						return nil, fmt.Errorf("invalid value of filter %q: %w", match[1], err)
+					}
+
+					values = append(values, parsed)
+				}
+				filter.Value = values
+			} else {
+				parsed, err := parseValue(value, field.Type)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value of filter %q: %w", match[1], err)
+				}
+				filter.Value = parsed
+			}
+
+			filters = append(filters, filter)
+		}
+	}
+
+	return filters, nil
+}
+
+// allowedOperator check if an operator can be applied to a type of field
+func allowedOperator(operator repository.Operator, fieldType models.FieldType) bool {
+	for _, allowed := range repository.Operators(fieldType) {
+		if allowed == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseValue parse the value of a filter to the go type of a field
+func parseValue(value string, fieldType models.FieldType) (interface{}, error) {
+	switch fieldType {
+	case models.UUIDField:
+		return uuid.FromString(value)
+	case models.TimeField:
+		return time.Parse(time.RFC3339Nano, value)
+	case models.IntField:
+		return strconv.Atoi(value)
+	case models.BoolField:
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
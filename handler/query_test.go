@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+)
+
+func TestGetPage(t *testing.T) {
+	userID := uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f1d")
+	createdAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	byDate := []repository.Sort{{Field: models.TopicFields["createdAt"], Desc: true}}
+	cursor := repository.Cursor{Sort: "-created_at,id", Values: []interface{}{createdAt, userID}}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    repository.Page
+		wantErr bool
+	}{
+		{
+			name: "first page",
+			want: repository.Page{Filters: []repository.Filter{}},
+		},
+		{
+			name:  "limit and cursor",
+			query: "?limit=10&sort=-createdAt&after=" + cursor.Encode(),
+			want:  repository.Page{Limit: 10, After: &cursor, Sort: byDate, Filters: []repository.Filter{}},
+		},
+		{
+			name:  "filters",
+			query: "?filter[userId]=" + userID.String() + "&filter[createdAt][gte]=2022-01-01T00:00:00Z&filter[postsCount][lt]=3&filter[name][in]=foo,bar",
+			want: repository.Page{Filters: []repository.Filter{
+				{Field: models.TopicFields["createdAt"], Operator: repository.GreaterThanOrEqual, Value: createdAt},
+				{Field: models.TopicFields["name"], Operator: repository.In, Value: []interface{}{"foo", "bar"}},
+				{Field: models.TopicFields["postsCount"], Operator: repository.LessThan, Value: 3},
+				{Field: models.TopicFields["userId"], Operator: repository.Equal, Value: userID},
+			}},
+		},
+		{
+			name:    "negative limit",
+			query:   "?limit=-1",
+			wantErr: true,
+		},
+		{
+			name:    "invalid cursor",
+			query:   "?after=foo",
+			wantErr: true,
+		},
+		{
+			name:    "cursor of another sort",
+			query:   "?after=" + cursor.Encode(),
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort field",
+			query:   "?sort=content",
+			wantErr: true,
+		},
+		{
+			name:    "not sortable field",
+			query:   "?sort=userId",
+			wantErr: true,
+		},
+		{
+			name:    "unknown filter field",
+			query:   "?filter[password]=foo",
+			wantErr: true,
+		},
+		{
+			name:    "unknown filter operator",
+			query:   "?filter[name][regexp]=foo",
+			wantErr: true,
+		},
+		{
+			name:    "operator not allowed for the field",
+			query:   "?filter[userId][gt]=" + userID.String(),
+			wantErr: true,
+		},
+		{
+			name:    "invalid filter value",
+			query:   "?filter[createdAt][gte]=yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+
+			got, err := GetPage(ctx, models.TopicFields)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPage() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
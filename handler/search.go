@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/search"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultSearchLimit is the number of hits of each type when the limit is not given
+	DefaultSearchLimit = 5
+	// MaxSearchLimit is the maximum number of hits of each type
+	MaxSearchLimit = 20
+)
+
+// searchGroups are the names of the groups of hits in a search response
+var searchGroups = map[search.Kind]string{
+	search.TopicKind:   "topics",
+	search.PostKind:    "posts",
+	search.BdaPostKind: "bdaPosts",
+	search.CommentKind: "comments",
+	search.UserKind:    "users",
+}
+
+// SearchHandler is a struct to define search handler
+type SearchHandler struct {
+	repository repository.SearchStore
+	blocks     repository.BlockStore
+}
+
+// NewSearchHandler is a factory for search handler
+func NewSearchHandler(repository repository.SearchStore, blocks repository.BlockStore) *SearchHandler {
+	return &SearchHandler{repository: repository, blocks: blocks}
+}
+
+// SearchResponse defines the response of a search, hits are grouped by type and the
+// best hits come first
+type SearchResponse struct {
+	Query   string                  `json:"query"`
+	Results map[string][]search.Hit `json:"results"`
+}
+
+// Search respond the resources matching the q query parameter, the resources of the
+// users blocked by the current user or who blocked them are not returned
+func (s *SearchHandler) Search(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	query, err := getSearchQuery(c)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	query.ExcludedUserIDs, err = s.blocks.ListBlockedUserIDs(user.ID.String())
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	results, err := s.repository.Search(query)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	kinds := query.Kinds
+	if len(kinds) == 0 {
+		kinds = search.Kinds
+	}
+
+	response := SearchResponse{Query: query.Text, Results: map[string][]search.Hit{}}
+	for _, kind := range kinds {
+		hits := results[kind]
+		if hits == nil {
+			hits = []search.Hit{}
+		}
+
+		response.Results[searchGroups[kind]] = hits
+	}
+
+	c.JSON(200, response)
+}
+
+// getSearchQuery read the q, type and limit query parameters
+func getSearchQuery(c *gin.Context) (search.Query, error) {
+	query := search.Query{Text: strings.TrimSpace(c.Query("q")), Limit: DefaultSearchLimit}
+	if query.Text == "" {
+		return query, fmt.Errorf("q query parameter is required")
+	}
+
+	if types := c.Query("type"); types != "" {
+		for _, name := range strings.Split(types, ",") {
+			kind, ok := searchKind(strings.TrimSpace(name))
+			if !ok {
+				return query, fmt.Errorf("unknown search type %q", name)
+			}
+
+			query.Kinds = append(query.Kinds, kind)
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxSearchLimit {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", MaxSearchLimit)
+		}
+
+		query.Limit = value
+	}
+
+	return query, nil
+}
+
+// searchKind find a kind by its group name, e.g. "posts"
+func searchKind(name string) (search.Kind, bool) {
+	for _, kind := range search.Kinds {
+		if searchGroups[kind] == name {
+			return kind, true
+		}
+	}
+
+	return "", false
+}
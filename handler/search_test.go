@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/search"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeSearchStore records the query it receives and returns a hit for each kind
+type fakeSearchStore struct {
+	query search.Query
+}
+
+func (f *fakeSearchStore) Search(query search.Query) (map[search.Kind][]search.Hit, error) {
+	f.query = query
+
+	results := map[search.Kind][]search.Hit{}
+	for _, kind := range query.Kinds {
+		results[kind] = []search.Hit{{Kind: kind, ID: "1", Title: "<mark>ada</mark>"}}
+	}
+
+	return results, nil
+}
+
+// fakeBlockedStore returns the same blocked users for any user
+type fakeBlockedStore struct {
+	repository.BlockStore
+	blockedIDs []string
+}
+
+func (f *fakeBlockedStore) ListBlockedUserIDs(userID string) ([]string, error) {
+	return f.blockedIDs, nil
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		wantCode  int
+		wantLimit int
+		wantTypes []string
+	}{
+		{name: "all types", url: "/?q=ada", wantCode: 200, wantLimit: DefaultSearchLimit, wantTypes: []string{"topics", "posts", "bdaPosts", "comments", "users"}},
+		{name: "some types", url: "/?q=ada&type=posts,users&limit=10", wantCode: 200, wantLimit: 10, wantTypes: []string{"posts", "users"}},
+		{name: "missing query", url: "/?q=%20", wantCode: 400},
+		{name: "unknown type", url: "/?q=ada&type=promos", wantCode: 400},
+		{name: "limit too high", url: "/?q=ada&limit=21", wantCode: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeSearchStore{}
+			blocks := &fakeBlockedStore{blockedIDs: []string{"80a08d36-cfea-4898-aee3-6902fa562f0d"}}
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Request = httptest.NewRequest(http.MethodGet, tt.url, nil)
+			ctx.Set(middleware.IdentityKey, &models.User{})
+
+			NewSearchHandler(store, blocks).Search(ctx)
+
+			if res.Code != tt.wantCode {
+				t.Fatalf("Search code = %v, want %v: %s", res.Code, tt.wantCode, res.Body.String())
+			}
+
+			if tt.wantCode != 200 {
+				return
+			}
+
+			if store.query.Limit != tt.wantLimit {
+				t.Errorf("Search limit = %v, want %v", store.query.Limit, tt.wantLimit)
+			}
+
+			if !reflect.DeepEqual(store.query.ExcludedUserIDs, blocks.blockedIDs) {
+				t.Errorf("Search excluded users = %v, want %v", store.query.ExcludedUserIDs, blocks.blockedIDs)
+			}
+
+			got := &SearchResponse{}
+			_ = json.Unmarshal(res.Body.Bytes(), got)
+
+			if len(got.Results) != len(tt.wantTypes) {
+				t.Errorf("Search got results %v, want types %v", got.Results, tt.wantTypes)
+			}
+
+			for _, name := range tt.wantTypes {
+				if _, ok := got.Results[name]; !ok {
+					t.Errorf("Search results miss type %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchBlockedUsers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(models.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = search.Register(db, search.NewLikeEngine())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@search.dev"}
+	blocked := &models.User{FirstName: "Ada", LastName: "Blocked", Email: "blocked@search.dev"}
+	blocking := &models.User{FirstName: "Ada", LastName: "Blocking", Email: "blocking@search.dev"}
+	for _, row := range []interface{}{user, blocked, blocking} {
+		err = db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, row := range []interface{}{
+		&models.Block{UserID: user.ID, BlockedID: blocked.ID},
+		&models.Block{UserID: blocking.ID, BlockedID: user.ID},
+		&models.Post{UserID: user.ID, Content: "ada post of the user"},
+		&models.Post{UserID: blocked.ID, Content: "ada post of the blocked user"},
+		&models.Post{UserID: blocking.ID, Content: "ada post of the blocking user"},
+	} {
+		err = db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?q=ada&type=posts,users", nil)
+	ctx.Set(middleware.IdentityKey, user)
+
+	NewSearchHandler(repository.NewSearchRepository(db), repository.NewBlockRepository(db)).Search(ctx)
+
+	if res.Code != 200 {
+		t.Fatalf("Search code = %v, want %v: %s", res.Code, 200, res.Body.String())
+	}
+
+	got := &SearchResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), got)
+
+	for _, name := range []string{"posts", "users"} {
+		if len(got.Results[name]) != 1 {
+			t.Errorf("Search got %s %v, want the ones of the user only", name, got.Results[name])
+		}
+	}
+}
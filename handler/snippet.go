@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/markdown"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// HighlightCacheControl is the cache policy of the CSS of the highlighted code
+const HighlightCacheControl = "public, max-age=86400"
+
+var (
+	// errNotSnippetAuthor is returned when a user updates or deletes the snippet of another user
+	errNotSnippetAuthor = errors.New("only the author of a snippet can update or delete it")
+	// errInvalidSnippet is returned when a post embeds a snippet which does not exist
+	errInvalidSnippet = errors.New("the embedded snippet does not exist")
+)
+
+// snippetRevisionOrder is the default order of the revisions, the last one first
+var snippetRevisionOrder = []repository.Sort{{Field: models.SnippetRevisionFields["revision"], Desc: true}}
+
+// SnippetHandler is a struct to define snippet handler
+type SnippetHandler struct {
+	repository repository.SnippetStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewSnippetHandler is a factory for snippet handler
+func NewSnippetHandler(repository repository.SnippetStore, unitOfWork repository.UnitOfWork) *SnippetHandler {
+	return &SnippetHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// ListSnippets respond a page of the snippets
+func (s *SnippetHandler) ListSnippets(c *gin.Context) {
+	page, err := GetPage(c, models.SnippetFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.SnippetRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	snippets := &[]models.Snippet{}
+
+	info, err := s.repository.ListSnippets(snippets, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(s.repository, collectionItems(snippets))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// CreateSnippet create a snippet of the current user with its first revision
+func (s *SnippetHandler) CreateSnippet(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	snippet := &models.Snippet{}
+
+	err = c.ShouldBindJSON(snippet)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	snippet.UserID = user.ID
+
+	err = s.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Snippets.CreateSnippet(snippet)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Snippets.CreateSnippetRevision(models.NewSnippetRevision(snippet))
+	})
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, snippet.Base, snippet)
+}
+
+// GetSnippet get a specific snippet
+func (s *SnippetHandler) GetSnippet(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.SnippetRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	snippet := &models.Snippet{}
+
+	err = s.repository.GetSnippetByID(snippet, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSnippetNotFound) {
+			httpError.NotFound(c, "snippet", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	resource, err := representation.RenderOne(s.repository, snippet)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, snippet.Base, resource)
+}
+
+// UpdateSnippet update a snippet of the current user, the new version is kept as a revision
+func (s *SnippetHandler) UpdateSnippet(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+	snippet := &models.Snippet{}
+
+	err = s.repository.GetSnippetByID(snippet, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSnippetNotFound) {
+			httpError.NotFound(c, "snippet", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	if !uuid.Equal(snippet.UserID, user.ID) {
+		httpError.Forbidden(c, errNotSnippetAuthor)
+		return
+	}
+
+	if !CheckIfMatch(c, snippet.Base) {
+		return
+	}
+
+	stored := *snippet
+
+	err = c.ShouldBindJSON(snippet)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	snippet.Base = stored.Base
+	snippet.UserID = stored.UserID
+
+	err = s.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Snippets.UpdateSnippet(snippet)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Snippets.CreateSnippetRevision(models.NewSnippetRevision(snippet))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrSnippetNotFound) {
+			httpError.NotFound(c, "snippet", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, snippet.Base, snippet)
+}
+
+// DeleteSnippet delete a snippet of the current user with its revisions, it is removed
+// from the posts embedding it
+func (s *SnippetHandler) DeleteSnippet(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	id, _ := c.Params.Get("id")
+
+	err = s.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		snippet := &models.Snippet{}
+
+		err := repositories.Snippets.GetSnippetByID(snippet, id)
+		if err != nil {
+			return err
+		}
+
+		if !uuid.Equal(snippet.UserID, user.ID) {
+			return errNotSnippetAuthor
+		}
+
+		err = checkPrecondition(c, func() (models.Base, error) { return snippet.Base, nil })
+		if err != nil {
+			return err
+		}
+
+		return repositories.Snippets.DeleteSnippetByID(id)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrSnippetNotFound) {
+			httpError.NotFound(c, "snippet", id, err)
+			return
+		}
+
+		if errors.Is(err, errNotSnippetAuthor) {
+			httpError.Forbidden(c, err)
+			return
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
+
+// ListSnippetRevisions respond a page of the revisions of a snippet, the last one first
+func (s *SnippetHandler) ListSnippetRevisions(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+
+	page, err := GetSortedPage(c, models.SnippetRevisionFields, snippetRevisionOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	err = s.repository.GetSnippetByID(&models.Snippet{}, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSnippetNotFound) {
+			httpError.NotFound(c, "snippet", id, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	revisions := &[]models.SnippetRevision{}
+
+	info, err := s.repository.ListSnippetRevisions(revisions, id, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(collectionItems(revisions), info))
+}
+
+// GetSnippetRevision get a specific revision of a snippet
+func (s *SnippetHandler) GetSnippetRevision(c *gin.Context) {
+	id, _ := c.Params.Get("id")
+	param, _ := c.Params.Get("revision")
+
+	number, err := strconv.Atoi(param)
+	if err != nil {
+		httpError.NotFound(c, "snippet revision", param, repository.ErrSnippetRevisionNotFound)
+		return
+	}
+
+	revision := &models.SnippetRevision{}
+
+	err = s.repository.GetSnippetRevision(revision, id, number)
+	if err != nil {
+		if errors.Is(err, repository.ErrSnippetRevisionNotFound) {
+			httpError.NotFound(c, "snippet revision", param, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, revision.Base, revision)
+}
+
+// ServeHighlightCSS respond the CSS of the classes of the highlighted code, in the style
+// of the style query parameter
+func (s *SnippetHandler) ServeHighlightCSS(c *gin.Context) {
+	style := c.DefaultQuery("style", markdown.DefaultStyle)
+	css := &bytes.Buffer{}
+
+	err := markdown.HighlightCSS(css, style)
+	if err != nil {
+		if errors.Is(err, markdown.ErrUnknownStyle) {
+			httpError.BadRequest(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", HighlightCacheControl)
+	c.Data(200, "text/css; charset=utf-8", css.Bytes())
+}
+
+// checkSnippet check the snippet embedded in a post exists
+func checkSnippet(repositories *repository.Repositories, snippetID *uuid.UUID) error {
+	if snippetID == nil {
+		return nil
+	}
+
+	err := repositories.Snippets.GetSnippetByID(&models.Snippet{}, snippetID.String())
+	if errors.Is(err, repository.ErrSnippetNotFound) {
+		return errInvalidSnippet
+	}
+
+	return err
+}
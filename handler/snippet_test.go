@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestSnippets(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@snippet.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@snippet.dev"}
+	db.Create(ada)
+	db.Create(alan)
+
+	unitOfWork := repository.NewUnitOfWork(db)
+	handler := NewSnippetHandler(repository.NewSnippetRepository(db), unitOfWork)
+
+	request := func(user *models.User, method string, params gin.Params, body string, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = params
+		ctx.Request = httptest.NewRequest(method, "/snippets", bytes.NewBufferString(body))
+
+		handle(ctx)
+
+		return res
+	}
+
+	res := request(ada, http.MethodPost, nil, `{"fileName": "main.go", "content": "package main\n\nfunc main() {}\n"}`, handler.CreateSnippet)
+	if res.Code != 200 {
+		t.Fatalf("CreateSnippet = %v, want 200", res.Code)
+	}
+
+	snippet := &models.Snippet{}
+	_ = json.Unmarshal(res.Body.Bytes(), snippet)
+	if snippet.Language != "go" || !strings.Contains(snippet.ContentHTML, `<span class="kd">func</span>`) {
+		t.Errorf("CreateSnippet got %+v, want highlighted go code", snippet)
+	}
+
+	id := gin.Params{{Key: "id", Value: snippet.ID.String()}}
+
+	t.Run("update", func(t *testing.T) {
+		res := request(alan, http.MethodPatch, id, `{"content": "print('hello')"}`, handler.UpdateSnippet)
+		if res.Code != 403 {
+			t.Errorf("UpdateSnippet of another user = %v, want 403", res.Code)
+		}
+
+		res = request(ada, http.MethodPatch, id, `{"fileName": "hello.py", "language": "", "content": "print('hello')"}`, handler.UpdateSnippet)
+		if res.Code != 200 {
+			t.Fatalf("UpdateSnippet = %v, want 200", res.Code)
+		}
+
+		updated := &models.Snippet{}
+		_ = json.Unmarshal(res.Body.Bytes(), updated)
+		if updated.Language != "python" || updated.Version != 2 || !uuid.Equal(updated.UserID, ada.ID) {
+			t.Errorf("UpdateSnippet got %+v, want the version 2 of a python snippet", updated)
+		}
+	})
+
+	t.Run("revisions", func(t *testing.T) {
+		res := request(ada, http.MethodGet, id, "", handler.ListSnippetRevisions)
+		if res.Code != 200 {
+			t.Fatalf("ListSnippetRevisions = %v, want 200", res.Code)
+		}
+
+		revisions := &struct {
+			Items []models.SnippetRevision `json:"items"`
+		}{}
+		_ = json.Unmarshal(res.Body.Bytes(), revisions)
+		if len(revisions.Items) != 2 || revisions.Items[0].Revision != 2 || revisions.Items[1].Language != "go" {
+			t.Errorf("ListSnippetRevisions got %+v, want the revisions 2 and 1", revisions.Items)
+		}
+
+		tests := []struct {
+			name     string
+			revision string
+			want     int
+		}{
+			{name: "first", revision: "1", want: 200},
+			{name: "unknown", revision: "3", want: 404},
+			{name: "not a number", revision: "first", want: 404},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				params := append(gin.Params{{Key: "revision", Value: test.revision}}, id...)
+				res := request(ada, http.MethodGet, params, "", handler.GetSnippetRevision)
+				if res.Code != test.want {
+					t.Errorf("GetSnippetRevision = %v, want %v", res.Code, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("embed", func(t *testing.T) {
+		missing := uuid.NewV4()
+
+		err := unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+			return checkSnippet(repositories, &missing)
+		})
+		if !errors.Is(err, errInvalidSnippet) {
+			t.Errorf("checkSnippet of an unknown snippet = %v, want %v", err, errInvalidSnippet)
+		}
+
+		err = unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+			return checkSnippet(repositories, &snippet.ID)
+		})
+		if err != nil {
+			t.Errorf("checkSnippet = %v, want nil", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		post := &models.Post{Content: "see the snippet", UserID: ada.ID, SnippetID: &snippet.ID}
+		db.Create(post)
+
+		res := request(alan, http.MethodDelete, id, "", handler.DeleteSnippet)
+		if res.Code != 403 {
+			t.Errorf("DeleteSnippet of another user = %v, want 403", res.Code)
+		}
+
+		res = request(ada, http.MethodDelete, id, "", handler.DeleteSnippet)
+		if res.Code != 204 {
+			t.Fatalf("DeleteSnippet = %v, want 204", res.Code)
+		}
+
+		var revisions int64
+		db.Model(&models.SnippetRevision{}).Where("snippet_id = ?", snippet.ID).Count(&revisions)
+		if revisions != 0 {
+			t.Errorf("DeleteSnippet kept %v revisions, want 0", revisions)
+		}
+
+		embedding := &models.Post{}
+		db.First(embedding, "id = ?", post.ID)
+		if embedding.SnippetID != nil {
+			t.Errorf("DeleteSnippet kept the snippet %v in the post", embedding.SnippetID)
+		}
+	})
+
+	t.Run("highlight css", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			style string
+			want  int
+		}{
+			{name: "default", want: 200},
+			{name: "style", style: "?style=monokai", want: 200},
+			{name: "unknown style", style: "?style=unknown", want: 400},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				res, ctx, _ := commonTesting.InitHTTPTest()
+				ctx.Request = httptest.NewRequest(http.MethodGet, "/highlight.css"+test.style, nil)
+
+				handler.ServeHighlightCSS(ctx)
+
+				if res.Code != test.want {
+					t.Errorf("ServeHighlightCSS = %v, want %v", res.Code, test.want)
+				}
+				if test.want == 200 && !strings.Contains(res.Body.String(), ".chroma .kd") {
+					t.Errorf("ServeHighlightCSS got %q, want the classes of the tokens", res.Body.String())
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,401 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// StreamKeepAlive is the interval of the pings sent on idle streams
+	StreamKeepAlive = 30 * time.Second
+	// streamWriteWait is the time allowed to write a message to a WebSocket
+	streamWriteWait = 10 * time.Second
+	// streamMaxMessage is the maximum size of a message received on a WebSocket
+	streamMaxMessage = 1024
+)
+
+// names of the resources of the events
+const (
+	bdaPostResource            = "bdaPost"
+	commentResource            = "comment"
+	conversationResource       = "conversation"
+	conversationMemberResource = "conversationMember"
+	likeResource               = "like"
+	messageResource            = "message"
+	notificationResource       = "notification"
+	postResource               = "post"
+	topicResource              = "topic"
+)
+
+// names of the channels clients subscribe to, topics and conversations are followed by
+// their id
+const (
+	notificationsStream = "notifications"
+	topicsStream        = "topics/"
+	conversationsStream = "conversations/"
+)
+
+var (
+	// errUnknownChannel is returned when a client subscribes to a channel which does not exist
+	errUnknownChannel = errors.New("unknown channel")
+	// errNoChannel is returned when a stream is opened without channel
+	errNoChannel = errors.New("at least one channel is required")
+)
+
+// StreamHandler is a struct to define the handler of the real-time event streams
+type StreamHandler struct {
+	broker     realtime.Broker
+	repository repository.ConversationStore
+	upgrader   websocket.Upgrader
+}
+
+// NewStreamHandler is a factory for stream handler, WebSockets are accepted from the
+// same host or from the allowed origin, any origin with "*"
+func NewStreamHandler(broker realtime.Broker, repository repository.ConversationStore, allowedOrigin string) *StreamHandler {
+	return &StreamHandler{
+		broker:     broker,
+		repository: repository,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" || allowedOrigin == "*" || (allowedOrigin != "" && origin == allowedOrigin) {
+					return true
+				}
+
+				u, err := url.Parse(origin)
+				return err == nil && strings.EqualFold(u.Host, r.Host)
+			},
+		},
+	}
+}
+
+// streamMessage is a message of a WebSocket which is not an event: a subscription
+// request of the client or its acknowledgement
+type streamMessage struct {
+	// Action is subscribe or unsubscribe, sent by the client
+	Action string `json:"action,omitempty"`
+	// Type is subscribed, unsubscribed or error, sent by the server
+	Type    string `json:"type,omitempty"`
+	Channel string `json:"channel"`
+	Message string `json:"message,omitempty"`
+}
+
+// StreamEvents respond the events of the channels of the channels query parameter as
+// Server-Sent Events, until the client disconnects
+func (s *StreamHandler) StreamEvents(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	names := streamChannels(c)
+	if len(names) == 0 {
+		httpError.BadRequest(c, errNoChannel)
+		return
+	}
+
+	subscription := s.broker.Subscribe()
+	defer subscription.Close()
+
+	channels := map[string]string{}
+	for _, name := range names {
+		channel, err := s.join(subscription, user, name)
+		if err != nil {
+			if errors.Is(err, repository.ErrConversationNotFound) {
+				httpError.NotFound(c, "conversation", strings.TrimPrefix(name, conversationsStream), err)
+				return
+			}
+
+			if errors.Is(err, errUnknownChannel) {
+				httpError.BadRequest(c, err)
+				return
+			}
+
+			httpError.Internal(c, err)
+			return
+		}
+
+		channels[channel] = name
+	}
+
+	keepAlive := time.NewTicker(StreamKeepAlive)
+	defer keepAlive.Stop()
+
+	// the stream is opened before the first event so the clients know they are subscribed
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+
+			if !s.deliverable(subscription, user, channels, &event) {
+				return true
+			}
+
+			c.SSEvent(event.Resource+"."+string(event.Type), event)
+			return true
+		case <-keepAlive.C:
+			c.SSEvent("ping", "")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// StreamWebSocket upgrade the request to a WebSocket sending the events of the channels
+// the client subscribes to, the channels query parameter are subscribed first
+func (s *StreamHandler) StreamWebSocket(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already responded the error
+		return
+	}
+	defer conn.Close()
+
+	subscription := s.broker.Subscribe()
+	defer subscription.Close()
+
+	// the messages of the client are read in another goroutine, the replies are written
+	// with the events so there is a single writer
+	requests := make(chan streamMessage)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		conn.SetReadLimit(streamMaxMessage)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * StreamKeepAlive))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * StreamKeepAlive))
+		})
+
+		for {
+			request := streamMessage{}
+			err := conn.ReadJSON(&request)
+			if err != nil {
+				return
+			}
+
+			select {
+			case requests <- request:
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+	}()
+
+	write := func(message interface{}) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		return conn.WriteJSON(message)
+	}
+
+	channels := map[string]string{}
+	for _, name := range streamChannels(c) {
+		err = write(s.handleRequest(subscription, user, channels, streamMessage{Action: "subscribe", Channel: name}))
+		if err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(StreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var message interface{}
+
+		select {
+		case request := <-requests:
+			message = s.handleRequest(subscription, user, channels, request)
+		case event, ok := <-subscription.Events():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(streamWriteWait))
+				return
+			}
+
+			if !s.deliverable(subscription, user, channels, &event) {
+				continue
+			}
+
+			message = event
+		case <-keepAlive.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait))
+			if err != nil {
+				return
+			}
+			continue
+		case <-done:
+			return
+		}
+
+		err = write(message)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleRequest subscribe or unsubscribe to a channel as requested by a WebSocket client
+// and returns the reply, channels are the joined channels with their name
+func (s *StreamHandler) handleRequest(subscription realtime.Subscription, user *models.User, channels map[string]string, request streamMessage) streamMessage {
+	reply := streamMessage{Channel: request.Channel}
+
+	switch request.Action {
+	case "subscribe":
+		channel, err := s.join(subscription, user, request.Channel)
+		if err != nil {
+			reply.Type = "error"
+			reply.Message = err.Error()
+			return reply
+		}
+
+		channels[channel] = request.Channel
+		reply.Type = "subscribed"
+	case "unsubscribe":
+		for channel, name := range channels {
+			if name != request.Channel {
+				continue
+			}
+
+			_ = subscription.Leave(channel)
+			delete(channels, channel)
+		}
+
+		reply.Type = "unsubscribed"
+	default:
+		reply.Type = "error"
+		reply.Message = "unknown action " + request.Action
+	}
+
+	return reply
+}
+
+// join subscribe to the channel of a name given by a client and returns it. The
+// conversations can only be joined by their members.
+func (s *StreamHandler) join(subscription realtime.Subscription, user *models.User, name string) (string, error) {
+	var channel string
+
+	switch {
+	case name == realtime.BdaFeedChannel:
+		channel = realtime.BdaFeedChannel
+	case name == notificationsStream:
+		channel = realtime.NotificationsChannel(user.ID.String())
+	case strings.HasPrefix(name, topicsStream):
+		topicID, err := uuid.FromString(strings.TrimPrefix(name, topicsStream))
+		if err != nil {
+			return "", errUnknownChannel
+		}
+
+		channel = realtime.TopicChannel(topicID.String())
+	case strings.HasPrefix(name, conversationsStream):
+		conversationID, err := uuid.FromString(strings.TrimPrefix(name, conversationsStream))
+		if err != nil {
+			return "", errUnknownChannel
+		}
+
+		err = findConversation(s.repository, &models.Conversation{}, &models.ConversationMember{}, conversationID.String(), user.ID.String())
+		if err != nil {
+			return "", err
+		}
+
+		channel = realtime.ConversationChannel(conversationID.String())
+	default:
+		return "", errUnknownChannel
+	}
+
+	return channel, subscription.Join(channel)
+}
+
+// deliverable returns if an event can be sent to the user and renames its channel as the
+// client named it. The channel of a conversation is left once the user is no longer one of
+// its members, the event of their removal is the last one sent: the events of a left
+// channel which were already received are skipped.
+func (s *StreamHandler) deliverable(subscription realtime.Subscription, user *models.User, channels map[string]string, event *realtime.Event) bool {
+	channel := event.Channel
+
+	name, ok := channels[channel]
+	if !ok {
+		return false
+	}
+
+	event.Channel = name
+
+	if event.Resource != conversationMemberResource || event.Type != realtime.Deleted {
+		return true
+	}
+
+	err := findConversation(s.repository, &models.Conversation{}, &models.ConversationMember{}, strings.TrimPrefix(name, conversationsStream), user.ID.String())
+	if err != nil {
+		// the channel is also left when the membership can not be checked, the client can
+		// subscribe again
+		_ = subscription.Leave(channel)
+		delete(channels, channel)
+	}
+
+	return true
+}
+
+// streamChannels returns the channels of the comma separated channels query parameter
+func streamChannels(c *gin.Context) []string {
+	names := []string{}
+	for _, name := range strings.Split(c.Query("channels"), ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// publish send an event about a resource to a channel once the unit of work is
+// committed, nothing is sent without channel
+func publish(repositories *repository.Repositories, channel string, eventType realtime.EventType, resource string, data interface{}) error {
+	if channel == "" {
+		return nil
+	}
+
+	return repositories.Events.Publish(realtime.Event{
+		Channel:  channel,
+		Type:     eventType,
+		Resource: resource,
+		Data:     data,
+	})
+}
+
+// deletedData returns the data of a deleted event, the id of the resource and the ids
+// of the resources it belonged to
+func deletedData(id string, parents gin.H) gin.H {
+	data := gin.H{"id": id}
+	for key, value := range parents {
+		data[key] = value
+	}
+
+	return data
+}
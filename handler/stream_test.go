@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestStream(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	ada := &models.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@stream.dev"}
+	alan := &models.User{FirstName: "Alan", LastName: "Turing", Email: "alan@stream.dev"}
+	db.Create(ada)
+	db.Create(alan)
+
+	conversation := &models.Conversation{UserID: alan.ID, IsGroup: true}
+	db.Create(conversation)
+	db.Create(&models.ConversationMember{ConversationID: conversation.ID, UserID: alan.ID})
+
+	// a group Ada leaves while connected to the stream
+	group := &models.Conversation{UserID: alan.ID, IsGroup: true}
+	db.Create(group)
+	db.Create(&models.ConversationMember{ConversationID: group.ID, UserID: alan.ID})
+
+	broker := realtime.NewMemoryBroker()
+	unitOfWork := repository.NewUnitOfWork(db).WithPublisher(broker)
+	handler := NewStreamHandler(broker, repository.NewConversationRepository(db), "")
+	bdaPostHandler := NewBdaPostHandler(repository.NewBdaPostRepository(db), unitOfWork)
+	conversationHandler := NewConversationHandler(repository.NewConversationRepository(db), unitOfWork)
+
+	_, _, engine := commonTesting.InitHTTPTest()
+	engine.Use(func(c *gin.Context) {
+		c.Set(middleware.IdentityKey, ada)
+	})
+	engine.GET("/stream/ws", handler.StreamWebSocket)
+	engine.GET("/stream/sse", handler.StreamEvents)
+
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	createBdaPost := func(t *testing.T, title string) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, ada)
+		commonTesting.AddRequestWithBodyToContext(ctx, models.BdaPost{Title: title, Content: "lorem ipsum"})
+
+		bdaPostHandler.CreateBdaPost(ctx)
+		if res.Code != 200 {
+			t.Fatalf("CreateBdaPost code = %v %s, want 200", res.Code, res.Body.String())
+		}
+	}
+
+	converse := func(t *testing.T, action gin.HandlerFunc, user *models.User, body interface{}) {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, user)
+		ctx.Params = gin.Params{{Key: "id", Value: group.ID.String()}}
+		commonTesting.AddRequestWithBodyToContext(ctx, body)
+
+		action(ctx)
+		if res.Code != 200 && res.Code != 204 {
+			t.Fatalf("conversation action code = %v %s, want 200 or 204", res.Code, res.Body.String())
+		}
+	}
+
+	t.Run("websocket", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/ws?channels=bdaposts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		reply := streamMessage{}
+		if err = conn.ReadJSON(&reply); err != nil || reply.Type != "subscribed" || reply.Channel != "bdaposts" {
+			t.Fatalf("initial subscription = %+v %v, want subscribed to bdaposts", reply, err)
+		}
+
+		// the conversations of other users can not be joined
+		_ = conn.WriteJSON(streamMessage{Action: "subscribe", Channel: "conversations/" + conversation.ID.String()})
+		if err = conn.ReadJSON(&reply); err != nil || reply.Type != "error" {
+			t.Errorf("subscribe to another conversation = %+v %v, want an error", reply, err)
+		}
+
+		_ = conn.WriteJSON(streamMessage{Action: "subscribe", Channel: "unknown"})
+		if err = conn.ReadJSON(&reply); err != nil || reply.Type != "error" {
+			t.Errorf("subscribe to an unknown channel = %+v %v, want an error", reply, err)
+		}
+
+		createBdaPost(t, "websocket")
+
+		event := &struct {
+			realtime.Event
+			Data models.BdaPost `json:"data"`
+		}{}
+		if err = conn.ReadJSON(event); err != nil || event.Channel != "bdaposts" || event.Type != realtime.Created || event.Data.Title != "websocket" {
+			t.Fatalf("event = %+v %v, want the created bda post", event, err)
+		}
+
+		_ = conn.WriteJSON(streamMessage{Action: "unsubscribe", Channel: "bdaposts"})
+		if err = conn.ReadJSON(&reply); err != nil || reply.Type != "unsubscribed" {
+			t.Errorf("unsubscribe = %+v %v, want unsubscribed", reply, err)
+		}
+	})
+
+	t.Run("server-sent events", func(t *testing.T) {
+		if res, err := http.Get(server.URL + "/stream/sse"); err != nil || res.StatusCode != 400 {
+			t.Errorf("StreamEvents without channel = %v %v, want 400", res.StatusCode, err)
+		}
+
+		if res, err := http.Get(server.URL + "/stream/sse?channels=conversations/" + conversation.ID.String()); err != nil || res.StatusCode != 404 {
+			t.Errorf("StreamEvents of another conversation = %v %v, want 404", res.StatusCode, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/stream/sse?channels=bdaposts,notifications", nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != 200 || res.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("StreamEvents = %v %v, want an event stream", res.StatusCode, res.Header.Get("Content-Type"))
+		}
+
+		// the subscription is joined before the response starts
+		createBdaPost(t, "server-sent")
+
+		reader := bufio.NewReader(res.Body)
+		name, _ := reader.ReadString('\n')
+		data, _ := reader.ReadString('\n')
+		if name != "event:bdaPost.created\n" || !strings.Contains(data, `"channel":"bdaposts"`) || !strings.Contains(data, `"title":"server-sent"`) {
+			t.Errorf("event = %q %q, want the created bda post", name, data)
+		}
+	})
+
+	t.Run("leave a conversation", func(t *testing.T) {
+		for _, stream := range []string{"websocket", "server-sent events"} {
+			db.Create(&models.ConversationMember{ConversationID: group.ID, UserID: ada.ID})
+			channels := "bdaposts,conversations/" + group.ID.String()
+
+			var next func() (string, string)
+			if stream == "websocket" {
+				conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/ws?channels="+channels, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+
+				_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				for i := 0; i < 2; i++ {
+					reply := streamMessage{}
+					if err = conn.ReadJSON(&reply); err != nil || reply.Type != "subscribed" {
+						t.Fatalf("initial subscription = %+v %v, want subscribed", reply, err)
+					}
+				}
+
+				next = func() (string, string) {
+					event := realtime.Event{}
+					_ = conn.ReadJSON(&event)
+					return event.Resource + "." + string(event.Type), event.Channel
+				}
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/stream/sse?channels="+channels, nil)
+				res, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer res.Body.Close()
+
+				reader := bufio.NewReader(res.Body)
+				next = func() (string, string) {
+					name, _ := reader.ReadString('\n')
+					data, _ := reader.ReadString('\n')
+					_, _ = reader.ReadString('\n')
+
+					event := realtime.Event{}
+					_ = json.Unmarshal([]byte(strings.TrimPrefix(data, "data:")), &event)
+					return strings.TrimSuffix(strings.TrimPrefix(name, "event:"), "\n"), event.Channel
+				}
+			}
+
+			converse(t, conversationHandler.LeaveConversation, ada, nil)
+			// the message sent after Ada left is skipped, the next event is the bda post
+			converse(t, conversationHandler.CreateMessage, alan, models.Message{Content: "Ada is gone"})
+			createBdaPost(t, stream)
+
+			for _, want := range []string{"conversationMember.deleted", "bdaPost.created"} {
+				if name, channel := next(); name != want {
+					t.Errorf("%s event = %s on %s, want %s", stream, name, channel, want)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// errAlreadySubscribed is returned when the current user is already subscribed to a target
+var errAlreadySubscribed = errors.New("already subscribed by this user")
+
+// SubscriptionHandler is a struct to define subscription handler
+type SubscriptionHandler struct {
+	repository repository.SubscriptionStore
+	unitOfWork repository.UnitOfWork
+}
+
+// NewSubscriptionHandler is a factory for subscription handler
+func NewSubscriptionHandler(repository repository.SubscriptionStore, unitOfWork repository.UnitOfWork) *SubscriptionHandler {
+	return &SubscriptionHandler{repository: repository, unitOfWork: unitOfWork}
+}
+
+// ListSubscriptions respond a list of the subscriptions of the current user
+func (s *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	page, err := GetPage(c, models.SubscriptionFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.SubscriptionRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	subscriptions := &[]models.Subscription{}
+
+	info, err := s.repository.ListSubscriptionsByUserID(subscriptions, user.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(s.repository, collectionItems(subscriptions))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// SubscribeTopic subscribe the current user to a specific topic
+func (s *SubscriptionHandler) SubscribeTopic(c *gin.Context) {
+	topicID, _ := c.Params.Get("id")
+
+	s.subscribe(c, models.TopicSubscription, topicID, func(repositories *repository.Repositories) error {
+		return repositories.Topics.GetTopicByID(&models.Topic{}, topicID)
+	})
+}
+
+// UnsubscribeTopic unsubscribe the current user from a specific topic
+func (s *SubscriptionHandler) UnsubscribeTopic(c *gin.Context) {
+	topicID, _ := c.Params.Get("id")
+	s.unsubscribe(c, models.TopicSubscription, topicID)
+}
+
+// SubscribeCategory subscribe the current user to a specific category
+func (s *SubscriptionHandler) SubscribeCategory(c *gin.Context) {
+	categoryID, _ := c.Params.Get("id")
+
+	s.subscribe(c, models.CategorySubscription, categoryID, func(repositories *repository.Repositories) error {
+		return repositories.Categories.GetCategoryByID(&models.Category{}, categoryID)
+	})
+}
+
+// UnsubscribeCategory unsubscribe the current user from a specific category
+func (s *SubscriptionHandler) UnsubscribeCategory(c *gin.Context) {
+	categoryID, _ := c.Params.Get("id")
+	s.unsubscribe(c, models.CategorySubscription, categoryID)
+}
+
+// SubscribeBdaFeed subscribe the current user to all the bda posts
+func (s *SubscriptionHandler) SubscribeBdaFeed(c *gin.Context) {
+	s.subscribe(c, models.BdaFeedSubscription, uuid.Nil.String(), nil)
+}
+
+// UnsubscribeBdaFeed unsubscribe the current user from the bda posts
+func (s *SubscriptionHandler) UnsubscribeBdaFeed(c *gin.Context) {
+	s.unsubscribe(c, models.BdaFeedSubscription, uuid.Nil.String())
+}
+
+// subscribe create a subscription of the current user to a target, exists returns the
+// not found error of the target when it does not exist
+func (s *SubscriptionHandler) subscribe(c *gin.Context, targetType models.SubscriptionTarget, targetID string, exists func(repositories *repository.Repositories) error) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	subscription := &models.Subscription{
+		UserID:     user.ID,
+		TargetType: targetType,
+		TargetID:   uuid.FromStringOrNil(targetID),
+	}
+
+	err = s.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		if exists != nil {
+			err := exists(repositories)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := repositories.Subscriptions.GetSubscription(&models.Subscription{}, user.ID.String(), targetType, targetID)
+		if err == nil {
+			return errAlreadySubscribed
+		}
+
+		if !errors.Is(err, repository.ErrSubscriptionNotFound) {
+			return err
+		}
+
+		return repositories.Subscriptions.CreateSubscription(subscription)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTopicNotFound) || errors.Is(err, repository.ErrCategoryNotFound) {
+			httpError.NotFound(c, string(targetType), targetID, err)
+			return
+		}
+
+		if errors.Is(err, errAlreadySubscribed) {
+			httpError.Conflict(c, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, subscription.Base, subscription)
+}
+
+// unsubscribe delete the subscription of the current user to a target
+func (s *SubscriptionHandler) unsubscribe(c *gin.Context, targetType models.SubscriptionTarget, targetID string) {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	subscription := &models.Subscription{}
+
+	err = s.repository.GetSubscription(subscription, user.ID.String(), targetType, targetID)
+	if err == nil {
+		err = s.repository.DeleteSubscriptionByID(subscription.ID.String())
+	}
+
+	if err != nil {
+		if errors.Is(err, repository.ErrSubscriptionNotFound) {
+			httpError.NotFound(c, "subscription", targetID, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(204, nil)
+}
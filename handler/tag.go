@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	httpError "github.com/ada-social-network/api/error"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// tagOrder is the default order of tags, the most used first
+var tagOrder = []repository.Sort{{Field: models.TagFields["usesCount"], Desc: true}}
+
+// taggedItemOrder is the order of the items of a tag, the last tagged first
+var taggedItemOrder = []repository.Sort{{Field: models.TaggingFields["createdAt"], Desc: true}}
+
+// taggedResources returns an empty list of the model of each type of tagged resource
+var taggedResources = map[string]func() interface{}{
+	string(models.PostTag):    func() interface{} { return &[]models.Post{} },
+	string(models.TopicTag):   func() interface{} { return &[]models.Topic{} },
+	string(models.BdaPostTag): func() interface{} { return &[]models.BdaPost{} },
+}
+
+// TagHandler is a struct to define tag handler
+type TagHandler struct {
+	repository repository.TagStore
+}
+
+// NewTagHandler is a factory for tag handler
+func NewTagHandler(repository repository.TagStore) *TagHandler {
+	return &TagHandler{repository: repository}
+}
+
+// TaggedItemResponse defines a resource tagged with a tag
+type TaggedItemResponse struct {
+	Type     models.TagSource `json:"type"`
+	TaggedAt time.Time        `json:"taggedAt"`
+	Resource interface{}      `json:"resource"`
+}
+
+// ListTags respond a list of the used tags, the most used first. Tags are sorted by
+// their number of recent uses with sort=-recentUses.
+func (t *TagHandler) ListTags(c *gin.Context) {
+	page, err := GetSortedPage(c, models.TagFields, tagOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	tags := &[]models.TagUsage{}
+
+	info, err := t.repository.ListTags(tags, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(collectionItems(tags), info))
+}
+
+// ListTagItems respond a list of the resources tagged with a tag, the last tagged first
+func (t *TagHandler) ListTagItems(c *gin.Context) {
+	name, _ := c.Params.Get("name")
+
+	page, err := GetSortedPage(c, models.TaggingFields, taggedItemOrder)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	tag := &models.Tag{}
+
+	normalized, _ := models.NormalizeTag(name)
+	err = t.repository.GetTagByName(tag, normalized)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			httpError.NotFound(c, "tag", name, err)
+			return
+		}
+
+		httpError.Internal(c, err)
+		return
+	}
+
+	taggings := &[]models.Tagging{}
+
+	info, err := t.repository.ListTaggingsByTagID(taggings, tag.ID.String(), page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	keys := map[string][]interface{}{}
+	for _, tagging := range *taggings {
+		keys[string(tagging.SourceType)] = append(keys[string(tagging.SourceType)], tagging.SourceID.String())
+	}
+
+	resources, err := loadResources(t.repository, taggedResources, keys)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items := []interface{}{}
+	for _, tagging := range *taggings {
+		resource, ok := resources[tagging.SourceID]
+		if !ok {
+			continue
+		}
+
+		items = append(items, TaggedItemResponse{Type: tagging.SourceType, TaggedAt: tagging.CreatedAt, Resource: resource})
+	}
+
+	c.JSON(200, NewCollection(items, info))
+}
+
+// normalizeTags returns the valid names of tags given by an author, without duplicates
+func normalizeTags(names []string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		tag, ok := models.NormalizeTag(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q: a tag starts with a letter and has at most %d letters, digits, - or _", name, models.MaxTagLength)
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// syncTags save the tags of a resource: the hashtags of its content and the explicit
+// tags given by its author. Explicit tags are kept when they are removed from the content.
+func syncTags(repositories *repository.Repositories, sourceType models.TagSource, sourceID uuid.UUID, content string, explicit []string) error {
+	taggings := &[]models.Tagging{}
+	err := repositories.Tags.ListTaggingsBySource(taggings, sourceType, sourceID.String())
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range models.ParseHashtags(content) {
+		wanted[name] = false
+	}
+
+	for _, name := range explicit {
+		wanted[name] = true
+	}
+
+	removed := []models.Tagging{}
+	for _, tagging := range *taggings {
+		if _, ok := wanted[tagging.Tag]; ok || tagging.Explicit {
+			delete(wanted, tagging.Tag)
+			continue
+		}
+
+		removed = append(removed, tagging)
+	}
+
+	for name, isExplicit := range wanted {
+		tag := &models.Tag{}
+		err = repositories.Tags.SaveTag(tag, name)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Tags.CreateTagging(&models.Tagging{
+			TagID:      tag.ID,
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			Tag:        name,
+			Explicit:   isExplicit,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Tags.UpdateTagUsesCount(tag.ID.String(), 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return deleteTaggings(repositories, removed)
+}
+
+// removeTags delete all the tags of a resource
+func removeTags(repositories *repository.Repositories, sourceType models.TagSource, sourceID string) error {
+	taggings := &[]models.Tagging{}
+	err := repositories.Tags.ListTaggingsBySource(taggings, sourceType, sourceID)
+	if err != nil {
+		return err
+	}
+
+	return deleteTaggings(repositories, *taggings)
+}
+
+// deleteTaggings delete taggings and decrement the uses counters of their tags
+func deleteTaggings(repositories *repository.Repositories, taggings []models.Tagging) error {
+	ids := []string{}
+	for _, tagging := range taggings {
+		err := repositories.Tags.UpdateTagUsesCount(tagging.TagID.String(), -1)
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, tagging.ID.String())
+	}
+
+	return repositories.Tags.DeleteTaggingsByIDs(ids)
+}
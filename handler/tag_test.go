@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ada-social-network/api/middleware"
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestTags(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	author := &models.User{FirstName: "Frances", LastName: "Allen", Email: "frances@tags.dev"}
+	db.Create(author)
+
+	unitOfWork := repository.NewUnitOfWork(db)
+	topicHandler := NewTopicHandler(repository.NewTopicRepository(db), unitOfWork)
+	postHandler := NewPostHandler(repository.NewPostRepository(db), unitOfWork)
+	tagHandler := NewTagHandler(repository.NewTagRepository(db))
+
+	call := func(action gin.HandlerFunc, params gin.Params, body interface{}) *httptest.ResponseRecorder {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Set(middleware.IdentityKey, author)
+		ctx.Params = params
+		commonTesting.AddRequestWithBodyToContext(ctx, body)
+
+		action(ctx)
+
+		return res
+	}
+
+	list := func(query string) map[string]models.TagUsage {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/"+query, nil)
+
+		tagHandler.ListTags(ctx)
+
+		got := &struct {
+			Items []models.TagUsage `json:"items"`
+		}{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		tags := map[string]models.TagUsage{}
+		for _, tag := range got.Items {
+			tags[tag.Name] = tag
+		}
+
+		return tags
+	}
+
+	categoryID := gin.Params{{Key: "id", Value: uuid.NewV4().String()}}
+
+	res := call(topicHandler.CreateTopic, categoryID, map[string]interface{}{"name": "compilers", "content": "About #Compilers", "tags": []string{"#PTRAN", "1nvalid"}})
+	if res.Code != 400 {
+		t.Errorf("CreateTopic with an invalid tag code = %v, want 400", res.Code)
+	}
+
+	res = call(topicHandler.CreateTopic, categoryID, map[string]interface{}{"name": "compilers", "content": "About #Compilers and #ptran", "tags": []string{"#PTRAN", "optimization"}})
+	if res.Code != 200 {
+		t.Fatalf("CreateTopic code = %v, want 200", res.Code)
+	}
+
+	topic := &models.Topic{}
+	_ = json.Unmarshal(res.Body.Bytes(), topic)
+
+	res = call(postHandler.CreatePost, gin.Params{{Key: "id", Value: topic.ID.String()}}, map[string]string{"content": "#compilers #fortran, not page#anchor nor &#35;"})
+	if res.Code != 200 {
+		t.Fatalf("CreatePost code = %v, want 200", res.Code)
+	}
+
+	post := &models.Post{}
+	_ = json.Unmarshal(res.Body.Bytes(), post)
+
+	tags := list("")
+	if len(tags) != 4 || tags["compilers"].UsesCount != 2 || tags["ptran"].UsesCount != 1 || tags["fortran"].RecentUses != 1 {
+		t.Errorf("ListTags = %v, want compilers used twice and ptran, optimization and fortran once", tags)
+	}
+
+	// explicit tags are kept when they are removed from the content
+	res = call(topicHandler.UpdateTopic, gin.Params{{Key: "id", Value: topic.ID.String()}}, map[string]string{"content": "About nothing"})
+	if res.Code != 200 {
+		t.Fatalf("UpdateTopic code = %v, want 200", res.Code)
+	}
+
+	res = call(postHandler.DeletePost, gin.Params{{Key: "postId", Value: post.ID.String()}}, nil)
+	if res.Code != 204 {
+		t.Fatalf("DeletePost code = %v, want 204", res.Code)
+	}
+
+	tags = list("?sort=-recentUses")
+	if len(tags) != 2 || tags["ptran"].UsesCount != 1 || tags["optimization"].UsesCount != 1 {
+		t.Errorf("ListTags = %v, want ptran and optimization", tags)
+	}
+
+	tests := []struct {
+		name      string
+		tag       string
+		wantCode  int
+		wantItems int
+	}{
+		{name: "tag", tag: "optimization", wantCode: 200, wantItems: 1},
+		{name: "hashtag in upper case", tag: "#PTRAN", wantCode: 200, wantItems: 1},
+		{name: "unused tag", tag: "fortran", wantCode: 200, wantItems: 0},
+		{name: "unknown tag", tag: "cobol", wantCode: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Params = gin.Params{{Key: "name", Value: tt.tag}}
+
+			tagHandler.ListTagItems(ctx)
+
+			if res.Code != tt.wantCode {
+				t.Fatalf("ListTagItems code = %v, want %v", res.Code, tt.wantCode)
+			}
+
+			got := &struct {
+				Items []TaggedItemResponse `json:"items"`
+			}{}
+			_ = json.Unmarshal(res.Body.Bytes(), got)
+
+			if len(got.Items) != tt.wantItems {
+				t.Errorf("ListTagItems got %d items, want %d", len(got.Items), tt.wantItems)
+			}
+
+			for _, item := range got.Items {
+				if item.Type != models.TopicTag {
+					t.Errorf("ListTagItems item type = %v, want %v", item.Type, models.TopicTag)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	httpError "github.com/ada-social-network/api/error"
 	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
 	"github.com/ada-social-network/api/repository"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
@@ -12,44 +13,75 @@ import (
 
 // TopicHandler is a struct to define bda post handler
 type TopicHandler struct {
-	repository *repository.TopicRepository
+	repository repository.TopicStore
+	unitOfWork repository.UnitOfWork
 }
 
 // NewTopicHandler is a factory for topic handler
-func NewTopicHandler(repository *repository.TopicRepository) *TopicHandler {
-	return &TopicHandler{repository: repository}
+func NewTopicHandler(repository repository.TopicStore, unitOfWork repository.UnitOfWork) *TopicHandler {
+	return &TopicHandler{repository: repository, unitOfWork: unitOfWork}
 }
 
 // GetTopic get a specific topic
 func (t *TopicHandler) GetTopic(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.TopicRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	id, _ := c.Params.Get("id")
 
 	topic := &models.Topic{}
 
-	err := t.repository.GetTopicByID(topic, id)
+	err = t.repository.GetTopicByID(topic, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrTopicNotFound) {
 			httpError.NotFound(c, "topic", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, topic)
+	resource, err := representation.RenderOne(t.repository, topic)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, topic.Base, resource)
 }
 
 // ListTopics respond a list of topics
 func (t *TopicHandler) ListTopics(c *gin.Context) {
+	page, err := GetPage(c, models.TopicFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.TopicRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	topics := &[]models.Topic{}
 
-	err := t.repository.ListAllTopics(topics)
+	info, err := t.repository.ListTopics(topics, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, topics)
+	items, err := representation.Render(t.repository, collectionItems(topics))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreateTopic create a topic
@@ -77,21 +109,86 @@ func (t *TopicHandler) CreateTopic(c *gin.Context) {
 	}
 	topic.CategoryID = catUUID
 
-	err = t.repository.CreateTopic(topic)
+	topic.Tags, err = normalizeTags(topic.Tags)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	err = t.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Topics.CreateTopic(topic)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.TopicMention, topic.ID, topic.UserID, topic.Content)
+		if err != nil {
+			return err
+		}
+
+		return syncTags(repositories, models.TopicTag, topic.ID, topic.Content, topic.Tags)
+	})
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, topic)
+	RespondResource(c, topic.Base, topic)
 }
 
 // DeleteTopic delete a specific topic
 func (t *TopicHandler) DeleteTopic(c *gin.Context) {
 	id, _ := c.Params.Get("id")
 
-	err := t.repository.DeleteTopicByID(id)
+	err := t.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			topic := &models.Topic{}
+			err := repositories.Topics.GetTopicByID(topic, id)
+
+			return topic.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Topics.DeleteTopicByID(id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Mentions.DeleteMentionsBySource(models.TopicMention, id)
+		if err != nil {
+			return err
+		}
+
+		err = deleteTargetComments(repositories, models.TopicComment, id)
+		if err != nil {
+			return err
+		}
+
+		err = removeTags(repositories, models.TopicTag, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Polls.DeletePollsByTarget(models.TopicPoll, id)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Subscriptions.DeleteSubscriptionsByTarget(models.TopicSubscription, id)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.TopicChannel(id), realtime.Deleted, topicResource, deletedData(id, nil))
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		if errors.Is(err, repository.ErrTopicNotFound) {
 			httpError.NotFound(c, "topic", id, err)
 			return
@@ -113,37 +210,90 @@ func (t *TopicHandler) UpdateTopic(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, repository.ErrTopicNotFound) {
 			httpError.NotFound(c, "topic", id, err)
-
+			return
 		}
 		httpError.Internal(c, err)
 		return
 	}
 
+	if !CheckIfMatch(c, topic.Base) {
+		return
+	}
+
+	// the author and the number of posts of the topic can not be updated
+	stored := *topic
+
 	err = c.ShouldBindJSON(topic)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	err = t.repository.UpdateTopic(topic)
+	topic.Version = stored.Version
+	topic.UserID = stored.UserID
+	topic.PostsCount = stored.PostsCount
+	// explicit tags are only given when the topic is created
+	topic.Tags = nil
+
+	err = t.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := repositories.Topics.UpdateTopic(topic)
+		if err != nil {
+			return err
+		}
+
+		err = syncMentions(repositories, models.TopicMention, topic.ID, topic.UserID, topic.Content)
+		if err != nil {
+			return err
+		}
+
+		err = syncTags(repositories, models.TopicTag, topic.ID, topic.Content, nil)
+		if err != nil {
+			return err
+		}
+
+		return publish(repositories, realtime.TopicChannel(id), realtime.Updated, topicResource, topic)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, topic)
+	RespondResource(c, topic.Base, topic)
 }
 
 // ListCategoryTopics get topics of a category
 func (t *TopicHandler) ListCategoryTopics(c *gin.Context) {
+	page, err := GetPage(c, models.TopicFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.TopicRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	categoryID, _ := c.Params.Get("id")
 	topics := &[]models.Topic{}
 
-	err := t.repository.ListAllTopicsByCategoryID(topics, categoryID)
+	info, err := t.repository.ListTopicsByCategoryID(topics, categoryID, page)
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	items, err := representation.Render(t.repository, collectionItems(topics))
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, topics)
+	c.JSON(200, NewCollection(items, info))
 }
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+	commonTesting "github.com/ada-social-network/api/testing"
+	"github.com/gin-gonic/gin"
+)
+
+func TestListTopicsSortedAndFiltered(t *testing.T) {
+	db := commonTesting.InitDB(&models.Topic{})
+	userID := uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f3a")
+
+	for i, count := range []int{3, 1, 3, 2, 5, 0} {
+		topic := &models.Topic{Name: "topic", UserID: userID, PostsCount: count}
+		if i == 5 {
+			topic.UserID = uuid.NewV4()
+		}
+
+		tx := db.Create(topic)
+		if tx.Error != nil {
+			t.Fatalf("ListTopics topic should be created: %v", tx.Error)
+		}
+	}
+
+	counts := []float64{}
+	after := ""
+	for {
+		res, ctx, _ := commonTesting.InitHTTPTest()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/?limit=2&sort=-postsCount&filter[userId]="+userID.String()+"&filter[postsCount][gte]=1&after="+after, nil)
+
+		NewTopicHandler(repository.NewTopicRepository(db), repository.NewUnitOfWork(db)).ListTopics(ctx)
+
+		if res.Code != 200 {
+			t.Fatalf("ListTopics code = %v, want 200: %s", res.Code, res.Body.String())
+		}
+
+		got := &Collection{}
+		_ = json.Unmarshal(res.Body.Bytes(), got)
+
+		if got.Total != 5 {
+			t.Errorf("ListTopics got total = %v, want 5", got.Total)
+		}
+
+		for _, item := range got.Items {
+			counts = append(counts, item.(map[string]interface{})["postsCount"].(float64))
+		}
+
+		if got.NextCursor == "" {
+			break
+		}
+
+		after = got.NextCursor
+	}
+
+	want := []float64{5, 3, 3, 2, 1}
+	if len(counts) != len(want) {
+		t.Fatalf("ListTopics got posts counts %v, want %v", counts, want)
+	}
+
+	for i := range want {
+		if counts[i] != want[i] {
+			t.Errorf("ListTopics got posts counts %v, want %v", counts, want)
+			break
+		}
+	}
+}
+
+func TestListTopicsUnknownFilter(t *testing.T) {
+	db := commonTesting.InitDB(&models.Topic{})
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?filter[content]=foo", nil)
+
+	NewTopicHandler(repository.NewTopicRepository(db), repository.NewUnitOfWork(db)).ListTopics(ctx)
+
+	if res.Code != 400 {
+		t.Errorf("ListTopics code = %v, want 400", res.Code)
+	}
+}
+
+func TestDeleteTargetSubscriptions(t *testing.T) {
+	db := commonTesting.InitAllDB()
+	userID := uuid.NewV4()
+
+	category := &models.Category{Name: "subscribed"}
+	db.Create(category)
+	topic := &models.Topic{Name: "subscribed", Content: "subscribed topic", UserID: userID, CategoryID: category.ID}
+	db.Create(topic)
+
+	db.Create(&models.Subscription{UserID: userID, TargetType: models.TopicSubscription, TargetID: topic.ID})
+	db.Create(&models.Subscription{UserID: userID, TargetType: models.CategorySubscription, TargetID: category.ID})
+
+	tests := []struct {
+		name   string
+		action func(ctx *gin.Context)
+		id     uuid.UUID
+	}{
+		{name: "topic", action: NewTopicHandler(repository.NewTopicRepository(db), repository.NewUnitOfWork(db)).DeleteTopic, id: topic.ID},
+		{name: "category", action: NewCategoryHandler(repository.NewCategoryRepository(db), repository.NewUnitOfWork(db)).DeleteCategory, id: category.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+			ctx.Params = gin.Params{{Key: "id", Value: tt.id.String()}}
+
+			tt.action(ctx)
+
+			if res.Code != 204 {
+				t.Fatalf("Delete %s code = %v, want 204", tt.name, res.Code)
+			}
+
+			var count int64
+			db.Model(&models.Subscription{}).Where("target_id = ?", tt.id).Count(&count)
+			if count != 0 {
+				t.Errorf("Delete %s left %v subscriptions", tt.name, count)
+			}
+		})
+	}
+}
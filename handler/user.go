@@ -6,20 +6,23 @@ import (
 	httpError "github.com/ada-social-network/api/error"
 	"github.com/ada-social-network/api/models"
 	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	uuid "github.com/satori/go.uuid"
-	"gorm.io/gorm"
 )
 
 // UserHandler is a struct to define user handler
 type UserHandler struct {
-	repository *repository.UserRepository
+	repository repository.UserStore
+	unitOfWork repository.UnitOfWork
+	files      storage.Store
 }
 
-// NewUserHandler is a factory user handler
-func NewUserHandler(repository *repository.UserRepository) *UserHandler {
-	return &UserHandler{repository: repository}
+// NewUserHandler is a factory user handler, the files of the media of the deleted users
+// are deleted from files
+func NewUserHandler(repository repository.UserStore, unitOfWork repository.UnitOfWork, files storage.Store) *UserHandler {
+	return &UserHandler{repository: repository, unitOfWork: unitOfWork, files: files}
 }
 
 // UserResponse define a user response
@@ -30,9 +33,9 @@ type UserResponse struct {
 	Email          string           `json:"email" binding:"required,email" gorm:"unique"`
 	DateOfBirth    string           `json:"dateOfBirth"`
 	Apprenticeship string           `json:"apprenticeAt"`
-	ProfilPic      string           `json:"profilPic"`
+	ProfilPicID    *uuid.UUID       `json:"profilPicId"`
 	Biography      string           `json:"biography"`
-	CoverPic       string           `json:"coverPic"`
+	CoverPicID     *uuid.UUID       `json:"coverPicId"`
 	PrivateMail    string           `json:"privateMail"`
 	ProjectPerso   string           `json:"projectPerso"`
 	ProjectPro     string           `json:"projectPro"`
@@ -43,6 +46,8 @@ type UserResponse struct {
 	MBTI           string           `json:"mbti"`
 	Admin          bool             `json:"isAdmin"`
 	PromoID        uuid.UUID        `gorm:"type=uuid" json:"promoId"`
+	FollowersCount int              `json:"followersCount"`
+	FollowingCount int              `json:"followingCount"`
 	BdaPosts       []models.BdaPost `json:"bdaPosts"`
 	Posts          []models.Post    `json:"posts"`
 	Comments       []models.Comment `json:"comments"`
@@ -101,11 +106,17 @@ func (us *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, createUserResponse(user))
+	RespondResource(c, user.Base, createUserResponse(user))
 }
 
 // Me provide informations about the connected user
 func (us *UserHandler) Me(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.UserRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	user, exist := c.Get("id")
 	if !exist {
 		httpError.Internal(c, errors.New("jwt does not exist"))
@@ -118,20 +129,27 @@ func (us *UserHandler) Me(c *gin.Context) {
 		return
 	}
 
-	err := us.repository.GetUserByID(u, u.ID.String())
+	err = us.repository.GetUserByID(u, u.ID.String())
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	resource, err := representation.RenderOne(us.repository, createUserResponse(u))
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, createUserResponse(u))
+	RespondResource(c, u.Base, resource)
 }
 
 // UpdatePassword update password of the current user
 func (us *UserHandler) UpdatePassword(c *gin.Context) {
-	user, err := GetCurrentUser(c)
+	currentUser, err := GetCurrentUser(c)
 	if err != nil {
 		httpError.Internal(c, err)
+		return
 	}
 
 	updatePasswordRequest := &UpdatePasswordRequest{}
@@ -141,9 +159,18 @@ func (us *UserHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
+	// the identity of the token only holds the id, load the whole user to not erase their profile
+	user := &models.User{}
+	err = us.repository.GetUserByID(user, currentUser.ID.String())
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
 	err = us.repository.UpdateUserWithPassword(user, updatePasswordRequest.Password)
 	if err != nil {
 		httpError.Internal(c, err)
+		return
 	}
 
 	c.JSON(204, nil)
@@ -151,16 +178,33 @@ func (us *UserHandler) UpdatePassword(c *gin.Context) {
 
 // ListUser respond a list of users
 func (us *UserHandler) ListUser(c *gin.Context) {
+	page, err := GetPage(c, models.UserFields)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
+	representation, err := GetRepresentation(c, models.UserRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	users := &[]models.User{}
 
-	err := us.repository.ListAllUser(users)
+	info, err := us.repository.ListUsers(users, page)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, createUsersResponse(users))
+	items, err := representation.Render(us.repository, createUsersResponse(users))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
 
+	c.JSON(200, NewCollection(items, info))
 }
 
 // CreateUser create a user
@@ -173,47 +217,254 @@ func (us *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// a new user has not uploaded pictures yet
+	user.ProfilPicID = nil
+	user.CoverPicID = nil
+
 	err = us.repository.CreateUserWithPassword(user, user.Password)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, user)
+	RespondResource(c, user.Base, createUserResponse(user))
 }
 
-// DeleteUser delete a specific user
+// DeleteUser delete a specific user and all the content they created
 func (us *UserHandler) DeleteUser(c *gin.Context) {
 
 	user, _ := c.Params.Get("id")
+	var unused []string
+
+	err := us.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPrecondition(c, func() (models.Base, error) {
+			current := &models.User{}
+			err := repositories.Users.GetUserByID(current, user)
+
+			return current.Base, err
+		})
+		if err != nil {
+			return err
+		}
+
+		hashes, err := repositories.Media.ListMediaHashesByUserID(user)
+		if err != nil {
+			return err
+		}
+
+		err = deleteUserContent(repositories, user)
+		if err != nil {
+			return err
+		}
 
-	err := us.repository.DeleteByUserID(user)
+		unused, err = unusedMediaHashes(repositories, hashes)
+		return err
+	})
 	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			httpError.PreconditionFailed(c, err)
+			return
+		}
+
+		if errors.Is(err, repository.ErrUserNotFound) {
+			httpError.NotFound(c, "user", user, err)
+			return
+		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
+	deleteMediaFiles(us.files, unused)
+
 	c.JSON(204, nil)
 
 }
 
+// deleteUserContent delete a user with their follows, subscriptions, blocks, messages, media,
+// likes, comments, snippets, polls and votes, posts and bda posts
+func deleteUserContent(repositories *repository.Repositories, userID string) error {
+	follows := &[]models.Follow{}
+	err := repositories.Follows.ListAllFollowsByUserID(follows, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, follow := range *follows {
+		err = updateFollowCounts(repositories, &follow, -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = repositories.Follows.DeleteFollowsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Subscriptions.DeleteSubscriptionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Blocks.DeleteBlocksByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Conversations.DeleteConversationsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Media.DeleteMediaByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Notifications.DeleteNotificationsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Mentions.DeleteMentionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	taggings := &[]models.Tagging{}
+	err = repositories.Tags.ListAllTaggingsByUserID(taggings, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, tagging := range *taggings {
+		err = repositories.Tags.UpdateTagUsesCount(tagging.TagID.String(), -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = repositories.Tags.DeleteTaggingsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Likes.DeleteLikesByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUserComments(repositories, userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Snippets.DeleteSnippetsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.Polls.DeletePollsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	posts := &[]models.Post{}
+	err = repositories.Posts.ListAllPostsByUserID(posts, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range *posts {
+		err = repositories.Likes.DeleteLikesByPostID(post.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = deleteTargetComments(repositories, models.PostComment, post.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.LinkPreviews.DeleteLinkPreviewsByPostID(post.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Topics.UpdatePostsCount(post.TopicID.String(), -1)
+		if err != nil && !errors.Is(err, repository.ErrTopicNotFound) {
+			return err
+		}
+	}
+
+	err = repositories.Posts.DeletePostsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	bdaPosts := &[]models.BdaPost{}
+	err = repositories.BdaPosts.ListAllBdaPostsByUserID(bdaPosts, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, bdaPost := range *bdaPosts {
+		err = deleteTargetComments(repositories, models.BdaPostComment, bdaPost.ID.String())
+		if err != nil {
+			return err
+		}
+
+		err = repositories.Likes.DeleteLikesByBdaPostID(bdaPost.ID.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = repositories.BdaPosts.DeleteBdaPostsByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	// the attachments of the deleted content and media
+	err = repositories.Attachments.DeleteOrphanAttachments()
+	if err != nil {
+		return err
+	}
+
+	return repositories.Users.DeleteByUserID(userID)
+}
+
 // GetUser get a specific user
 func (us *UserHandler) GetUser(c *gin.Context) {
+	representation, err := GetRepresentation(c, models.UserRelations)
+	if err != nil {
+		httpError.BadRequest(c, err)
+		return
+	}
+
 	userID, _ := c.Params.Get("id")
 
 	user := &models.User{}
 
-	err := us.repository.GetUserByID(user, userID)
+	err = us.repository.GetUserByID(user, userID)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
 			httpError.NotFound(c, "user", userID, err)
-
+			return
 		}
+
 		httpError.Internal(c, err)
 		return
 	}
 
-	c.JSON(200, user)
+	resource, err := representation.RenderOne(us.repository, createUserResponse(user))
+	if err != nil {
+		httpError.Internal(c, err)
+		return
+	}
+
+	RespondResource(c, user.Base, resource)
 }
 
 // UpdateUser update a specific user
@@ -223,7 +474,7 @@ func (us *UserHandler) UpdateUser(c *gin.Context) {
 
 	err := us.repository.GetUserByID(user, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrUserNotFound) {
 			httpError.NotFound(c, "User", userID, err)
 		} else {
 			httpError.Internal(c, err)
@@ -231,20 +482,38 @@ func (us *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if !CheckIfMatch(c, user.Base) {
+		return
+	}
+
+	version := user.Version
+
 	err = c.ShouldBindJSON(user)
 	if err != nil {
 		httpError.Internal(c, err)
 		return
 	}
 
-	if user.Password == "" {
-		err = us.repository.UpdateUserWithoutPassword(user)
-	} else {
-		err = us.repository.UpdateUserWithPassword(user, user.Password)
-	}
+	user.Version = version
 
+	err = us.unitOfWork.Transaction(func(repositories *repository.Repositories) error {
+		err := checkPictures(repositories, &user.ID, user.ProfilPicID, user.CoverPicID)
+		if err != nil {
+			return err
+		}
+
+		if user.Password == "" {
+			return repositories.Users.UpdateUserWithoutPassword(user)
+		}
+
+		return repositories.Users.UpdateUserWithPassword(user, user.Password)
+	})
 	if err != nil {
-		if errors.Is(err, repository.ErrUserNotFound) {
+		if errors.Is(err, errInvalidMedia) {
+			httpError.BadRequest(c, err)
+		} else if errors.Is(err, repository.ErrVersionConflict) {
+			httpError.PreconditionFailed(c, err)
+		} else if errors.Is(err, repository.ErrUserNotFound) {
 			httpError.NotFound(c, "User", userID, err)
 		} else {
 			httpError.Internal(c, err)
@@ -252,7 +521,7 @@ func (us *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, createUserResponse(user))
+	RespondResource(c, user.Base, createUserResponse(user))
 }
 
 // createUserResponse map the values of user to createUserResponse
@@ -264,9 +533,9 @@ func createUserResponse(user *models.User) UserResponse {
 		Email:          user.Email,
 		DateOfBirth:    user.DateOfBirth,
 		Apprenticeship: user.Apprenticeship,
-		ProfilPic:      user.ProfilPic,
+		ProfilPicID:    user.ProfilPicID,
 		Biography:      user.Biography,
-		CoverPic:       user.CoverPic,
+		CoverPicID:     user.CoverPicID,
 		PrivateMail:    user.PrivateMail,
 		ProjectPerso:   user.ProjectPerso,
 		ProjectPro:     user.ProjectPro,
@@ -277,6 +546,8 @@ func createUserResponse(user *models.User) UserResponse {
 		MBTI:           user.MBTI,
 		Admin:          user.Admin,
 		PromoID:        user.PromoID,
+		FollowersCount: user.FollowersCount,
+		FollowingCount: user.FollowingCount,
 		BdaPosts:       user.BdaPosts,
 		Posts:          user.Posts,
 		Comments:       user.Comments,
@@ -285,9 +556,9 @@ func createUserResponse(user *models.User) UserResponse {
 	}
 }
 
-// createUserResponse map the values of all users to a list of createUserResponse
-func createUsersResponse(users *[]models.User) []UserResponse {
-	usersList := []UserResponse{}
+// createUsersResponse map the values of all users to a list of createUserResponse
+func createUsersResponse(users *[]models.User) []interface{} {
+	usersList := []interface{}{}
 	for _, u := range *users {
 		usersList = append(usersList, createUserResponse(&u))
 	}
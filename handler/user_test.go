@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/ada-social-network/api/models"
@@ -9,6 +10,7 @@ import (
 	commonTesting "github.com/ada-social-network/api/testing"
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
 )
 
 func TestListUserHandler(t *testing.T) {
@@ -18,12 +20,12 @@ func TestListUserHandler(t *testing.T) {
 	db.Create(&models.User{})
 
 	userRepository := repository.NewCommentRepository(db)
-	NewUserHandler((*repository.UserRepository)(userRepository)).ListUser(ctx)
+	NewUserHandler((*repository.UserRepository)(userRepository), nil, nil).ListUser(ctx)
 
-	got := &[]models.User{}
+	got := &Collection{}
 	_ = json.Unmarshal(res.Body.Bytes(), got)
 
-	if len(*got) == 0 {
+	if len(got.Items) == 0 {
 		t.Error("ListUser response should not be empty")
 	}
 }
@@ -83,7 +85,7 @@ func TestCreateUserHandler(t *testing.T) {
 			commonTesting.AddRequestWithBodyToContext(ctx, tt.args.user)
 
 			userRepository := repository.NewCommentRepository(db)
-			NewUserHandler((*repository.UserRepository)(userRepository)).CreateUser(ctx)
+			NewUserHandler((*repository.UserRepository)(userRepository), nil, nil).CreateUser(ctx)
 
 			user := &models.User{}
 			_ = json.Unmarshal(res.Body.Bytes(), user)
@@ -100,13 +102,230 @@ func TestCreateUserHandler(t *testing.T) {
 	}
 }
 
+// fakeUserStore is an in-memory user store
+type fakeUserStore struct {
+	repository.UserStore
+	users map[string]*models.User
+}
+
+func (f *fakeUserStore) DeleteByUserID(userID string) error {
+	if _, ok := f.users[userID]; !ok {
+		return repository.ErrUserNotFound
+	}
+
+	delete(f.users, userID)
+	return nil
+}
+
+// fakeContentStore records the content deleted for a user
+type fakeContentStore struct {
+	deleted []string
+}
+
+type fakePostStore struct {
+	repository.PostStore
+	*fakeContentStore
+}
+
+func (f *fakePostStore) ListAllPostsByUserID(posts *[]models.Post, userID string) error {
+	return nil
+}
+
+func (f *fakePostStore) DeletePostsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "posts")
+	return nil
+}
+
+type fakeCommentStore struct {
+	repository.CommentStore
+	*fakeContentStore
+}
+
+func (f *fakeCommentStore) ListAllCommentsByUserID(comments *[]models.Comment, userID string) error {
+	*comments = []models.Comment{{Base: models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0c")}}}
+	return nil
+}
+
+func (f *fakeCommentStore) GetCommentByID(comment *models.Comment, commentID string) error {
+	comment.ID = uuid.FromStringOrNil(commentID)
+	return nil
+}
+
+func (f *fakeCommentStore) DeleteCommentByID(commentID string) error {
+	f.deleted = append(f.deleted, "comments")
+	return nil
+}
+
+type fakeBdaPostStore struct {
+	repository.BdaPostStore
+	*fakeContentStore
+}
+
+func (f *fakeBdaPostStore) ListAllBdaPostsByUserID(bdaPosts *[]models.BdaPost, userID string) error {
+	return nil
+}
+
+func (f *fakeBdaPostStore) DeleteBdaPostsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "bdaPosts")
+	return nil
+}
+
+type fakeLikeStore struct {
+	repository.LikeStore
+	*fakeContentStore
+}
+
+func (f *fakeLikeStore) DeleteLikesByUserID(userID string) error {
+	f.deleted = append(f.deleted, "likes")
+	return nil
+}
+
+func (f *fakeLikeStore) DeleteLikesByCommentID(commentID string) error {
+	return nil
+}
+
+type fakeFollowStore struct {
+	repository.FollowStore
+	*fakeContentStore
+}
+
+func (f *fakeFollowStore) ListAllFollowsByUserID(follows *[]models.Follow, userID string) error {
+	return nil
+}
+
+func (f *fakeFollowStore) DeleteFollowsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "follows")
+	return nil
+}
+
+type fakeSubscriptionStore struct {
+	repository.SubscriptionStore
+	*fakeContentStore
+}
+
+func (f *fakeSubscriptionStore) DeleteSubscriptionsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "subscriptions")
+	return nil
+}
+
+type fakeBlockStore struct {
+	repository.BlockStore
+	*fakeContentStore
+}
+
+func (f *fakeBlockStore) DeleteBlocksByUserID(userID string) error {
+	f.deleted = append(f.deleted, "blocks")
+	return nil
+}
+
+type fakeConversationStore struct {
+	repository.ConversationStore
+	*fakeContentStore
+}
+
+func (f *fakeConversationStore) DeleteConversationsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "conversations")
+	return nil
+}
+
+type fakeMediaStore struct {
+	repository.MediaStore
+	*fakeContentStore
+}
+
+func (f *fakeMediaStore) ListMediaHashesByUserID(userID string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (f *fakeMediaStore) DeleteMediaByUserID(userID string) error {
+	f.deleted = append(f.deleted, "media")
+	return nil
+}
+
+type fakeNotificationStore struct {
+	repository.NotificationStore
+	*fakeContentStore
+}
+
+func (f *fakeNotificationStore) DeleteNotificationsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "notifications")
+	return nil
+}
+
+func (f *fakeNotificationStore) DeleteNotificationsByTarget(notifications *[]models.Notification, targetType models.NotificationTarget, targetID string) error {
+	return nil
+}
+
+type fakeMentionStore struct {
+	repository.MentionStore
+	*fakeContentStore
+}
+
+func (f *fakeMentionStore) DeleteMentionsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "mentions")
+	return nil
+}
+
+func (f *fakeMentionStore) DeleteMentionsBySource(sourceType models.MentionSource, sourceID string) error {
+	return nil
+}
+
+type fakeTagStore struct {
+	repository.TagStore
+	*fakeContentStore
+}
+
+func (f *fakeTagStore) ListAllTaggingsByUserID(taggings *[]models.Tagging, userID string) error {
+	return nil
+}
+
+func (f *fakeTagStore) DeleteTaggingsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "tags")
+	return nil
+}
+
+type fakeAttachmentStore struct {
+	repository.AttachmentStore
+	*fakeContentStore
+}
+
+func (f *fakeAttachmentStore) DeleteAttachmentsBySource(sourceType models.AttachmentSource, sourceID string) error {
+	return nil
+}
+
+func (f *fakeAttachmentStore) DeleteOrphanAttachments() error {
+	f.deleted = append(f.deleted, "attachments")
+	return nil
+}
+
+type fakeSnippetStore struct {
+	repository.SnippetStore
+	*fakeContentStore
+}
+
+func (f *fakeSnippetStore) DeleteSnippetsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "snippets")
+	return nil
+}
+
+type fakePollStore struct {
+	repository.PollStore
+	*fakeContentStore
+}
+
+func (f *fakePollStore) DeletePollsByUserID(userID string) error {
+	f.deleted = append(f.deleted, "polls")
+	return nil
+}
+
 func TestDeleteUserHandler(t *testing.T) {
-	db := commonTesting.InitDB(&models.User{})
+	db := commonTesting.InitAllDB()
 	res, ctx, _ := commonTesting.InitHTTPTest()
 
 	db.Create(&models.User{
 
-		Base: models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b")},
+		Base:  models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b")},
+		Email: "deleted@users.dev",
 	})
 
 	ctx.Params = gin.Params{
@@ -117,7 +336,7 @@ func TestDeleteUserHandler(t *testing.T) {
 	}
 
 	userRepository := repository.NewCommentRepository(db)
-	NewUserHandler((*repository.UserRepository)(userRepository)).DeleteUser(ctx)
+	NewUserHandler((*repository.UserRepository)(userRepository), repository.NewUnitOfWork(db), nil).DeleteUser(ctx)
 
 	if res.Code != 204 {
 		t.Errorf("DeleteUser want:%d, got:%d", 204, res.Code)
@@ -128,3 +347,167 @@ func TestDeleteUserHandler(t *testing.T) {
 		t.Errorf("DeleteUser User should be deleted")
 	}
 }
+
+// TestDeleteUserContent check the content deleted with a user, in the order of the unit
+// of work. The content is deleted before the user is looked up, and rolled back with the
+// transaction when it does not exist.
+func TestDeleteUserContent(t *testing.T) {
+	deleted := []string{"follows", "subscriptions", "blocks", "conversations", "media", "notifications", "mentions", "tags", "likes", "comments", "snippets", "polls", "posts", "bdaPosts", "attachments"}
+
+	tests := []struct {
+		name   string
+		userID string
+		code   int
+	}{
+		{
+			name:   "nominal",
+			userID: "80a08d36-cfea-4898-aee3-6902fa562f0b",
+			code:   204,
+		},
+		{
+			name:   "not found",
+			userID: "99999999-9999-9999-9999-999999999999",
+			code:   404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ctx, _ := commonTesting.InitHTTPTest()
+
+			users := &fakeUserStore{users: map[string]*models.User{
+				"80a08d36-cfea-4898-aee3-6902fa562f0b": {
+					Base: models.Base{ID: uuid.FromStringOrNil("80a08d36-cfea-4898-aee3-6902fa562f0b")},
+				},
+			}}
+			content := &fakeContentStore{}
+			unitOfWork := &commonTesting.UnitOfWork{Repositories: &repository.Repositories{
+				Users:         users,
+				Posts:         &fakePostStore{fakeContentStore: content},
+				Comments:      &fakeCommentStore{fakeContentStore: content},
+				BdaPosts:      &fakeBdaPostStore{fakeContentStore: content},
+				Likes:         &fakeLikeStore{fakeContentStore: content},
+				Follows:       &fakeFollowStore{fakeContentStore: content},
+				Subscriptions: &fakeSubscriptionStore{fakeContentStore: content},
+				Blocks:        &fakeBlockStore{fakeContentStore: content},
+				Conversations: &fakeConversationStore{fakeContentStore: content},
+				Media:         &fakeMediaStore{fakeContentStore: content},
+				Notifications: &fakeNotificationStore{fakeContentStore: content},
+				Mentions:      &fakeMentionStore{fakeContentStore: content},
+				Tags:          &fakeTagStore{fakeContentStore: content},
+				Attachments:   &fakeAttachmentStore{fakeContentStore: content},
+				Snippets:      &fakeSnippetStore{fakeContentStore: content},
+				Polls:         &fakePollStore{fakeContentStore: content},
+			}}
+
+			ctx.Params = gin.Params{
+				{
+					Key:   "id",
+					Value: tt.userID,
+				},
+			}
+
+			NewUserHandler(users, unitOfWork, nil).DeleteUser(ctx)
+
+			if res.Code != tt.code {
+				t.Errorf("DeleteUser want:%d, got:%d", tt.code, res.Code)
+			}
+
+			if _, ok := users.users[tt.userID]; ok {
+				t.Errorf("DeleteUser User should be deleted")
+			}
+
+			if !reflect.DeepEqual(content.deleted, deleted) {
+				t.Errorf("DeleteUser want deleted:%v, got:%v", deleted, content.deleted)
+			}
+		})
+	}
+}
+
+// TestDeleteUserRows check no row of a deleted user is left in the database. All the
+// tables are checked, so the content of a new feature is checked without changing this
+// test; the topics of the user are kept for the posts of the others.
+func TestDeleteUserRows(t *testing.T) {
+	db := commonTesting.InitAllDB()
+
+	user := &models.User{Email: "rows@users.dev"}
+	other := &models.User{Email: "other-rows@users.dev"}
+	db.Create(user)
+	db.Create(other)
+
+	topic := &models.Topic{UserID: user.ID, Name: "rows", Content: "rows of the user"}
+	bdaPost := &models.BdaPost{UserID: other.ID, Title: "rows", Content: "rows of the others"}
+	conversation := &models.Conversation{UserID: user.ID, IsGroup: true}
+	media := &models.Media{UserID: user.ID, Hash: "deleted-user"}
+	tag := &models.Tag{Name: "deleted-user", UsesCount: 1}
+	snippet := &models.Snippet{UserID: user.ID, FileName: "main.go", Content: "package main"}
+	for _, row := range []interface{}{topic, bdaPost, conversation, media, tag, snippet} {
+		err := db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := &models.Post{UserID: user.ID, TopicID: topic.ID, Content: "post of the user"}
+	comment := &models.Comment{UserID: user.ID, TargetType: models.BdaPostComment, TargetID: bdaPost.ID, Content: "comment of the user"}
+	poll := &models.Poll{TargetType: models.BdaPostPoll, TargetID: bdaPost.ID, UserID: other.ID, Question: "?"}
+	for _, row := range []interface{}{post, comment, poll} {
+		err := db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	option := &models.PollOption{PollID: poll.ID, Text: "yes"}
+	vote := &models.PollVote{PollID: poll.ID, UserID: user.ID}
+	db.Create(option)
+	db.Create(vote)
+
+	for _, row := range []interface{}{
+		&models.Like{UserID: user.ID, BdaPostID: bdaPost.ID},
+		&models.Follow{UserID: user.ID, FollowedID: other.ID},
+		&models.Subscription{UserID: user.ID, TargetType: models.TopicSubscription, TargetID: topic.ID},
+		&models.PollChoice{VoteID: vote.ID, OptionID: option.ID, PollID: poll.ID, UserID: user.ID},
+		&models.ConversationMember{ConversationID: conversation.ID, UserID: user.ID},
+		&models.ConversationMember{ConversationID: conversation.ID, UserID: other.ID},
+		&models.Message{ConversationID: conversation.ID, UserID: user.ID, Content: "hello"},
+		&models.Block{UserID: user.ID, BlockedID: other.ID},
+		&models.Notification{UserID: user.ID, ActorID: other.ID, Type: models.LikeNotification, TargetType: models.BdaPostTarget, TargetID: bdaPost.ID},
+		&models.NotificationPreference{UserID: user.ID, Type: models.PostNotification},
+		&models.Mention{SourceType: models.BdaPostMention, SourceID: bdaPost.ID, UserID: user.ID, Handle: "deleted"},
+		&models.Tagging{TagID: tag.ID, SourceType: models.PostTag, SourceID: post.ID, Tag: tag.Name},
+		&models.Attachment{SourceType: models.PostAttachment, SourceID: post.ID, UserID: user.ID, MediaID: media.ID},
+		&models.SnippetRevision{SnippetID: snippet.ID, Revision: 1, UserID: user.ID},
+	} {
+		err := db.Create(row).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, ctx, _ := commonTesting.InitHTTPTest()
+	ctx.Params = gin.Params{{Key: "id", Value: user.ID.String()}}
+	NewUserHandler(repository.NewUserRepository(db), repository.NewUnitOfWork(db), nil).DeleteUser(ctx)
+
+	if res.Code != 204 {
+		t.Fatalf("DeleteUser want:%d, got:%d", 204, res.Code)
+	}
+
+	for _, model := range models.All() {
+		stmt := &gorm.Statement{DB: db}
+		err := stmt.Parse(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stmt.Schema.Table == "topics" || stmt.Schema.LookUpField("user_id") == nil {
+			continue
+		}
+
+		var count int64
+		db.Table(stmt.Schema.Table).Where("user_id = ?", user.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("DeleteUser left %d rows of the user in %s", count, stmt.Schema.Table)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package handler
 
 import (
 	"errors"
+	"reflect"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/ada-social-network/api/middleware"
 	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
 )
 
 // GetCurrentUser Get the current connected user
@@ -24,25 +26,44 @@ func GetCurrentUser(c *gin.Context) (*models.User, error) {
 	return u, nil
 }
 
-// Collection defines the count of items and items
+// Collection defines a page of items, the count of items of the page, the total count
+// of items and the cursor of the next page
 type Collection struct {
-	Items []interface{} `json:"items"`
-	Count int           `json:"count"`
+	Items      []interface{} `json:"items"`
+	Count      int           `json:"count"`
+	Total      int64         `json:"total"`
+	NextCursor string        `json:"nextCursor"`
 }
 
-//LikeCollection defines the count of items and items and if is liked by current user
+// LikeCollection defines a collection of likes and if is liked by current user
 type LikeCollection struct {
-	Items                []interface{} `json:"items"`
-	Count                int           `json:"count"`
-	IsLikedByCurrentUser bool          `json:"isLikedByCurrentUser"`
+	Collection
+	IsLikedByCurrentUser bool `json:"isLikedByCurrentUser"`
 }
 
-// NewCollection create a new collection
-func NewCollection(items []interface{}) *Collection {
-	return &Collection{Items: items, Count: len(items)}
+// NewCollection create a new collection from a page of items
+func NewCollection(items []interface{}, info repository.PageInfo) *Collection {
+	collection := &Collection{Items: items, Count: len(items), Total: info.Total}
+	if info.Next != nil {
+		collection.NextCursor = info.Next.Encode()
+	}
+
+	return collection
 }
 
 // NewLikeCollection create a new collection with a boolean for likes
-func NewLikeCollection(items []interface{}, isLikedByCurrentUser bool) *LikeCollection {
-	return &LikeCollection{Items: items, Count: len(items), IsLikedByCurrentUser: isLikedByCurrentUser}
+func NewLikeCollection(items []interface{}, info repository.PageInfo, isLikedByCurrentUser bool) *LikeCollection {
+	return &LikeCollection{Collection: *NewCollection(items, info), IsLikedByCurrentUser: isLikedByCurrentUser}
+}
+
+// collectionItems returns the items of a pointer to a slice of models as a list
+// of items of a collection
+func collectionItems(list interface{}) []interface{} {
+	values := reflect.Indirect(reflect.ValueOf(list))
+	items := make([]interface{}, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		items = append(items, values.Index(i).Interface())
+	}
+
+	return items
 }
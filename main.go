@@ -9,15 +9,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/ada-social-network/api/handler"
+	"github.com/ada-social-network/api/media"
 	"github.com/ada-social-network/api/middleware"
-	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
 	"github.com/ada-social-network/api/repository"
+	"github.com/ada-social-network/api/storage"
+	"github.com/ada-social-network/api/unfurl"
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
@@ -26,6 +28,7 @@ var version = "dev"
 const (
 	basePath     = "/api/rest/v1"
 	basePathAuth = "/auth"
+	streamPath   = basePath + "/stream/"
 )
 
 // CORS used for adding cors support
@@ -45,7 +48,31 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
+// withWriteTimeout limit the time to respond the requests which are not event streams
+func withWriteTimeout(h http.Handler, timeout time.Duration) http.Handler {
+	timeoutHandler := http.TimeoutHandler(h, timeout, "")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, streamPath) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		timeoutHandler.ServeHTTP(w, r)
+	})
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := findCommand(os.Args[1]); ok {
+			err := cmd.run(os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	var wait time.Duration
 	var port int
 	var host string
@@ -54,32 +81,35 @@ func main() {
 	var withAuth bool
 	var showVersion bool
 	var allowedDomain string
+	var commentMaxDepth int
+	var mediaStorage string
+	var mediaMaxSize int64
+	var linkPreviews bool
+	var linkPreviewTimeout time.Duration
 
 	flag.BoolVar(&withAuth, "auth", true, "Use api authentication")
 	flag.BoolVar(&showVersion, "version", false, "Show application current version")
 	flag.IntVar(&port, "http-port", 8080, "Default port")
 	flag.StringVar(&host, "http-host", "0.0.0.0", "Default interface")
 	flag.StringVar(&allowedDomain, "allowed-domain", "", "domain allowed for Cross Domain Request (CORS)")
+	flag.IntVar(&commentMaxDepth, "comment-max-depth", handler.DefaultCommentMaxDepth, "maximum depth of the replies to comments, deeper replies are attached to the comment at this depth")
 	flag.StringVar(&mode, "mode", gin.ReleaseMode, "Running mode, can be 'debug', 'release' or 'test'")
 	flag.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) that will store data")
+	flag.StringVar(&mediaStorage, "media-storage", "uploads", "directory or s3:// URL of the storage of the files of the uploaded media")
+	flag.Int64Var(&mediaMaxSize, "media-max-size", media.DefaultMaxSize, "maximum size in bytes of an uploaded media")
+	flag.BoolVar(&linkPreviews, "link-previews", true, "fetch in background the previews of the links of the posts")
+	flag.DurationVar(&linkPreviewTimeout, "link-preview-timeout", unfurl.DefaultTimeout, "maximum duration to fetch the preview of a link")
 	flag.DurationVar(&wait, "graceful-timeout", time.Second*15, "the duration for which the server gracefully wait for existing connections to finish - e.g. 15s or 1m")
+	flag.Usage = usage
 	flag.Parse()
 
 	if showVersion {
 		fmt.Printf("Current version: %s\n", version)
 		return
 	}
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	db, err := openDatabase(dsn, logger.Info)
 	if err != nil {
-		log.Fatal("DB connection failed", err)
-	}
-
-	err = db.AutoMigrate(&models.Post{}, &models.User{}, &models.BdaPost{}, &models.Promo{}, &models.Comment{}, &models.Category{}, &models.Topic{}, &models.Like{})
-
-	if err != nil {
-		log.Fatal("Automigration failed", err)
+		log.Fatal(err)
 	}
 
 	gin.SetMode(mode)
@@ -106,32 +136,46 @@ func main() {
 		log.Fatal(err)
 	}
 
-	userRepository := repository.NewUserRepository(db)
-	userHandler := handler.NewUserHandler(userRepository)
-
-	commentRepository := repository.NewCommentRepository(db)
-	commentHandler := handler.NewCommentHandler(commentRepository)
-
-	bdaPostRepository := repository.NewBdaPostRepository(db)
-	bdaPostHandler := handler.NewBdaPostHandler(bdaPostRepository)
-
-	postRepository := repository.NewPostRepository(db)
-	postHandler := handler.NewPostHandler(postRepository)
-
-	categoryRepository := repository.NewCategoryRepository(db)
-	categoryHandler := handler.NewCategoryHandler(categoryRepository)
-
-	promoRepository := repository.NewPromoRepository(db)
-	promoHandler := handler.NewPromoHandler(promoRepository)
+	files, err := storage.Open(mediaStorage)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	topicRepository := repository.NewTopicRepository(db)
-	topicHandler := handler.NewTopicHandler(topicRepository)
+	broker := realtime.NewMemoryBroker()
+	repositories := repository.NewRepositories(db)
+	unitOfWork := repository.NewUnitOfWork(db).WithPublisher(broker)
+
+	userHandler := handler.NewUserHandler(repositories.Users, unitOfWork, files)
+	commentHandler := handler.NewCommentHandler(repositories.Comments, unitOfWork, commentMaxDepth)
+	bdaPostHandler := handler.NewBdaPostHandler(repositories.BdaPosts, unitOfWork)
+	postHandler := handler.NewPostHandler(repositories.Posts, unitOfWork)
+	categoryHandler := handler.NewCategoryHandler(repositories.Categories, unitOfWork)
+	promoHandler := handler.NewPromoHandler(repositories.Promos, unitOfWork)
+	topicHandler := handler.NewTopicHandler(repositories.Topics, unitOfWork)
+	searchHandler := handler.NewSearchHandler(repositories.Search, repositories.Blocks)
+	followHandler := handler.NewFollowHandler(repositories.Follows, unitOfWork)
+	feedHandler := handler.NewFeedHandler(repositories.Feeds)
+	subscriptionHandler := handler.NewSubscriptionHandler(repositories.Subscriptions, unitOfWork)
+	notificationHandler := handler.NewNotificationHandler(repositories.Notifications)
+	tagHandler := handler.NewTagHandler(repositories.Tags)
+	blockHandler := handler.NewBlockHandler(repositories.Blocks, unitOfWork)
+	conversationHandler := handler.NewConversationHandler(repositories.Conversations, unitOfWork)
+	streamHandler := handler.NewStreamHandler(broker, repositories.Conversations, allowedDomain)
+	mediaHandler := handler.NewMediaHandler(repositories.Media, unitOfWork, files, mediaMaxSize)
+	snippetHandler := handler.NewSnippetHandler(repositories.Snippets, unitOfWork)
+	pollHandler := handler.NewPollHandler(repositories.Polls, unitOfWork)
 
 	r.Group(basePathAuth).
 		POST("/register", userHandler.Register).
 		POST("/login", authMiddleware.LoginHandler).
 		GET("/refresh", authMiddleware.RefreshHandler)
 
+	// the files of the media and the CSS of the highlighted code are public so they can be
+	// loaded by the browsers
+	r.Group(basePath).
+		GET("/media/:id/file", mediaHandler.ServeMediaFile).
+		GET("/highlight.css", snippetHandler.ServeHighlightCSS)
+
 	protected := r.Group(basePath)
 
 	if withAuth {
@@ -141,11 +185,46 @@ func main() {
 	protected.
 		GET("/me", userHandler.Me).
 		PATCH("/me/password", userHandler.UpdatePassword).
+		GET("/me/subscriptions", subscriptionHandler.ListSubscriptions).
+		GET("/me/feed", feedHandler.GetFeed).
+		GET("/me/notifications", notificationHandler.ListNotifications).
+		POST("/me/notifications/read", notificationHandler.ReadAllNotifications).
+		POST("/me/notifications/:id/read", notificationHandler.ReadNotification).
+		GET("/me/notification-preferences", notificationHandler.GetNotificationPreferences).
+		PATCH("/me/notification-preferences", notificationHandler.UpdateNotificationPreferences).
+		GET("/me/blocks", blockHandler.ListBlocks).
+		GET("/me/media", mediaHandler.ListMedia).
+		POST("/media", mediaHandler.UploadMedia).
+		GET("/media/:id", mediaHandler.GetMedia).
+		DELETE("/media/:id", mediaHandler.DeleteMedia).
+		GET("/snippets", snippetHandler.ListSnippets).
+		POST("/snippets", snippetHandler.CreateSnippet).
+		GET("/snippets/:id", snippetHandler.GetSnippet).
+		PATCH("/snippets/:id", snippetHandler.UpdateSnippet).
+		DELETE("/snippets/:id", snippetHandler.DeleteSnippet).
+		GET("/snippets/:id/revisions", snippetHandler.ListSnippetRevisions).
+		GET("/snippets/:id/revisions/:revision", snippetHandler.GetSnippetRevision).
+		GET("/conversations", conversationHandler.ListConversations).
+		POST("/conversations", conversationHandler.CreateConversation).
+		GET("/conversations/:id", conversationHandler.GetConversation).
+		POST("/conversations/:id/read", conversationHandler.ReadConversation).
+		POST("/conversations/:id/leave", conversationHandler.LeaveConversation).
+		GET("/conversations/:id/messages", conversationHandler.ListMessages).
+		POST("/conversations/:id/messages", conversationHandler.CreateMessage).
+		GET("/stream/ws", streamHandler.StreamWebSocket).
+		GET("/stream/sse", streamHandler.StreamEvents).
 		GET("/users", userHandler.ListUser).
+		GET("/users/mention-suggestions", userHandler.ListMentionSuggestions).
 		GET("/users/:id", userHandler.GetUser).
 		POST("/users", userHandler.CreateUser).
 		PATCH("/users/:id", userHandler.UpdateUser).
 		DELETE("/users/:id", userHandler.DeleteUser).
+		GET("/users/:id/followers", followHandler.ListFollowers).
+		GET("/users/:id/following", followHandler.ListFollowing).
+		POST("/users/:id/follow", followHandler.FollowUser).
+		DELETE("/users/:id/follow", followHandler.UnfollowUser).
+		POST("/users/:id/block", blockHandler.BlockUser).
+		DELETE("/users/:id/block", blockHandler.UnblockUser).
 		GET("/topics/:id/posts", postHandler.ListPost).
 		GET("/topics/:id/posts/:postId", postHandler.GetPost).
 		POST("/topics/:id/posts", postHandler.CreatePost).
@@ -154,7 +233,14 @@ func main() {
 		GET("/posts/:id/likes", postHandler.ListPostLikes).
 		POST("/posts/:id/likes", postHandler.CreatePostLike).
 		DELETE("/posts/:id/likes/:likeId", postHandler.DeletePostLike).
+		GET("/posts/:id/comments", commentHandler.ListPostComments).
+		GET("/posts/:id/comments/:commentId", commentHandler.GetComment).
+		POST("/posts/:id/comments", commentHandler.CreatePostComment).
+		PATCH("/posts/:id/comments/:commentId", commentHandler.UpdateComment).
+		DELETE("/posts/:id/comments/:commentId", commentHandler.DeleteComment).
 		GET("/bdaposts", bdaPostHandler.ListBdaPost).
+		POST("/bdaposts/subscription", subscriptionHandler.SubscribeBdaFeed).
+		DELETE("/bdaposts/subscription", subscriptionHandler.UnsubscribeBdaFeed).
 		GET("/bdaposts/:id", bdaPostHandler.GetBdaPost).
 		POST("/bdaposts", bdaPostHandler.CreateBdaPost).
 		PATCH("/bdaposts/:id", bdaPostHandler.UpdateBdaPost).
@@ -163,10 +249,13 @@ func main() {
 		POST("/bdaposts/:id/likes", bdaPostHandler.CreateBdaPostLike).
 		DELETE("/bdaposts/:id/likes/:likeId", bdaPostHandler.DeleteBdaPostLike).
 		GET("/bdaposts/:id/comments", commentHandler.ListBdaPostComments).
-		GET("/bdaposts/:id/comments/:commentId", commentHandler.GetBdaPostComment).
+		GET("/bdaposts/:id/comments/:commentId", commentHandler.GetComment).
 		POST("/bdaposts/:id/comments", commentHandler.CreateBdaPostComment).
-		PATCH("/bdaposts/:id/comments/:commentId", commentHandler.UpdateBdaPostComment).
-		DELETE("bdaposts/:id/comments/:commentId", commentHandler.DeleteBdaPostComment).
+		PATCH("/bdaposts/:id/comments/:commentId", commentHandler.UpdateComment).
+		DELETE("bdaposts/:id/comments/:commentId", commentHandler.DeleteComment).
+		GET("/bdaposts/:id/poll", pollHandler.GetBdaPostPoll).
+		POST("/bdaposts/:id/poll", pollHandler.CreateBdaPostPoll).
+		GET("/comments/:id/replies", commentHandler.ListCommentReplies).
 		GET("/comments/:id/likes", commentHandler.ListCommentLikes).
 		POST("/comments/:id/likes", commentHandler.CreateCommentLike).
 		DELETE("/comments/:id/likes/:likeId", commentHandler.DeleteCommentLike).
@@ -181,19 +270,49 @@ func main() {
 		PATCH("/categories/:id", categoryHandler.UpdateCategory).
 		DELETE("/categories/:id", categoryHandler.DeleteCategory).
 		GET("/categories/:id/topics", topicHandler.ListCategoryTopics).
+		POST("/categories/:id/subscription", subscriptionHandler.SubscribeCategory).
+		DELETE("/categories/:id/subscription", subscriptionHandler.UnsubscribeCategory).
 		GET("/topics", topicHandler.ListTopics).
 		POST("/categories/:id/topics", topicHandler.CreateTopic).
 		PATCH("/topics/:id", topicHandler.UpdateTopic).
 		DELETE("/topics/:id", topicHandler.DeleteTopic).
-		GET("/topics/:id", topicHandler.GetTopic)
+		GET("/topics/:id", topicHandler.GetTopic).
+		POST("/topics/:id/subscription", subscriptionHandler.SubscribeTopic).
+		DELETE("/topics/:id/subscription", subscriptionHandler.UnsubscribeTopic).
+		GET("/topics/:id/comments", commentHandler.ListTopicComments).
+		GET("/topics/:id/comments/:commentId", commentHandler.GetComment).
+		POST("/topics/:id/comments", commentHandler.CreateTopicComment).
+		PATCH("/topics/:id/comments/:commentId", commentHandler.UpdateComment).
+		DELETE("/topics/:id/comments/:commentId", commentHandler.DeleteComment).
+		GET("/topics/:id/poll", pollHandler.GetTopicPoll).
+		POST("/topics/:id/poll", pollHandler.CreateTopicPoll).
+		GET("/polls/:id", pollHandler.GetPoll).
+		DELETE("/polls/:id", pollHandler.DeletePoll).
+		POST("/polls/:id/close", pollHandler.ClosePoll).
+		PUT("/polls/:id/vote", pollHandler.VotePoll).
+		DELETE("/polls/:id/vote", pollHandler.DeletePollVote).
+		GET("/polls/:id/results", pollHandler.GetPollResults).
+		GET("/tags", tagHandler.ListTags).
+		GET("/tags/:name/items", tagHandler.ListTagItems).
+		GET("/search", searchHandler.Search)
 
 	srv := &http.Server{
 		Addr: fmt.Sprintf("%s:%d", host, port),
-		// Good practice to set timeouts to avoid Slowloris attacks.
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
-		Handler:      r,
+		// Good practice to set timeouts to avoid Slowloris attacks, the write timeout is
+		// set by request so the event streams stay open.
+		ReadTimeout: time.Second * 15,
+		IdleTimeout: time.Second * 60,
+		Handler:     withWriteTimeout(r, time.Second*15),
+	}
+
+	// The previews of the links are fetched in background until the server is stopped,
+	// the links saved without preview are fetched on the next start.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if linkPreviews {
+		fetcher := unfurl.NewFetcher(linkPreviewTimeout, unfurl.DefaultMaxSize)
+		go unfurl.NewWorker(repositories.LinkPreviews, fetcher, unfurl.DefaultInterval, unfurl.DefaultCacheTTL).Run(workers)
 	}
 
 	// Run our server in a goroutine so that it doesn't block.
@@ -212,6 +331,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutdown Server ...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
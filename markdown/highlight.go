@@ -0,0 +1,149 @@
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultStyle is the style of the highlighted code when none is requested
+const DefaultStyle = "github"
+
+// ErrUnknownStyle is returned when the CSS of a style which does not exist is requested
+var ErrUnknownStyle = errors.New("unknown style")
+
+// invalidLanguageChars are the characters removed from the languages given by the users
+var invalidLanguageChars = regexp.MustCompile(`[^\w+#.-]`)
+
+// Highlight returns the HTML of a code highlighted with CSS classes, and its language.
+// The language is found from its name or alias, from the file name or else detected from
+// the code. A language which is not supported is kept but the code is not highlighted.
+func Highlight(code string, language string, fileName string) (string, string) {
+	language = strings.ToLower(invalidLanguageChars.ReplaceAllString(language, ""))
+
+	var lexer chroma.Lexer
+	switch {
+	case language != "":
+		lexer = lexers.Get(language)
+	case fileName != "":
+		lexer = lexers.Match(fileName)
+	}
+	if lexer == nil && language == "" {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer != nil {
+		language = languageOf(lexer)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(`<pre class="chroma"><code`)
+	if language != "" {
+		buf.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+	}
+	buf.WriteString(">")
+
+	var tokens chroma.Iterator
+	if lexer != nil {
+		tokens, _ = chroma.Coalesce(lexer).Tokenise(nil, code)
+	}
+	if tokens == nil {
+		buf.WriteString(html.EscapeString(code))
+	} else {
+		for token := tokens(); token != chroma.EOF; token = tokens() {
+			class := tokenClass(token.Type)
+			if class == "" {
+				buf.WriteString(html.EscapeString(token.Value))
+				continue
+			}
+
+			buf.WriteString(`<span class="` + class + `">` + html.EscapeString(token.Value) + `</span>`)
+		}
+	}
+
+	buf.WriteString("</code></pre>\n")
+
+	return buf.String(), language
+}
+
+// HighlightCSS write the CSS of the classes of the highlighted code for a style
+func HighlightCSS(w io.Writer, style string) error {
+	if _, ok := styles.Registry[style]; !ok {
+		return ErrUnknownStyle
+	}
+
+	return chromahtml.New(chromahtml.WithClasses(true)).WriteCSS(w, styles.Get(style))
+}
+
+// languageOf returns the short name of the language of a lexer, e.g. "go" or "python"
+func languageOf(lexer chroma.Lexer) string {
+	config := lexer.Config()
+	if len(config.Aliases) > 0 {
+		return config.Aliases[0]
+	}
+
+	return strings.ToLower(strings.ReplaceAll(config.Name, " ", "-"))
+}
+
+// tokenClass returns the CSS class of a type of token, or of its closest parent type
+func tokenClass(tokenType chroma.TokenType) string {
+	for {
+		if class, ok := chroma.StandardTypes[tokenType]; ok || tokenType == 0 {
+			return class
+		}
+
+		tokenType = tokenType.Parent()
+	}
+}
+
+// codeBlockRenderer render the code blocks of the contents highlighted, the language of
+// a fenced code block is the first word of its info string
+type codeBlockRenderer struct{}
+
+// RegisterFuncs register the rendering of the code blocks
+func (r codeBlockRenderer) RegisterFuncs(registerer renderer.NodeRendererFuncRegisterer) {
+	registerer.Register(ast.KindCodeBlock, r.renderCodeBlock)
+	registerer.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+}
+
+// renderCodeBlock write the highlighted code of a code block
+func (r codeBlockRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var code bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	language := ""
+	if fenced, ok := node.(*ast.FencedCodeBlock); ok {
+		language = string(fenced.Language(source))
+	}
+
+	highlighted, _ := Highlight(code.String(), language, "")
+	_, err := w.WriteString(highlighted)
+
+	return ast.WalkSkipChildren, err
+}
+
+// codeBlockPriority is the priority of codeBlockRenderer, it replaces the rendering of the
+// code blocks by the HTML renderer
+const codeBlockPriority = 200
+
+// highlighting is the renderer option highlighting the code blocks
+var highlighting = renderer.WithNodeRenderers(util.Prioritized(codeBlockRenderer{}, codeBlockPriority))
@@ -0,0 +1,54 @@
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		language string
+		fileName string
+		want     string
+		html     string
+	}{
+		{name: "language", code: "package main", language: "go", want: "go", html: `<span class="kn">package</span>`},
+		{name: "alias", code: "package main", language: "Golang", want: "go", html: `<span class="kn">package</span>`},
+		{name: "file name", code: "def f(): pass", fileName: "main.py", want: "python", html: `<span class="k">def</span>`},
+		{name: "detected", code: "#!/bin/bash\necho hi", want: "bash", html: `<span class="cp">#!/bin/bash`},
+		{name: "unknown language", code: "graph TD; A-->B", language: "mermaid", want: "mermaid", html: `<code class="language-mermaid">graph TD; A--&gt;B</code>`},
+		{name: "invalid language", code: "x", language: `"><script>`, want: "script", html: `<code class="language-script">x</code>`},
+		{name: "plain text", code: "<b>hello</b>", want: "", html: `<pre class="chroma"><code>&lt;b&gt;hello&lt;/b&gt;</code></pre>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			html, language := Highlight(test.code, test.language, test.fileName)
+			if language != test.want {
+				t.Errorf("Highlight language = %q, want %q", language, test.want)
+			}
+
+			if !strings.Contains(html, test.html) {
+				t.Errorf("Highlight = %q, want it to contain %q", html, test.html)
+			}
+		})
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := HighlightCSS(buf, DefaultStyle)
+	if err != nil || !strings.Contains(buf.String(), ".chroma .kd {") {
+		t.Errorf("HighlightCSS = %v %q, want the CSS of the classes", err, buf.String())
+	}
+
+	err = HighlightCSS(&bytes.Buffer{}, "unknown")
+	if !errors.Is(err, ErrUnknownStyle) {
+		t.Errorf("HighlightCSS of an unknown style = %v, want %v", err, ErrUnknownStyle)
+	}
+}
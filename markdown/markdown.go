@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// converter converts CommonMark with the GitHub extensions (tables, strikethrough, task
+// lists and autolinks) to HTML with the code blocks highlighted. The raw HTML of the
+// content is kept, it is removed by the policy.
+var converter = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithUnsafe(), highlighting),
+)
+
+// policy is the allow-list of the elements and attributes of the rendered HTML, the links
+// can not run scripts and the external links are opened in a new tab
+var policy = newPolicy()
+
+// newPolicy returns the allow-list of the user generated content with the task lists and
+// the classes of the highlighted code blocks
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^chroma$`)).OnElements("pre")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-z0-9]{1,4}$`)).OnElements("span")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// Render returns the sanitized HTML of a markdown content
+func Render(content string) string {
+	if content == "" {
+		return ""
+	}
+
+	var buf bytes.Buffer
+
+	// the conversion fails only when the buffer can not be written
+	_ = converter.Convert([]byte(content), &buf)
+
+	return policy.Sanitize(buf.String())
+}
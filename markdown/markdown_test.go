@@ -0,0 +1,55 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty", content: "", want: ""},
+		{name: "paragraph", content: "Hello *Ada*", want: "<p>Hello <em>Ada</em></p>\n"},
+		{name: "strikethrough", content: "~~bug~~", want: "<p><del>bug</del></p>\n"},
+		{
+			name:    "table",
+			content: "| a | b |\n|---|---|\n| 1 | 2 |",
+			want:    "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:    "task list",
+			content: "- [x] done\n- [ ] todo",
+			want:    "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n<li><input disabled=\"\" type=\"checkbox\"> todo</li>\n</ul>\n",
+		},
+		{
+			name:    "autolink",
+			content: "see https://golang.org",
+			want:    "<p>see <a href=\"https://golang.org\" rel=\"nofollow noopener\" target=\"_blank\">https://golang.org</a></p>\n",
+		},
+		{name: "relative link", content: "[topic](/topics/42)", want: "<p><a href=\"/topics/42\" rel=\"nofollow\">topic</a></p>\n"},
+		{
+			name:    "code block",
+			content: "```go\nfmt.Println(\"<3\")\n```",
+			want:    "<pre class=\"chroma\"><code class=\"language-go\"><span class=\"nx\">fmt</span><span class=\"p\">.</span><span class=\"nf\">Println</span><span class=\"p\">(</span><span class=\"s\">&#34;&lt;3&#34;</span><span class=\"p\">)</span>\n</code></pre>\n",
+		},
+		{
+			name:    "indented code block",
+			content: "    x := 1",
+			want:    "<pre class=\"chroma\"><code>x := 1</code></pre>\n",
+		},
+		{name: "highlighting classes", content: "<pre class=\"evil\"><span class=\"big-title\">x</span></pre>", want: "<pre><span>x</span></pre>"},
+		{name: "script", content: "<script>alert(1)</script>hi", want: "hi"},
+		{name: "event handler", content: "<b onclick=\"alert(1)\">bold</b>", want: "<p><b>bold</b></p>\n"},
+		{name: "javascript link", content: "[click](javascript:alert(1))", want: "<p>click</p>\n"},
+		{name: "style", content: "<p style=\"color: red\" class=\"big\">text</p>", want: "<p>text</p>"},
+		{name: "input", content: "<input type=\"text\" value=\"x\">", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(test.content); got != test.want {
+				t.Errorf("Render(%q) = %q, want %q", test.content, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+const (
+	// DefaultMaxSize is the default maximum size of an uploaded file in bytes
+	DefaultMaxSize = 5 << 20
+	// MaxPixels is the maximum number of pixels of an uploaded image, it prevents small
+	// files from being decoded to huge images
+	MaxPixels = 40000000
+	// jpegQuality is the quality of the encoded JPEG images
+	jpegQuality = 88
+)
+
+// Thumbnail is a fixed size of the thumbnails generated for each image, an image is
+// scaled down to fit in a square of this size and never scaled up
+type Thumbnail struct {
+	Name string
+	Size int
+}
+
+// Thumbnails are the thumbnails generated for each image
+var Thumbnails = []Thumbnail{
+	{Name: "small", Size: 96},
+	{Name: "medium", Size: 320},
+	{Name: "large", Size: 1024},
+}
+
+// FileKey returns the key of the file of a media in the blob storage, with the name of a
+// thumbnail or the original file without name. The files are spread in directories named
+// after the first characters of their hash.
+func FileKey(hash string, thumbnail string) string {
+	key := hash[:2] + "/" + hash
+	if thumbnail == "" {
+		return key
+	}
+
+	return key + "-" + thumbnail
+}
+
+// ContentTypes are the types of the images which can be uploaded, detected from their
+// content rather than their name or the type given by the client
+var ContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// DocumentTypes are the types of the documents which can be uploaded, they are stored
+// as is and have no thumbnails
+var DocumentTypes = map[string]bool{
+	"application/pdf": true,
+}
+
+var (
+	// ErrTooLarge is returned when a file is larger than the maximum size
+	ErrTooLarge = errors.New("the file is too large")
+	// ErrUnsupportedType is returned when a file is not an image of a supported type
+	ErrUnsupportedType = errors.New("the file must be a JPEG, PNG, GIF or WebP image or a PDF document")
+	// ErrTooManyPixels is returned when an image has more pixels than MaxPixels
+	ErrTooManyPixels = errors.New("the image dimensions are too large")
+)
+
+// File is an uploaded file, an image is cleaned of its metadata and has thumbnails
+type File struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+	// Thumbnails are the encoded thumbnails by name
+	Thumbnails map[string][]byte
+}
+
+// Hash returns the hash of the content of the file, it is stored by this hash
+func (f *File) Hash() string {
+	sum := sha256.Sum256(f.Data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsImage check if a content type is the type of an image, which has thumbnails
+func IsImage(contentType string) bool {
+	return ContentTypes[contentType]
+}
+
+// Process read an uploaded file. An image is re-encoded, so the metadata like the EXIF
+// location are stripped. JPEG images are rotated according to their EXIF orientation
+// and kept as JPEG, the other images are encoded as PNG. GIF animations keep their first
+// frame. A document is kept as is.
+func Process(r io.Reader, maxSize int64) (*File, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if DocumentTypes[contentType] {
+		return &File{Data: data, ContentType: contentType, Thumbnails: map[string][]byte{}}, nil
+	}
+
+	if !ContentTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	} else {
+		contentType = "image/png"
+	}
+
+	processed := &File{
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Thumbnails:  map[string][]byte{},
+	}
+
+	processed.Data, err = encode(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, thumbnail := range Thumbnails {
+		processed.Thumbnails[thumbnail.Name], err = encode(scale(img, thumbnail.Size), contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return processed, nil
+}
+
+// encode an image as JPEG or PNG
+func encode(img image.Image, contentType string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(buf, img)
+	}
+
+	return buf.Bytes(), err
+}
+
+// scale down an image to fit in a square of a size, smaller images are returned as is
+func scale(img image.Image, size int) image.Image {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width > height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return scaled
+}
+
+// max returns the largest of two numbers
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifJPEG returns a JPEG image of a size with an EXIF orientation
+func exifJPEG(t *testing.T, width, height int, orientation byte) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := jpeg.Encode(buf, img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a little endian TIFF header with a single directory holding the orientation
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 1, 0, 0x12, 0x01, 3, 0, 1, 0, 0, 0, orientation, 0, 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(segment) + 2
+
+	data := buf.Bytes()
+	exif := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, segment...)
+
+	return append(append([]byte{0xFF, 0xD8}, exif...), data[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		maxSize     int64
+		width       int
+		height      int
+		contentType string
+		thumbnails  int
+		err         error
+	}{
+		{name: "upright", data: exifJPEG(t, 40, 20, 1), maxSize: DefaultMaxSize, width: 40, height: 20, contentType: "image/jpeg", thumbnails: len(Thumbnails)},
+		{name: "rotated", data: exifJPEG(t, 40, 20, 6), maxSize: DefaultMaxSize, width: 20, height: 40, contentType: "image/jpeg", thumbnails: len(Thumbnails)},
+		{name: "document", data: []byte("%PDF-1.4\n%Exif\n"), maxSize: DefaultMaxSize, contentType: "application/pdf"},
+		{name: "too large", data: exifJPEG(t, 40, 20, 1), maxSize: 64, err: ErrTooLarge},
+		{name: "not an image", data: []byte("<svg></svg>"), maxSize: DefaultMaxSize, err: ErrUnsupportedType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processed, err := Process(bytes.NewReader(test.data), test.maxSize)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Process = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if processed.Width != test.width || processed.Height != test.height || processed.ContentType != test.contentType {
+				t.Errorf("Process got %vx%v %v, want %vx%v %v", processed.Width, processed.Height, processed.ContentType, test.width, test.height, test.contentType)
+			}
+
+			if IsImage(processed.ContentType) && bytes.Contains(processed.Data, []byte("Exif")) {
+				t.Error("Process kept the EXIF metadata")
+			}
+
+			// the documents are stored as uploaded
+			if !IsImage(processed.ContentType) && !bytes.Equal(processed.Data, test.data) {
+				t.Error("Process changed the document")
+			}
+
+			if len(processed.Thumbnails) != test.thumbnails {
+				t.Errorf("Process got %v thumbnails, want %v", len(processed.Thumbnails), test.thumbnails)
+			}
+		})
+	}
+}
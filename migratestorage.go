@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ada-social-network/api/storage"
+)
+
+// runMigrateStorage copy the files of a blob storage to another one, e.g. from the local
+// directory of the media to an S3 bucket
+func runMigrateStorage(args []string) error {
+	var from string
+	var to string
+	var prefix string
+
+	flags := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	flags.StringVar(&from, "from", "", "directory or s3:// URL of the storage to copy (required)")
+	flags.StringVar(&to, "to", "", "directory or s3:// URL of the storage to fill (required)")
+	flags.StringVar(&prefix, "prefix", "", "only copy the files with keys starting with this prefix")
+	_ = flags.Parse(args)
+
+	if from == "" || to == "" {
+		return errors.New("missing --from or --to storage")
+	}
+
+	source, err := storage.Open(from)
+	if err != nil {
+		return err
+	}
+
+	destination, err := storage.Open(to)
+	if err != nil {
+		return err
+	}
+
+	result, err := storage.Migrate(source, destination, prefix)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d files copied, %d already there\n", result.Copied, result.Skipped)
+
+	return nil
+}
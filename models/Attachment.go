@@ -0,0 +1,49 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// MaxAttachments is the maximum number of attachments of a resource
+const MaxAttachments = 10
+
+// AttachmentSource is a type of resource which can have attachments
+type AttachmentSource string
+
+const (
+	// PostAttachment is an attachment of a post
+	PostAttachment AttachmentSource = "post"
+	// BdaPostAttachment is an attachment of a bda post
+	BdaPostAttachment AttachmentSource = "bdaPost"
+	// CommentAttachment is an attachment of a comment
+	CommentAttachment AttachmentSource = "comment"
+	// MessageAttachment is an attachment of a message
+	MessageAttachment AttachmentSource = "message"
+)
+
+// Attachment define an uploaded media attached to a resource, the metadata of the media
+// are copied so the attachments can be displayed without loading the media
+type Attachment struct {
+	Base
+	SourceType AttachmentSource `gorm:"not null;index:idx_attachments_source" json:"sourceType"`
+	SourceID   uuid.UUID        `gorm:"type=uuid;index:idx_attachments_source" json:"sourceId"`
+	// UserID is the author of the resource, who uploaded the media
+	UserID  uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	MediaID uuid.UUID `gorm:"type=uuid;index" json:"mediaId" binding:"required"`
+	// Position is the order of the attachment in the resource, from 0
+	Position int `gorm:"not null;default:0" json:"position"`
+	// Name is the file name displayed and used to download the media
+	Name        string `json:"name" binding:"max=255"`
+	AltText     string `json:"altText" binding:"max=1000"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// attachmentsRelation is the relation to the attachments of a resource
+var attachmentsRelation = Relation{
+	Key:        "id",
+	RelatedKey: "sourceId",
+	Column:     "source_id",
+	Many:       true,
+	New:        func() interface{} { return &[]Attachment{} },
+}
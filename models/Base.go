@@ -10,15 +10,20 @@ import (
 // Base contains common columns for all tables.
 type Base struct {
 	ID        uuid.UUID  `gorm:"type=uuid,primary_key" json:"id"`
-	CreatedAt time.Time  `json:"createdAt"`
+	CreatedAt time.Time  `gorm:"index" json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	DeletedAt *time.Time `json:"deletedAt" sql:"index"`
+	// Version is incremented on each update, it is used to detect concurrent updates
+	Version int `gorm:"not null;default:1" json:"version"`
 }
 
-// BeforeCreate will set a UUID rather than numeric ID.
+// BeforeCreate will set a UUID rather than numeric ID and the first version.
 func (base *Base) BeforeCreate(tx *gorm.DB) error {
 	if uuid.Equal(base.ID, uuid.Nil) {
 		tx.Statement.SetColumn("ID", uuid.NewV4().String())
 	}
+	if base.Version == 0 {
+		tx.Statement.SetColumn("Version", 1)
+	}
 	return nil
 }
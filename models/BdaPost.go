@@ -7,8 +7,28 @@ type BdaPost struct {
 	Base
 	Title   string `json:"title" binding:"required,min=4,max=100"`
 	Content string `json:"content" binding:"required,min=4,max=21474"`
+	// ContentHTML is the content rendered from markdown to sanitized HTML when it is saved
+	ContentHTML string `json:"contentHtml"`
 	// By default, gorm will try to use UserID as a foreign key to the model User
-	UserID   uuid.UUID `gorm:"type=uuid" json:"userId"`
-	Comments []Comment
+	UserID   uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	Comments []Comment `gorm:"polymorphic:Target;polymorphicValue:bdaPost"`
 	Likes    []Like
+	// Attachments are the files of the announcement, e.g. a PDF, given on creation or update
+	Attachments []Attachment `gorm:"-" json:"attachments,omitempty" binding:"dive"`
+}
+
+// BdaPostFields are the fields bda posts can be filtered and sorted by
+var BdaPostFields = withBaseFields(Fields{
+	"title":  {Column: "title", Type: StringField, Sortable: true},
+	"userId": {Column: "user_id", Type: UUIDField},
+})
+
+// BdaPostRelations are the resources which can be included with bda posts
+var BdaPostRelations = Relations{
+	"author":      authorRelation,
+	"comments":    commentsRelation,
+	"likes":       likesRelation("bdapostId", "bda_post_id"),
+	"mentions":    mentionsRelation,
+	"tags":        tagsRelation,
+	"attachments": attachmentsRelation,
 }
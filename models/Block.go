@@ -0,0 +1,27 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// Block define a user blocking another user, a blocked user can not start a
+// conversation with the user who blocked them
+type Block struct {
+	Base
+	UserID    uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_blocks_user_blocked" json:"userId"`
+	BlockedID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_blocks_user_blocked;index" json:"blockedId"`
+}
+
+// BlockFields are the fields blocks can be filtered and sorted by
+var BlockFields = withBaseFields(Fields{
+	"blockedId": {Column: "blocked_id", Type: UUIDField},
+})
+
+// BlockRelations are the resources which can be included with blocks
+var BlockRelations = Relations{
+	"blocked": {
+		Key:        "blockedId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]User{} },
+		Hidden:     []string{"password"},
+	},
+}
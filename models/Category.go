@@ -6,3 +6,11 @@ type Category struct {
 	Name   string  `json:"name" binding:"required"`
 	Topics []Topic `json:"topics"`
 }
+
+// CategoryFields are the fields categories can be filtered and sorted by
+var CategoryFields = withBaseFields(Fields{
+	"name": {Column: "name", Type: StringField, Sortable: true},
+})
+
+// CategoryRelations are the resources which can be included with categories
+var CategoryRelations = Relations{}
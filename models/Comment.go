@@ -1,14 +1,122 @@
 package models
 
-import uuid "github.com/satori/go.uuid"
+import (
+	"strings"
+	"time"
 
-// Comment define comment for a post
+	uuid "github.com/satori/go.uuid"
+)
+
+// commentPathTime is the layout of the creation date in a comment path, it sorts
+// like the dates
+const commentPathTime = "20060102150405.000000000"
+
+// Commentable is a type of resource which can be commented
+type Commentable string
+
+const (
+	// PostComment is a comment of a forum post
+	PostComment Commentable = "post"
+	// TopicComment is a comment of a topic
+	TopicComment Commentable = "topic"
+	// BdaPostComment is a comment of a bda post
+	BdaPostComment Commentable = "bdaPost"
+)
+
+// Comment define comment for a commentable resource
 type Comment struct {
 	Base
-	UserID    uuid.UUID `gorm:"type=uuid" json:"userId"`
-	BdaPostID uuid.UUID `gorm:"type=uuid" json:"bdapostId"`
-	Content   string    `json:"content" binding:"required,min=4,max=1024"`
-	Likes     []Like
+	UserID     uuid.UUID   `gorm:"type=uuid;index" json:"userId"`
+	TargetType Commentable `gorm:"index:idx_comments_target" json:"targetType"`
+	TargetID   uuid.UUID   `gorm:"type=uuid;index:idx_comments_target" json:"targetId"`
+	// ParentID is the comment replied to, nil for a comment of the resource
+	ParentID *uuid.UUID `gorm:"type=uuid;index" json:"parentId"`
+	// Depth is the number of parents of the comment
+	Depth        int    `gorm:"not null;default:0" json:"depth"`
+	RepliesCount int    `gorm:"not null;default:0" json:"repliesCount"`
+	Content      string `json:"content" binding:"required,min=4,max=1024"`
+	// ContentHTML is the content rendered from markdown to sanitized HTML when it is saved
+	ContentHTML string `json:"contentHtml"`
+	// Path is the creation date and id of the parents and of the comment, the comments
+	// of a thread are listed depth first when sorted by path
+	Path  string `gorm:"index" json:"-"`
+	Likes []Like
+	// Attachments are the files attached to the comment by its author
+	Attachments []Attachment `gorm:"-" json:"attachments,omitempty" binding:"dive"`
+}
+
+// IsDeleted returns true when the comment has been deleted but is kept because of its replies
+func (co *Comment) IsDeleted() bool {
+	return co.DeletedAt != nil
+}
+
+// SetParent place the comment in the thread of parent, or at the top of the thread of
+// the commented resource when parent is nil. The id and creation date are set when they are missing
+// as they are part of the comment path.
+func (co *Comment) SetParent(parent *Comment) {
+	if uuid.Equal(co.ID, uuid.Nil) {
+		co.ID = uuid.NewV4()
+	}
+	if co.CreatedAt.IsZero() {
+		co.CreatedAt = time.Now()
+	}
+
+	co.ParentID = nil
+	co.Depth = 0
+	co.Path = CommentPathSegment(co)
+
+	if parent != nil {
+		parentID := parent.ID
+		co.ParentID = &parentID
+		co.Depth = parent.Depth + 1
+		co.Path = strings.Join([]string{parent.Path, co.Path}, "/")
+	}
 }
 
-// we do not have comment for all posts for now only for BDA
+// CommentPathSegment returns the part of the path of a comment identifying it
+func CommentPathSegment(comment *Comment) string {
+	return comment.CreatedAt.UTC().Format(commentPathTime) + comment.ID.String()
+}
+
+// CommentFields are the fields comments can be filtered and sorted by
+var CommentFields = withBaseFields(Fields{
+	"userId":       {Column: "user_id", Type: UUIDField},
+	"targetType":   {Column: "target_type", Type: StringField},
+	"targetId":     {Column: "target_id", Type: UUIDField},
+	"parentId":     {Column: "parent_id", Type: UUIDField},
+	"depth":        {Column: "depth", Type: IntField},
+	"repliesCount": {Column: "replies_count", Type: IntField, Sortable: true},
+})
+
+// CommentRelations are the resources which can be included with comments, the commented
+// resource is included with the relation of its type
+var CommentRelations = Relations{
+	"author": authorRelation,
+	"post": {
+		Key:        "targetId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Post{} },
+	},
+	"topic": {
+		Key:        "targetId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Topic{} },
+	},
+	"bdaPost": {
+		Key:        "targetId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]BdaPost{} },
+	},
+	"parent": {
+		Key:        "parentId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Comment{} },
+	},
+	"likes":       likesRelation("commentId", "comment_id"),
+	"mentions":    mentionsRelation,
+	"attachments": attachmentsRelation,
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Conversation define private messages between two users, or between the members of
+// a small group
+type Conversation struct {
+	Base
+	// UserID is the user who started the conversation
+	UserID  uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	Title   string    `json:"title" binding:"max=128"`
+	IsGroup bool      `gorm:"not null;default:false" json:"isGroup"`
+	// LastMessageAt is the date of the last message, the creation date of the
+	// conversation when it has no message
+	LastMessageAt time.Time `gorm:"index" json:"lastMessageAt"`
+}
+
+// ConversationFields are the fields conversations can be filtered and sorted by
+var ConversationFields = withBaseFields(Fields{
+	"userId":        {Column: "user_id", Type: UUIDField},
+	"isGroup":       {Column: "is_group", Type: BoolField},
+	"lastMessageAt": {Column: "last_message_at", Type: TimeField, Sortable: true},
+})
+
+// ConversationRelations are the resources which can be included with conversations
+var ConversationRelations = Relations{
+	"author": authorRelation,
+}
+
+// ConversationMember define a user taking part in a conversation
+type ConversationMember struct {
+	Base
+	ConversationID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_conversation_members_conversation_user" json:"conversationId"`
+	UserID         uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_conversation_members_conversation_user;index" json:"userId"`
+	// LastReadAt is the date of the last message read by the member, nil when he has
+	// not read any message
+	LastReadAt *time.Time `json:"lastReadAt"`
+}
+
+// Message define a message sent in a conversation
+type Message struct {
+	Base
+	ConversationID uuid.UUID `gorm:"type=uuid;index" json:"conversationId"`
+	UserID         uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	Content        string    `json:"content" binding:"required,max=2000"`
+	// Attachments are the files sent with the message
+	Attachments []Attachment `gorm:"-" json:"attachments,omitempty" binding:"dive"`
+}
+
+// MessageFields are the fields messages can be filtered and sorted by
+var MessageFields = withBaseFields(Fields{
+	"userId": {Column: "user_id", Type: UUIDField},
+})
+
+// MessageRelations are the resources which can be included with messages
+var MessageRelations = Relations{
+	"author":      authorRelation,
+	"attachments": attachmentsRelation,
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// FeedItemType is the type of the resource of a feed item
+type FeedItemType string
+
+const (
+	// PostFeedItem is a post in a subscribed topic or by a followed user
+	PostFeedItem FeedItemType = "post"
+	// TopicFeedItem is a topic in a subscribed category or by a followed user
+	TopicFeedItem FeedItemType = "topic"
+	// BdaPostFeedItem is a bda post when the bda feed is subscribed or by a followed user
+	BdaPostFeedItem FeedItemType = "bdaPost"
+	// CommentFeedItem is a comment by a followed user
+	CommentFeedItem FeedItemType = "comment"
+)
+
+// FeedItem is a resource of the feed of a user, the feed is computed when it is read
+// and is not stored
+type FeedItem struct {
+	ID        uuid.UUID    `json:"id"`
+	Type      FeedItemType `json:"type"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UserID    uuid.UUID    `json:"userId"`
+}
+
+// FeedFields are the fields the feed can be filtered by, it is always sorted by date
+var FeedFields = Fields{
+	"type":      {Column: "type", Type: StringField},
+	"userId":    {Column: "user_id", Type: UUIDField},
+	"createdAt": {Column: "created_at", Type: TimeField},
+}
@@ -0,0 +1,16 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// Follow define a user following another user
+type Follow struct {
+	Base
+	UserID     uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_follows_user_followed" json:"userId"`
+	FollowedID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_follows_user_followed;index" json:"followedId"`
+}
+
+// FollowFields are the fields follows can be filtered and sorted by
+var FollowFields = withBaseFields(Fields{
+	"userId":     {Column: "user_id", Type: UUIDField},
+	"followedId": {Column: "followed_id", Type: UUIDField},
+})
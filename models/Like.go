@@ -10,3 +10,13 @@ type Like struct {
 	PostID    uuid.UUID `gorm:"type=uuid" json:"postId"`
 	CommentID uuid.UUID `gorm:"type=uuid" json:"commentId"`
 }
+
+// LikeFields are the fields likes can be filtered and sorted by
+var LikeFields = withBaseFields(Fields{
+	"userId": {Column: "user_id", Type: UUIDField},
+})
+
+// LikeRelations are the resources which can be included with likes
+var LikeRelations = Relations{
+	"author": authorRelation,
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// MaxLinkPreviews is the maximum number of links of a post which are previewed
+const MaxLinkPreviews = 3
+
+// MaxLinkLength is the maximum length of a previewed link
+const MaxLinkLength = 2048
+
+// LinkPreviewStatus is the state of the fetch of a link preview
+type LinkPreviewStatus string
+
+const (
+	// PendingPreview is a preview waiting to be fetched
+	PendingPreview LinkPreviewStatus = "pending"
+	// FetchedPreview is a preview whose metadata have been fetched
+	FetchedPreview LinkPreviewStatus = "fetched"
+	// FailedPreview is a preview which could not be fetched, it has no metadata
+	FailedPreview LinkPreviewStatus = "failed"
+)
+
+// LinkPreview define the preview of a link found in the content of a post. The previews
+// are fetched in background from the OpenGraph, Twitter card and oEmbed metadata of the
+// page, and the fetched previews are reused for the same link in other posts.
+type LinkPreview struct {
+	Base
+	PostID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_link_previews_post_url" json:"postId"`
+	URL    string    `gorm:"not null;uniqueIndex:idx_link_previews_post_url;index" json:"url"`
+	// Position is the order of the link in the post, from 0
+	Position    int               `gorm:"not null;default:0" json:"position"`
+	Status      LinkPreviewStatus `gorm:"not null;index" json:"status"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	ImageURL    string            `json:"imageUrl"`
+	SiteName    string            `json:"siteName"`
+	FetchedAt   *time.Time        `json:"fetchedAt"`
+}
+
+// linkPattern matches the http and https links of a content, up to a space or a markdown
+// delimiter
+var linkPattern = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// ParseLinks returns the http and https links of a content without duplicates, in their
+// order of appearance, at most MaxLinkPreviews. The punctuation ending a sentence is not
+// part of a link.
+func ParseLinks(content string) []string {
+	links := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range linkPattern.FindAllString(content, -1) {
+		link := strings.TrimRight(match, ".,;:!?*_~")
+		if len(link) > MaxLinkLength || seen[link] {
+			continue
+		}
+
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+
+		seen[link] = true
+		links = append(links, link)
+
+		if len(links) == MaxLinkPreviews {
+			break
+		}
+	}
+
+	return links
+}
+
+// previewsRelation is the relation to the link previews of a post
+var previewsRelation = Relation{
+	Key:        "id",
+	RelatedKey: "postId",
+	Column:     "post_id",
+	Many:       true,
+	New:        func() interface{} { return &[]LinkPreview{} },
+}
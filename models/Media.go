@@ -0,0 +1,37 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// Media define an image uploaded by a user, its file and thumbnails are stored by the
+// hash of their content so identical uploads share their files
+type Media struct {
+	Base
+	UserID      uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	Hash        string    `gorm:"index" json:"hash"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+}
+
+// MediaFields are the fields media can be filtered and sorted by
+var MediaFields = withBaseFields(Fields{
+	"userId":      {Column: "user_id", Type: UUIDField},
+	"contentType": {Column: "content_type", Type: StringField},
+	"size":        {Column: "size", Type: IntField, Sortable: true},
+})
+
+// MediaRelations are the resources which can be included with media
+var MediaRelations = Relations{
+	"author": authorRelation,
+}
+
+// mediaRelation returns the relation to a media referenced by a field of a resource
+func mediaRelation(key string) Relation {
+	return Relation{
+		Key:        key,
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Media{} },
+	}
+}
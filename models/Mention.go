@@ -0,0 +1,67 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// MentionSource is a type of resource whose content can mention users
+type MentionSource string
+
+const (
+	// PostMention is a mention in the content of a post
+	PostMention MentionSource = "post"
+	// CommentMention is a mention in the content of a comment
+	CommentMention MentionSource = "comment"
+	// TopicMention is a mention in the content of a topic
+	TopicMention MentionSource = "topic"
+	// BdaPostMention is a mention in the content of a bda post
+	BdaPostMention MentionSource = "bdaPost"
+)
+
+// Mention define a user mentioned in the content of a resource, a user is mentioned
+// once by a resource whatever the number of times the handle is written
+type Mention struct {
+	Base
+	SourceType MentionSource `gorm:"not null;uniqueIndex:idx_mentions_source_user" json:"sourceType"`
+	SourceID   uuid.UUID     `gorm:"type=uuid;uniqueIndex:idx_mentions_source_user" json:"sourceId"`
+	UserID     uuid.UUID     `gorm:"type=uuid;uniqueIndex:idx_mentions_source_user;index" json:"userId"`
+	// Handle is the handle written in the content, without the @
+	Handle string `gorm:"not null" json:"handle"`
+}
+
+// mentionPattern matches the @firstname.lastname handles which are not part of a word
+// or of an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_-]+\.[\p{L}\p{N}_-]+)`)
+
+// MentionHandle returns the handle mentioning a user: its first and last names in lower
+// case, separated by a dot, the spaces of the names are replaced by dashes
+func MentionHandle(user User) string {
+	return handlePart(user.FirstName) + "." + handlePart(user.LastName)
+}
+
+// handlePart returns a name as a part of a handle
+func handlePart(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// ParseMentions returns the handles mentioned in a content in lower case, without the
+// @ and duplicates, in their order of appearance
+func ParseMentions(content string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		handle := strings.ToLower(match[1])
+		if seen[handle] {
+			continue
+		}
+
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+
+	return handles
+}
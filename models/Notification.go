@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// NotificationType is the event a notification is about
+type NotificationType string
+
+const (
+	// LikeNotification is sent when a resource of the user is liked
+	LikeNotification NotificationType = "like"
+	// CommentNotification is sent when a bda post, a post or a topic of the user is commented
+	CommentNotification NotificationType = "comment"
+	// ReplyNotification is sent when a comment of the user gets a reply
+	ReplyNotification NotificationType = "reply"
+	// MentionNotification is sent when the user is mentioned
+	MentionNotification NotificationType = "mention"
+	// PostNotification is sent when a post is created in a topic the user subscribed to
+	PostNotification NotificationType = "post"
+)
+
+// NotificationTypes are all the types of notifications
+var NotificationTypes = []NotificationType{LikeNotification, CommentNotification, ReplyNotification, MentionNotification, PostNotification}
+
+// NotificationTarget is the type of the resource a notification is about
+type NotificationTarget string
+
+const (
+	// PostTarget is a notification about a post
+	PostTarget NotificationTarget = "post"
+	// BdaPostTarget is a notification about a bda post
+	BdaPostTarget NotificationTarget = "bdaPost"
+	// CommentTarget is a notification about a comment
+	CommentTarget NotificationTarget = "comment"
+	// TopicTarget is a notification about a topic
+	TopicTarget NotificationTarget = "topic"
+)
+
+// Notification define an event sent to a user. Events of the same type on the same
+// target are grouped in one notification while it is unread.
+type Notification struct {
+	Base
+	UserID     uuid.UUID          `gorm:"type=uuid;index:idx_notifications_user_read" json:"userId"`
+	ReadAt     *time.Time         `gorm:"index:idx_notifications_user_read" json:"readAt"`
+	Type       NotificationType   `gorm:"not null" json:"type"`
+	TargetType NotificationTarget `gorm:"not null" json:"targetType"`
+	TargetID   uuid.UUID          `gorm:"type=uuid" json:"targetId"`
+	// ActorID is the user who triggered the last event
+	ActorID uuid.UUID `gorm:"type=uuid" json:"actorId"`
+	// Count is the number of grouped events
+	Count int `gorm:"not null;default:1" json:"count"`
+	// ActorsCount is the number of distinct users who triggered the grouped events
+	ActorsCount int `gorm:"not null;default:1" json:"actorsCount"`
+}
+
+// NotificationActor define a user who triggered an event grouped in a notification, a
+// user is counted once by notification however many times they repeat the event
+type NotificationActor struct {
+	Base
+	NotificationID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_notification_actors_notification_actor" json:"notificationId"`
+	ActorID        uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_notification_actors_notification_actor;index" json:"actorId"`
+	// Count is the number of grouped events of the actor
+	Count int `gorm:"not null;default:1" json:"count"`
+}
+
+// NotificationFields are the fields notifications can be filtered and sorted by
+var NotificationFields = withBaseFields(Fields{
+	"type":       {Column: "type", Type: StringField},
+	"targetType": {Column: "target_type", Type: StringField},
+	"targetId":   {Column: "target_id", Type: UUIDField},
+	"actorId":    {Column: "actor_id", Type: UUIDField},
+	"readAt":     {Column: "read_at", Type: TimeField},
+})
+
+// NotificationRelations are the resources which can be included with notifications
+var NotificationRelations = Relations{
+	"actor": {
+		Key:        "actorId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]User{} },
+		Hidden:     []string{"password"},
+	},
+}
+
+// NotificationPreference define if a user receives a type of notifications, all the
+// types are enabled when the user has no preference
+type NotificationPreference struct {
+	Base
+	UserID  uuid.UUID        `gorm:"type=uuid;uniqueIndex:idx_notification_preferences_user_type" json:"userId"`
+	Type    NotificationType `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type" json:"type"`
+	Enabled bool             `gorm:"not null" json:"enabled"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// MaxPollOptions is the maximum number of options of a poll, and so of the options chosen
+// in a vote
+const MaxPollOptions = 20
+
+// PollTarget is a type of resource a poll can be attached to
+type PollTarget string
+
+const (
+	// TopicPoll is a poll attached to a topic
+	TopicPoll PollTarget = "topic"
+	// BdaPostPoll is a poll attached to a bda post
+	BdaPostPoll PollTarget = "bdaPost"
+)
+
+// Poll define a question asked by the author of a topic or a bda post, with single or
+// multiple choice options. A resource has at most one poll.
+type Poll struct {
+	Base
+	TargetType PollTarget `gorm:"not null;uniqueIndex:idx_polls_target" json:"targetType"`
+	TargetID   uuid.UUID  `gorm:"type=uuid;uniqueIndex:idx_polls_target" json:"targetId"`
+	UserID     uuid.UUID  `gorm:"type=uuid;index" json:"userId"`
+	Question   string     `json:"question" binding:"required,max=300"`
+	// Multiple is true when the voters can choose several options
+	Multiple bool `gorm:"not null;default:false" json:"multiple"`
+	// Anonymous is true when the choices of the voters are not shown in the results
+	Anonymous bool `gorm:"not null;default:false" json:"anonymous"`
+	// ClosesAt is the deadline of the votes, a poll without deadline is open until its
+	// author closes it
+	ClosesAt *time.Time   `json:"closesAt"`
+	Options  []PollOption `gorm:"-" json:"options" binding:"required,min=2,dive"`
+}
+
+// Closed returns true when the votes of the poll are closed at a time
+func (p *Poll) Closed(at time.Time) bool {
+	return p.ClosesAt != nil && !at.Before(*p.ClosesAt)
+}
+
+// PollOption define an option of a poll
+type PollOption struct {
+	Base
+	PollID uuid.UUID `gorm:"type=uuid;index" json:"pollId"`
+	// Position is the order of the option in the poll, from 0
+	Position int    `gorm:"not null;default:0" json:"position"`
+	Text     string `json:"text" binding:"required,max=200"`
+}
+
+// PollVote define the ballot of a user in a poll, a user votes once by poll and can
+// change their choices until the poll is closed
+type PollVote struct {
+	Base
+	PollID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_poll_votes_voter" json:"pollId"`
+	UserID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_poll_votes_voter;index" json:"userId"`
+	// OptionIDs are the options chosen by the user
+	OptionIDs []uuid.UUID `gorm:"-" json:"optionIds" binding:"required,min=1"`
+}
+
+// PollChoice define an option chosen in a ballot
+type PollChoice struct {
+	Base
+	VoteID   uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_poll_choices_option" json:"voteId"`
+	OptionID uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_poll_choices_option;index" json:"optionId"`
+	PollID   uuid.UUID `gorm:"type=uuid;index" json:"pollId"`
+	UserID   uuid.UUID `gorm:"type=uuid" json:"userId"`
+}
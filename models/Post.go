@@ -6,8 +6,40 @@ import uuid "github.com/satori/go.uuid"
 type Post struct {
 	Base
 	Content string `json:"content" binding:"required,min=4,max=21474"`
+	// ContentHTML is the content rendered from markdown to sanitized HTML when it is saved
+	ContentHTML string `json:"contentHtml"`
 	// By default, gorm will try to use UserID as a foreign key to the model User
-	UserID  uuid.UUID `gorm:"type=uuid" json:"userId"`
-	TopicID uuid.UUID `gorm:"type=uuid" json:"topicId"`
+	UserID  uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	TopicID uuid.UUID `gorm:"type=uuid;index" json:"topicId"`
 	Likes   []Like
+	// SnippetID is the snippet of code embedded in the post, if any
+	SnippetID *uuid.UUID `gorm:"type=uuid;index" json:"snippetId"`
+	// Attachments are the files attached to the post, when they are given on update they
+	// replace the existing ones
+	Attachments []Attachment `gorm:"-" json:"attachments,omitempty" binding:"dive"`
+}
+
+// PostFields are the fields posts can be filtered and sorted by
+var PostFields = withBaseFields(Fields{
+	"userId":    {Column: "user_id", Type: UUIDField},
+	"topicId":   {Column: "topic_id", Type: UUIDField},
+	"snippetId": {Column: "snippet_id", Type: UUIDField},
+})
+
+// PostRelations are the resources which can be included with posts
+var PostRelations = Relations{
+	"author": authorRelation,
+	"topic": {
+		Key:        "topicId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Topic{} },
+	},
+	"comments":    commentsRelation,
+	"likes":       likesRelation("postId", "post_id"),
+	"mentions":    mentionsRelation,
+	"tags":        tagsRelation,
+	"attachments": attachmentsRelation,
+	"snippet":     snippetRelation,
+	"previews":    previewsRelation,
 }
@@ -1,13 +1,27 @@
 package models
 
+import uuid "github.com/satori/go.uuid"
+
 // Promo define a promo resource
 type Promo struct {
 	Base
-	Name       string `json:"name"`
-	StartDate  string `json:"dateOfStart"`
-	EndDate    string `json:"dateOfEnd"`
-	Bio        string `json:"biography"`
-	ProfilePic string `json:"profilePic"`
+	Name         string     `json:"name"`
+	StartDate    string     `json:"dateOfStart"`
+	EndDate      string     `json:"dateOfEnd"`
+	Bio          string     `json:"biography"`
+	ProfilePicID *uuid.UUID `gorm:"type=uuid" json:"profilePicId"`
 	// By default, gorm will try to use UserID as a foreign key to the model User
 	Users []User `json:"users"`
 }
+
+// PromoFields are the fields promos can be filtered and sorted by
+var PromoFields = withBaseFields(Fields{
+	"name":        {Column: "name", Type: StringField, Sortable: true},
+	"dateOfStart": {Column: "start_date", Type: StringField, Sortable: true},
+	"dateOfEnd":   {Column: "end_date", Type: StringField, Sortable: true},
+})
+
+// PromoRelations are the resources which can be included with promos
+var PromoRelations = Relations{
+	"profilePic": mediaRelation("profilePicId"),
+}
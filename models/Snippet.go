@@ -0,0 +1,73 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// MaxSnippetSize is the maximum size of the content of a snippet in bytes
+const MaxSnippetSize = 65536
+
+// Snippet define a file of code shared by a user, which can be embedded in posts. Each
+// version of a snippet is kept as a revision.
+type Snippet struct {
+	Base
+	UserID      uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	FileName    string    `json:"fileName" binding:"required,max=255"`
+	Description string    `json:"description" binding:"max=1024"`
+	// Language is found from the file name or the content when it is not given
+	Language string `gorm:"index" json:"language" binding:"max=50"`
+	Content  string `json:"content" binding:"required,max=65536"`
+	// ContentHTML is the code highlighted in HTML when it is saved
+	ContentHTML string `json:"contentHtml"`
+}
+
+// SnippetFields are the fields snippets can be filtered and sorted by
+var SnippetFields = withBaseFields(Fields{
+	"userId":   {Column: "user_id", Type: UUIDField},
+	"language": {Column: "language", Type: StringField},
+	"fileName": {Column: "file_name", Type: StringField, Sortable: true},
+})
+
+// SnippetRelations are the resources which can be included with snippets
+var SnippetRelations = Relations{
+	"author": authorRelation,
+}
+
+// SnippetRevision define a version of a snippet, the revision number is the version of
+// the snippet
+type SnippetRevision struct {
+	Base
+	SnippetID   uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_snippet_revisions_revision" json:"snippetId"`
+	Revision    int       `gorm:"not null;uniqueIndex:idx_snippet_revisions_revision" json:"revision"`
+	UserID      uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	FileName    string    `json:"fileName"`
+	Description string    `json:"description"`
+	Language    string    `json:"language"`
+	Content     string    `json:"content"`
+	ContentHTML string    `json:"contentHtml"`
+}
+
+// SnippetRevisionFields are the fields snippet revisions can be filtered and sorted by
+var SnippetRevisionFields = withBaseFields(Fields{
+	"revision": {Column: "revision", Type: IntField, Sortable: true},
+})
+
+// NewSnippetRevision returns the revision of the current version of a snippet
+func NewSnippetRevision(snippet *Snippet) *SnippetRevision {
+	return &SnippetRevision{
+		SnippetID:   snippet.ID,
+		Revision:    snippet.Version,
+		UserID:      snippet.UserID,
+		FileName:    snippet.FileName,
+		Description: snippet.Description,
+		Language:    snippet.Language,
+		Content:     snippet.Content,
+		ContentHTML: snippet.ContentHTML,
+	}
+}
+
+// snippetRelation is the relation to the snippet embedded in a resource
+var snippetRelation = Relation{
+	Key:        "snippetId",
+	RelatedKey: "id",
+	Column:     "id",
+	New:        func() interface{} { return &[]Snippet{} },
+}
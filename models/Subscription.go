@@ -0,0 +1,45 @@
+package models
+
+import uuid "github.com/satori/go.uuid"
+
+// SubscriptionTarget is the type of resource a user subscribes to
+type SubscriptionTarget string
+
+const (
+	// TopicSubscription is a subscription to the posts of a topic
+	TopicSubscription SubscriptionTarget = "topic"
+	// CategorySubscription is a subscription to the topics of a category
+	CategorySubscription SubscriptionTarget = "category"
+	// BdaFeedSubscription is a subscription to all the bda posts, it has no target id
+	BdaFeedSubscription SubscriptionTarget = "bdaFeed"
+)
+
+// Subscription define a user subscribing to a topic, a category or the bda feed
+type Subscription struct {
+	Base
+	UserID     uuid.UUID          `gorm:"type=uuid;uniqueIndex:idx_subscriptions_user_target" json:"userId"`
+	TargetType SubscriptionTarget `gorm:"not null;uniqueIndex:idx_subscriptions_user_target;index:idx_subscriptions_target" json:"targetType"`
+	TargetID   uuid.UUID          `gorm:"type=uuid;uniqueIndex:idx_subscriptions_user_target;index:idx_subscriptions_target" json:"targetId"`
+}
+
+// SubscriptionFields are the fields subscriptions can be filtered and sorted by
+var SubscriptionFields = withBaseFields(Fields{
+	"targetType": {Column: "target_type", Type: StringField},
+	"targetId":   {Column: "target_id", Type: UUIDField},
+})
+
+// SubscriptionRelations are the resources which can be included with subscriptions
+var SubscriptionRelations = Relations{
+	"topic": {
+		Key:        "targetId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Topic{} },
+	},
+	"category": {
+		Key:        "targetId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Category{} },
+	},
+}
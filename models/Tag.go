@@ -0,0 +1,100 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// MaxTagLength is the maximum number of characters of a tag name
+const MaxTagLength = 50
+
+// TagSource is a type of resource which can be tagged
+type TagSource string
+
+const (
+	// PostTag is a tag of a post
+	PostTag TagSource = "post"
+	// TopicTag is a tag of a topic
+	TopicTag TagSource = "topic"
+	// BdaPostTag is a tag of a bda post
+	BdaPostTag TagSource = "bdaPost"
+)
+
+// Tag define a hashtag, its name is unique and in lower case
+type Tag struct {
+	Base
+	Name string `gorm:"not null;uniqueIndex" json:"name"`
+	// UsesCount is the number of resources tagged with the tag
+	UsesCount int `gorm:"not null;default:0" json:"usesCount"`
+}
+
+// TagUsage define a tag with its number of recent uses, it is computed when it is read
+type TagUsage struct {
+	Tag
+	// RecentUses is the number of resources tagged during the trending period
+	RecentUses int `json:"recentUses"`
+}
+
+// TagFields are the fields tags can be filtered and sorted by
+var TagFields = withBaseFields(Fields{
+	"name":       {Column: "name", Type: StringField, Sortable: true},
+	"usesCount":  {Column: "uses_count", Type: IntField, Sortable: true},
+	"recentUses": {Column: "recent_uses", Type: IntField, Sortable: true},
+})
+
+// Tagging define a resource tagged with a tag
+type Tagging struct {
+	Base
+	TagID      uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_taggings_tag_source" json:"tagId"`
+	SourceType TagSource `gorm:"not null;uniqueIndex:idx_taggings_tag_source;index:idx_taggings_source" json:"sourceType"`
+	SourceID   uuid.UUID `gorm:"type=uuid;uniqueIndex:idx_taggings_tag_source;index:idx_taggings_source" json:"sourceId"`
+	// Tag is the name of the tag
+	Tag string `gorm:"not null" json:"tag"`
+	// Explicit is true when the tag has been given by the author, false when it is
+	// written in the content
+	Explicit bool `gorm:"not null" json:"explicit"`
+}
+
+// TaggingFields are the fields taggings can be filtered and sorted by
+var TaggingFields = withBaseFields(Fields{
+	"sourceType": {Column: "source_type", Type: StringField},
+})
+
+// hashtagPattern matches the #hashtags which are not part of a word, an url or an HTML
+// entity, a hashtag starts with a letter
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#(\p{L}[\p{L}\p{N}_-]*)`)
+
+// tagPattern matches a valid tag name
+var tagPattern = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_-]*$`)
+
+// NormalizeTag returns the name of a tag in lower case without its leading #, false
+// when the name is not valid
+func NormalizeTag(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if !tagPattern.MatchString(name) || len([]rune(name)) > MaxTagLength {
+		return "", false
+	}
+
+	return name, true
+}
+
+// ParseHashtags returns the valid tags written as #hashtags in a content in lower case,
+// without duplicates, in their order of appearance
+func ParseHashtags(content string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag, ok := NormalizeTag(match[1])
+		if !ok || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
@@ -7,7 +7,35 @@ type Topic struct {
 	Base
 	Name       string    `json:"name" binding:"required"`
 	Content    string    `json:"content" binding:"required,min=4,max=21474"`
-	UserID     uuid.UUID `gorm:"type=uuid" json:"userId"`
-	CategoryID uuid.UUID `gorm:"type=uuid" json:"categoryId"`
+	UserID     uuid.UUID `gorm:"type=uuid;index" json:"userId"`
+	CategoryID uuid.UUID `gorm:"type=uuid;index" json:"categoryId"`
+	PostsCount int       `gorm:"not null;default:0" json:"postsCount"`
 	Posts      []Post    `json:"posts"`
+	// ContentHTML is the content rendered from markdown to sanitized HTML when it is saved
+	ContentHTML string `json:"contentHtml"`
+	// Tags are the tags given by the author when the topic is created, in addition to
+	// the hashtags of its content
+	Tags []string `gorm:"-" json:"tags,omitempty" binding:"max=10"`
+}
+
+// TopicFields are the fields topics can be filtered and sorted by
+var TopicFields = withBaseFields(Fields{
+	"name":       {Column: "name", Type: StringField, Sortable: true},
+	"userId":     {Column: "user_id", Type: UUIDField},
+	"categoryId": {Column: "category_id", Type: UUIDField},
+	"postsCount": {Column: "posts_count", Type: IntField, Sortable: true},
+})
+
+// TopicRelations are the resources which can be included with topics
+var TopicRelations = Relations{
+	"author": authorRelation,
+	"category": {
+		Key:        "categoryId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Category{} },
+	},
+	"comments": commentsRelation,
+	"mentions": mentionsRelation,
+	"tags":     tagsRelation,
 }
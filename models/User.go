@@ -8,30 +8,32 @@ import (
 // User define a user resource
 type User struct {
 	Base
-	LastName       string    `json:"lastName" binding:"required,min=2,max=20"`
-	FirstName      string    `json:"firstName" binding:"required,min=2,max=20"`
-	Email          string    `json:"email" binding:"required,email" gorm:"unique"`
-	Password       string    `json:"password"`
-	DateOfBirth    string    `json:"dateOfBirth"`
-	Apprenticeship string    `json:"apprenticeAt"`
-	ProfilPic      string    `json:"profilPic"`
-	Biography      string    `json:"biography"`
-	CoverPic       string    `json:"coverPic"`
-	PrivateMail    string    `json:"privateMail"`
-	ProjectPerso   string    `json:"projectPerso"`
-	ProjectPro     string    `json:"projectPro"`
-	Instagram      string    `json:"instagram"`
-	Facebook       string    `json:"facebook"`
-	Github         string    `json:"github"`
-	Linkedin       string    `json:"linkedin"`
-	MBTI           string    `json:"mbti"`
-	Admin          bool      `json:"isAdmin"`
-	PromoID        uuid.UUID `gorm:"type=uuid" json:"promoId"`
-	BdaPosts       []BdaPost `json:"bdaPosts"`
-	Posts          []Post    `json:"posts"`
-	Comments       []Comment `json:"comments"`
-	Topics         []Topic   `json:"topics"`
-	Likes          []Like    `json:"likes"`
+	LastName       string     `json:"lastName" binding:"required,min=2,max=20"`
+	FirstName      string     `json:"firstName" binding:"required,min=2,max=20"`
+	Email          string     `json:"email" binding:"required,email" gorm:"unique"`
+	Password       string     `json:"password"`
+	DateOfBirth    string     `json:"dateOfBirth"`
+	Apprenticeship string     `json:"apprenticeAt"`
+	ProfilPicID    *uuid.UUID `gorm:"type=uuid" json:"profilPicId"`
+	Biography      string     `json:"biography"`
+	CoverPicID     *uuid.UUID `gorm:"type=uuid" json:"coverPicId"`
+	PrivateMail    string     `json:"privateMail"`
+	ProjectPerso   string     `json:"projectPerso"`
+	ProjectPro     string     `json:"projectPro"`
+	Instagram      string     `json:"instagram"`
+	Facebook       string     `json:"facebook"`
+	Github         string     `json:"github"`
+	Linkedin       string     `json:"linkedin"`
+	MBTI           string     `json:"mbti"`
+	Admin          bool       `json:"isAdmin"`
+	PromoID        uuid.UUID  `gorm:"type=uuid" json:"promoId"`
+	FollowersCount int        `gorm:"not null;default:0" json:"followersCount"`
+	FollowingCount int        `gorm:"not null;default:0" json:"followingCount"`
+	BdaPosts       []BdaPost  `json:"bdaPosts"`
+	Posts          []Post     `json:"posts"`
+	Comments       []Comment  `json:"comments"`
+	Topics         []Topic    `json:"topics"`
+	Likes          []Like     `json:"likes"`
 }
 
 //ComparePassword compares User.Password hash with raw password
@@ -48,3 +50,27 @@ func HashPassword(password string) (string, error) {
 
 	return string(hash), nil
 }
+
+// UserFields are the fields users can be filtered and sorted by, private fields
+// like the email or the password are left out
+var UserFields = withBaseFields(Fields{
+	"lastName":       {Column: "last_name", Type: StringField, Sortable: true},
+	"firstName":      {Column: "first_name", Type: StringField, Sortable: true},
+	"apprenticeAt":   {Column: "apprenticeship", Type: StringField},
+	"mbti":           {Column: "mbti", Type: StringField},
+	"isAdmin":        {Column: "admin", Type: BoolField},
+	"promoId":        {Column: "promo_id", Type: UUIDField},
+	"followersCount": {Column: "followers_count", Type: IntField, Sortable: true},
+})
+
+// UserRelations are the resources which can be included with users
+var UserRelations = Relations{
+	"profilPic": mediaRelation("profilPicId"),
+	"coverPic":  mediaRelation("coverPicId"),
+	"promo": {
+		Key:        "promoId",
+		RelatedKey: "id",
+		Column:     "id",
+		New:        func() interface{} { return &[]Promo{} },
+	},
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/ada-social-network/api/markdown"
+	"gorm.io/gorm"
+)
+
+// The HTML of the markdown contents and of the snippets is rendered once when they are
+// created or updated and stored with them, rather than on each read.
+
+// BeforeSave render the content of a topic
+func (t *Topic) BeforeSave(tx *gorm.DB) error {
+	t.ContentHTML = markdown.Render(t.Content)
+	return nil
+}
+
+// BeforeSave render the content of a post
+func (p *Post) BeforeSave(tx *gorm.DB) error {
+	p.ContentHTML = markdown.Render(p.Content)
+	return nil
+}
+
+// BeforeSave render the content of a bda post
+func (bp *BdaPost) BeforeSave(tx *gorm.DB) error {
+	bp.ContentHTML = markdown.Render(bp.Content)
+	return nil
+}
+
+// BeforeSave render the content of a comment, a deleted comment has no content
+func (c *Comment) BeforeSave(tx *gorm.DB) error {
+	c.ContentHTML = markdown.Render(c.Content)
+	return nil
+}
+
+// BeforeSave highlight the code of a snippet and find its language
+func (s *Snippet) BeforeSave(tx *gorm.DB) error {
+	s.ContentHTML, s.Language = markdown.Highlight(s.Content, s.Language, s.FileName)
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+// FieldType is the type of the values of a field
+type FieldType int
+
+const (
+	// StringField is a text field
+	StringField FieldType = iota
+	// UUIDField is a field referencing a resource by id
+	UUIDField
+	// TimeField is a date field
+	TimeField
+	// IntField is a number field
+	IntField
+	// BoolField is a boolean field
+	BoolField
+)
+
+// Field is a field of a resource which can be used to filter and sort a list
+type Field struct {
+	// Column is the column of the field in the database
+	Column string
+	Type   FieldType
+	// Sortable is true when the list can be sorted by the field
+	Sortable bool
+}
+
+// Fields are the fields a list of resources can be filtered by, by JSON name
+type Fields map[string]Field
+
+// withBaseFields add the fields of Base to fields
+func withBaseFields(fields Fields) Fields {
+	fields["id"] = Field{Column: "id", Type: UUIDField, Sortable: true}
+	fields["createdAt"] = Field{Column: "created_at", Type: TimeField, Sortable: true}
+	fields["updatedAt"] = Field{Column: "updated_at", Type: TimeField, Sortable: true}
+
+	return fields
+}
@@ -0,0 +1,37 @@
+package models
+
+// All returns all the models of the application, resources referenced by
+// other resources come first
+func All() []interface{} {
+	return []interface{}{
+		&Media{},
+		&Promo{},
+		&User{},
+		&Category{},
+		&Topic{},
+		&Snippet{},
+		&SnippetRevision{},
+		&Post{},
+		&BdaPost{},
+		&Comment{},
+		&Like{},
+		&Follow{},
+		&Subscription{},
+		&Notification{},
+		&NotificationPreference{},
+		&NotificationActor{},
+		&Mention{},
+		&Tag{},
+		&Tagging{},
+		&Block{},
+		&Conversation{},
+		&ConversationMember{},
+		&Message{},
+		&Attachment{},
+		&LinkPreview{},
+		&Poll{},
+		&PollOption{},
+		&PollVote{},
+		&PollChoice{},
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+// Relation is a resource related to another resource which can be included with it
+type Relation struct {
+	// Key is the JSON field of the resource matched with RelatedKey
+	Key string
+	// RelatedKey is the JSON field of the related resource matched with Key
+	RelatedKey string
+	// Column is the column of RelatedKey in the database
+	Column string
+	// Many is true when several related resources can match a resource
+	Many bool
+	// New returns a pointer to an empty slice of the related model
+	New func() interface{}
+	// Hidden are the JSON fields of the related resource which must never be included
+	Hidden []string
+}
+
+// Relations are the relations of a resource, by name
+type Relations map[string]Relation
+
+// authorRelation is the relation to the user who created a resource
+var authorRelation = Relation{
+	Key:        "userId",
+	RelatedKey: "id",
+	Column:     "id",
+	New:        func() interface{} { return &[]User{} },
+	Hidden:     []string{"password"},
+}
+
+// likesRelation returns the relation to the likes of a resource, column is the
+// column of the likes referencing the resource
+func likesRelation(relatedKey, column string) Relation {
+	return Relation{
+		Key:        "id",
+		RelatedKey: relatedKey,
+		Column:     column,
+		Many:       true,
+		New:        func() interface{} { return &[]Like{} },
+	}
+}
+
+// commentsRelation is the relation to the comments of a resource
+var commentsRelation = Relation{
+	Key:        "id",
+	RelatedKey: "targetId",
+	Column:     "target_id",
+	Many:       true,
+	New:        func() interface{} { return &[]Comment{} },
+}
+
+// mentionsRelation is the relation to the users mentioned in the content of a resource
+var mentionsRelation = Relation{
+	Key:        "id",
+	RelatedKey: "sourceId",
+	Column:     "source_id",
+	Many:       true,
+	New:        func() interface{} { return &[]Mention{} },
+}
+
+// tagsRelation is the relation to the tags of a resource
+var tagsRelation = Relation{
+	Key:        "id",
+	RelatedKey: "sourceId",
+	Column:     "source_id",
+	Many:       true,
+	New:        func() interface{} { return &[]Tagging{} },
+}
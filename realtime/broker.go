@@ -0,0 +1,152 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+)
+
+// SubscriptionBuffer is the number of events a subscription keeps until they are received
+const SubscriptionBuffer = 64
+
+// ErrSubscriptionClosed is an error when a closed subscription is used
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+// Broker publishes events to the subscriptions of their channel. MemoryBroker works in
+// a single process, another implementation can use a message broker to share the
+// events between several processes.
+type Broker interface {
+	Publisher
+	// Subscribe returns a new subscription without channel
+	Subscribe() Subscription
+}
+
+// Subscription receives the events of the channels it joined
+type Subscription interface {
+	// Events returns the events of the joined channels, it is closed with the subscription
+	Events() <-chan Event
+	Join(channel string) error
+	Leave(channel string) error
+	// Close leave all the channels and close the events
+	Close() error
+}
+
+// MemoryBroker is an in-process broker. A subscription which does not receive its
+// events fast enough is closed, so its client can reconnect and fetch what it missed.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	channels map[string]map[*memorySubscription]bool
+}
+
+// NewMemoryBroker is to create a new in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{channels: map[string]map[*memorySubscription]bool{}}
+}
+
+// Publish send an event to all the subscriptions of its channel without waiting for them
+func (b *MemoryBroker) Publish(event Event) error {
+	overflowed := []*memorySubscription{}
+
+	b.mu.RLock()
+	for subscription := range b.channels[event.Channel] {
+		select {
+		case subscription.events <- event:
+		default:
+			overflowed = append(overflowed, subscription)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, subscription := range overflowed {
+		_ = subscription.Close()
+	}
+
+	return nil
+}
+
+// Subscribe returns a new subscription without channel
+func (b *MemoryBroker) Subscribe() Subscription {
+	return &memorySubscription{
+		broker:   b,
+		events:   make(chan Event, SubscriptionBuffer),
+		channels: map[string]bool{},
+	}
+}
+
+// memorySubscription is a subscription of a MemoryBroker, its channels and state are
+// guarded by the broker lock
+type memorySubscription struct {
+	broker   *MemoryBroker
+	events   chan Event
+	channels map[string]bool
+	closed   bool
+}
+
+// Events returns the events of the joined channels
+func (s *memorySubscription) Events() <-chan Event {
+	return s.events
+}
+
+// Join start receiving the events of a channel
+func (s *memorySubscription) Join(channel string) error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if s.closed {
+		return ErrSubscriptionClosed
+	}
+
+	subscriptions, ok := s.broker.channels[channel]
+	if !ok {
+		subscriptions = map[*memorySubscription]bool{}
+		s.broker.channels[channel] = subscriptions
+	}
+
+	subscriptions[s] = true
+	s.channels[channel] = true
+
+	return nil
+}
+
+// Leave stop receiving the events of a channel
+func (s *memorySubscription) Leave(channel string) error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if s.closed {
+		return ErrSubscriptionClosed
+	}
+
+	s.leave(channel)
+
+	return nil
+}
+
+// Close leave all the channels and close the events
+func (s *memorySubscription) Close() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	for channel := range s.channels {
+		s.leave(channel)
+	}
+
+	s.closed = true
+	close(s.events)
+
+	return nil
+}
+
+// leave remove the subscription from a channel, the broker lock must be held
+func (s *memorySubscription) leave(channel string) {
+	delete(s.channels, channel)
+
+	subscriptions := s.broker.channels[channel]
+	delete(subscriptions, s)
+	if len(subscriptions) == 0 {
+		delete(s.broker.channels, channel)
+	}
+}
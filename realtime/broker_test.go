@@ -0,0 +1,104 @@
+package realtime
+
+import (
+	"testing"
+)
+
+// receive returns the events waiting in a subscription
+func receive(subscription Subscription) []Event {
+	events := []Event{}
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	feed := broker.Subscribe()
+	topic := broker.Subscribe()
+
+	_ = feed.Join(BdaFeedChannel)
+	_ = topic.Join(TopicChannel("1"))
+	_ = topic.Join(BdaFeedChannel)
+
+	_ = broker.Publish(Event{Channel: BdaFeedChannel, Type: Created, Resource: "bdaPost"})
+	_ = broker.Publish(Event{Channel: TopicChannel("1"), Type: Updated, Resource: "topic"})
+	_ = broker.Publish(Event{Channel: TopicChannel("2"), Type: Updated, Resource: "topic"})
+
+	if events := receive(feed); len(events) != 1 || events[0].Resource != "bdaPost" {
+		t.Errorf("feed events = %v, want the bda post", events)
+	}
+
+	if events := receive(topic); len(events) != 2 {
+		t.Errorf("topic events = %v, want the bda post and the topic 1", events)
+	}
+
+	_ = topic.Leave(BdaFeedChannel)
+	_ = broker.Publish(Event{Channel: BdaFeedChannel, Type: Deleted, Resource: "bdaPost"})
+
+	if events := receive(topic); len(events) != 0 {
+		t.Errorf("events after leave = %v, want none", events)
+	}
+
+	if events := receive(feed); len(events) != 1 || events[0].Type != Deleted {
+		t.Errorf("feed events = %v, want the deleted bda post", events)
+	}
+
+	_ = feed.Close()
+	if err := feed.Join(BdaFeedChannel); err != ErrSubscriptionClosed {
+		t.Errorf("Join after close = %v, want %v", err, ErrSubscriptionClosed)
+	}
+
+	if _, ok := <-feed.Events(); ok {
+		t.Errorf("events of a closed subscription should be closed")
+	}
+
+	// the publisher does not wait for a slow subscription, it is closed instead
+	for i := 0; i < SubscriptionBuffer+1; i++ {
+		_ = broker.Publish(Event{Channel: TopicChannel("1"), Type: Updated, Resource: "topic"})
+	}
+
+	if events := receive(topic); len(events) != SubscriptionBuffer {
+		t.Errorf("slow subscription events = %v, want %v", len(events), SubscriptionBuffer)
+	}
+
+	if err := topic.Join(BdaFeedChannel); err != ErrSubscriptionClosed {
+		t.Errorf("slow subscription should be closed, Join = %v", err)
+	}
+
+	if len(broker.channels) != 0 {
+		t.Errorf("channels without subscriptions = %v, want none", broker.channels)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	broker := NewMemoryBroker()
+	subscription := broker.Subscribe()
+	_ = subscription.Join(BdaFeedChannel)
+
+	buffer := NewBuffer()
+	_ = buffer.Publish(Event{Channel: BdaFeedChannel, Type: Created, Resource: "bdaPost"})
+	_ = buffer.Publish(Event{Channel: BdaFeedChannel, Type: Deleted, Resource: "bdaPost"})
+
+	if events := receive(subscription); len(events) != 0 {
+		t.Errorf("events before flush = %v, want none", events)
+	}
+
+	buffer.Flush(broker)
+	buffer.Flush(broker)
+
+	events := receive(subscription)
+	if len(events) != 2 || events[0].Type != Created || events[1].Type != Deleted {
+		t.Errorf("events after flush = %v, want created then deleted", events)
+	}
+}
@@ -0,0 +1,80 @@
+package realtime
+
+import "log"
+
+// EventType is the change of a resource an event is about
+type EventType string
+
+const (
+	// Created is sent when a resource is created
+	Created EventType = "created"
+	// Updated is sent when a resource is updated
+	Updated EventType = "updated"
+	// Deleted is sent when a resource is deleted
+	Deleted EventType = "deleted"
+)
+
+// BdaFeedChannel is the channel of the events of the bda posts
+const BdaFeedChannel = "bdaposts"
+
+// Event is a change of a resource published to the subscribers of a channel
+type Event struct {
+	// Channel is the channel the event is published to
+	Channel string    `json:"channel"`
+	Type    EventType `json:"type"`
+	// Resource is the type of the changed resource, e.g. post or message
+	Resource string `json:"resource"`
+	// Data is the resource, only its id when it has been deleted
+	Data interface{} `json:"data"`
+}
+
+// TopicChannel returns the channel of the events of a topic, its posts and their comments
+// and likes
+func TopicChannel(topicID string) string {
+	return "topics/" + topicID
+}
+
+// ConversationChannel returns the channel of the events of a conversation and its messages
+func ConversationChannel(conversationID string) string {
+	return "conversations/" + conversationID
+}
+
+// NotificationsChannel returns the channel of the notifications of a user
+func NotificationsChannel(userID string) string {
+	return "users/" + userID + "/notifications"
+}
+
+// Publisher publishes events to the subscribers of their channel
+type Publisher interface {
+	Publish(event Event) error
+}
+
+// Buffer is a publisher keeping the events until they are flushed, so the events of a
+// transaction are only published once it is committed
+type Buffer struct {
+	events []Event
+}
+
+// NewBuffer is to create an empty buffer
+func NewBuffer() *Buffer {
+	return &Buffer{}
+}
+
+// Publish keep an event in the buffer
+func (b *Buffer) Publish(event Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+// Flush publish the events of the buffer in order and empty it, an event which can
+// not be published is logged and skipped
+func (b *Buffer) Flush(publisher Publisher) {
+	for _, event := range b.events {
+		err := publisher.Publish(event)
+		if err != nil {
+			log.Printf("can not publish %s %s event to %s: %s", event.Resource, event.Type, event.Channel, err)
+		}
+	}
+
+	b.events = nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/ada-social-network/api/search"
+	"gorm.io/gorm/logger"
+)
+
+// runReindex rebuild the search index, e.g. after the database has been edited by hand
+func runReindex(args []string) error {
+	var dsn string
+
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) to reindex")
+	_ = flags.Parse(args)
+
+	db, err := openDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	count, err := search.Reindex(db, search.EngineOf(db))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d documents indexed\n", count)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// AttachmentStore is the interface implemented by AttachmentRepository
+type AttachmentStore interface {
+	RelationStore
+	CreateAttachment(attachment *models.Attachment) error
+	ListAttachmentsBySource(attachments *[]models.Attachment, sourceType models.AttachmentSource, sourceID string) error
+	DeleteAttachmentsBySource(sourceType models.AttachmentSource, sourceID string) error
+	DeleteAttachmentsByMediaID(mediaID string) error
+	DeleteOrphanAttachments() error
+}
+
+// AttachmentRepository is a repository for attachment resource
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository is to create a new attachment repository
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the
+// DB, the attachments are listed in their order in the resources
+func (a *AttachmentRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(a.db.Order("position"), related, column, keys)
+}
+
+// CreateAttachment create an attachment in the DB
+func (a *AttachmentRepository) CreateAttachment(attachment *models.Attachment) error {
+	return a.db.Create(attachment).Error
+}
+
+// ListAttachmentsBySource list all the attachments of a resource in their order in the DB
+func (a *AttachmentRepository) ListAttachmentsBySource(attachments *[]models.Attachment, sourceType models.AttachmentSource, sourceID string) error {
+	return a.db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Order("position").Find(attachments).Error
+}
+
+// DeleteAttachmentsBySource delete all the attachments of a resource in the DB
+func (a *AttachmentRepository) DeleteAttachmentsBySource(sourceType models.AttachmentSource, sourceID string) error {
+	return a.db.Delete(&models.Attachment{}, "source_type = ? AND source_id = ?", sourceType, sourceID).Error
+}
+
+// DeleteAttachmentsByMediaID delete the attachments of a media in the DB
+func (a *AttachmentRepository) DeleteAttachmentsByMediaID(mediaID string) error {
+	return a.db.Delete(&models.Attachment{}, "media_id = ?", mediaID).Error
+}
+
+// DeleteOrphanAttachments delete the attachments of the deleted resources and media in
+// the DB, e.g. after the content of a user has been deleted
+func (a *AttachmentRepository) DeleteOrphanAttachments() error {
+	return a.db.Where("media_id NOT IN (?)", a.db.Model(&models.Media{}).Select("id")).
+		Or("source_type = ? AND source_id NOT IN (?)", models.PostAttachment, a.db.Model(&models.Post{}).Select("id")).
+		Or("source_type = ? AND source_id NOT IN (?)", models.BdaPostAttachment, a.db.Model(&models.BdaPost{}).Select("id")).
+		Or("source_type = ? AND source_id NOT IN (?)", models.CommentAttachment, a.db.Model(&models.Comment{}).Select("id")).
+		Or("source_type = ? AND source_id NOT IN (?)", models.MessageAttachment, a.db.Model(&models.Message{}).Select("id")).
+		Delete(&models.Attachment{}).Error
+}
@@ -13,6 +13,22 @@ var (
 	ErrBdaPostNotFound = errors.New("bda post not found")
 )
 
+// BdaPostStore is the interface implemented by BdaPostRepository
+type BdaPostStore interface {
+	RelationStore
+	CreateBdaPost(bdaPost *models.BdaPost) error
+	ListBdaPosts(bdaPosts *[]models.BdaPost, page Page) (PageInfo, error)
+	ListAllBdaPostsByUserID(bdaPosts *[]models.BdaPost, userID string) error
+	GetBdaPostByID(bdaPost *models.BdaPost, bdaPostID string) error
+	UpdateBdaPost(bdaPost *models.BdaPost) error
+	DeleteBdaPostByID(bdaPostID string) error
+	DeleteBdaPostsByUserID(userID string) error
+	CheckLikeByUserAndBdaPostID(like *models.Like, userID uuid.UUID, bdaPostID uuid.UUID) (bool, error)
+	CreateLike(like *models.Like) error
+	ListLikesByBdaPostID(likes *[]models.Like, bdaPostID string, page Page) (PageInfo, error)
+	DeleteLikeByID(likeID string) error
+}
+
 // BdaPostRepository is a repository for bda post resource
 type BdaPostRepository struct {
 	db *gorm.DB
@@ -23,14 +39,24 @@ func NewBdaPostRepository(db *gorm.DB) *BdaPostRepository {
 	return &BdaPostRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (bp *BdaPostRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(bp.db, related, column, keys)
+}
+
 // CreateBdaPost create a bda post in the DB
 func (bp *BdaPostRepository) CreateBdaPost(bdaPost *models.BdaPost) error {
 	return bp.db.Create(bdaPost).Error
 }
 
-// ListAllBdaPosts list all the bda post in the DB
-func (bp *BdaPostRepository) ListAllBdaPosts(bdaPosts *[]models.BdaPost) error {
-	return bp.db.Find(bdaPosts).Error
+// ListBdaPosts list a page of the bda posts in the DB
+func (bp *BdaPostRepository) ListBdaPosts(bdaPosts *[]models.BdaPost, page Page) (PageInfo, error) {
+	return paginate(bp.db, bdaPosts, page)
+}
+
+// ListAllBdaPostsByUserID list all the bda posts of a specific user in the DB
+func (bp *BdaPostRepository) ListAllBdaPostsByUserID(bdaPosts *[]models.BdaPost, userID string) error {
+	return bp.db.Find(bdaPosts, "user_id = ?", userID).Error
 }
 
 // GetBdaPostByID get a bda post by id in the DB
@@ -43,9 +69,9 @@ func (bp *BdaPostRepository) GetBdaPostByID(bdaPost *models.BdaPost, bdaPostID s
 	return tx.Error
 }
 
-// UpdateBdaPost update a bda post in the DB
+// UpdateBdaPost update a bda post in the DB if it has not been updated since it has been read
 func (bp *BdaPostRepository) UpdateBdaPost(bdaPost *models.BdaPost) error {
-	return bp.db.Save(bdaPost).Error
+	return updateVersioned(bp.db, bdaPost, &bdaPost.Base, ErrBdaPostNotFound)
 }
 
 // DeleteBdaPostByID delete a bda post by ID in the DB
@@ -58,6 +84,11 @@ func (bp *BdaPostRepository) DeleteBdaPostByID(bdaPostID string) error {
 	return tx.Error
 }
 
+// DeleteBdaPostsByUserID delete all the bda posts of a specific user in the DB
+func (bp *BdaPostRepository) DeleteBdaPostsByUserID(userID string) error {
+	return bp.db.Delete(&models.BdaPost{}, "user_id = ?", userID).Error
+}
+
 // CheckLikeByUserAndBdaPostID will check if a like by this user already exist on a bda post
 func (bp *BdaPostRepository) CheckLikeByUserAndBdaPostID(like *models.Like, userID uuid.UUID, bdaPostID uuid.UUID) (bool, error) {
 	tx := bp.db.Where("user_id= ? AND bda_post_id= ?", userID, bdaPostID).Find(like)
@@ -69,9 +100,9 @@ func (bp *BdaPostRepository) CreateLike(like *models.Like) error {
 	return bp.db.Create(like).Error
 }
 
-// ListAllPostsByBdaPostID list all likes of a specific bda post in the DB
-func (bp *BdaPostRepository) ListAllPostsByBdaPostID(likes *[]models.Like, bdaPostID string) error {
-	return bp.db.Find(likes, "bda_post_id=?", bdaPostID).Error
+// ListLikesByBdaPostID list a page of likes of a specific bda post in the DB
+func (bp *BdaPostRepository) ListLikesByBdaPostID(likes *[]models.Like, bdaPostID string, page Page) (PageInfo, error) {
+	return paginate(bp.db.Where("bda_post_id = ?", bdaPostID), likes, page)
 }
 
 // DeleteLikeByID delete a like by ID in the DB
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// ErrBlockNotFound is an error when resource is not found
+var (
+	ErrBlockNotFound = errors.New("block not found")
+)
+
+// BlockStore is the interface implemented by BlockRepository
+type BlockStore interface {
+	RelationStore
+	CreateBlock(block *models.Block) error
+	GetBlock(block *models.Block, userID string, blockedID string) error
+	ListBlocksByUserID(blocks *[]models.Block, userID string, page Page) (PageInfo, error)
+	HasBlockBetween(userID string, otherIDs []string) (bool, error)
+	ListBlockedUserIDs(userID string) ([]string, error)
+	DeleteBlockByID(blockID string) error
+	DeleteBlocksByUserID(userID string) error
+}
+
+// BlockRepository is a repository for block resource
+type BlockRepository struct {
+	db *gorm.DB
+}
+
+// NewBlockRepository is to create a new block repository
+func NewBlockRepository(db *gorm.DB) *BlockRepository {
+	return &BlockRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (b *BlockRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(b.db, related, column, keys)
+}
+
+// CreateBlock create a block in the DB
+func (b *BlockRepository) CreateBlock(block *models.Block) error {
+	return b.db.Create(block).Error
+}
+
+// GetBlock get the block of a user by another user in the DB
+func (b *BlockRepository) GetBlock(block *models.Block, userID string, blockedID string) error {
+	tx := b.db.First(block, "user_id = ? AND blocked_id = ?", userID, blockedID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrBlockNotFound
+	}
+
+	return tx.Error
+}
+
+// ListBlocksByUserID list a page of the blocks of a specific user in the DB
+func (b *BlockRepository) ListBlocksByUserID(blocks *[]models.Block, userID string, page Page) (PageInfo, error) {
+	return paginate(b.db.Where("user_id = ?", userID), blocks, page)
+}
+
+// HasBlockBetween returns true when a user blocked one of the other users or has been
+// blocked by one of them in the DB
+func (b *BlockRepository) HasBlockBetween(userID string, otherIDs []string) (bool, error) {
+	if len(otherIDs) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := b.db.Model(&models.Block{}).
+		Where("(user_id = ? AND blocked_id IN ?) OR (blocked_id = ? AND user_id IN ?)", userID, otherIDs, userID, otherIDs).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// ListBlockedUserIDs list the ids of the users blocked by a user and of the users who
+// blocked them in the DB
+func (b *BlockRepository) ListBlockedUserIDs(userID string) ([]string, error) {
+	ids := []string{}
+	err := b.db.Model(&models.Block{}).
+		Select("CASE WHEN user_id = ? THEN blocked_id ELSE user_id END", userID).
+		Where("user_id = ? OR blocked_id = ?", userID, userID).
+		Scan(&ids).Error
+
+	return ids, err
+}
+
+// DeleteBlockByID delete a block by ID in the DB
+func (b *BlockRepository) DeleteBlockByID(blockID string) error {
+	tx := b.db.Delete(&models.Block{}, "id = ?", blockID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrBlockNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteBlocksByUserID delete all the blocks of a user and of the users who blocked them in the DB
+func (b *BlockRepository) DeleteBlocksByUserID(userID string) error {
+	return b.db.Delete(&models.Block{}, "user_id = ? OR blocked_id = ?", userID, userID).Error
+}
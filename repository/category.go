@@ -12,6 +12,16 @@ var (
 	ErrCategoryNotFound = errors.New("category not found")
 )
 
+// CategoryStore is the interface implemented by CategoryRepository
+type CategoryStore interface {
+	RelationStore
+	CreateCategory(category *models.Category) error
+	ListCategories(categories *[]models.Category, page Page) (PageInfo, error)
+	GetCategoryByID(category *models.Category, categoryID string) error
+	UpdateCategory(category *models.Category) error
+	DeleteCategoryByID(categoryID string) error
+}
+
 //CategoryRepository is a repository for category resource
 type CategoryRepository struct {
 	db *gorm.DB
@@ -22,14 +32,19 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (ca *CategoryRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(ca.db, related, column, keys)
+}
+
 // CreateCategory create a category in the DB
 func (ca *CategoryRepository) CreateCategory(category *models.Category) error {
 	return ca.db.Create(category).Error
 }
 
-// ListAllCategories list all categories in the DB
-func (ca *CategoryRepository) ListAllCategories(categories *[]models.Category) error {
-	return ca.db.Find(categories).Error
+// ListCategories list a page of categories in the DB
+func (ca *CategoryRepository) ListCategories(categories *[]models.Category, page Page) (PageInfo, error) {
+	return paginate(ca.db, categories, page)
 }
 
 // GetCategoryByID get a category by id in the DB
@@ -42,9 +57,9 @@ func (ca *CategoryRepository) GetCategoryByID(category *models.Category, categor
 	return tx.Error
 }
 
-// UpdateCategory update a category in the DB
+// UpdateCategory update a category in the DB if it has not been updated since it has been read
 func (ca *CategoryRepository) UpdateCategory(category *models.Category) error {
-	return ca.db.Save(category).Error
+	return updateVersioned(ca.db, category, &category.Base, ErrCategoryNotFound)
 }
 
 // DeleteCategoryByID delete a category by ID in the DB
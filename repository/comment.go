@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/ada-social-network/api/models"
 	uuid "github.com/satori/go.uuid"
@@ -13,6 +14,31 @@ var (
 	ErrCommentNotFound = errors.New("comment not found")
 )
 
+// CommentStore is the interface implemented by CommentRepository
+type CommentStore interface {
+	RelationStore
+	GetCommentByID(comment *models.Comment, commentID string) error
+	ListAllComments(comments *[]models.Comment) error
+	ListAllCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string) error
+	ListCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string, page Page) (PageInfo, error)
+	ListTopLevelCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string, page Page) (PageInfo, error)
+	ListCommentsByParentID(comments *[]models.Comment, parentID string, page Page) (PageInfo, error)
+	ListCommentThread(comments *[]models.Comment, targetType models.Commentable, targetID string, parent *models.Comment, page Page) (PageInfo, error)
+	ListFirstRepliesByParentIDs(comments *[]models.Comment, parentIDs []string, limit int) error
+	ListAllCommentsByUserID(comments *[]models.Comment, userID string) error
+	CreateComment(comment *models.Comment) error
+	UpdateComment(comment *models.Comment) error
+	UpdateRepliesCount(commentID string, delta int) error
+	MarkCommentDeleted(commentID string) error
+	DeleteCommentByID(commentID string) error
+	DeleteCommentsByTarget(targetType models.Commentable, targetID string) error
+	DeleteCommentsByUserID(userID string) error
+	CheckLikeByUserAndCommentID(like *models.Like, userID uuid.UUID, commentID uuid.UUID) (bool, error)
+	CreateLike(like *models.Like) error
+	ListLikesByCommentID(likes *[]models.Like, commentID string, page Page) (PageInfo, error)
+	DeleteLikeByID(likeID string) error
+}
+
 // CommentRepository is a repository for comment resource
 type CommentRepository struct {
 	db *gorm.DB
@@ -23,6 +49,11 @@ func NewCommentRepository(db *gorm.DB) *CommentRepository {
 	return &CommentRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (co *CommentRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(co.db, related, column, keys)
+}
+
 // GetCommentByID get a comment by id in the DB
 func (co *CommentRepository) GetCommentByID(comment *models.Comment, commentID string) error {
 	tx := co.db.First(comment, "id = ?", commentID)
@@ -38,9 +69,59 @@ func (co *CommentRepository) ListAllComments(comments *[]models.Comment) error {
 	return co.db.Find(comments).Error
 }
 
-// ListAllCommentsByBdaPostID list all comments of a specific bdaPost in the DB
-func (co *CommentRepository) ListAllCommentsByBdaPostID(comments *[]models.Comment, bdaPostID string) error {
-	return co.db.Find(comments, "bda_post_id=?", bdaPostID).Error
+// ListAllCommentsByTarget list all comments of a specific resource in the DB
+func (co *CommentRepository) ListAllCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string) error {
+	return co.db.Find(comments, "target_type = ? AND target_id = ?", targetType, targetID).Error
+}
+
+// ListCommentsByTarget list a page of comments of a specific resource in the DB
+func (co *CommentRepository) ListCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string, page Page) (PageInfo, error) {
+	return paginate(co.db.Where("target_type = ? AND target_id = ?", targetType, targetID), comments, page)
+}
+
+// ListTopLevelCommentsByTarget list a page of the comments of a specific resource which
+// are not replies in the DB
+func (co *CommentRepository) ListTopLevelCommentsByTarget(comments *[]models.Comment, targetType models.Commentable, targetID string, page Page) (PageInfo, error) {
+	return paginate(co.db.Where("target_type = ? AND target_id = ? AND parent_id IS NULL", targetType, targetID), comments, page)
+}
+
+// ListCommentsByParentID list a page of the replies to a specific comment in the DB
+func (co *CommentRepository) ListCommentsByParentID(comments *[]models.Comment, parentID string, page Page) (PageInfo, error) {
+	return paginate(co.db.Where("parent_id = ?", parentID), comments, page)
+}
+
+// ListCommentThread list a page of the comments of a specific resource, or of the replies
+// at any depth of parent when it is not nil, in the DB. The page must be sorted by path
+// for the replies to follow the comment they reply to.
+func (co *CommentRepository) ListCommentThread(comments *[]models.Comment, targetType models.Commentable, targetID string, parent *models.Comment, page Page) (PageInfo, error) {
+	query := co.db.Where("target_type = ? AND target_id = ?", targetType, targetID)
+	if parent != nil {
+		query = query.Where("path LIKE ?", parent.Path+"/%")
+	}
+
+	return paginate(query, comments, page)
+}
+
+// ListFirstRepliesByParentIDs list the oldest replies to each of the given comments, at
+// most limit replies by comment, in the DB
+func (co *CommentRepository) ListFirstRepliesByParentIDs(comments *[]models.Comment, parentIDs []string, limit int) error {
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	ranked := co.db.Model(&models.Comment{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at, id) AS reply_rank").
+		Where("parent_id IN ?", parentIDs)
+
+	return co.db.Table("(?) AS replies", ranked).
+		Where("reply_rank <= ?", limit).
+		Order("created_at, id").
+		Find(comments).Error
+}
+
+// ListAllCommentsByUserID list all comments of a specific user in the DB
+func (co *CommentRepository) ListAllCommentsByUserID(comments *[]models.Comment, userID string) error {
+	return co.db.Find(comments, "user_id = ?", userID).Error
 }
 
 // CreateComment create a comment in the DB
@@ -48,9 +129,41 @@ func (co *CommentRepository) CreateComment(comment *models.Comment) error {
 	return co.db.Create(comment).Error
 }
 
-// UpdateComment update a comment in the DB
+// UpdateComment update a comment in the DB if it has not been updated since it has been read
 func (co *CommentRepository) UpdateComment(comment *models.Comment) error {
-	return co.db.Save(comment).Error
+	return updateVersioned(co.db.Omit("ParentID", "Depth", "RepliesCount", "Path"), comment, &comment.Base, ErrCommentNotFound)
+}
+
+// UpdateRepliesCount add delta to the replies counter of a comment in the DB
+func (co *CommentRepository) UpdateRepliesCount(commentID string, delta int) error {
+	tx := co.db.Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		UpdateColumn("replies_count", gorm.Expr("replies_count + ?", delta))
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return tx.Error
+}
+
+// MarkCommentDeleted remove the content and author of a comment in the DB, the comment
+// is kept in its thread as a deleted placeholder
+func (co *CommentRepository) MarkCommentDeleted(commentID string) error {
+	tx := co.db.Model(&models.Comment{}).
+		Where("id = ?", commentID).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at":   time.Now(),
+			"updated_at":   time.Now(),
+			"content":      "",
+			"content_html": "",
+			"user_id":      uuid.Nil,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return tx.Error
 }
 
 // DeleteCommentByID delete a comment by ID in the DB
@@ -63,6 +176,16 @@ func (co *CommentRepository) DeleteCommentByID(commentID string) error {
 	return tx.Error
 }
 
+// DeleteCommentsByTarget delete all comments of a specific resource in the DB
+func (co *CommentRepository) DeleteCommentsByTarget(targetType models.Commentable, targetID string) error {
+	return co.db.Delete(&models.Comment{}, "target_type = ? AND target_id = ?", targetType, targetID).Error
+}
+
+// DeleteCommentsByUserID delete all comments of a specific user in the DB
+func (co *CommentRepository) DeleteCommentsByUserID(userID string) error {
+	return co.db.Delete(&models.Comment{}, "user_id = ?", userID).Error
+}
+
 // CheckLikeByUserAndCommentID will check if a like by this user already exist on a comment
 func (co *CommentRepository) CheckLikeByUserAndCommentID(like *models.Like, userID uuid.UUID, commentID uuid.UUID) (bool, error) {
 	tx := co.db.Where("user_id= ? AND comment_id= ?", userID, commentID).Find(like)
@@ -74,9 +197,9 @@ func (co *CommentRepository) CreateLike(like *models.Like) error {
 	return co.db.Create(like).Error
 }
 
-// ListAllPostsByCommentID list all likes of a specific comment in the DB
-func (co *CommentRepository) ListAllPostsByCommentID(likes *[]models.Like, commentID string) error {
-	return co.db.Find(likes, "comment_id=?", commentID).Error
+// ListLikesByCommentID list a page of likes of a specific comment in the DB
+func (co *CommentRepository) ListLikesByCommentID(likes *[]models.Like, commentID string, page Page) (PageInfo, error) {
+	return paginate(co.db.Where("comment_id = ?", commentID), likes, page)
 }
 
 // DeleteLikeByID delete a like by ID in the DB
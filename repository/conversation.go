@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrConversationNotFound is an error when resource is not found
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrConversationMemberNotFound is an error when a user is not a member of a conversation
+	ErrConversationMemberNotFound = errors.New("conversation member not found")
+)
+
+// ConversationStore is the interface implemented by ConversationRepository
+type ConversationStore interface {
+	RelationStore
+	CreateConversation(conversation *models.Conversation) error
+	GetConversationByID(conversation *models.Conversation, conversationID string) error
+	GetDirectConversation(conversation *models.Conversation, userID string, otherID string) error
+	ListConversationsByUserID(conversations *[]models.Conversation, userID string, page Page) (PageInfo, error)
+	UpdateLastMessageAt(conversationID string, lastMessageAt time.Time) error
+	DeleteConversationByID(conversationID string) error
+	DeleteConversationsByUserID(userID string) error
+	CreateConversationMember(member *models.ConversationMember) error
+	GetConversationMember(member *models.ConversationMember, conversationID string, userID string) error
+	ListConversationMembers(members *[]models.ConversationMember, conversationIDs []string) error
+	CountConversationMembers(conversationID string) (int64, error)
+	MarkConversationRead(member *models.ConversationMember, readAt time.Time) error
+	DeleteConversationMemberByID(memberID string) error
+	CreateMessage(message *models.Message) error
+	ListMessagesByConversationID(messages *[]models.Message, conversationID string, page Page) (PageInfo, error)
+	CountUnreadMessages(userID string, conversationIDs []string) (map[string]int64, error)
+}
+
+// ConversationRepository is a repository for conversation, conversation member and
+// message resources
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository is to create a new conversation repository
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (co *ConversationRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(co.db, related, column, keys)
+}
+
+// CreateConversation create a conversation in the DB
+func (co *ConversationRepository) CreateConversation(conversation *models.Conversation) error {
+	return co.db.Create(conversation).Error
+}
+
+// GetConversationByID get a conversation by ID in the DB
+func (co *ConversationRepository) GetConversationByID(conversation *models.Conversation, conversationID string) error {
+	tx := co.db.First(conversation, "id = ?", conversationID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrConversationNotFound
+	}
+
+	return tx.Error
+}
+
+// GetDirectConversation get the conversation between two users which is not a group
+// in the DB
+func (co *ConversationRepository) GetDirectConversation(conversation *models.Conversation, userID string, otherID string) error {
+	ofUser := co.db.Model(&models.ConversationMember{}).Select("conversation_id").Where("user_id = ?", userID)
+	ofOther := co.db.Model(&models.ConversationMember{}).Select("conversation_id").Where("user_id = ?", otherID)
+
+	tx := co.db.
+		Where("is_group = ? AND id IN (?) AND id IN (?)", false, ofUser, ofOther).
+		First(conversation)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrConversationNotFound
+	}
+
+	return tx.Error
+}
+
+// ListConversationsByUserID list a page of the conversations of a specific user in the DB
+func (co *ConversationRepository) ListConversationsByUserID(conversations *[]models.Conversation, userID string, page Page) (PageInfo, error) {
+	ofUser := co.db.Model(&models.ConversationMember{}).Select("conversation_id").Where("user_id = ?", userID)
+	return paginate(co.db.Where("id IN (?)", ofUser), conversations, page)
+}
+
+// UpdateLastMessageAt set the date of the last message of a conversation in the DB
+func (co *ConversationRepository) UpdateLastMessageAt(conversationID string, lastMessageAt time.Time) error {
+	tx := co.db.Model(&models.Conversation{}).Where("id = ?", conversationID).UpdateColumn("last_message_at", lastMessageAt)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrConversationNotFound
+	}
+
+	return tx.Error
+}
+
+// deleteMessages delete the messages matching conditions with their attachments in the DB
+func (co *ConversationRepository) deleteMessages(query interface{}, args ...interface{}) error {
+	messageIDs := co.db.Model(&models.Message{}).Select("id").Where(query, args...)
+	err := co.db.Delete(&models.Attachment{}, "source_type = ? AND source_id IN (?)", models.MessageAttachment, messageIDs).Error
+	if err != nil {
+		return err
+	}
+
+	return co.db.Delete(&models.Message{}, append([]interface{}{query}, args...)...).Error
+}
+
+// DeleteConversationByID delete a conversation with its members, messages and their
+// attachments by ID in the DB
+func (co *ConversationRepository) DeleteConversationByID(conversationID string) error {
+	err := co.deleteMessages("conversation_id = ?", conversationID)
+	if err != nil {
+		return err
+	}
+
+	err = co.db.Delete(&models.ConversationMember{}, "conversation_id = ?", conversationID).Error
+	if err != nil {
+		return err
+	}
+
+	tx := co.db.Delete(&models.Conversation{}, "id = ?", conversationID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrConversationNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteConversationsByUserID delete the messages, with their attachments, and
+// memberships of a user with their conversations which are not groups in the DB. The
+// groups left without members are deleted and the groups they started are kept without
+// author.
+func (co *ConversationRepository) DeleteConversationsByUserID(userID string) error {
+	direct := []string{}
+	err := co.db.Model(&models.Conversation{}).
+		Where("is_group = ? AND id IN (?)", false, co.db.Model(&models.ConversationMember{}).Select("conversation_id").Where("user_id = ?", userID)).
+		Pluck("id", &direct).Error
+	if err != nil {
+		return err
+	}
+
+	err = co.deleteMessages("user_id = ? OR conversation_id IN ?", userID, direct)
+	if err != nil {
+		return err
+	}
+
+	err = co.db.Delete(&models.ConversationMember{}, "user_id = ? OR conversation_id IN ?", userID, direct).Error
+	if err != nil {
+		return err
+	}
+
+	members := co.db.Model(&models.ConversationMember{}).Select("conversation_id")
+	err = co.deleteMessages("conversation_id NOT IN (?)", members)
+	if err != nil {
+		return err
+	}
+
+	err = co.db.Delete(&models.Conversation{}, "id NOT IN (?)", members).Error
+	if err != nil {
+		return err
+	}
+
+	return co.db.Model(&models.Conversation{}).Where("user_id = ?", userID).UpdateColumn("user_id", uuid.Nil).Error
+}
+
+// CreateConversationMember add a user to a conversation in the DB
+func (co *ConversationRepository) CreateConversationMember(member *models.ConversationMember) error {
+	return co.db.Create(member).Error
+}
+
+// GetConversationMember get the membership of a user in a conversation in the DB
+func (co *ConversationRepository) GetConversationMember(member *models.ConversationMember, conversationID string, userID string) error {
+	tx := co.db.First(member, "conversation_id = ? AND user_id = ?", conversationID, userID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrConversationMemberNotFound
+	}
+
+	return tx.Error
+}
+
+// ListConversationMembers list the members of conversations, the oldest first, in the DB
+func (co *ConversationRepository) ListConversationMembers(members *[]models.ConversationMember, conversationIDs []string) error {
+	if len(conversationIDs) == 0 {
+		return nil
+	}
+
+	return co.db.Where("conversation_id IN ?", conversationIDs).Order("created_at").Order("id").Find(members).Error
+}
+
+// CountConversationMembers count the members of a conversation in the DB
+func (co *ConversationRepository) CountConversationMembers(conversationID string) (int64, error) {
+	var count int64
+	err := co.db.Model(&models.ConversationMember{}).Where("conversation_id = ?", conversationID).Count(&count).Error
+
+	return count, err
+}
+
+// MarkConversationRead move the read marker of a member to a date in the DB, a marker
+// is never moved back
+func (co *ConversationRepository) MarkConversationRead(member *models.ConversationMember, readAt time.Time) error {
+	if member.LastReadAt != nil && !readAt.After(*member.LastReadAt) {
+		return nil
+	}
+
+	member.LastReadAt = &readAt
+
+	return co.db.Model(member).UpdateColumn("last_read_at", readAt).Error
+}
+
+// DeleteConversationMemberByID delete a membership by ID in the DB
+func (co *ConversationRepository) DeleteConversationMemberByID(memberID string) error {
+	tx := co.db.Delete(&models.ConversationMember{}, "id = ?", memberID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrConversationMemberNotFound
+	}
+
+	return tx.Error
+}
+
+// CreateMessage create a message in the DB
+func (co *ConversationRepository) CreateMessage(message *models.Message) error {
+	return co.db.Create(message).Error
+}
+
+// ListMessagesByConversationID list a page of the messages of a conversation in the DB
+func (co *ConversationRepository) ListMessagesByConversationID(messages *[]models.Message, conversationID string, page Page) (PageInfo, error) {
+	return paginate(co.db.Where("conversation_id = ?", conversationID), messages, page)
+}
+
+// CountUnreadMessages count the messages of other members a user has not read in each
+// of the conversations in the DB, by conversation id
+func (co *ConversationRepository) CountUnreadMessages(userID string, conversationIDs []string) (map[string]int64, error) {
+	counts := map[string]int64{}
+	if len(conversationIDs) == 0 {
+		return counts, nil
+	}
+
+	rows := []struct {
+		ConversationID string
+		Count          int64
+	}{}
+
+	err := co.db.Raw(`SELECT m.conversation_id AS conversation_id, COUNT(*) AS count
+		FROM messages m
+		JOIN conversation_members cm ON cm.conversation_id = m.conversation_id AND cm.user_id = ?
+		WHERE m.conversation_id IN ? AND m.user_id <> ? AND (cm.last_read_at IS NULL OR m.created_at > cm.last_read_at)
+		GROUP BY m.conversation_id`, userID, conversationIDs, userID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// feedQuery selects the items of the feed of a user: posts in subscribed topics, topics
+// in subscribed categories, bda posts when the bda feed is subscribed and everything
+// created by followed users. The user's own resources are left out. Each branch uses
+// the index of the referencing column, so the feed is computed without loading tables.
+const feedQuery = `
+	SELECT 'post' AS type, p.id AS id, p.created_at AS created_at, p.user_id AS user_id FROM posts p
+	WHERE p.user_id <> @user AND (
+		p.topic_id IN (SELECT target_id FROM subscriptions WHERE user_id = @user AND target_type = 'topic')
+		OR p.user_id IN (SELECT followed_id FROM follows WHERE user_id = @user))
+	UNION ALL
+	SELECT 'topic', t.id, t.created_at, t.user_id FROM topics t
+	WHERE t.user_id <> @user AND (
+		t.category_id IN (SELECT target_id FROM subscriptions WHERE user_id = @user AND target_type = 'category')
+		OR t.user_id IN (SELECT followed_id FROM follows WHERE user_id = @user))
+	UNION ALL
+	SELECT 'bdaPost', b.id, b.created_at, b.user_id FROM bda_posts b
+	WHERE b.user_id <> @user AND (
+		EXISTS (SELECT 1 FROM subscriptions WHERE user_id = @user AND target_type = 'bdaFeed')
+		OR b.user_id IN (SELECT followed_id FROM follows WHERE user_id = @user))
+	UNION ALL
+	SELECT 'comment', c.id, c.created_at, c.user_id FROM comments c
+	WHERE c.user_id IN (SELECT followed_id FROM follows WHERE user_id = @user)`
+
+// FeedStore is the interface implemented by FeedRepository
+type FeedStore interface {
+	RelationStore
+	ListFeed(items *[]models.FeedItem, userID string, page Page) (PageInfo, error)
+}
+
+// FeedRepository is a repository for the feed of the users
+type FeedRepository struct {
+	db *gorm.DB
+}
+
+// NewFeedRepository is to create a new feed repository
+func NewFeedRepository(db *gorm.DB) *FeedRepository {
+	return &FeedRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (f *FeedRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(f.db, related, column, keys)
+}
+
+// ListFeed list a page of the feed of a specific user in the DB
+func (f *FeedRepository) ListFeed(items *[]models.FeedItem, userID string, page Page) (PageInfo, error) {
+	feed := f.db.Raw(feedQuery, sql.Named("user", userID))
+	return paginate(f.db.Table("(?) AS feed", feed), items, page)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// ErrFollowNotFound is an error when resource is not found
+var (
+	ErrFollowNotFound = errors.New("follow not found")
+)
+
+// FollowStore is the interface implemented by FollowRepository
+type FollowStore interface {
+	RelationStore
+	CreateFollow(follow *models.Follow) error
+	GetFollow(follow *models.Follow, userID string, followedID string) error
+	ListFollowers(users *[]models.User, userID string, page Page) (PageInfo, error)
+	ListFollowing(users *[]models.User, userID string, page Page) (PageInfo, error)
+	ListAllFollowsByUserID(follows *[]models.Follow, userID string) error
+	DeleteFollowByID(followID string) error
+	DeleteFollowsByUserID(userID string) error
+}
+
+// FollowRepository is a repository for follow resource
+type FollowRepository struct {
+	db *gorm.DB
+}
+
+// NewFollowRepository is to create a new follow repository
+func NewFollowRepository(db *gorm.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (f *FollowRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(f.db, related, column, keys)
+}
+
+// CreateFollow create a follow in the DB
+func (f *FollowRepository) CreateFollow(follow *models.Follow) error {
+	return f.db.Create(follow).Error
+}
+
+// GetFollow get the follow of a user by another user in the DB
+func (f *FollowRepository) GetFollow(follow *models.Follow, userID string, followedID string) error {
+	tx := f.db.First(follow, "user_id = ? AND followed_id = ?", userID, followedID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrFollowNotFound
+	}
+
+	return tx.Error
+}
+
+// ListFollowers list a page of the users following a specific user in the DB
+func (f *FollowRepository) ListFollowers(users *[]models.User, userID string, page Page) (PageInfo, error) {
+	followers := f.db.Model(&models.Follow{}).Select("user_id").Where("followed_id = ?", userID)
+	return paginate(f.db.Where("id IN (?)", followers), users, page)
+}
+
+// ListFollowing list a page of the users followed by a specific user in the DB
+func (f *FollowRepository) ListFollowing(users *[]models.User, userID string, page Page) (PageInfo, error) {
+	following := f.db.Model(&models.Follow{}).Select("followed_id").Where("user_id = ?", userID)
+	return paginate(f.db.Where("id IN (?)", following), users, page)
+}
+
+// ListAllFollowsByUserID list all the follows of a user and of their followers in the DB
+func (f *FollowRepository) ListAllFollowsByUserID(follows *[]models.Follow, userID string) error {
+	return f.db.Where("user_id = ? OR followed_id = ?", userID, userID).Find(follows).Error
+}
+
+// DeleteFollowByID delete a follow by ID in the DB
+func (f *FollowRepository) DeleteFollowByID(followID string) error {
+	tx := f.db.Delete(&models.Follow{}, "id = ?", followID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrFollowNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteFollowsByUserID delete all the follows of a user and of their followers in the DB
+func (f *FollowRepository) DeleteFollowsByUserID(userID string) error {
+	return f.db.Delete(&models.Follow{}, "user_id = ? OR followed_id = ?", userID, userID).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// LikeStore is the interface implemented by LikeRepository
+type LikeStore interface {
+	GetLikeByID(like *models.Like, likeID string) error
+	DeleteLikesByUserID(userID string) error
+	DeleteLikesByPostID(postID string) error
+	DeleteLikesByBdaPostID(bdaPostID string) error
+	DeleteLikesByCommentID(commentID string) error
+}
+
+// LikeRepository is a repository for operations on likes shared by all liked resources
+type LikeRepository struct {
+	db *gorm.DB
+}
+
+// NewLikeRepository is to create a new like repository
+func NewLikeRepository(db *gorm.DB) *LikeRepository {
+	return &LikeRepository{db: db}
+}
+
+// GetLikeByID get a like by ID in the DB
+func (l *LikeRepository) GetLikeByID(like *models.Like, likeID string) error {
+	tx := l.db.First(like, "id = ?", likeID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrLikeNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteLikesByUserID delete all likes given by a specific user in the DB
+func (l *LikeRepository) DeleteLikesByUserID(userID string) error {
+	return l.db.Delete(&models.Like{}, "user_id = ?", userID).Error
+}
+
+// DeleteLikesByPostID delete all likes of a specific post in the DB
+func (l *LikeRepository) DeleteLikesByPostID(postID string) error {
+	return l.db.Delete(&models.Like{}, "post_id = ?", postID).Error
+}
+
+// DeleteLikesByBdaPostID delete all likes of a specific bda post in the DB
+func (l *LikeRepository) DeleteLikesByBdaPostID(bdaPostID string) error {
+	return l.db.Delete(&models.Like{}, "bda_post_id = ?", bdaPostID).Error
+}
+
+// DeleteLikesByCommentID delete all likes of a specific comment in the DB
+func (l *LikeRepository) DeleteLikesByCommentID(commentID string) error {
+	return l.db.Delete(&models.Like{}, "comment_id = ?", commentID).Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// LinkPreviewStore is the interface implemented by LinkPreviewRepository
+type LinkPreviewStore interface {
+	RelationStore
+	CreateLinkPreview(preview *models.LinkPreview) error
+	ListLinkPreviewsByPostID(previews *[]models.LinkPreview, postID string) error
+	ListPendingLinkPreviews(previews *[]models.LinkPreview, limit int) error
+	GetFetchedLinkPreview(preview *models.LinkPreview, url string, since time.Time) (bool, error)
+	UpdateLinkPreviewPosition(previewID string, position int) error
+	SaveFetchedLinkPreview(preview *models.LinkPreview) error
+	DeleteLinkPreviewByID(previewID string) error
+	DeleteLinkPreviewsByPostID(postID string) error
+}
+
+// LinkPreviewRepository is a repository for link preview resource
+type LinkPreviewRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkPreviewRepository is to create a new link preview repository
+func NewLinkPreviewRepository(db *gorm.DB) *LinkPreviewRepository {
+	return &LinkPreviewRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the
+// DB, the previews are listed in the order of the links in the posts
+func (l *LinkPreviewRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(l.db.Order("position"), related, column, keys)
+}
+
+// CreateLinkPreview create a link preview in the DB
+func (l *LinkPreviewRepository) CreateLinkPreview(preview *models.LinkPreview) error {
+	return l.db.Create(preview).Error
+}
+
+// ListLinkPreviewsByPostID list all the link previews of a post in their order in the DB
+func (l *LinkPreviewRepository) ListLinkPreviewsByPostID(previews *[]models.LinkPreview, postID string) error {
+	return l.db.Where("post_id = ?", postID).Order("position").Find(previews).Error
+}
+
+// ListPendingLinkPreviews list the oldest link previews waiting to be fetched in the DB
+func (l *LinkPreviewRepository) ListPendingLinkPreviews(previews *[]models.LinkPreview, limit int) error {
+	return l.db.Where("status = ?", models.PendingPreview).Order("created_at").Limit(limit).Find(previews).Error
+}
+
+// GetFetchedLinkPreview get the last preview of a link fetched since a date in the DB, it
+// returns false when the link has not been fetched since then
+func (l *LinkPreviewRepository) GetFetchedLinkPreview(preview *models.LinkPreview, url string, since time.Time) (bool, error) {
+	err := l.db.Where("url = ? AND status = ? AND fetched_at >= ?", url, models.FetchedPreview, since).
+		Order("fetched_at DESC").First(preview).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// UpdateLinkPreviewPosition update the position of a link preview in its post in the DB
+func (l *LinkPreviewRepository) UpdateLinkPreviewPosition(previewID string, position int) error {
+	return l.db.Model(&models.LinkPreview{}).Where("id = ?", previewID).UpdateColumn("position", position).Error
+}
+
+// SaveFetchedLinkPreview save the status and the metadata of a fetched link preview in
+// the DB, a preview deleted in the meantime is ignored
+func (l *LinkPreviewRepository) SaveFetchedLinkPreview(preview *models.LinkPreview) error {
+	return l.db.Model(preview).
+		Select("status", "title", "description", "image_url", "site_name", "fetched_at").
+		Updates(preview).Error
+}
+
+// DeleteLinkPreviewByID delete a link preview by ID in the DB
+func (l *LinkPreviewRepository) DeleteLinkPreviewByID(previewID string) error {
+	return l.db.Delete(&models.LinkPreview{}, "id = ?", previewID).Error
+}
+
+// DeleteLinkPreviewsByPostID delete all the link previews of a post in the DB
+func (l *LinkPreviewRepository) DeleteLinkPreviewsByPostID(postID string) error {
+	return l.db.Delete(&models.LinkPreview{}, "post_id = ?", postID).Error
+}
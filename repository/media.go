@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// ErrMediaNotFound is an error when resource is not found
+var (
+	ErrMediaNotFound = errors.New("media not found")
+)
+
+// MediaStore is the interface implemented by MediaRepository
+type MediaStore interface {
+	RelationStore
+	CreateMedia(media *models.Media) error
+	GetMediaByID(media *models.Media, mediaID string) error
+	GetMediaByUserAndHash(media *models.Media, userID string, hash string) error
+	ListMediaByUserID(media *[]models.Media, userID string, page Page) (PageInfo, error)
+	ListMediaHashesByUserID(userID string) ([]string, error)
+	CountMediaByHash(hash string) (int64, error)
+	DeleteMediaByID(mediaID string) error
+	DeleteMediaByUserID(userID string) error
+	ClearMediaReferences(mediaID string) error
+}
+
+// MediaRepository is a repository for media resource
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+// NewMediaRepository is to create a new media repository
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (m *MediaRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(m.db, related, column, keys)
+}
+
+// CreateMedia create a media in the DB
+func (m *MediaRepository) CreateMedia(media *models.Media) error {
+	return m.db.Create(media).Error
+}
+
+// GetMediaByID get a media by ID in the DB
+func (m *MediaRepository) GetMediaByID(media *models.Media, mediaID string) error {
+	tx := m.db.First(media, "id = ?", mediaID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrMediaNotFound
+	}
+
+	return tx.Error
+}
+
+// GetMediaByUserAndHash get the media of a user with a specific content in the DB
+func (m *MediaRepository) GetMediaByUserAndHash(media *models.Media, userID string, hash string) error {
+	tx := m.db.First(media, "user_id = ? AND hash = ?", userID, hash)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrMediaNotFound
+	}
+
+	return tx.Error
+}
+
+// ListMediaByUserID list a page of the media uploaded by a specific user in the DB
+func (m *MediaRepository) ListMediaByUserID(media *[]models.Media, userID string, page Page) (PageInfo, error) {
+	return paginate(m.db.Where("user_id = ?", userID), media, page)
+}
+
+// ListMediaHashesByUserID list the hashes of the media uploaded by a specific user in the DB
+func (m *MediaRepository) ListMediaHashesByUserID(userID string) ([]string, error) {
+	hashes := []string{}
+	err := m.db.Model(&models.Media{}).Where("user_id = ?", userID).Distinct().Pluck("hash", &hashes).Error
+
+	return hashes, err
+}
+
+// CountMediaByHash count the media sharing the files of a content in the DB
+func (m *MediaRepository) CountMediaByHash(hash string) (int64, error) {
+	var count int64
+	err := m.db.Model(&models.Media{}).Where("hash = ?", hash).Count(&count).Error
+
+	return count, err
+}
+
+// DeleteMediaByID delete a media by ID in the DB
+func (m *MediaRepository) DeleteMediaByID(mediaID string) error {
+	tx := m.db.Delete(&models.Media{}, "id = ?", mediaID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrMediaNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteMediaByUserID delete the media uploaded by a user in the DB, the pictures of the
+// promos are kept without user
+func (m *MediaRepository) DeleteMediaByUserID(userID string) error {
+	promoPictures := m.db.Model(&models.Promo{}).Select("profile_pic_id").Where("profile_pic_id IS NOT NULL")
+
+	err := m.db.Model(&models.Media{}).Where("user_id = ? AND id IN (?)", userID, promoPictures).UpdateColumn("user_id", uuid.Nil).Error
+	if err != nil {
+		return err
+	}
+
+	return m.db.Delete(&models.Media{}, "user_id = ?", userID).Error
+}
+
+// ClearMediaReferences remove a media from the pictures of the users and promos in the DB
+func (m *MediaRepository) ClearMediaReferences(mediaID string) error {
+	err := m.db.Model(&models.User{}).Where("profil_pic_id = ?", mediaID).UpdateColumn("profil_pic_id", nil).Error
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Model(&models.User{}).Where("cover_pic_id = ?", mediaID).UpdateColumn("cover_pic_id", nil).Error
+	if err != nil {
+		return err
+	}
+
+	return m.db.Model(&models.Promo{}).Where("profile_pic_id = ?", mediaID).UpdateColumn("profile_pic_id", nil).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// MentionStore is the interface implemented by MentionRepository
+type MentionStore interface {
+	RelationStore
+	CreateMention(mention *models.Mention) error
+	ListMentionsBySource(mentions *[]models.Mention, sourceType models.MentionSource, sourceID string) error
+	DeleteMentionsByIDs(mentionIDs []string) error
+	DeleteMentionsBySource(sourceType models.MentionSource, sourceID string) error
+	DeleteMentionsByUserID(userID string) error
+}
+
+// MentionRepository is a repository for mention resource
+type MentionRepository struct {
+	db *gorm.DB
+}
+
+// NewMentionRepository is to create a new mention repository
+func NewMentionRepository(db *gorm.DB) *MentionRepository {
+	return &MentionRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (m *MentionRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(m.db, related, column, keys)
+}
+
+// CreateMention create a mention in the DB
+func (m *MentionRepository) CreateMention(mention *models.Mention) error {
+	return m.db.Create(mention).Error
+}
+
+// ListMentionsBySource list all the mentions of a resource in the DB
+func (m *MentionRepository) ListMentionsBySource(mentions *[]models.Mention, sourceType models.MentionSource, sourceID string) error {
+	return m.db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Find(mentions).Error
+}
+
+// DeleteMentionsByIDs delete mentions by ID in the DB
+func (m *MentionRepository) DeleteMentionsByIDs(mentionIDs []string) error {
+	if len(mentionIDs) == 0 {
+		return nil
+	}
+
+	return m.db.Delete(&models.Mention{}, "id IN ?", mentionIDs).Error
+}
+
+// DeleteMentionsBySource delete all the mentions of a resource in the DB
+func (m *MentionRepository) DeleteMentionsBySource(sourceType models.MentionSource, sourceID string) error {
+	return m.db.Delete(&models.Mention{}, "source_type = ? AND source_id = ?", sourceType, sourceID).Error
+}
+
+// DeleteMentionsByUserID delete the mentions of a user and the mentions in the content
+// of the user in the DB
+func (m *MentionRepository) DeleteMentionsByUserID(userID string) error {
+	return m.db.Where("user_id = ?", userID).
+		Or("source_type = ? AND source_id IN (?)", models.PostMention, m.db.Model(&models.Post{}).Select("id").Where("user_id = ?", userID)).
+		Or("source_type = ? AND source_id IN (?)", models.CommentMention, m.db.Model(&models.Comment{}).Select("id").Where("user_id = ?", userID)).
+		Or("source_type = ? AND source_id IN (?)", models.TopicMention, m.db.Model(&models.Topic{}).Select("id").Where("user_id = ?", userID)).
+		Or("source_type = ? AND source_id IN (?)", models.BdaPostMention, m.db.Model(&models.BdaPost{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.Mention{}).Error
+}
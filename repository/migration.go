@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ada-social-network/api/markdown"
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// ErrOutdatedSchema is an error when the tables of the DB do not match the models
+var ErrOutdatedSchema = errors.New("the database schema is outdated, run the migrate command first")
+
+// Migrate create or update the tables of all the models in the DB, then move the data
+// stored by previous versions to the new columns
+func Migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(models.All()...)
+	if err != nil {
+		return err
+	}
+
+	err = migrateCommentTargets(db)
+	if err != nil {
+		return err
+	}
+
+	err = migrateContentHTML(db)
+	if err != nil {
+		return err
+	}
+
+	err = migrateNotificationActors(db)
+	if err != nil {
+		return err
+	}
+
+	return migrateLegacyPictures(db)
+}
+
+// CheckSchema check that the tables and columns of all the models exist in the DB,
+// without changing it
+func CheckSchema(db *gorm.DB) error {
+	missing := []string{}
+
+	for _, model := range models.All() {
+		stmt := &gorm.Statement{DB: db}
+		err := stmt.Parse(model)
+		if err != nil {
+			return err
+		}
+
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			missing = append(missing, table)
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(table, field.DBName) {
+				missing = append(missing, table+"."+field.DBName)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s missing", ErrOutdatedSchema, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// migrateCommentTargets set the target of the comments created when only bda posts could
+// be commented. The bda_post_id column is kept, it is not read anymore.
+func migrateCommentTargets(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Comment{}, "bda_post_id") {
+		return nil
+	}
+
+	return db.Exec("UPDATE comments SET target_type = ?, target_id = bda_post_id WHERE COALESCE(target_type, '') = ''", models.BdaPostComment).Error
+}
+
+// contentRow is the content of a resource rendered by migrateContentHTML
+type contentRow struct {
+	ID      string
+	Content string
+}
+
+// migrateContentHTML render the contents saved before they were rendered in HTML, or
+// whose code blocks were not highlighted yet. The update date and version of the
+// resources are kept.
+func migrateContentHTML(db *gorm.DB) error {
+	for _, table := range []string{"topics", "posts", "bda_posts", "comments"} {
+		rows := []contentRow{}
+
+		err := db.Table(table).
+			Select("id", "content").
+			Where("COALESCE(content_html, '') = '' AND COALESCE(content, '') <> ''").
+			Or("content_html LIKE ?", "%<pre><code%").
+			FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+				for _, row := range rows {
+					err := db.Table(table).Where("id = ?", row.ID).UpdateColumn("content_html", markdown.Render(row.Content)).Error
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateNotificationActors record the actor of the notifications created before their
+// distinct actors were counted, only their last actor is known and all the events are
+// given to them
+func migrateNotificationActors(db *gorm.DB) error {
+	notifications := []models.Notification{}
+
+	return db.Select("id", "actor_id", "count").
+		Where("NOT EXISTS (SELECT 1 FROM notification_actors a WHERE a.notification_id = notifications.id)").
+		FindInBatches(&notifications, 500, func(tx *gorm.DB, batch int) error {
+			actors := make([]models.NotificationActor, 0, len(notifications))
+			for _, notification := range notifications {
+				actors = append(actors, models.NotificationActor{NotificationID: notification.ID, ActorID: notification.ActorID, Count: notification.Count})
+			}
+
+			return db.Create(&actors).Error
+		}).Error
+}
+
+// LegacyPicture is a column of the pictures saved as free text before the media were
+// uploaded, replaced by the id of a media
+type LegacyPicture struct {
+	Table       string
+	Column      string
+	MediaColumn string
+}
+
+// LegacyPictures are the columns of the pictures saved before the media were uploaded
+var LegacyPictures = []LegacyPicture{
+	{Table: "users", Column: "profil_pic", MediaColumn: "profil_pic_id"},
+	{Table: "users", Column: "cover_pic", MediaColumn: "cover_pic_id"},
+	{Table: "promos", Column: "profile_pic", MediaColumn: "profile_pic_id"},
+}
+
+// LegacyMediaID returns the id of the media of a legacy picture, given as the id of the
+// media or as its URL, e.g. https://api.example.com/media/<id>/file?size=small
+func LegacyMediaID(picture string) (uuid.UUID, bool) {
+	picture = strings.SplitN(picture, "?", 2)[0]
+
+	segments := strings.Split(strings.Trim(picture, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		id, err := uuid.FromString(segments[i])
+		if err == nil {
+			return id, len(segments) == 1 || (i > 0 && segments[i-1] == "media")
+		}
+	}
+
+	return uuid.Nil, false
+}
+
+// legacyPictureRow is a picture migrated by migrateLegacyPictures
+type legacyPictureRow struct {
+	ID      string
+	Picture string
+	MediaID *string
+}
+
+// migrateLegacyPictures move the pictures saved before the media were uploaded to their
+// media column when they reference an existing media, the other values can not be
+// converted as their files were never uploaded. The legacy values are cleared, the
+// columns are kept, and the number of pictures moved and cleared is logged.
+func migrateLegacyPictures(db *gorm.DB) error {
+	for _, legacy := range LegacyPictures {
+		if !db.Migrator().HasColumn(legacy.Table, legacy.Column) {
+			continue
+		}
+
+		rows := []legacyPictureRow{}
+		err := db.Table(legacy.Table).
+			Select("id", legacy.Column+" AS picture", legacy.MediaColumn+" AS media_id").
+			Where("COALESCE(" + legacy.Column + ", '') <> ''").
+			Find(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		moved := 0
+		for _, row := range rows {
+			updates := map[string]interface{}{legacy.Column: ""}
+
+			// a media chosen since the upgrade is kept
+			mediaID, ok := LegacyMediaID(row.Picture)
+			if ok && row.MediaID == nil {
+				var count int64
+				err = db.Model(&models.Media{}).Where("id = ?", mediaID).Count(&count).Error
+				if err != nil {
+					return err
+				}
+
+				if count > 0 {
+					updates[legacy.MediaColumn] = mediaID
+					moved++
+				}
+			}
+
+			err = db.Table(legacy.Table).Where("id = ?", row.ID).UpdateColumns(updates).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(rows) > 0 {
+			log.Printf("migration: %d pictures of %s.%s moved to %s, %d cleared as they are not uploaded media",
+				moved, legacy.Table, legacy.Column, legacy.MediaColumn, len(rows)-moved)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// legacyUser is a user of a previous version, with the pictures saved as free text
+type legacyUser struct {
+	models.Base
+	Email     string
+	ProfilPic string
+	CoverPic  string
+}
+
+func (legacyUser) TableName() string {
+	return "users"
+}
+
+// legacyPromo is a promo of a previous version, with the picture saved as free text
+type legacyPromo struct {
+	models.Base
+	Name       string
+	ProfilePic string
+}
+
+func (legacyPromo) TableName() string {
+	return "promos"
+}
+
+func TestMigrateLegacyPictures(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(&legacyUser{}, &legacyPromo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(&models.Media{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	media := &models.Media{Hash: "legacy"}
+	db.Create(media)
+
+	userID, promoID := uuid.NewV4(), uuid.NewV4()
+	db.Exec("INSERT INTO users (id, email, profil_pic, cover_pic) VALUES (?, ?, ?, ?)",
+		userID, "ada@legacy.dev", "/media/"+media.ID.String()+"/file?size=small", "https://example.com/cover.png")
+	db.Exec("INSERT INTO promos (id, name, profile_pic) VALUES (?, ?, ?)", promoID, "legacy", uuid.NewV4().String())
+
+	err = Migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{}
+	db.First(user, "id = ?", userID)
+	if user.ProfilPicID == nil || !uuid.Equal(*user.ProfilPicID, media.ID) || user.CoverPicID != nil {
+		t.Errorf("Migrate user pictures want:the media as profil pic and no cover pic, got:%v %v", user.ProfilPicID, user.CoverPicID)
+	}
+
+	promo := &models.Promo{}
+	db.First(promo, "id = ?", promoID)
+	if promo.ProfilePicID != nil {
+		t.Errorf("Migrate promo picture of a missing media want:none, got:%v", promo.ProfilePicID)
+	}
+
+	var legacy int64
+	db.Table("users").Where("profil_pic <> '' OR cover_pic <> ''").Count(&legacy)
+	if legacy != 0 {
+		t.Errorf("Migrate should clear the legacy pictures, got %d users", legacy)
+	}
+}
+
+func TestLegacyMediaID(t *testing.T) {
+	id := "80a08d36-cfea-4898-aee3-6902fa562f0b"
+
+	tests := []struct {
+		picture string
+		want    bool
+	}{
+		{picture: id, want: true},
+		{picture: "https://api.ada.dev/media/" + id + "/file?size=large", want: true},
+		{picture: "/media/" + id, want: true},
+		{picture: "https://example.com/pictures/" + id + ".png", want: false},
+		{picture: "https://example.com/" + id + "/cover.png", want: false},
+		{picture: "", want: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := LegacyMediaID(tt.picture)
+		if ok != tt.want || (ok && got.String() != id) {
+			t.Errorf("LegacyMediaID(%q) = %v %v, want %v", tt.picture, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotificationNotFound is an error when resource is not found
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// NotificationStore is the interface implemented by NotificationRepository
+type NotificationStore interface {
+	RelationStore
+	Notify(notification *models.Notification) error
+	Unnotify(notification *models.Notification) error
+	ListNotificationsByUserID(notifications *[]models.Notification, userID string, unreadOnly bool, page Page) (PageInfo, error)
+	CountUnreadNotifications(userID string) (int64, error)
+	MarkNotificationRead(notification *models.Notification, userID string, notificationID string) error
+	MarkAllNotificationsRead(userID string) error
+	ListNotificationPreferences(preferences *[]models.NotificationPreference, userID string) error
+	SaveNotificationPreference(preference *models.NotificationPreference) error
+	DeleteNotificationsByUserID(userID string) error
+	DeleteNotificationsByTarget(notifications *[]models.Notification, targetType models.NotificationTarget, targetID string) error
+}
+
+// NotificationRepository is a repository for notification resource
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository is to create a new notification repository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (n *NotificationRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(n.db, related, column, keys)
+}
+
+// Notify create a notification in the DB unless the user disabled its type. An unread
+// notification of the same type on the same target is updated and loaded instead, so
+// repeated events are grouped and their actors are counted once.
+func (n *NotificationRepository) Notify(notification *models.Notification) error {
+	var disabled int64
+	err := n.db.Model(&models.NotificationPreference{}).
+		Where("user_id = ? AND type = ? AND enabled = ?", notification.UserID, notification.Type, false).
+		Count(&disabled).Error
+	if err != nil || disabled > 0 {
+		return err
+	}
+
+	actorID := notification.ActorID
+	unread := n.db.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ? AND target_type = ? AND target_id = ? AND read_at IS NULL",
+			notification.UserID, notification.Type, notification.TargetType, notification.TargetID)
+
+	tx := unread.Session(&gorm.Session{}).UpdateColumns(map[string]interface{}{
+		"actor_id":   actorID,
+		"count":      gorm.Expr("count + 1"),
+		"updated_at": time.Now(),
+	})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		notification.Count = 1
+		notification.ActorsCount = 1
+
+		err = n.db.Create(notification).Error
+		if err != nil {
+			return err
+		}
+
+		return n.db.Create(&models.NotificationActor{NotificationID: notification.ID, ActorID: actorID, Count: 1}).Error
+	}
+
+	err = unread.Session(&gorm.Session{}).First(notification).Error
+	if err != nil {
+		return err
+	}
+
+	actor := &models.NotificationActor{}
+	tx = n.db.Where("notification_id = ? AND actor_id = ?", notification.ID, actorID).Limit(1).Find(actor)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// the actor repeats the event, e.g. comments again a post, they become the last actor
+	if tx.RowsAffected > 0 {
+		return n.db.Model(actor).UpdateColumns(map[string]interface{}{
+			"count":      gorm.Expr("count + 1"),
+			"updated_at": time.Now(),
+		}).Error
+	}
+
+	err = n.db.Create(&models.NotificationActor{NotificationID: notification.ID, ActorID: actorID, Count: 1}).Error
+	if err != nil {
+		return err
+	}
+
+	notification.ActorsCount++
+	return n.db.Model(notification).UpdateColumn("actors_count", notification.ActorsCount).Error
+}
+
+// Unnotify withdraw the event of an actor from the unread notification of the same type
+// on the same target in the DB, e.g. when a like is removed. The notification is updated
+// and loaded, or deleted when it has no actor left and its actors count is set to 0.
+func (n *NotificationRepository) Unnotify(notification *models.Notification) error {
+	actorID := notification.ActorID
+
+	tx := n.db.Where("user_id = ? AND type = ? AND target_type = ? AND target_id = ? AND read_at IS NULL",
+		notification.UserID, notification.Type, notification.TargetType, notification.TargetID).
+		Limit(1).Find(notification)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return n.removeActor(notification, actorID)
+}
+
+// removeActor remove an actor and their events from a notification in the DB, the last of
+// the other actors becomes the actor of the notification
+func (n *NotificationRepository) removeActor(notification *models.Notification, actorID interface{}) error {
+	actor := &models.NotificationActor{}
+	tx := n.db.Where("notification_id = ? AND actor_id = ?", notification.ID, actorID).Limit(1).Find(actor)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	err := n.db.Delete(actor).Error
+	if err != nil {
+		return err
+	}
+
+	last := &models.NotificationActor{}
+	tx = n.db.Where("notification_id = ?", notification.ID).Order("updated_at DESC").Limit(1).Find(last)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		notification.ActorsCount = 0
+		return n.db.Delete(notification).Error
+	}
+
+	notification.ActorID = last.ActorID
+	notification.ActorsCount--
+	notification.Count -= actor.Count
+	if notification.Count < notification.ActorsCount {
+		notification.Count = notification.ActorsCount
+	}
+
+	return n.db.Model(notification).UpdateColumns(map[string]interface{}{
+		"actor_id":     notification.ActorID,
+		"actors_count": notification.ActorsCount,
+		"count":        notification.Count,
+	}).Error
+}
+
+// ListNotificationsByUserID list a page of the notifications of a specific user in the DB
+func (n *NotificationRepository) ListNotificationsByUserID(notifications *[]models.Notification, userID string, unreadOnly bool, page Page) (PageInfo, error) {
+	db := n.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+
+	return paginate(db, notifications, page)
+}
+
+// CountUnreadNotifications count the unread notifications of a specific user in the DB
+func (n *NotificationRepository) CountUnreadNotifications(userID string) (int64, error) {
+	var count int64
+	err := n.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+
+	return count, err
+}
+
+// MarkNotificationRead mark a notification of a specific user as read in the DB
+func (n *NotificationRepository) MarkNotificationRead(notification *models.Notification, userID string, notificationID string) error {
+	tx := n.db.First(notification, "id = ? AND user_id = ?", notificationID, userID)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return ErrNotificationNotFound
+		}
+
+		return tx.Error
+	}
+
+	if notification.ReadAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	notification.ReadAt = &now
+
+	return n.db.Model(notification).UpdateColumn("read_at", now).Error
+}
+
+// MarkAllNotificationsRead mark all the notifications of a specific user as read in the DB
+func (n *NotificationRepository) MarkAllNotificationsRead(userID string) error {
+	return n.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now()).Error
+}
+
+// ListNotificationPreferences list the notification preferences of a specific user in the DB
+func (n *NotificationRepository) ListNotificationPreferences(preferences *[]models.NotificationPreference, userID string) error {
+	return n.db.Where("user_id = ?", userID).Find(preferences).Error
+}
+
+// SaveNotificationPreference create or update the preference of a user for a type of
+// notifications in the DB
+func (n *NotificationRepository) SaveNotificationPreference(preference *models.NotificationPreference) error {
+	return n.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(preference).Error
+}
+
+// DeleteNotificationsByTarget delete the notifications about a resource with their actors
+// in the DB, e.g. when the resource is deleted. The deleted notifications are loaded.
+func (n *NotificationRepository) DeleteNotificationsByTarget(notifications *[]models.Notification, targetType models.NotificationTarget, targetID string) error {
+	err := n.db.Where("target_type = ? AND target_id = ?", targetType, targetID).Find(notifications).Error
+	if err != nil || len(*notifications) == 0 {
+		return err
+	}
+
+	notificationIDs := n.db.Model(&models.Notification{}).Select("id").Where("target_type = ? AND target_id = ?", targetType, targetID)
+
+	err = n.db.Delete(&models.NotificationActor{}, "notification_id IN (?)", notificationIDs).Error
+	if err != nil {
+		return err
+	}
+
+	return n.db.Delete(&models.Notification{}, "target_type = ? AND target_id = ?", targetType, targetID).Error
+}
+
+// DeleteNotificationsByUserID delete all the notifications and preferences of a user in
+// the DB, the user is removed from the actors of the notifications of the other users
+func (n *NotificationRepository) DeleteNotificationsByUserID(userID string) error {
+	notificationIDs := n.db.Model(&models.Notification{}).Select("id").Where("user_id = ?", userID)
+
+	err := n.db.Delete(&models.NotificationActor{}, "notification_id IN (?)", notificationIDs).Error
+	if err != nil {
+		return err
+	}
+
+	err = n.db.Delete(&models.Notification{}, "user_id = ?", userID).Error
+	if err != nil {
+		return err
+	}
+
+	notifications := []models.Notification{}
+	err = n.db.Where("id IN (?)", n.db.Model(&models.NotificationActor{}).Select("notification_id").Where("actor_id = ?", userID)).
+		Find(&notifications).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		err = n.removeActor(&notifications[i], userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// notifications created before their actors were recorded
+	err = n.db.Delete(&models.Notification{}, "actor_id = ?", userID).Error
+	if err != nil {
+		return err
+	}
+
+	return n.db.Delete(&models.NotificationPreference{}, "user_id = ?", userID).Error
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ada-social-network/api/models"
+)
+
+const (
+	// DefaultPageSize is the number of items of a page when no limit is requested
+	DefaultPageSize = 20
+	// MaxPageSize is the maximum number of items of a page, larger limits are lowered to it
+	MaxPageSize = 100
+)
+
+var (
+	// ErrInvalidCursor is an error when a cursor can not be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidOperator is an error when a filter operator can not be applied to a field
+	ErrInvalidOperator = errors.New("invalid filter operator")
+)
+
+// Operator is a comparison of a filter
+type Operator string
+
+const (
+	// Equal keeps items with a field equal to the value
+	Equal Operator = "eq"
+	// NotEqual keeps items with a field different from the value
+	NotEqual Operator = "ne"
+	// GreaterThan keeps items with a field greater than the value
+	GreaterThan Operator = "gt"
+	// GreaterThanOrEqual keeps items with a field greater than or equal to the value
+	GreaterThanOrEqual Operator = "gte"
+	// LessThan keeps items with a field less than the value
+	LessThan Operator = "lt"
+	// LessThanOrEqual keeps items with a field less than or equal to the value
+	LessThanOrEqual Operator = "lte"
+	// In keeps items with a field equal to one of the values
+	In Operator = "in"
+	// Contains keeps items with a text field containing the value
+	Contains Operator = "contains"
+)
+
+// Operators returns the operators which can be applied to a type of field
+func Operators(fieldType models.FieldType) []Operator {
+	switch fieldType {
+	case models.StringField:
+		return []Operator{Equal, NotEqual, In, Contains}
+	case models.UUIDField:
+		return []Operator{Equal, NotEqual, In}
+	case models.TimeField, models.IntField:
+		return []Operator{Equal, NotEqual, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual}
+	default:
+		return []Operator{Equal, NotEqual}
+	}
+}
+
+// Filter keeps the items of a list with a field matching a value, the value of the In
+// operator is a slice of values
+type Filter struct {
+	Field    models.Field
+	Operator Operator
+	Value    interface{}
+}
+
+// Sort is an order of a list on a field
+type Sort struct {
+	Field models.Field
+	Desc  bool
+}
+
+// Page is a request for a slice of a filtered and sorted list
+type Page struct {
+	// Limit is the maximum number of items, DefaultPageSize when zero and at most MaxPageSize
+	Limit int
+	// After is the position of the last item of the previous page, nil for the first page
+	After *Cursor
+	// Filters must all match the items of the list
+	Filters []Filter
+	// Sort is the order of the list, by creation date when empty. Items are always
+	// ordered by id last, so the order is stable.
+	Sort []Sort
+}
+
+// size returns the number of items of the page
+func (p Page) size() int {
+	if p.Limit <= 0 {
+		return DefaultPageSize
+	}
+
+	if p.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return p.Limit
+}
+
+// order returns the sort of the page ending with the id
+func (p Page) order() []Sort {
+	order := p.Sort
+	if len(order) == 0 {
+		order = []Sort{{Field: models.Field{Column: "created_at", Type: models.TimeField}}}
+	}
+
+	if order[len(order)-1].Field.Column != "id" {
+		order = append(order[:len(order):len(order)], Sort{Field: models.Field{Column: "id", Type: models.UUIDField}})
+	}
+
+	return order
+}
+
+// signature identifies the sort of a list, so a cursor is not used with another sort
+func signature(order []Sort) string {
+	columns := make([]string, 0, len(order))
+	for _, sort := range order {
+		if sort.Desc {
+			columns = append(columns, "-"+sort.Field.Column)
+		} else {
+			columns = append(columns, sort.Field.Column)
+		}
+	}
+
+	return strings.Join(columns, ",")
+}
+
+// Cursor is the position of an item in a sorted list, it holds the values of the
+// sort fields of the item
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// Encode returns the opaque representation of the cursor given to clients
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decode a cursor returned by Encode for a list sorted by sort
+func DecodeCursor(s string, sort []Sort) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	raw := struct {
+		Sort   string            `json:"s"`
+		Values []json.RawMessage `json:"v"`
+	}{}
+	err = json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	order := Page{Sort: sort}.order()
+	if raw.Sort != signature(order) || len(raw.Values) != len(order) {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{Sort: raw.Sort}
+	for i, value := range raw.Values {
+		v, err := decodeValue(value, order[i].Field.Type)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		cursor.Values = append(cursor.Values, v)
+	}
+
+	return cursor, nil
+}
+
+// decodeValue decode a JSON value to the go type of a field
+func decodeValue(b json.RawMessage, fieldType models.FieldType) (interface{}, error) {
+	var err error
+	switch fieldType {
+	case models.UUIDField:
+		v := uuid.UUID{}
+		err = json.Unmarshal(b, &v)
+		return v, err
+	case models.TimeField:
+		v := time.Time{}
+		err = json.Unmarshal(b, &v)
+		return v, err
+	case models.IntField:
+		v := 0
+		err = json.Unmarshal(b, &v)
+		return v, err
+	case models.BoolField:
+		v := false
+		err = json.Unmarshal(b, &v)
+		return v, err
+	default:
+		v := ""
+		err = json.Unmarshal(b, &v)
+		return v, err
+	}
+}
+
+// PageInfo describes a page of a list
+type PageInfo struct {
+	// Total is the number of items of the whole filtered list
+	Total int64
+	// Next is the cursor of the next page, nil for the last page
+	Next *Cursor
+}
+
+// filter add the conditions of filters to db
+func filter(db *gorm.DB, filters []Filter) (*gorm.DB, error) {
+	for _, f := range filters {
+		column := clause.Column{Name: f.Field.Column}
+		value := f.Value
+		// dates are stored in the local time zone of the server, they must be compared
+		// in the same time zone
+		if t, ok := value.(time.Time); ok {
+			value = t.Local()
+		}
+
+		var expression clause.Expression
+		switch f.Operator {
+		case Equal:
+			expression = clause.Eq{Column: column, Value: value}
+		case NotEqual:
+			expression = clause.Neq{Column: column, Value: value}
+		case GreaterThan:
+			expression = clause.Gt{Column: column, Value: value}
+		case GreaterThanOrEqual:
+			expression = clause.Gte{Column: column, Value: value}
+		case LessThan:
+			expression = clause.Lt{Column: column, Value: value}
+		case LessThanOrEqual:
+			expression = clause.Lte{Column: column, Value: value}
+		case In:
+			values, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: %s expects a list of values", ErrInvalidOperator, f.Operator)
+			}
+			expression = clause.IN{Column: column, Values: values}
+		case Contains:
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s expects a text", ErrInvalidOperator, f.Operator)
+			}
+			expression = clause.Expr{SQL: "? LIKE ? ESCAPE '\\'", Vars: []interface{}{column, "%" + escapeLike(s) + "%"}}
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidOperator, f.Operator)
+		}
+
+		db = db.Where(expression)
+	}
+
+	return db, nil
+}
+
+// escapeLike escape the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// after add to db the condition to keep the items after the cursor, items are after
+// the cursor when their first different sort field is after the cursor value
+func after(db *gorm.DB, order []Sort, cursor *Cursor) (*gorm.DB, error) {
+	if cursor.Sort != signature(order) || len(cursor.Values) != len(order) {
+		return nil, ErrInvalidCursor
+	}
+
+	alternatives := make([]clause.Expression, 0, len(order))
+	for i, sort := range order {
+		conditions := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Name: order[j].Field.Column}, Value: cursor.Values[j]})
+		}
+
+		column := clause.Column{Name: sort.Field.Column}
+		if sort.Desc {
+			conditions = append(conditions, clause.Lt{Column: column, Value: cursor.Values[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: column, Value: cursor.Values[i]})
+		}
+
+		alternatives = append(alternatives, clause.And(conditions...))
+	}
+
+	return db.Where(clause.Or(alternatives...)), nil
+}
+
+// paginate find a page of the records matching db in dest, a pointer to a slice of
+// models. Records are filtered and sorted as requested by the page.
+func paginate(db *gorm.DB, dest interface{}, page Page) (PageInfo, error) {
+	info := PageInfo{}
+	order := page.order()
+
+	db, err := filter(db.Session(&gorm.Session{}), page.Filters)
+	if err != nil {
+		return info, err
+	}
+
+	err = db.Model(dest).Count(&info.Total).Error
+	if err != nil {
+		return info, err
+	}
+
+	query := db
+	for _, sort := range order {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Field.Column}, Desc: sort.Desc})
+	}
+
+	if page.After != nil {
+		query, err = after(query, order, page.After)
+		if err != nil {
+			return info, err
+		}
+	}
+
+	// one more record is fetched to know if there is a next page
+	size := page.size()
+	tx := query.Limit(size + 1).Find(dest)
+	if tx.Error != nil {
+		return info, tx.Error
+	}
+
+	items := reflect.ValueOf(dest).Elem()
+	if items.Len() > size {
+		items.Set(items.Slice(0, size))
+
+		last := items.Index(size - 1)
+		cursor := &Cursor{Sort: signature(order)}
+		for _, sort := range order {
+			field := tx.Statement.Schema.LookUpField(sort.Field.Column)
+			if field == nil {
+				return info, fmt.Errorf("unknown sort column %s", sort.Field.Column)
+			}
+
+			cursor.Values = append(cursor.Values, last.FieldByIndex(field.StructField.Index).Interface())
+		}
+
+		info.Next = cursor
+	}
+
+	return info, nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPollNotFound is an error when resource is not found
+	ErrPollNotFound = errors.New("poll not found")
+	// ErrPollVoteNotFound is an error when a user has not voted in a poll
+	ErrPollVoteNotFound = errors.New("poll vote not found")
+	// ErrPollExists is an error when a resource already has a poll
+	ErrPollExists = errors.New("the resource already has a poll")
+	// ErrPollVoteExists is an error when a user has already voted in a poll
+	ErrPollVoteExists = errors.New("the user has already voted in the poll")
+)
+
+// PollStore is the interface implemented by PollRepository
+type PollStore interface {
+	CreatePoll(poll *models.Poll) error
+	GetPollByID(poll *models.Poll, pollID string) error
+	GetPollByTarget(poll *models.Poll, targetType models.PollTarget, targetID string) error
+	ClosePoll(poll *models.Poll, at time.Time) error
+	DeletePollByID(pollID string) error
+	DeletePollsByTarget(targetType models.PollTarget, targetID string) error
+	DeletePollsByUserID(userID string) error
+	CreatePollVote(vote *models.PollVote) error
+	GetPollVote(vote *models.PollVote, pollID string, userID string) error
+	DeletePollVote(pollID string, userID string) error
+	ListPollChoices(choices *[]models.PollChoice, pollID string) error
+}
+
+// PollRepository is a repository for poll resource
+type PollRepository struct {
+	db *gorm.DB
+}
+
+// NewPollRepository is to create a new poll repository
+func NewPollRepository(db *gorm.DB) *PollRepository {
+	return &PollRepository{db: db}
+}
+
+// CreatePoll create a poll with its options in their order in the DB
+func (p *PollRepository) CreatePoll(poll *models.Poll) error {
+	err := p.db.Create(poll).Error
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPollExists
+		}
+
+		return err
+	}
+
+	for i := range poll.Options {
+		poll.Options[i].PollID = poll.ID
+		poll.Options[i].Position = i
+	}
+
+	return p.db.Create(&poll.Options).Error
+}
+
+// GetPollByID get a poll with its options by ID in the DB
+func (p *PollRepository) GetPollByID(poll *models.Poll, pollID string) error {
+	return p.getPoll(poll, "id = ?", pollID)
+}
+
+// GetPollByTarget get the poll of a resource with its options in the DB
+func (p *PollRepository) GetPollByTarget(poll *models.Poll, targetType models.PollTarget, targetID string) error {
+	return p.getPoll(poll, "target_type = ? AND target_id = ?", targetType, targetID)
+}
+
+// getPoll get a poll matching a condition with its options in the DB
+func (p *PollRepository) getPoll(poll *models.Poll, query string, args ...interface{}) error {
+	tx := p.db.First(poll, append([]interface{}{query}, args...)...)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return ErrPollNotFound
+		}
+
+		return tx.Error
+	}
+
+	return p.db.Where("poll_id = ?", poll.ID).Order("position").Find(&poll.Options).Error
+}
+
+// ClosePoll set the deadline of a poll in the DB
+func (p *PollRepository) ClosePoll(poll *models.Poll, at time.Time) error {
+	poll.ClosesAt = &at
+	return updateVersioned(p.db, poll, &poll.Base, ErrPollNotFound)
+}
+
+// DeletePollByID delete a poll with its options and votes in the DB
+func (p *PollRepository) DeletePollByID(pollID string) error {
+	tx := p.db.Delete(&models.Poll{}, "id = ?", pollID)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrPollNotFound
+	}
+
+	return p.deletePollContent([]string{pollID})
+}
+
+// DeletePollsByTarget delete the poll of a resource with its options and votes in the DB
+func (p *PollRepository) DeletePollsByTarget(targetType models.PollTarget, targetID string) error {
+	return p.deletePolls(p.db.Where("target_type = ? AND target_id = ?", targetType, targetID))
+}
+
+// DeletePollsByUserID delete the polls created by a specific user with their options and
+// votes, and the votes of the user in the other polls in the DB
+func (p *PollRepository) DeletePollsByUserID(userID string) error {
+	err := p.deletePolls(p.db.Where("user_id = ?", userID))
+	if err != nil {
+		return err
+	}
+
+	err = p.db.Delete(&models.PollChoice{}, "user_id = ?", userID).Error
+	if err != nil {
+		return err
+	}
+
+	return p.db.Delete(&models.PollVote{}, "user_id = ?", userID).Error
+}
+
+// deletePolls delete the polls matching a query with their options and votes in the DB
+func (p *PollRepository) deletePolls(query *gorm.DB) error {
+	var pollIDs []string
+
+	err := query.Model(&models.Poll{}).Pluck("id", &pollIDs).Error
+	if err != nil {
+		return err
+	}
+
+	if len(pollIDs) == 0 {
+		return nil
+	}
+
+	err = p.db.Delete(&models.Poll{}, "id IN ?", pollIDs).Error
+	if err != nil {
+		return err
+	}
+
+	return p.deletePollContent(pollIDs)
+}
+
+// deletePollContent delete the options and the votes of deleted polls in the DB
+func (p *PollRepository) deletePollContent(pollIDs []string) error {
+	for _, model := range []interface{}{&models.PollChoice{}, &models.PollVote{}, &models.PollOption{}} {
+		err := p.db.Delete(model, "poll_id IN ?", pollIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreatePollVote create the ballot of a user with their choices in the DB, a user votes
+// once by poll
+func (p *PollRepository) CreatePollVote(vote *models.PollVote) error {
+	err := p.db.Create(vote).Error
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPollVoteExists
+		}
+
+		return err
+	}
+
+	choices := make([]models.PollChoice, 0, len(vote.OptionIDs))
+	for _, optionID := range vote.OptionIDs {
+		choices = append(choices, models.PollChoice{VoteID: vote.ID, OptionID: optionID, PollID: vote.PollID, UserID: vote.UserID})
+	}
+
+	return p.db.Create(&choices).Error
+}
+
+// GetPollVote get the ballot of a user in a poll with their choices in the DB
+func (p *PollRepository) GetPollVote(vote *models.PollVote, pollID string, userID string) error {
+	tx := p.db.First(vote, "poll_id = ? AND user_id = ?", pollID, userID)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return ErrPollVoteNotFound
+		}
+
+		return tx.Error
+	}
+
+	return p.db.Model(&models.PollChoice{}).Where("vote_id = ?", vote.ID).Pluck("option_id", &vote.OptionIDs).Error
+}
+
+// DeletePollVote delete the ballot of a user in a poll with their choices in the DB
+func (p *PollRepository) DeletePollVote(pollID string, userID string) error {
+	tx := p.db.Delete(&models.PollVote{}, "poll_id = ? AND user_id = ?", pollID, userID)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrPollVoteNotFound
+	}
+
+	return p.db.Delete(&models.PollChoice{}, "poll_id = ? AND user_id = ?", pollID, userID).Error
+}
+
+// ListPollChoices list all the choices of the voters of a poll, the oldest first, in the DB
+func (p *PollRepository) ListPollChoices(choices *[]models.PollChoice, pollID string) error {
+	return p.db.Where("poll_id = ?", pollID).Order("created_at").Find(choices).Error
+}
+
+// isUniqueViolation returns true when an error is the violation of a unique index
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
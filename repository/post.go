@@ -14,6 +14,23 @@ var (
 	ErrLikeNotFound = errors.New("like not found")
 )
 
+// PostStore is the interface implemented by PostRepository
+type PostStore interface {
+	RelationStore
+	CreatePost(Post *models.Post) error
+	ListAllPosts(posts *[]models.Post) error
+	ListPostsByTopicID(posts *[]models.Post, topicID string, page Page) (PageInfo, error)
+	ListAllPostsByUserID(posts *[]models.Post, userID string) error
+	GetPostByID(post *models.Post, postID string) error
+	UpdatePost(post *models.Post) error
+	DeletePostByID(postID string) error
+	DeletePostsByUserID(userID string) error
+	CheckLikeByUserAndPostID(like *models.Like, userID uuid.UUID, postID uuid.UUID) (bool, error)
+	CreateLike(like *models.Like) error
+	ListLikesByPostID(likes *[]models.Like, postID string, page Page) (PageInfo, error)
+	DeleteLikeByID(likeID string) error
+}
+
 // PostRepository is a repository for post resource
 type PostRepository struct {
 	db *gorm.DB
@@ -24,6 +41,11 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (p *PostRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(p.db, related, column, keys)
+}
+
 // CreatePost create a post in the DB
 func (p *PostRepository) CreatePost(Post *models.Post) error {
 	return p.db.Create(Post).Error
@@ -34,9 +56,14 @@ func (p *PostRepository) ListAllPosts(posts *[]models.Post) error {
 	return p.db.Find(posts).Error
 }
 
-// ListAllPostsByTopicID list all posts of a specific topic in the DB
-func (p *PostRepository) ListAllPostsByTopicID(posts *[]models.Post, topicID string) error {
-	return p.db.Find(posts, "topic_id=?", topicID).Error
+// ListPostsByTopicID list a page of posts of a specific topic in the DB
+func (p *PostRepository) ListPostsByTopicID(posts *[]models.Post, topicID string, page Page) (PageInfo, error) {
+	return paginate(p.db.Where("topic_id = ?", topicID), posts, page)
+}
+
+// ListAllPostsByUserID list all posts of a specific user in the DB
+func (p *PostRepository) ListAllPostsByUserID(posts *[]models.Post, userID string) error {
+	return p.db.Find(posts, "user_id = ?", userID).Error
 }
 
 // GetPostByID get a post by id in the DB
@@ -49,9 +76,9 @@ func (p *PostRepository) GetPostByID(post *models.Post, postID string) error {
 	return tx.Error
 }
 
-// UpdatePost update a post in the DB
+// UpdatePost update a post in the DB if it has not been updated since it has been read
 func (p *PostRepository) UpdatePost(post *models.Post) error {
-	return p.db.Save(post).Error
+	return updateVersioned(p.db, post, &post.Base, ErrPostNotFound)
 }
 
 // DeletePostByID delete a post by ID in the DB
@@ -64,6 +91,11 @@ func (p *PostRepository) DeletePostByID(postID string) error {
 	return tx.Error
 }
 
+// DeletePostsByUserID delete all posts of a specific user in the DB
+func (p *PostRepository) DeletePostsByUserID(userID string) error {
+	return p.db.Delete(&models.Post{}, "user_id = ?", userID).Error
+}
+
 // CheckLikeByUserAndPostID will check if a like by this user already exist on a post
 func (p *PostRepository) CheckLikeByUserAndPostID(like *models.Like, userID uuid.UUID, postID uuid.UUID) (bool, error) {
 	tx := p.db.Where("user_id= ? AND post_id= ?", userID, postID).Find(like)
@@ -75,9 +107,9 @@ func (p *PostRepository) CreateLike(like *models.Like) error {
 	return p.db.Create(like).Error
 }
 
-// ListAllPostsByPostID list all likes of a specific post in the DB
-func (p *PostRepository) ListAllPostsByPostID(likes *[]models.Like, postID string) error {
-	return p.db.Find(likes, "post_id=?", postID).Error
+// ListLikesByPostID list a page of likes of a specific post in the DB
+func (p *PostRepository) ListLikesByPostID(likes *[]models.Like, postID string, page Page) (PageInfo, error) {
+	return paginate(p.db.Where("post_id = ?", postID), likes, page)
 }
 
 // DeleteLikeByID delete a like by ID in the DB
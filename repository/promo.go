@@ -12,6 +12,17 @@ var (
 	ErrPromoNotFound = errors.New("promo not found")
 )
 
+// PromoStore is the interface implemented by PromoRepository
+type PromoStore interface {
+	RelationStore
+	GetPromoByID(promo *models.Promo, promoID string) error
+	ListPromos(promos *[]models.Promo, page Page) (PageInfo, error)
+	ListUsersByPromoID(users *[]models.User, promoID string, page Page) (PageInfo, error)
+	CreatePromo(promo *models.Promo) error
+	UpdatePromo(promo *models.Promo) error
+	DeleteByPromoID(promoID string) error
+}
+
 // PromoRepository is a repository for promo resource
 type PromoRepository struct {
 	db *gorm.DB
@@ -22,6 +33,11 @@ func NewPromoRepository(db *gorm.DB) *PromoRepository {
 	return &PromoRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (p *PromoRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(p.db, related, column, keys)
+}
+
 // GetPromoByID get a promo by id in the DB
 func (p *PromoRepository) GetPromoByID(promo *models.Promo, promoID string) error {
 	tx := p.db.First(promo, "id = ?", promoID)
@@ -32,14 +48,14 @@ func (p *PromoRepository) GetPromoByID(promo *models.Promo, promoID string) erro
 	return tx.Error
 }
 
-// ListAllPromos list all promos in the DB
-func (p *PromoRepository) ListAllPromos(promos *[]models.Promo) error {
-	return p.db.Find(promos).Error
+// ListPromos list a page of promos in the DB
+func (p *PromoRepository) ListPromos(promos *[]models.Promo, page Page) (PageInfo, error) {
+	return paginate(p.db, promos, page)
 }
 
-// ListAllUsersByPromoID list all users of a specific Promo in the DB
-func (p *PromoRepository) ListAllUsersByPromoID(promos *[]models.User, promoID string) error {
-	return p.db.Find(promos, "promo_id=?", promoID).Error
+// ListUsersByPromoID list a page of users of a specific Promo in the DB
+func (p *PromoRepository) ListUsersByPromoID(users *[]models.User, promoID string, page Page) (PageInfo, error) {
+	return paginate(p.db.Where("promo_id = ?", promoID), users, page)
 }
 
 // CreatePromo create a promo in the DB
@@ -47,9 +63,9 @@ func (p *PromoRepository) CreatePromo(promo *models.Promo) error {
 	return p.db.Create(promo).Error
 }
 
-// UpdatePromo update a promo in the DB
+// UpdatePromo update a promo in the DB if it has not been updated since it has been read
 func (p *PromoRepository) UpdatePromo(promo *models.Promo) error {
-	return p.db.Save(promo).Error
+	return updateVersioned(p.db, promo, &promo.Base, ErrPromoNotFound)
 }
 
 // DeleteByPromoID delete a promo by ID in the DB
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RelationStore loads the resources related to a list of resources
+type RelationStore interface {
+	// ListRelated find in related, a pointer to a slice of models, the records with
+	// a column matching one of the keys
+	ListRelated(related interface{}, column string, keys []interface{}) error
+}
+
+// listRelated find the records with a column matching one of the keys in a single query
+func listRelated(db *gorm.DB, related interface{}, column string, keys []interface{}) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return db.Where(clause.IN{Column: clause.Column{Name: column}, Values: keys}).Find(related).Error
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is an error when a resource has been updated since it has been read
+var ErrVersionConflict = errors.New("resource has been modified since it has been read")
+
+// Repositories gathers all the repositories sharing the same database connection
+type Repositories struct {
+	Attachments   AttachmentStore
+	BdaPosts      BdaPostStore
+	Blocks        BlockStore
+	Categories    CategoryStore
+	Comments      CommentStore
+	Conversations ConversationStore
+	Feeds         FeedStore
+	Follows       FollowStore
+	Likes         LikeStore
+	LinkPreviews  LinkPreviewStore
+	Media         MediaStore
+	Mentions      MentionStore
+	Notifications NotificationStore
+	Polls         PollStore
+	Posts         PostStore
+	Promos        PromoStore
+	Search        SearchStore
+	Snippets      SnippetStore
+	Subscriptions SubscriptionStore
+	Tags          TagStore
+	Topics        TopicStore
+	Users         UserStore
+	// Events are published once the unit of work is committed
+	Events realtime.Publisher
+}
+
+// NewRepositories is to create all the repositories on top of a database connection
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Attachments:   NewAttachmentRepository(db),
+		BdaPosts:      NewBdaPostRepository(db),
+		Blocks:        NewBlockRepository(db),
+		Categories:    NewCategoryRepository(db),
+		Comments:      NewCommentRepository(db),
+		Conversations: NewConversationRepository(db),
+		Feeds:         NewFeedRepository(db),
+		Follows:       NewFollowRepository(db),
+		Likes:         NewLikeRepository(db),
+		LinkPreviews:  NewLinkPreviewRepository(db),
+		Media:         NewMediaRepository(db),
+		Mentions:      NewMentionRepository(db),
+		Notifications: NewNotificationRepository(db),
+		Polls:         NewPollRepository(db),
+		Posts:         NewPostRepository(db),
+		Promos:        NewPromoRepository(db),
+		Search:        NewSearchRepository(db),
+		Snippets:      NewSnippetRepository(db),
+		Subscriptions: NewSubscriptionRepository(db),
+		Tags:          NewTagRepository(db),
+		Topics:        NewTopicRepository(db),
+		Users:         NewUserRepository(db),
+		Events:        realtime.NewBuffer(),
+	}
+}
+
+// UnitOfWork runs several repository operations as a single unit
+type UnitOfWork interface {
+	// Transaction calls fn with repositories bound to a transaction, the transaction
+	// is committed if fn returns nil and rolled back otherwise
+	Transaction(fn func(repositories *Repositories) error) error
+}
+
+// TransactionUnitOfWork is a unit of work backed by a database transaction
+type TransactionUnitOfWork struct {
+	db        *gorm.DB
+	publisher realtime.Publisher
+}
+
+// NewUnitOfWork is to create a new unit of work
+func NewUnitOfWork(db *gorm.DB) *TransactionUnitOfWork {
+	return &TransactionUnitOfWork{db: db}
+}
+
+// WithPublisher set the publisher of the events of the transactions, the events are
+// dropped without publisher
+func (u *TransactionUnitOfWork) WithPublisher(publisher realtime.Publisher) *TransactionUnitOfWork {
+	u.publisher = publisher
+	return u
+}
+
+// Transaction run fn in a database transaction, the events of fn are published once
+// the transaction is committed
+func (u *TransactionUnitOfWork) Transaction(fn func(repositories *Repositories) error) error {
+	events := realtime.NewBuffer()
+
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		repositories := NewRepositories(tx)
+		repositories.Events = events
+
+		return fn(repositories)
+	})
+	if err != nil {
+		return err
+	}
+
+	if u.publisher != nil {
+		events.Flush(u.publisher)
+	}
+
+	return nil
+}
+
+// updateVersioned save all the fields of a model only if its version is still the
+// version that has been read, and increment the version. It returns notFound if
+// the model does not exist anymore and ErrVersionConflict if it has been updated.
+func updateVersioned(db *gorm.DB, model interface{}, base *models.Base, notFound error) error {
+	version := base.Version
+	base.Version++
+
+	tx := db.Model(model).Where("version = ?", version).Select("*").Updates(model)
+	if tx.Error == nil && tx.RowsAffected > 0 {
+		return nil
+	}
+
+	base.Version = version
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	var count int64
+	err := db.Model(model).Where("id = ?", base.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return notFound
+	}
+
+	return ErrVersionConflict
+}
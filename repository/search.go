@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/ada-social-network/api/search"
+)
+
+// ErrSearchNotRegistered is an error when the search plugin is not registered on the database
+var ErrSearchNotRegistered = errors.New("search is not registered on the database")
+
+// SearchStore is the interface implemented by SearchRepository
+type SearchStore interface {
+	Search(query search.Query) (map[search.Kind][]search.Hit, error)
+}
+
+// SearchRepository is a repository searching the resources
+type SearchRepository struct {
+	db     *gorm.DB
+	engine search.Engine
+}
+
+// NewSearchRepository is to create a new search repository using the engine registered on db
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{db: db, engine: search.EngineOf(db)}
+}
+
+// Search find the resources matching a query, grouped by kind
+func (s *SearchRepository) Search(query search.Query) (map[search.Kind][]search.Hit, error) {
+	if s.engine == nil {
+		return nil, ErrSearchNotRegistered
+	}
+
+	return search.Search(s.db, s.engine, query)
+}
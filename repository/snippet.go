@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSnippetNotFound is an error when resource is not found
+	ErrSnippetNotFound = errors.New("snippet not found")
+	// ErrSnippetRevisionNotFound is an error when a revision of a snippet is not found
+	ErrSnippetRevisionNotFound = errors.New("snippet revision not found")
+)
+
+// SnippetStore is the interface implemented by SnippetRepository
+type SnippetStore interface {
+	RelationStore
+	CreateSnippet(snippet *models.Snippet) error
+	ListSnippets(snippets *[]models.Snippet, page Page) (PageInfo, error)
+	GetSnippetByID(snippet *models.Snippet, snippetID string) error
+	UpdateSnippet(snippet *models.Snippet) error
+	DeleteSnippetByID(snippetID string) error
+	DeleteSnippetsByUserID(userID string) error
+	CreateSnippetRevision(revision *models.SnippetRevision) error
+	ListSnippetRevisions(revisions *[]models.SnippetRevision, snippetID string, page Page) (PageInfo, error)
+	GetSnippetRevision(revision *models.SnippetRevision, snippetID string, number int) error
+}
+
+// SnippetRepository is a repository for snippet resource
+type SnippetRepository struct {
+	db *gorm.DB
+}
+
+// NewSnippetRepository is to create a new snippet repository
+func NewSnippetRepository(db *gorm.DB) *SnippetRepository {
+	return &SnippetRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (s *SnippetRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(s.db, related, column, keys)
+}
+
+// CreateSnippet create a snippet in the DB
+func (s *SnippetRepository) CreateSnippet(snippet *models.Snippet) error {
+	return s.db.Create(snippet).Error
+}
+
+// ListSnippets list a page of the snippets in the DB
+func (s *SnippetRepository) ListSnippets(snippets *[]models.Snippet, page Page) (PageInfo, error) {
+	return paginate(s.db, snippets, page)
+}
+
+// GetSnippetByID get a snippet by ID in the DB
+func (s *SnippetRepository) GetSnippetByID(snippet *models.Snippet, snippetID string) error {
+	tx := s.db.First(snippet, "id = ?", snippetID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrSnippetNotFound
+	}
+
+	return tx.Error
+}
+
+// UpdateSnippet update a snippet in the DB if it has not been updated since it has been read
+func (s *SnippetRepository) UpdateSnippet(snippet *models.Snippet) error {
+	return updateVersioned(s.db, snippet, &snippet.Base, ErrSnippetNotFound)
+}
+
+// DeleteSnippetByID delete a snippet with its revisions in the DB, it is removed from the
+// posts embedding it
+func (s *SnippetRepository) DeleteSnippetByID(snippetID string) error {
+	tx := s.db.Delete(&models.Snippet{}, "id = ?", snippetID)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrSnippetNotFound
+	}
+
+	return s.deleteSnippetReferences([]string{snippetID})
+}
+
+// DeleteSnippetsByUserID delete all the snippets of a specific user with their revisions
+// in the DB, they are removed from the posts embedding them
+func (s *SnippetRepository) DeleteSnippetsByUserID(userID string) error {
+	var snippetIDs []string
+
+	err := s.db.Model(&models.Snippet{}).Where("user_id = ?", userID).Pluck("id", &snippetIDs).Error
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&models.Snippet{}, "user_id = ?", userID).Error
+	if err != nil {
+		return err
+	}
+
+	return s.deleteSnippetReferences(snippetIDs)
+}
+
+// deleteSnippetReferences delete the revisions of deleted snippets and remove them from
+// the posts in the DB
+func (s *SnippetRepository) deleteSnippetReferences(snippetIDs []string) error {
+	if len(snippetIDs) == 0 {
+		return nil
+	}
+
+	err := s.db.Delete(&models.SnippetRevision{}, "snippet_id IN ?", snippetIDs).Error
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Post{}).Where("snippet_id IN ?", snippetIDs).UpdateColumn("snippet_id", nil).Error
+}
+
+// CreateSnippetRevision create a revision of a snippet in the DB
+func (s *SnippetRepository) CreateSnippetRevision(revision *models.SnippetRevision) error {
+	return s.db.Create(revision).Error
+}
+
+// ListSnippetRevisions list a page of the revisions of a snippet in the DB
+func (s *SnippetRepository) ListSnippetRevisions(revisions *[]models.SnippetRevision, snippetID string, page Page) (PageInfo, error) {
+	return paginate(s.db.Where("snippet_id = ?", snippetID), revisions, page)
+}
+
+// GetSnippetRevision get a revision of a snippet by its number in the DB
+func (s *SnippetRepository) GetSnippetRevision(revision *models.SnippetRevision, snippetID string, number int) error {
+	tx := s.db.First(revision, "snippet_id = ? AND revision = ?", snippetID, number)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrSnippetRevisionNotFound
+	}
+
+	return tx.Error
+}
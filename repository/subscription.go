@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+)
+
+// ErrSubscriptionNotFound is an error when resource is not found
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// SubscriptionStore is the interface implemented by SubscriptionRepository
+type SubscriptionStore interface {
+	RelationStore
+	CreateSubscription(subscription *models.Subscription) error
+	GetSubscription(subscription *models.Subscription, userID string, targetType models.SubscriptionTarget, targetID string) error
+	ListSubscriptionsByUserID(subscriptions *[]models.Subscription, userID string, page Page) (PageInfo, error)
+	ListSubscriberIDs(targetType models.SubscriptionTarget, targetID string) ([]string, error)
+	DeleteSubscriptionByID(subscriptionID string) error
+	DeleteSubscriptionsByUserID(userID string) error
+	DeleteSubscriptionsByTarget(targetType models.SubscriptionTarget, targetID string) error
+}
+
+// SubscriptionRepository is a repository for subscription resource
+type SubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewSubscriptionRepository is to create a new subscription repository
+func NewSubscriptionRepository(db *gorm.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (s *SubscriptionRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(s.db, related, column, keys)
+}
+
+// CreateSubscription create a subscription in the DB
+func (s *SubscriptionRepository) CreateSubscription(subscription *models.Subscription) error {
+	return s.db.Create(subscription).Error
+}
+
+// GetSubscription get the subscription of a user to a target in the DB
+func (s *SubscriptionRepository) GetSubscription(subscription *models.Subscription, userID string, targetType models.SubscriptionTarget, targetID string) error {
+	tx := s.db.First(subscription, "user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrSubscriptionNotFound
+	}
+
+	return tx.Error
+}
+
+// ListSubscriptionsByUserID list a page of the subscriptions of a specific user in the DB
+func (s *SubscriptionRepository) ListSubscriptionsByUserID(subscriptions *[]models.Subscription, userID string, page Page) (PageInfo, error) {
+	return paginate(s.db.Where("user_id = ?", userID), subscriptions, page)
+}
+
+// ListSubscriberIDs list the ids of all the users subscribed to a target in the DB
+func (s *SubscriptionRepository) ListSubscriberIDs(targetType models.SubscriptionTarget, targetID string) ([]string, error) {
+	userIDs := []string{}
+	err := s.db.Model(&models.Subscription{}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Pluck("user_id", &userIDs).Error
+
+	return userIDs, err
+}
+
+// DeleteSubscriptionByID delete a subscription by ID in the DB
+func (s *SubscriptionRepository) DeleteSubscriptionByID(subscriptionID string) error {
+	tx := s.db.Delete(&models.Subscription{}, "id = ?", subscriptionID)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	return tx.Error
+}
+
+// DeleteSubscriptionsByUserID delete all the subscriptions of a user in the DB
+func (s *SubscriptionRepository) DeleteSubscriptionsByUserID(userID string) error {
+	return s.db.Delete(&models.Subscription{}, "user_id = ?", userID).Error
+}
+
+// DeleteSubscriptionsByTarget delete all the subscriptions to a target in the DB
+func (s *SubscriptionRepository) DeleteSubscriptionsByTarget(targetType models.SubscriptionTarget, targetID string) error {
+	return s.db.Delete(&models.Subscription{}, "target_type = ? AND target_id = ?", targetType, targetID).Error
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrendingPeriod is the period during which the uses of a tag make it trending
+const TrendingPeriod = 7 * 24 * time.Hour
+
+// ErrTagNotFound is an error when resource is not found
+var (
+	ErrTagNotFound = errors.New("tag not found")
+)
+
+// TagStore is the interface implemented by TagRepository
+type TagStore interface {
+	RelationStore
+	ListTags(tags *[]models.TagUsage, page Page) (PageInfo, error)
+	GetTagByName(tag *models.Tag, name string) error
+	SaveTag(tag *models.Tag, name string) error
+	UpdateTagUsesCount(tagID string, delta int) error
+	CreateTagging(tagging *models.Tagging) error
+	ListTaggingsByTagID(taggings *[]models.Tagging, tagID string, page Page) (PageInfo, error)
+	ListTaggingsBySource(taggings *[]models.Tagging, sourceType models.TagSource, sourceID string) error
+	ListAllTaggingsByUserID(taggings *[]models.Tagging, userID string) error
+	DeleteTaggingsByIDs(taggingIDs []string) error
+	DeleteTaggingsByUserID(userID string) error
+}
+
+// TagRepository is a repository for tag resource
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository is to create a new tag repository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (t *TagRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(t.db, related, column, keys)
+}
+
+// ListTags list a page of the used tags with their number of uses during the trending
+// period in the DB
+func (t *TagRepository) ListTags(tags *[]models.TagUsage, page Page) (PageInfo, error) {
+	recentUses := t.db.Model(&models.Tagging{}).
+		Select("COUNT(*)").
+		Where("taggings.tag_id = tags.id AND taggings.created_at >= ?", time.Now().Add(-TrendingPeriod))
+
+	usages := t.db.Model(&models.Tag{}).
+		Select("tags.*, (?) AS recent_uses", recentUses).
+		Where("uses_count > 0")
+
+	return paginate(t.db.Table("(?) AS tags", usages), tags, page)
+}
+
+// GetTagByName get a tag by name in the DB
+func (t *TagRepository) GetTagByName(tag *models.Tag, name string) error {
+	tx := t.db.First(tag, "name = ?", name)
+	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return ErrTagNotFound
+	}
+
+	return tx.Error
+}
+
+// SaveTag get a tag by name in the DB, the tag is created when it does not exist yet
+func (t *TagRepository) SaveTag(tag *models.Tag, name string) error {
+	err := t.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Tag{Name: name}).Error
+	if err != nil {
+		return err
+	}
+
+	return t.GetTagByName(tag, name)
+}
+
+// UpdateTagUsesCount add delta to the uses counter of a tag in the DB
+func (t *TagRepository) UpdateTagUsesCount(tagID string, delta int) error {
+	tx := t.db.Model(&models.Tag{}).
+		Where("id = ?", tagID).
+		UpdateColumn("uses_count", gorm.Expr("uses_count + ?", delta))
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrTagNotFound
+	}
+
+	return tx.Error
+}
+
+// CreateTagging create a tagging in the DB
+func (t *TagRepository) CreateTagging(tagging *models.Tagging) error {
+	return t.db.Create(tagging).Error
+}
+
+// ListTaggingsByTagID list a page of the taggings of a tag in the DB
+func (t *TagRepository) ListTaggingsByTagID(taggings *[]models.Tagging, tagID string, page Page) (PageInfo, error) {
+	return paginate(t.db.Where("tag_id = ?", tagID), taggings, page)
+}
+
+// ListTaggingsBySource list all the taggings of a resource in the DB
+func (t *TagRepository) ListTaggingsBySource(taggings *[]models.Tagging, sourceType models.TagSource, sourceID string) error {
+	return t.db.Where("source_type = ? AND source_id = ?", sourceType, sourceID).Find(taggings).Error
+}
+
+// ListAllTaggingsByUserID list all the taggings of the resources of a user in the DB
+func (t *TagRepository) ListAllTaggingsByUserID(taggings *[]models.Tagging, userID string) error {
+	return t.byUserID(userID).Find(taggings).Error
+}
+
+// DeleteTaggingsByIDs delete taggings by ID in the DB
+func (t *TagRepository) DeleteTaggingsByIDs(taggingIDs []string) error {
+	if len(taggingIDs) == 0 {
+		return nil
+	}
+
+	return t.db.Delete(&models.Tagging{}, "id IN ?", taggingIDs).Error
+}
+
+// DeleteTaggingsByUserID delete all the taggings of the resources of a user in the DB
+func (t *TagRepository) DeleteTaggingsByUserID(userID string) error {
+	return t.byUserID(userID).Delete(&models.Tagging{}).Error
+}
+
+// byUserID returns a query of the taggings of the resources of a user
+func (t *TagRepository) byUserID(userID string) *gorm.DB {
+	return t.db.
+		Where("source_type = ? AND source_id IN (?)", models.PostTag, t.db.Model(&models.Post{}).Select("id").Where("user_id = ?", userID)).
+		Or("source_type = ? AND source_id IN (?)", models.TopicTag, t.db.Model(&models.Topic{}).Select("id").Where("user_id = ?", userID)).
+		Or("source_type = ? AND source_id IN (?)", models.BdaPostTag, t.db.Model(&models.BdaPost{}).Select("id").Where("user_id = ?", userID))
+}
@@ -12,6 +12,18 @@ var (
 	ErrTopicNotFound = errors.New("topic not found")
 )
 
+// TopicStore is the interface implemented by TopicRepository
+type TopicStore interface {
+	RelationStore
+	CreateTopic(topic *models.Topic) error
+	ListTopics(topics *[]models.Topic, page Page) (PageInfo, error)
+	ListTopicsByCategoryID(topics *[]models.Topic, categoryID string, page Page) (PageInfo, error)
+	GetTopicByID(topic *models.Topic, topicID string) error
+	UpdateTopic(topic *models.Topic) error
+	UpdatePostsCount(topicID string, delta int) error
+	DeleteTopicByID(topicID string) error
+}
+
 // TopicRepository is a repository for topic resource
 type TopicRepository struct {
 	db *gorm.DB
@@ -22,19 +34,24 @@ func NewTopicRepository(db *gorm.DB) *TopicRepository {
 	return &TopicRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (t *TopicRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(t.db, related, column, keys)
+}
+
 // CreateTopic create a topic in the DB
 func (t *TopicRepository) CreateTopic(topic *models.Topic) error {
 	return t.db.Create(topic).Error
 }
 
-// ListAllTopics list all the topics in the DB
-func (t *TopicRepository) ListAllTopics(topics *[]models.Topic) error {
-	return t.db.Find(topics).Error
+// ListTopics list a page of the topics in the DB
+func (t *TopicRepository) ListTopics(topics *[]models.Topic, page Page) (PageInfo, error) {
+	return paginate(t.db, topics, page)
 }
 
-// ListAllTopicsByCategoryID list all the topics from a specific category in the DB
-func (t *TopicRepository) ListAllTopicsByCategoryID(topics *[]models.Topic, categoryID string) error {
-	return t.db.Find(topics, "category_id= ?", categoryID).Error
+// ListTopicsByCategoryID list a page of the topics from a specific category in the DB
+func (t *TopicRepository) ListTopicsByCategoryID(topics *[]models.Topic, categoryID string, page Page) (PageInfo, error) {
+	return paginate(t.db.Where("category_id = ?", categoryID), topics, page)
 }
 
 // GetTopicByID get a bda post by id in the DB
@@ -47,9 +64,21 @@ func (t *TopicRepository) GetTopicByID(topic *models.Topic, topicID string) erro
 	return tx.Error
 }
 
-// UpdateTopic update a topic in the DB
+// UpdateTopic update a topic in the DB if it has not been updated since it has been read
 func (t *TopicRepository) UpdateTopic(topic *models.Topic) error {
-	return t.db.Save(topic).Error
+	return updateVersioned(t.db.Omit("PostsCount"), topic, &topic.Base, ErrTopicNotFound)
+}
+
+// UpdatePostsCount add delta to the posts counter of a topic in the DB
+func (t *TopicRepository) UpdatePostsCount(topicID string, delta int) error {
+	tx := t.db.Model(&models.Topic{}).
+		Where("id = ?", topicID).
+		UpdateColumn("posts_count", gorm.Expr("posts_count + ?", delta))
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrTopicNotFound
+	}
+
+	return tx.Error
 }
 
 // DeleteTopicByID delete a topic by ID in the DB
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/ada-social-network/api/models"
 	"gorm.io/gorm"
@@ -12,6 +13,22 @@ var (
 	ErrUserNotFound = errors.New("user not found")
 )
 
+// UserStore is the interface implemented by UserRepository
+type UserStore interface {
+	RelationStore
+	CreateUserWithPassword(user *models.User, password string) error
+	GetUserByID(user *models.User, userID string) error
+	ListUsers(users *[]models.User, page Page) (PageInfo, error)
+	UpdateUserWithoutPassword(user *models.User) error
+	UpdateUserWithPassword(user *models.User, password string) error
+	UpdateFollowersCount(userID string, delta int) error
+	UpdateFollowingCount(userID string, delta int) error
+	ListUsersByMentionHandles(users *[]models.User, handles []string) error
+	ListMentionSuggestions(users *[]models.User, query string, limit int) error
+	CheckUniqueMailInUsers(user *models.User, email string) (bool, error)
+	DeleteByUserID(userID string) error
+}
+
 // UserRepository is a repository for user resource
 type UserRepository struct {
 	db *gorm.DB
@@ -22,6 +39,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// ListRelated list the records of related with a column matching one of the keys in the DB
+func (us *UserRepository) ListRelated(related interface{}, column string, keys []interface{}) error {
+	return listRelated(us.db, related, column, keys)
+}
+
 // CreateUserWithPassword create a user in the DB
 func (us *UserRepository) CreateUserWithPassword(user *models.User, password string) error {
 	passwordEncrypted, err := models.HashPassword(password)
@@ -29,6 +51,9 @@ func (us *UserRepository) CreateUserWithPassword(user *models.User, password str
 		return err
 	}
 	user.Password = passwordEncrypted
+	// counters are only changed by follows
+	user.FollowersCount = 0
+	user.FollowingCount = 0
 	return us.db.Create(user).Error
 }
 
@@ -42,14 +67,14 @@ func (us *UserRepository) GetUserByID(user *models.User, userID string) error {
 	return tx.Error
 }
 
-// ListAllUser list all users in the DB
-func (us *UserRepository) ListAllUser(users *[]models.User) error {
-	return us.db.Find(users).Error
+// ListUsers list a page of users in the DB
+func (us *UserRepository) ListUsers(users *[]models.User, page Page) (PageInfo, error) {
+	return paginate(us.db, users, page)
 }
 
 // UpdateUserWithoutPassword update a user in the DB without password
 func (us *UserRepository) UpdateUserWithoutPassword(user *models.User) error {
-	return us.db.Omit("Password").Save(user).Error
+	return updateVersioned(us.db.Omit("Password", "FollowersCount", "FollowingCount"), user, &user.Base, ErrUserNotFound)
 }
 
 // UpdateUserWithPassword update user password only
@@ -59,7 +84,54 @@ func (us *UserRepository) UpdateUserWithPassword(user *models.User, password str
 		return err
 	}
 	user.Password = passwordEncrypted
-	return us.db.Save(user).Error
+	return updateVersioned(us.db.Omit("FollowersCount", "FollowingCount"), user, &user.Base, ErrUserNotFound)
+}
+
+// UpdateFollowersCount add delta to the followers counter of a user in the DB
+func (us *UserRepository) UpdateFollowersCount(userID string, delta int) error {
+	return us.updateCount(userID, "followers_count", delta)
+}
+
+// UpdateFollowingCount add delta to the following counter of a user in the DB
+func (us *UserRepository) UpdateFollowingCount(userID string, delta int) error {
+	return us.updateCount(userID, "following_count", delta)
+}
+
+// updateCount add delta to a counter column of a user in the DB
+func (us *UserRepository) updateCount(userID string, column string, delta int) error {
+	tx := us.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumn(column, gorm.Expr(column+" + ?", delta))
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return tx.Error
+}
+
+// mentionHandle is the SQL expression of the handle mentioning a user, see models.MentionHandle.
+// Only ASCII letters are lowered by sqlite.
+const mentionHandle = `LOWER(REPLACE(TRIM(first_name), ' ', '-')) || '.' || LOWER(REPLACE(TRIM(last_name), ' ', '-'))`
+
+// ListUsersByMentionHandles list the users mentioned by one of the handles in the DB
+func (us *UserRepository) ListUsersByMentionHandles(users *[]models.User, handles []string) error {
+	if len(handles) == 0 {
+		return nil
+	}
+
+	return us.db.Where(mentionHandle+" IN ?", handles).Find(users).Error
+}
+
+// ListMentionSuggestions list the users whose handle or last name starts with query in
+// the DB, the most followed first
+func (us *UserRepository) ListMentionSuggestions(users *[]models.User, query string, limit int) error {
+	pattern := escapeLike(strings.ToLower(query)) + "%"
+
+	return us.db.
+		Where(mentionHandle+` LIKE ? ESCAPE '\' OR LOWER(last_name) LIKE ? ESCAPE '\'`, pattern, pattern).
+		Order("followers_count DESC, first_name, last_name, id").
+		Limit(limit).
+		Find(users).Error
 }
 
 // CheckUniqueMailInUsers will check if a user with this email already exist in DB
@@ -71,7 +143,7 @@ func (us *UserRepository) CheckUniqueMailInUsers(user *models.User, email string
 // DeleteByUserID delete a comment by ID in the DB
 func (us *UserRepository) DeleteByUserID(userID string) error {
 	tx := us.db.Delete(&models.User{}, "id = ?", userID)
-	if tx.Error != nil && errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+	if tx.Error == nil && tx.RowsAffected == 0 {
 		return ErrUserNotFound
 	}
 
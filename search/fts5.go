@@ -0,0 +1,123 @@
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FTS5Engine is an engine using a SQLite FTS5 virtual table, hits are ranked by bm25
+type FTS5Engine struct{}
+
+// NewFTS5Engine is to create a new FTS5 engine
+func NewFTS5Engine() *FTS5Engine {
+	return &FTS5Engine{}
+}
+
+// FTS5Available check if sqlite is built with FTS5
+func FTS5Available(db *gorm.DB) bool {
+	var used int
+	err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used).Error
+	return err == nil && used == 1
+}
+
+// Migrate create the documents table and the FTS5 index, the index is filled from the
+// documents when it does not exist yet, and filled again when its documents have no
+// author yet
+func (e *FTS5Engine) Migrate(db *gorm.DB) (bool, error) {
+	created := !db.Migrator().HasTable(&documentRow{}) || !db.Migrator().HasColumn(&documentRow{}, "user_id")
+
+	err := db.AutoMigrate(&documentRow{})
+	if err != nil {
+		return false, err
+	}
+
+	if db.Migrator().HasTable("search_index") {
+		return created, nil
+	}
+
+	err = db.Exec(`CREATE VIRTUAL TABLE search_index USING fts5(title, content, tokenize = 'unicode61 remove_diacritics 2')`).Error
+	if err != nil {
+		return false, err
+	}
+
+	return created, db.Exec(`INSERT INTO search_index (rowid, title, content) SELECT row_id, title, content FROM search_documents`).Error
+}
+
+// Index add or replace documents in the index
+func (e *FTS5Engine) Index(db *gorm.DB, documents []Document) error {
+	rowIDs, err := saveDocuments(db, documents)
+	if err != nil {
+		return err
+	}
+
+	for i, document := range documents {
+		err = db.Exec(`DELETE FROM search_index WHERE rowid = ?`, rowIDs[i]).Error
+		if err != nil {
+			return err
+		}
+
+		err = db.Exec(`INSERT INTO search_index (rowid, title, content) VALUES (?, ?, ?)`, rowIDs[i], document.Title, document.Content).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove documents of a kind from the index
+func (e *FTS5Engine) Remove(db *gorm.DB, kind Kind, ids []string) error {
+	rows := db.Model(&documentRow{}).Select("row_id").Where("kind = ? AND id IN ?", string(kind), ids)
+
+	err := db.Exec(`DELETE FROM search_index WHERE rowid IN (?)`, rows).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Where("kind = ? AND id IN ?", string(kind), ids).Delete(&documentRow{}).Error
+}
+
+// Clear remove all the documents from the index
+func (e *FTS5Engine) Clear(db *gorm.DB) error {
+	err := db.Exec(`DELETE FROM search_index`).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Where("1 = 1").Delete(&documentRow{}).Error
+}
+
+// Search find the documents of a kind matching all the terms of the query, the last
+// term matches as a prefix so results show up while typing
+func (e *FTS5Engine) Search(db *gorm.DB, kind Kind, query Query) ([]Hit, error) {
+	words := terms(query.Text)
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	match := strings.Join(words, " ") + "*"
+
+	excluded := ""
+	args := []interface{}{highlightStart, highlightEnd, highlightStart, highlightEnd, match, string(kind)}
+	if len(query.ExcludedUserIDs) > 0 {
+		excluded = "AND d.user_id NOT IN ?"
+		args = append(args, query.ExcludedUserIDs)
+	}
+	args = append(args, query.Limit)
+
+	hits := []Hit{}
+	err := db.Raw(`
+		SELECT d.kind AS kind, d.id AS id, d.parent_id AS parent_id,
+			highlight(search_index, 0, ?, ?) AS title,
+			snippet(search_index, 1, ?, ?, '…', 16) AS snippet,
+			-bm25(search_index, 2.0, 1.0) AS score
+		FROM search_index
+		JOIN search_documents d ON d.row_id = search_index.rowid
+		WHERE search_index MATCH ? AND d.kind = ? `+excluded+`
+		ORDER BY bm25(search_index, 2.0, 1.0)
+		LIMIT ?`,
+		args...,
+	).Scan(&hits).Error
+
+	return hits, err
+}
@@ -0,0 +1,394 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ada-social-network/api/models"
+)
+
+// documentRow is a document stored in the database, it is the reference of the index
+type documentRow struct {
+	RowID    int64  `gorm:"column:row_id;primaryKey;autoIncrement"`
+	Kind     string `gorm:"not null;uniqueIndex:idx_search_documents_kind_id"`
+	ID       string `gorm:"column:id;not null;uniqueIndex:idx_search_documents_kind_id"`
+	ParentID string
+	UserID   string `gorm:"index"`
+	Title    string
+	Content  string
+}
+
+// TableName returns the table of the documents
+func (documentRow) TableName() string {
+	return "search_documents"
+}
+
+// saveDocuments insert or update documents and returns their row ids
+func saveDocuments(db *gorm.DB, documents []Document) ([]int64, error) {
+	rowIDs := make([]int64, 0, len(documents))
+	for _, document := range documents {
+		row := &documentRow{
+			Kind:     string(document.Kind),
+			ID:       document.ID,
+			ParentID: document.ParentID,
+			UserID:   document.UserID,
+			Title:    document.Title,
+			Content:  document.Content,
+		}
+
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kind"}, {Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"parent_id", "user_id", "title", "content"}),
+		}).Create(row).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// the row id is not returned when the document is updated
+		err = db.Model(&documentRow{}).Where("kind = ? AND id = ?", row.Kind, row.ID).Pluck("row_id", &row.RowID).Error
+		if err != nil {
+			return nil, err
+		}
+
+		rowIDs = append(rowIDs, row.RowID)
+	}
+
+	return rowIDs, nil
+}
+
+// documentOf returns the document of a model, false when the model is not searchable
+func documentOf(model interface{}) (Document, bool) {
+	switch m := model.(type) {
+	case *models.Topic:
+		return Document{Kind: TopicKind, ID: m.ID.String(), ParentID: m.CategoryID.String(), UserID: m.UserID.String(), Title: m.Name, Content: m.Content}, true
+	case *models.Post:
+		return Document{Kind: PostKind, ID: m.ID.String(), ParentID: m.TopicID.String(), UserID: m.UserID.String(), Content: m.Content}, true
+	case *models.BdaPost:
+		return Document{Kind: BdaPostKind, ID: m.ID.String(), UserID: m.UserID.String(), Title: m.Title, Content: m.Content}, true
+	case *models.Comment:
+		return Document{Kind: CommentKind, ID: m.ID.String(), ParentID: m.TargetID.String(), UserID: m.UserID.String(), Content: m.Content}, true
+	case *models.User:
+		return Document{Kind: UserKind, ID: m.ID.String(), UserID: m.ID.String(), Title: m.FirstName + " " + m.LastName}, true
+	default:
+		return Document{}, false
+	}
+}
+
+// kindOf returns the kind of a model type, false when the model is not searchable
+func kindOf(modelType reflect.Type) (Kind, bool) {
+	document, ok := documentOf(reflect.New(modelType).Interface())
+	return document.Kind, ok
+}
+
+// searchableModels returns an empty slice of each searchable model
+func searchableModels() []interface{} {
+	return []interface{}{&[]models.Topic{}, &[]models.Post{}, &[]models.BdaPost{}, &[]models.Comment{}, &[]models.User{}}
+}
+
+// Plugin is a gorm plugin creating the index of an engine and keeping it in sync with
+// the searchable resources on each create, update and delete
+type Plugin struct {
+	Engine Engine
+}
+
+// Name returns the name of the plugin
+func (p *Plugin) Name() string {
+	return "search"
+}
+
+// Initialize create the index and register the callbacks, the index is filled when it
+// is created
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	created, err := p.Engine.Migrate(db)
+	if err != nil {
+		return err
+	}
+
+	callbacks := &indexCallbacks{engine: p.Engine}
+
+	err = db.Callback().Create().After("gorm:create").Register("search:index_created", callbacks.afterCreate)
+	if err != nil {
+		return err
+	}
+
+	err = db.Callback().Update().Before("gorm:update").Register("search:find_updated", callbacks.beforeUpdate)
+	if err != nil {
+		return err
+	}
+
+	err = db.Callback().Update().After("gorm:update").Register("search:index_updated", callbacks.afterUpdate)
+	if err != nil {
+		return err
+	}
+
+	err = db.Callback().Delete().Before("gorm:delete").Register("search:find_deleted", callbacks.beforeDelete)
+	if err != nil {
+		return err
+	}
+
+	err = db.Callback().Delete().After("gorm:delete").Register("search:remove_deleted", callbacks.afterDelete)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		_, err = Reindex(db, p.Engine)
+	}
+
+	return err
+}
+
+// Register add the search plugin of an engine to db
+func Register(db *gorm.DB, engine Engine) error {
+	return db.Use(&Plugin{Engine: engine})
+}
+
+// EngineOf returns the engine registered on db, nil when the search plugin is not registered
+func EngineOf(db *gorm.DB) Engine {
+	plugin, ok := db.Config.Plugins[(&Plugin{}).Name()].(*Plugin)
+	if !ok {
+		return nil
+	}
+
+	return plugin.Engine
+}
+
+// Reindex rebuild the whole index from the resources, it returns the number of documents
+func Reindex(db *gorm.DB, engine Engine) (int, error) {
+	count := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := engine.Clear(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, rows := range searchableModels() {
+			result := tx.Session(&gorm.Session{NewDB: true}).FindInBatches(rows, 500, func(_ *gorm.DB, _ int) error {
+				documents := documentsOf(reflect.ValueOf(rows))
+				count += len(documents)
+
+				return engine.Index(tx.Session(&gorm.Session{NewDB: true}), documents)
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// documentsOf returns the documents of a model, a slice of models or a pointer to them
+func documentsOf(value reflect.Value) []Document {
+	value = reflect.Indirect(value)
+
+	documents := []Document{}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			documents = append(documents, documentsOf(value.Index(i))...)
+		}
+	case reflect.Struct:
+		if !value.CanAddr() {
+			copied := reflect.New(value.Type())
+			copied.Elem().Set(value)
+			value = copied.Elem()
+		}
+
+		document, ok := documentOf(value.Addr().Interface())
+		if ok && document.ID != uuid.Nil.String() {
+			documents = append(documents, document)
+		}
+	}
+
+	return documents
+}
+
+// indexCallbacks are the gorm callbacks keeping the index in sync
+type indexCallbacks struct {
+	engine Engine
+}
+
+// deletedIDsKey is the setting of a delete statement holding the ids of the deleted rows
+const deletedIDsKey = "search:deleted_ids"
+
+// updatedIDsKey is the setting of an update statement holding the ids of the rows
+// selected by its conditions
+const updatedIDsKey = "search:updated_ids"
+
+// afterCreate index the created resources
+func (i *indexCallbacks) afterCreate(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	kind, ok := kindOf(tx.Statement.Schema.ModelType)
+	if !ok {
+		return
+	}
+
+	i.index(tx, kind, documentsOf(tx.Statement.ReflectValue))
+}
+
+// beforeUpdate find the ids of the resources about to be updated, when they are
+// selected by conditions rather than given as models
+func (i *indexCallbacks) beforeUpdate(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	if _, ok := kindOf(tx.Statement.Schema.ModelType); !ok {
+		return
+	}
+
+	if len(documentsOf(tx.Statement.ReflectValue)) > 0 {
+		return
+	}
+
+	where, ok := tx.Statement.Clauses["WHERE"]
+	if !ok || where.Expression == nil {
+		return
+	}
+
+	ids := []string{}
+	err := tx.Session(&gorm.Session{NewDB: true}).Table(tx.Statement.Table).Clauses(where.Expression).Pluck("id", &ids).Error
+	if err != nil {
+		_ = tx.AddError(err)
+		return
+	}
+
+	tx.Statement.Settings.Store(updatedIDsKey, ids)
+}
+
+// afterUpdate index the updated resources, the whole resources are read again as an
+// update may only change some of their columns; the resources soft deleted by the
+// update are removed from the index
+func (i *indexCallbacks) afterUpdate(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	kind, ok := kindOf(tx.Statement.Schema.ModelType)
+	if !ok {
+		return
+	}
+
+	ids := []string{}
+	if value, ok := tx.Statement.Settings.Load(updatedIDsKey); ok {
+		ids, _ = value.([]string)
+	} else {
+		for _, document := range documentsOf(tx.Statement.ReflectValue) {
+			ids = append(ids, document.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(tx.Statement.Schema.ModelType))
+	err := tx.Session(&gorm.Session{NewDB: true}).Where("id IN ?", ids).Find(rows.Interface()).Error
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("search index of %s: %w", kind, err))
+		return
+	}
+
+	documents := documentsOf(rows)
+	i.index(tx, kind, documents)
+
+	found := map[string]bool{}
+	for _, document := range documents {
+		found[document.ID] = true
+	}
+
+	removed := []string{}
+	for _, id := range ids {
+		if !found[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return
+	}
+
+	err = i.engine.Remove(tx.Session(&gorm.Session{NewDB: true}), kind, removed)
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("search index of %s: %w", kind, err))
+	}
+}
+
+// index add documents to the index in the transaction of a statement
+func (i *indexCallbacks) index(tx *gorm.DB, kind Kind, documents []Document) {
+	if len(documents) == 0 {
+		return
+	}
+
+	err := i.engine.Index(tx.Session(&gorm.Session{NewDB: true}), documents)
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("search index of %s: %w", kind, err))
+	}
+}
+
+// beforeDelete find the ids of the resources about to be deleted
+func (i *indexCallbacks) beforeDelete(tx *gorm.DB) {
+	if tx.Error != nil || tx.Statement.Schema == nil {
+		return
+	}
+
+	if _, ok := kindOf(tx.Statement.Schema.ModelType); !ok {
+		return
+	}
+
+	query := tx.Session(&gorm.Session{NewDB: true}).Table(tx.Statement.Table)
+
+	where, ok := tx.Statement.Clauses["WHERE"]
+	if ok && where.Expression != nil {
+		query = query.Clauses(where.Expression)
+	} else {
+		documents := documentsOf(tx.Statement.ReflectValue)
+		if len(documents) == 0 {
+			return
+		}
+
+		ids := []string{}
+		for _, document := range documents {
+			ids = append(ids, document.ID)
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	ids := []string{}
+	err := query.Pluck("id", &ids).Error
+	if err != nil {
+		_ = tx.AddError(err)
+		return
+	}
+
+	tx.Statement.Settings.Store(deletedIDsKey, ids)
+}
+
+// afterDelete remove the deleted resources from the index
+func (i *indexCallbacks) afterDelete(tx *gorm.DB) {
+	value, ok := tx.Statement.Settings.Load(deletedIDsKey)
+	if !ok || tx.Error != nil {
+		return
+	}
+
+	ids, _ := value.([]string)
+	if len(ids) == 0 {
+		return
+	}
+
+	kind, _ := kindOf(tx.Statement.Schema.ModelType)
+	err := i.engine.Remove(tx.Session(&gorm.Session{NewDB: true}), kind, ids)
+	if err != nil {
+		_ = tx.AddError(fmt.Errorf("search index of %s: %w", kind, err))
+	}
+}
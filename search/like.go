@@ -0,0 +1,150 @@
+package search
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeCandidates is the maximum number of documents of a kind ranked by the LIKE engine
+const likeCandidates = 500
+
+// snippetWords is the number of words of a snippet
+const snippetWords = 16
+
+// LikeEngine is an engine searching the documents with LIKE, it is used when sqlite is
+// built without FTS5. Hits are ranked by the number of matches.
+type LikeEngine struct{}
+
+// NewLikeEngine is to create a new LIKE engine
+func NewLikeEngine() *LikeEngine {
+	return &LikeEngine{}
+}
+
+// Migrate create the documents table, it is filled again when its documents have no
+// author yet
+func (e *LikeEngine) Migrate(db *gorm.DB) (bool, error) {
+	created := !db.Migrator().HasTable(&documentRow{}) || !db.Migrator().HasColumn(&documentRow{}, "user_id")
+
+	return created, db.AutoMigrate(&documentRow{})
+}
+
+// Index add or replace documents in the index
+func (e *LikeEngine) Index(db *gorm.DB, documents []Document) error {
+	_, err := saveDocuments(db, documents)
+	return err
+}
+
+// Remove documents of a kind from the index
+func (e *LikeEngine) Remove(db *gorm.DB, kind Kind, ids []string) error {
+	return db.Where("kind = ? AND id IN ?", string(kind), ids).Delete(&documentRow{}).Error
+}
+
+// Clear remove all the documents from the index
+func (e *LikeEngine) Clear(db *gorm.DB) error {
+	return db.Where("1 = 1").Delete(&documentRow{}).Error
+}
+
+// Search find the documents of a kind containing all the terms of the query
+func (e *LikeEngine) Search(db *gorm.DB, kind Kind, query Query) ([]Hit, error) {
+	words := terms(query.Text)
+
+	tx := db.Model(&documentRow{}).Where("kind = ?", string(kind))
+	if len(query.ExcludedUserIDs) > 0 {
+		tx = tx.Where("user_id NOT IN ?", query.ExcludedUserIDs)
+	}
+	for _, word := range words {
+		pattern := "%" + escapeLike(word) + "%"
+		tx = tx.Where(`(title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+
+	rows := []documentRow{}
+	err := tx.Limit(likeCandidates).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		title, titleMatches := highlight(row.Title, words)
+		content, contentMatches := highlight(row.Content, words)
+
+		hits = append(hits, Hit{
+			Kind:     Kind(row.Kind),
+			ID:       row.ID,
+			ParentID: row.ParentID,
+			Title:    title,
+			Snippet:  snippet(content),
+			// matches of the title count twice, as with the FTS5 engine
+			Score: float64(2*titleMatches + contentMatches),
+		})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+
+	return hits, nil
+}
+
+// escapeLike escape the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// highlight surround the words of a text starting with one of the terms by the
+// highlight markers, it returns the number of matches
+func highlight(text string, terms []string) (string, int) {
+	matches := 0
+	words := strings.Fields(text)
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				words[i] = highlightStart + word + highlightEnd
+				matches++
+				break
+			}
+		}
+	}
+
+	return strings.Join(words, " "), matches
+}
+
+// snippet returns the words of a highlighted text around its first match
+func snippet(text string) string {
+	words := strings.Fields(text)
+	if len(words) <= snippetWords {
+		return text
+	}
+
+	first := 0
+	for i, word := range words {
+		if strings.Contains(word, highlightStart) {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetWords/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+		start = end - snippetWords
+	}
+
+	s := strings.Join(words[start:end], " ")
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(words) {
+		s += "…"
+	}
+
+	return s
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"html"
+	"log"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Kind is a type of resource which can be searched
+type Kind string
+
+const (
+	// TopicKind is the kind of the topics, their name and content are searched
+	TopicKind Kind = "topic"
+	// PostKind is the kind of the posts, their content is searched
+	PostKind Kind = "post"
+	// BdaPostKind is the kind of the bda posts, their title and content are searched
+	BdaPostKind Kind = "bdaPost"
+	// CommentKind is the kind of the comments, their content is searched
+	CommentKind Kind = "comment"
+	// UserKind is the kind of the users, their first and last names are searched
+	UserKind Kind = "user"
+)
+
+// Kinds are all the kinds of resources which can be searched
+var Kinds = []Kind{TopicKind, PostKind, BdaPostKind, CommentKind, UserKind}
+
+// highlight markers surround the matches in titles and snippets until they are rendered
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// Document is the searchable part of a resource. Only the fields any connected user
+// can read are indexed.
+type Document struct {
+	Kind Kind
+	ID   string
+	// ParentID is the id of the resource holding this resource, e.g. the topic of a post
+	ParentID string
+	// UserID is the author of the resource, the user itself for a user
+	UserID  string
+	Title   string
+	Content string
+}
+
+// Query is a search of resources
+type Query struct {
+	Text string
+	// Kinds are the kinds of resources searched, all the kinds when empty
+	Kinds []Kind
+	// Limit is the maximum number of hits of each kind
+	Limit int
+	// ExcludedUserIDs are the users whose resources are not returned, e.g. the users
+	// blocked by the current user or who blocked them
+	ExcludedUserIDs []string
+}
+
+// Hit is a resource matching a query
+type Hit struct {
+	Kind     Kind   `json:"type"`
+	ID       string `json:"id"`
+	ParentID string `json:"parentId,omitempty"`
+	// Title and Snippet are HTML, matches are surrounded by <mark> tags
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+	// Score is the relevance of the hit, the higher the better
+	Score float64 `json:"score"`
+}
+
+// Engine indexes and searches documents. Operations receive the database connection
+// of the current transaction, so the index is kept in sync with the resources.
+type Engine interface {
+	// Migrate create the storage of the index, it returns true when the index was
+	// created and must be filled
+	Migrate(db *gorm.DB) (bool, error)
+	// Index add or replace documents in the index
+	Index(db *gorm.DB, documents []Document) error
+	// Remove documents of a kind from the index
+	Remove(db *gorm.DB, kind Kind, ids []string) error
+	// Clear remove all the documents from the index
+	Clear(db *gorm.DB) error
+	// Search find the documents of a kind matching a query, best hits first
+	Search(db *gorm.DB, kind Kind, query Query) ([]Hit, error)
+}
+
+// NewEngine is to create the best engine supported by the database: FTS5 when sqlite
+// is built with it (the sqlite_fts5 build tag), a slower LIKE based engine otherwise
+func NewEngine(db *gorm.DB) Engine {
+	if FTS5Available(db) {
+		return NewFTS5Engine()
+	}
+
+	log.Println("sqlite is built without FTS5, the search uses a slower engine: build with -tags sqlite_fts5")
+	return NewLikeEngine()
+}
+
+// Search find the resources matching a query, grouped by kind
+func Search(db *gorm.DB, engine Engine, query Query) (map[Kind][]Hit, error) {
+	kinds := query.Kinds
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+
+	results := map[Kind][]Hit{}
+	if len(terms(query.Text)) == 0 {
+		return results, nil
+	}
+
+	for _, kind := range kinds {
+		hits, err := engine.Search(db, kind, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range hits {
+			hits[i].Title = renderHighlight(hits[i].Title)
+			hits[i].Snippet = renderHighlight(hits[i].Snippet)
+		}
+
+		results[kind] = hits
+	}
+
+	return results, nil
+}
+
+// terms split a query in words, the query syntax of the engines is never exposed
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// renderHighlight escape a text and replace the highlight markers by <mark> tags
+func renderHighlight(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightStart, "<mark>")
+	return strings.ReplaceAll(text, highlightEnd, "</mark>")
+}
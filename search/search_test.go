@@ -0,0 +1,232 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/ada-social-network/api/models"
+)
+
+func initDB(t *testing.T, engine Engine) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(models.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// created before the index, it is indexed when the index is created
+	err = db.Create(&models.User{FirstName: "Grace", LastName: "Hopper", Email: "grace@ada.dev"}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Register(db, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func engines(t *testing.T) map[string]Engine {
+	engines := map[string]Engine{"like": NewLikeEngine()}
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if FTS5Available(db) {
+		engines["fts5"] = NewFTS5Engine()
+	} else {
+		t.Log("sqlite is built without FTS5, run the tests with -tags sqlite_fts5 to test the FTS5 engine")
+	}
+
+	return engines
+}
+
+func ids(hits []Hit) []string {
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	for name, engine := range engines(t) {
+		t.Run(name, func(t *testing.T) {
+			db := initDB(t, engine)
+
+			topic := &models.Topic{Name: "Golang tips", Content: "Share your best tips", CategoryID: uuid.NewV4()}
+			post := &models.Post{Content: "Use <b>gofmt</b> before each commit, golang tools are great", TopicID: topic.CategoryID, UserID: uuid.NewV4()}
+			other := &models.Post{Content: "Nothing to see here"}
+			for _, model := range []interface{}{topic, post, other} {
+				err := db.Create(model).Error
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			results, err := Search(db, engine, Query{Text: "golang", Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ids(results[TopicKind]); len(got) != 1 || got[0] != topic.ID.String() {
+				t.Errorf("Search() topics = %v, want [%v]", got, topic.ID)
+			}
+
+			if got := ids(results[PostKind]); len(got) != 1 || got[0] != post.ID.String() {
+				t.Errorf("Search() posts = %v, want [%v]", got, post.ID)
+			}
+
+			hit := results[PostKind][0]
+			if !strings.Contains(hit.Snippet, "<mark>") || strings.Contains(hit.Snippet, "<b>") {
+				t.Errorf("Search() snippet = %v, want escaped and highlighted", hit.Snippet)
+			}
+
+			if hit.ParentID != topic.CategoryID.String() {
+				t.Errorf("Search() parent = %v, want %v", hit.ParentID, topic.CategoryID)
+			}
+
+			results, err = Search(db, engine, Query{Text: "hopp", Kinds: []Kind{UserKind}, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results[UserKind]) != 1 || len(results[PostKind]) != 0 {
+				t.Errorf("Search() users = %v, want the existing user only", results)
+			}
+
+			results, err = Search(db, engine, Query{Text: "golang", Kinds: []Kind{PostKind}, Limit: 10, ExcludedUserIDs: []string{post.UserID.String()}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results[PostKind]) != 0 {
+				t.Errorf("Search() posts = %v, want no post of the excluded user", ids(results[PostKind]))
+			}
+		})
+	}
+}
+
+func TestSearchSync(t *testing.T) {
+	for name, engine := range engines(t) {
+		t.Run(name, func(t *testing.T) {
+			db := initDB(t, engine)
+
+			post := &models.Post{Content: "first version"}
+			err := db.Create(post).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			post.Content = "second version"
+			err = db.Select("*").Updates(post).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			search := func(text string) []Hit {
+				results, err := Search(db, engine, Query{Text: text, Kinds: []Kind{PostKind}, Limit: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return results[PostKind]
+			}
+
+			if hits := search("first"); len(hits) != 0 {
+				t.Errorf("Search() after update got %v, want no hit", ids(hits))
+			}
+
+			if hits := search("second"); len(hits) != 1 {
+				t.Errorf("Search() after update got %v, want 1 hit", ids(hits))
+			}
+
+			err = db.Delete(&models.Post{}, "id = ?", post.ID).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hits := search("second"); len(hits) != 0 {
+				t.Errorf("Search() after delete got %v, want no hit", ids(hits))
+			}
+
+			err = db.Exec("INSERT INTO posts (id, content) VALUES (?, ?)", uuid.NewV4().String(), "inserted without the index").Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			count, err := Reindex(db, engine)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != 2 {
+				t.Errorf("Reindex() = %v documents, want 2", count)
+			}
+
+			if hits := search("inserted"); len(hits) != 1 {
+				t.Errorf("Search() after reindex got %v, want 1 hit", ids(hits))
+			}
+		})
+	}
+}
+
+func TestSearchSyncConditions(t *testing.T) {
+	for name, engine := range engines(t) {
+		t.Run(name, func(t *testing.T) {
+			db := initDB(t, engine)
+
+			comment := &models.Comment{Content: "first version"}
+			err := db.Create(comment).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			search := func(text string) []Hit {
+				results, err := Search(db, engine, Query{Text: text, Kinds: []Kind{CommentKind}, Limit: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return results[CommentKind]
+			}
+
+			// the updated rows are selected by the conditions, the model has no id
+			err = db.Model(&models.Comment{}).Where("id = ?", comment.ID).UpdateColumn("content", "second version").Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hits := search("second"); len(hits) != 1 {
+				t.Errorf("Search() after update got %v, want 1 hit", ids(hits))
+			}
+
+			// a comment deleted as a placeholder of its thread is soft deleted by an update
+			err = db.Model(&models.Comment{}).Where("id = ?", comment.ID).UpdateColumns(map[string]interface{}{
+				"deleted_at": time.Now(),
+				"content":    "",
+			}).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hits := search("version"); len(hits) != 0 {
+				t.Errorf("Search() after placeholder delete got %v, want no hit", ids(hits))
+			}
+		})
+	}
+}
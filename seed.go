@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ada-social-network/api/seed"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/logger"
+)
+
+// runSeed populate a development database with fake data
+func runSeed(args []string) error {
+	var dsn string
+	var mode string
+	var now string
+
+	options := seed.DefaultOptions()
+
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.StringVar(&dsn, "sqlite-dsn", "gorm.db", "sqlite database file (dsn) that will store data")
+	flags.StringVar(&mode, "mode", gin.ReleaseMode, "Running mode, seeding requires 'debug' or 'test' and is refused in the default 'release' mode")
+	flags.Int64Var(&options.Seed, "seed", options.Seed, "Seed of the fake data generator, the same seed generate the same data")
+	flags.StringVar(&options.Password, "password", options.Password, "Password of all the seeded users")
+	flags.StringVar(&now, "now", options.Now.Format("2006-01-02"), "Date of the most recent generated content, YYYY-MM-DD")
+	flags.IntVar(&options.Promos, "promos", options.Promos, "Number of promos")
+	flags.IntVar(&options.Users, "users", options.Users, "Number of users")
+	flags.IntVar(&options.Categories, "categories", options.Categories, "Number of categories")
+	flags.IntVar(&options.Topics, "topics", options.Topics, "Number of topics")
+	flags.IntVar(&options.Posts, "posts", options.Posts, "Number of posts")
+	flags.IntVar(&options.BdaPosts, "bdaposts", options.BdaPosts, "Number of bda posts")
+	flags.IntVar(&options.Comments, "comments", options.Comments, "Number of comments")
+	flags.IntVar(&options.Likes, "likes", options.Likes, "Maximum number of likes")
+	flags.IntVar(&options.Follows, "follows", options.Follows, "Maximum number of follows between users")
+	flags.IntVar(&options.Subscriptions, "subscriptions", options.Subscriptions, "Maximum number of subscriptions to topics, categories and the bda feed")
+	_ = flags.Parse(args)
+
+	if mode != gin.DebugMode && mode != gin.TestMode {
+		return errors.New("seed is only allowed in debug or test mode, run it with --mode=debug")
+	}
+
+	var err error
+	options.Now, err = time.Parse("2006-01-02", now)
+	if err != nil {
+		return fmt.Errorf("invalid --now date: %w", err)
+	}
+
+	db, err := openDatabase(dsn, logger.Warn)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	report, err := seed.Run(db, options)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range report {
+		fmt.Fprintf(os.Stdout, "%-12s %d created\n", table.Table, table.Created)
+	}
+	fmt.Fprintf(os.Stdout, "\nSeed %d done in %s, log in with %s / %s\n", options.Seed, time.Since(start).Round(time.Millisecond), seed.AdminEmail, options.Password)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// namespace is the namespace of the UUIDs generated by the faker
+var namespace = uuid.NewV5(uuid.NamespaceURL, "https://github.com/ada-social-network/api/seed")
+
+var (
+	firstNames = []string{
+		"Ada", "Grace", "Margaret", "Katherine", "Hedy", "Radia", "Frances", "Barbara", "Anita", "Shafi",
+		"Karen", "Joan", "Adele", "Sophie", "Camille", "Lea", "Manon", "Ines", "Chloe", "Sarah",
+		"Yasmine", "Nora", "Amina", "Lucie", "Julie", "Alice", "Emma", "Louise", "Jade", "Lina",
+		"Hugo", "Lucas", "Nathan", "Karim", "Mehdi", "Thomas", "Antoine", "Samuel", "Omar", "Paul",
+	}
+	lastNames = []string{
+		"Lovelace", "Hopper", "Hamilton", "Johnson", "Lamarr", "Perlman", "Allen", "Liskov", "Borg", "Goldwasser",
+		"Sparck", "Clarke", "Goldberg", "Germain", "Martin", "Bernard", "Dubois", "Thomas", "Robert", "Richard",
+		"Petit", "Durand", "Leroy", "Moreau", "Simon", "Laurent", "Lefebvre", "Michel", "Garcia", "David",
+		"Bertrand", "Roux", "Vincent", "Fournier", "Morel", "Girard", "Andre", "Mercier", "Dupont", "Lambert",
+	}
+	promoNames = []string{
+		"Lovelace", "Hopper", "Hamilton", "Johnson", "Lamarr", "Perlman", "Allen", "Liskov", "Borg", "Goldwasser",
+	}
+	categoryNames = []string{
+		"Entraide", "Projets", "Alternance", "Veille techno", "Evenements", "Off-topic", "Carriere", "Ressources",
+	}
+	mbtis = []string{
+		"INTJ", "INTP", "ENTJ", "ENTP", "INFJ", "INFP", "ENFJ", "ENFP",
+		"ISTJ", "ISFJ", "ESTJ", "ESFJ", "ISTP", "ISFP", "ESTP", "ESFP",
+	}
+	words = []string{
+		"lorem", "ipsum", "dolor", "sit", "amet", "code", "review", "commit", "branch", "merge",
+		"golang", "javascript", "react", "api", "database", "query", "test", "deploy", "docker", "server",
+		"client", "bug", "feature", "sprint", "project", "team", "pair", "programming", "design", "pattern",
+		"function", "variable", "loop", "array", "object", "promise", "callback", "framework", "library", "module",
+		"question", "help", "idea", "meetup", "workshop", "demo", "interview", "internship", "company", "mentor",
+	}
+)
+
+// Faker is a deterministic fake data generator: two fakers created with the same
+// seed generate the same sequence of data
+type Faker struct {
+	seed int64
+	rand *rand.Rand
+}
+
+// NewFaker is to create a new faker from a seed
+func NewFaker(seed int64) *Faker {
+	return &Faker{seed: seed, rand: rand.New(rand.NewSource(seed))}
+}
+
+// UUID returns a stable identifier for the index-th resource of a kind
+func (f *Faker) UUID(kind string, index int) uuid.UUID {
+	return uuid.NewV5(namespace, fmt.Sprintf("%d/%s/%d", f.seed, kind, index))
+}
+
+// Intn returns a number in [0,n)
+func (f *Faker) Intn(n int) int {
+	return f.rand.Intn(n)
+}
+
+// Bool returns true with a probability of percent/100
+func (f *Faker) Bool(percent int) bool {
+	return f.rand.Intn(100) < percent
+}
+
+// Pick returns a random item of items
+func (f *Faker) Pick(items []string) string {
+	return items[f.rand.Intn(len(items))]
+}
+
+// FirstName returns a random first name
+func (f *Faker) FirstName() string {
+	return f.Pick(firstNames)
+}
+
+// LastName returns a random last name
+func (f *Faker) LastName() string {
+	return f.Pick(lastNames)
+}
+
+// Sentence returns a sentence between min and max words
+func (f *Faker) Sentence(min, max int) string {
+	count := min + f.rand.Intn(max-min+1)
+	sentence := make([]string, count)
+	for i := range sentence {
+		sentence[i] = f.Pick(words)
+	}
+
+	return strings.ToUpper(sentence[0][:1]) + strings.Join(sentence, " ")[1:] + "."
+}
+
+// Paragraph returns a paragraph between min and max sentences
+func (f *Faker) Paragraph(min, max int) string {
+	count := min + f.rand.Intn(max-min+1)
+	paragraph := make([]string, count)
+	for i := range paragraph {
+		paragraph[i] = f.Sentence(4, 14)
+	}
+
+	return strings.Join(paragraph, " ")
+}
+
+// Time returns a time between since and until
+func (f *Faker) Time(since, until time.Time) time.Time {
+	delta := until.Sub(since)
+	if delta <= 0 {
+		return since
+	}
+
+	return since.Add(time.Duration(f.rand.Int63n(int64(delta))))
+}
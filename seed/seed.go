@@ -0,0 +1,473 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminEmail is the email of the seeded administrator
+const AdminEmail = "admin@ada.dev"
+
+// DefaultNow is the default date of the most recent generated content, it is fixed so the
+// same seed generates the same data whenever it is run
+var DefaultNow = time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Options define the volume of data generated by the seed
+type Options struct {
+	// Seed of the fake data generator, the same seed always generate the same data
+	Seed int64
+	// Password of all the seeded users
+	Password string
+	// Now is the date of the most recent generated content
+	Now time.Time
+
+	Promos     int
+	Users      int
+	Categories int
+	Topics     int
+	Posts      int
+	BdaPosts   int
+	Comments   int
+	Likes      int
+	// Follows and Subscriptions are maximum numbers, duplicates are skipped
+	Follows       int
+	Subscriptions int
+}
+
+// DefaultOptions returns the options for a small but usable network
+func DefaultOptions() Options {
+	return Options{
+		Seed:          1,
+		Password:      "adapassword",
+		Now:           DefaultNow,
+		Promos:        3,
+		Users:         30,
+		Categories:    5,
+		Topics:        20,
+		Posts:         100,
+		BdaPosts:      10,
+		Comments:      40,
+		Likes:         200,
+		Follows:       60,
+		Subscriptions: 30,
+	}
+}
+
+// TableReport is the count of rows created in a table
+type TableReport struct {
+	Table   string
+	Created int64
+}
+
+// Report is the result of a seed
+type Report []TableReport
+
+// seeder keeps the state of the resources generated during a seed
+type seeder struct {
+	options Options
+	faker   *Faker
+	report  Report
+
+	promos        []models.Promo
+	users         []models.User
+	categories    []models.Category
+	topics        []models.Topic
+	posts         []models.Post
+	bdaPosts      []models.BdaPost
+	comments      []models.Comment
+	likes         []models.Like
+	follows       []models.Follow
+	subscriptions []models.Subscription
+}
+
+// Run populate the database with fake data, resources already seeded with the
+// same seed are left untouched so a seed can be run several times. The content
+// generated for a user whose email already exists is given to the existing user.
+func Run(db *gorm.DB, options Options) (Report, error) {
+	if options.Users < 1 && (options.Topics > 0 || options.Posts > 0 || options.BdaPosts > 0 || options.Comments > 0 || options.Likes > 0 || options.Follows > 0 || options.Subscriptions > 0) {
+		return nil, fmt.Errorf("at least one user is required to seed content")
+	}
+
+	if options.Categories < 1 && options.Topics > 0 {
+		return nil, fmt.Errorf("at least one category is required to seed topics")
+	}
+
+	if options.Topics < 1 && options.Posts > 0 {
+		return nil, fmt.Errorf("at least one topic is required to seed posts")
+	}
+
+	if options.BdaPosts < 1 && options.Posts < 1 && options.Topics < 1 && options.Comments > 0 {
+		return nil, fmt.Errorf("at least one bda post, post or topic is required to seed comments")
+	}
+
+	password, err := models.HashPassword(options.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &seeder{options: options, faker: NewFaker(options.Seed)}
+	s.generatePromos()
+	s.generateUsers(password)
+
+	err = s.reuseExistingUsers(db)
+	if err != nil {
+		return nil, err
+	}
+
+	s.generateCategories()
+	s.generateTopics()
+	s.generatePosts()
+	s.generateBdaPosts()
+	s.generateComments()
+	s.generateLikes()
+	s.generateFollows()
+	s.generateSubscriptions()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		inserts := []struct {
+			table string
+			rows  interface{}
+			count int
+		}{
+			{"promos", &s.promos, len(s.promos)},
+			{"users", &s.users, len(s.users)},
+			{"categories", &s.categories, len(s.categories)},
+			{"topics", &s.topics, len(s.topics)},
+			{"posts", &s.posts, len(s.posts)},
+			{"bda_posts", &s.bdaPosts, len(s.bdaPosts)},
+			{"comments", &s.comments, len(s.comments)},
+			{"likes", &s.likes, len(s.likes)},
+			{"follows", &s.follows, len(s.follows)},
+			{"subscriptions", &s.subscriptions, len(s.subscriptions)},
+		}
+
+		for _, insert := range inserts {
+			created, err := insertIgnoringExisting(tx, insert.rows, insert.count)
+			if err != nil {
+				return fmt.Errorf("seed %s: %w", insert.table, err)
+			}
+
+			s.report = append(s.report, TableReport{Table: insert.table, Created: created})
+		}
+
+		// topics and users may already exist from a previous seed with fewer posts or follows
+		err := tx.Exec("UPDATE topics SET posts_count = (SELECT COUNT(*) FROM posts WHERE posts.topic_id = topics.id)").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE users SET
+			followers_count = (SELECT COUNT(*) FROM follows WHERE follows.followed_id = users.id),
+			following_count = (SELECT COUNT(*) FROM follows WHERE follows.user_id = users.id)`).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.report, nil
+}
+
+// insertIgnoringExisting insert rows, skipping the ones whose id or unique key already exists
+func insertIgnoringExisting(tx *gorm.DB, rows interface{}, count int) (int64, error) {
+	if count == 0 {
+		return 0, nil
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 100)
+
+	return result.RowsAffected, result.Error
+}
+
+// base returns the common columns of the index-th resource of a kind
+func (s *seeder) base(kind string, index int, since time.Time) models.Base {
+	createdAt := s.faker.Time(since, s.options.Now)
+
+	return models.Base{
+		ID:        s.faker.UUID(kind, index),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+// since returns the date of the oldest generated content
+func (s *seeder) since() time.Time {
+	return s.options.Now.AddDate(0, -6, 0)
+}
+
+func (s *seeder) generatePromos() {
+	for i := 0; i < s.options.Promos; i++ {
+		start := s.options.Now.AddDate(-i, -1, 0)
+		name := promoNames[i%len(promoNames)]
+		if i >= len(promoNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(promoNames)+1)
+		}
+
+		s.promos = append(s.promos, models.Promo{
+			Base:      s.base("promo", i, s.since()),
+			Name:      name,
+			StartDate: start.Format("2006-01-02"),
+			EndDate:   start.AddDate(2, 0, 0).Format("2006-01-02"),
+			Bio:       s.faker.Paragraph(1, 3),
+		})
+	}
+}
+
+func (s *seeder) generateUsers(password string) {
+	emails := map[string]bool{}
+
+	for i := 0; i < s.options.Users; i++ {
+		firstName, lastName := s.faker.FirstName(), s.faker.LastName()
+		email := fmt.Sprintf("%s.%s@ada.dev", strings.ToLower(firstName), strings.ToLower(lastName))
+		if emails[email] {
+			email = fmt.Sprintf("%s.%s%d@ada.dev", strings.ToLower(firstName), strings.ToLower(lastName), i)
+		}
+
+		user := models.User{
+			Base:         s.base("user", i, s.since()),
+			FirstName:    firstName,
+			LastName:     lastName,
+			Email:        email,
+			Password:     password,
+			Biography:    s.faker.Paragraph(1, 2),
+			ProjectPerso: s.faker.Sentence(3, 8),
+			ProjectPro:   s.faker.Sentence(3, 8),
+			Github:       fmt.Sprintf("https://github.com/%s%s", strings.ToLower(firstName), strings.ToLower(lastName)),
+			MBTI:         s.faker.Pick(mbtis),
+		}
+
+		// the first user is a well known administrator
+		if i == 0 {
+			user.FirstName, user.LastName, user.Email, user.Admin = "Ada", "Admin", AdminEmail, true
+		}
+
+		if len(s.promos) > 0 {
+			user.PromoID = s.promos[s.faker.Intn(len(s.promos))].ID
+		}
+
+		emails[user.Email] = true
+		s.users = append(s.users, user)
+	}
+}
+
+// reuseExistingUsers replace the ids of the generated users whose email already exists by
+// the ids of the users in the DB. These users are not inserted again, e.g. the administrator
+// or a user seeded with another seed, and their content must not point to a missing user.
+func (s *seeder) reuseExistingUsers(db *gorm.DB) error {
+	if len(s.users) == 0 {
+		return nil
+	}
+
+	emails := make([]string, 0, len(s.users))
+	for _, user := range s.users {
+		emails = append(emails, user.Email)
+	}
+
+	var existing []models.User
+	err := db.Unscoped().Select("id", "email").Where("email IN ?", emails).Find(&existing).Error
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]uuid.UUID, len(existing))
+	for _, user := range existing {
+		ids[user.Email] = user.ID
+	}
+
+	for i := range s.users {
+		if id, ok := ids[s.users[i].Email]; ok {
+			s.users[i].ID = id
+		}
+	}
+
+	return nil
+}
+
+func (s *seeder) generateCategories() {
+	for i := 0; i < s.options.Categories; i++ {
+		name := categoryNames[i%len(categoryNames)]
+		if i >= len(categoryNames) {
+			name = fmt.Sprintf("%s %d", name, i/len(categoryNames)+1)
+		}
+
+		s.categories = append(s.categories, models.Category{
+			Base: s.base("category", i, s.since()),
+			Name: name,
+		})
+	}
+}
+
+func (s *seeder) generateTopics() {
+	for i := 0; i < s.options.Topics; i++ {
+		s.topics = append(s.topics, models.Topic{
+			Base:       s.base("topic", i, s.since()),
+			Name:       strings.TrimSuffix(s.faker.Sentence(2, 6), "."),
+			Content:    s.faker.Paragraph(1, 4),
+			UserID:     s.randomUserID(),
+			CategoryID: s.categories[s.faker.Intn(len(s.categories))].ID,
+		})
+	}
+}
+
+func (s *seeder) generatePosts() {
+	for i := 0; i < s.options.Posts; i++ {
+		topic := s.topics[s.faker.Intn(len(s.topics))]
+
+		s.posts = append(s.posts, models.Post{
+			Base:    s.base("post", i, topic.CreatedAt),
+			Content: s.faker.Paragraph(1, 5),
+			UserID:  s.randomUserID(),
+			TopicID: topic.ID,
+		})
+	}
+}
+
+func (s *seeder) generateBdaPosts() {
+	for i := 0; i < s.options.BdaPosts; i++ {
+		s.bdaPosts = append(s.bdaPosts, models.BdaPost{
+			Base:    s.base("bdapost", i, s.since()),
+			Title:   strings.TrimSuffix(s.faker.Sentence(2, 8), "."),
+			Content: s.faker.Paragraph(2, 6),
+			UserID:  s.randomUserID(),
+		})
+	}
+}
+
+func (s *seeder) generateComments() {
+	for i := 0; i < s.options.Comments; i++ {
+		targetType, target := s.randomCommentable()
+
+		comment := models.Comment{
+			Base:       s.base("comment", i, target.CreatedAt),
+			UserID:     s.randomUserID(),
+			TargetType: targetType,
+			TargetID:   target.ID,
+			Content:    s.faker.Sentence(3, 20),
+		}
+
+		// some comments are replies to a previous comment, a few levels deep
+		var parent *models.Comment
+		if i > 0 && s.faker.Intn(3) == 0 {
+			if candidate := &s.comments[s.faker.Intn(i)]; candidate.Depth < 2 {
+				parent = candidate
+				parent.RepliesCount++
+				comment.Base = s.base("comment", i, parent.CreatedAt)
+				comment.TargetType = parent.TargetType
+				comment.TargetID = parent.TargetID
+			}
+		}
+
+		comment.SetParent(parent)
+		s.comments = append(s.comments, comment)
+	}
+}
+
+// randomCommentable returns a random bda post, post or topic to comment
+func (s *seeder) randomCommentable() (models.Commentable, models.Base) {
+	targets := []models.Commentable{}
+	if len(s.bdaPosts) > 0 {
+		targets = append(targets, models.BdaPostComment)
+	}
+	if len(s.posts) > 0 {
+		targets = append(targets, models.PostComment)
+	}
+	if len(s.topics) > 0 {
+		targets = append(targets, models.TopicComment)
+	}
+
+	switch target := targets[s.faker.Intn(len(targets))]; target {
+	case models.PostComment:
+		return target, s.posts[s.faker.Intn(len(s.posts))].Base
+	case models.TopicComment:
+		return target, s.topics[s.faker.Intn(len(s.topics))].Base
+	default:
+		return target, s.bdaPosts[s.faker.Intn(len(s.bdaPosts))].Base
+	}
+}
+
+func (s *seeder) generateLikes() {
+	liked := map[string]bool{}
+
+	// a user likes a resource only once, so there may be fewer likes than requested
+	for i := 0; i < s.options.Likes; i++ {
+		like := models.Like{UserID: s.randomUserID()}
+
+		var target models.Base
+		switch choice := s.faker.Intn(3); {
+		case choice == 0 && len(s.posts) > 0:
+			post := s.posts[s.faker.Intn(len(s.posts))]
+			like.PostID, target = post.ID, post.Base
+		case choice == 1 && len(s.bdaPosts) > 0:
+			bdaPost := s.bdaPosts[s.faker.Intn(len(s.bdaPosts))]
+			like.BdaPostID, target = bdaPost.ID, bdaPost.Base
+		case len(s.comments) > 0:
+			comment := s.comments[s.faker.Intn(len(s.comments))]
+			like.CommentID, target = comment.ID, comment.Base
+		default:
+			continue
+		}
+
+		key := like.UserID.String() + target.ID.String()
+		if liked[key] {
+			continue
+		}
+		liked[key] = true
+
+		like.Base = s.base("like", i, target.CreatedAt)
+		s.likes = append(s.likes, like)
+	}
+}
+
+func (s *seeder) generateFollows() {
+	followed := map[string]bool{}
+
+	// a user follows another user only once and never themselves
+	for i := 0; i < s.options.Follows; i++ {
+		follow := models.Follow{UserID: s.randomUserID(), FollowedID: s.randomUserID()}
+
+		key := follow.UserID.String() + follow.FollowedID.String()
+		if uuid.Equal(follow.UserID, follow.FollowedID) || followed[key] {
+			continue
+		}
+		followed[key] = true
+
+		follow.Base = s.base("follow", i, s.since())
+		s.follows = append(s.follows, follow)
+	}
+}
+
+func (s *seeder) generateSubscriptions() {
+	subscribed := map[string]bool{}
+
+	// a user subscribes to a target only once
+	for i := 0; i < s.options.Subscriptions; i++ {
+		subscription := models.Subscription{UserID: s.randomUserID(), TargetType: models.BdaFeedSubscription}
+
+		switch choice := s.faker.Intn(3); {
+		case choice == 0 && len(s.topics) > 0:
+			subscription.TargetType = models.TopicSubscription
+			subscription.TargetID = s.topics[s.faker.Intn(len(s.topics))].ID
+		case choice == 1 && len(s.categories) > 0:
+			subscription.TargetType = models.CategorySubscription
+			subscription.TargetID = s.categories[s.faker.Intn(len(s.categories))].ID
+		}
+
+		key := subscription.UserID.String() + string(subscription.TargetType) + subscription.TargetID.String()
+		if subscribed[key] {
+			continue
+		}
+		subscribed[key] = true
+
+		subscription.Base = s.base("subscription", i, s.since())
+		s.subscriptions = append(s.subscriptions, subscription)
+	}
+}
+
+func (s *seeder) randomUserID() uuid.UUID {
+	return s.users[s.faker.Intn(len(s.users))].ID
+}
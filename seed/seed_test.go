@@ -0,0 +1,146 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ada-social-network/api/fsck"
+	"github.com/ada-social-network/api/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func initDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.AutoMigrate(models.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestFakerIsDeterministic(t *testing.T) {
+	a, b := NewFaker(42), NewFaker(42)
+
+	for i := 0; i < 10; i++ {
+		if got, want := a.Paragraph(1, 3), b.Paragraph(1, 3); got != want {
+			t.Errorf("Paragraph got:%s, want:%s", got, want)
+		}
+	}
+
+	if a.UUID("user", 1) != b.UUID("user", 1) {
+		t.Error("UUID should be stable for a seed")
+	}
+
+	if a.UUID("user", 1) == NewFaker(43).UUID("user", 1) {
+		t.Error("UUID should depend on the seed")
+	}
+}
+
+func TestRun(t *testing.T) {
+	db := initDB(t)
+
+	options := DefaultOptions()
+	options.Now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users, posts int64
+	db.Model(&models.User{}).Count(&users)
+	db.Model(&models.Post{}).Count(&posts)
+
+	if users != int64(options.Users) || posts != int64(options.Posts) {
+		t.Errorf("Run want %d users and %d posts, got:%d and %d", options.Users, options.Posts, users, posts)
+	}
+
+	if report[1].Table != "users" || report[1].Created != int64(options.Users) {
+		t.Errorf("Run report got:%v", report[1])
+	}
+
+	var admin models.User
+	db.First(&admin, "email = ?", AdminEmail)
+	if !admin.Admin || admin.ComparePassword(options.Password) != nil {
+		t.Error("Run should create an administrator with the seed password")
+	}
+
+	var postsCount int64
+	db.Model(&models.Topic{}).Select("SUM(posts_count)").Scan(&postsCount)
+	if postsCount != posts {
+		t.Errorf("Run topics posts count want:%d, got:%d", posts, postsCount)
+	}
+
+	// a second run with the same seed does not create anything
+	report, err = Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range report {
+		if table.Created != 0 {
+			t.Errorf("Run should be idempotent, %s got %d created", table.Table, table.Created)
+		}
+	}
+}
+
+func TestRunWithAnotherSeed(t *testing.T) {
+	db := initDB(t)
+
+	options := DefaultOptions()
+	options.Now = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the administrator exists, the content generated for them is given to the existing user
+	options.Seed = 2
+	_, err = Run(db, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var admins int64
+	db.Model(&models.User{}).Where("email = ?", AdminEmail).Count(&admins)
+	if admins != 1 {
+		t.Errorf("Run want 1 administrator, got:%d", admins)
+	}
+
+	report, err := fsck.Run(db, fsck.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range report.Checks {
+		if len(c.Issues) > 0 {
+			t.Errorf("Run with another seed should not corrupt the DB, %s got:%v", c.Name, c.Issues)
+		}
+	}
+}
+
+func TestRunRequiresUsers(t *testing.T) {
+	db := initDB(t)
+
+	options := DefaultOptions()
+	options.Users = 0
+
+	_, err := Run(db, options)
+	if err == nil {
+		t.Error("Run should fail without users")
+	}
+
+	var got []models.Promo
+	db.Find(&got)
+	if !reflect.DeepEqual(got, []models.Promo{}) {
+		t.Error("Run should not create anything when options are invalid")
+	}
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MigrateResult counts the blobs handled by Migrate
+type MigrateResult struct {
+	Copied  int
+	Skipped int
+}
+
+// Migrate copy the blobs starting with a prefix from a store to another, the blobs which
+// are already in the destination with the same size are skipped so an interrupted
+// migration can be run again. The source is left untouched. The type of the blobs of the
+// stores which do not keep it is detected from their content.
+func Migrate(from Store, to Store, prefix string) (MigrateResult, error) {
+	result := MigrateResult{}
+
+	keys, err := from.List(prefix)
+	if err != nil {
+		return result, err
+	}
+
+	for _, key := range keys {
+		copied, err := migrateBlob(from, to, key)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if copied {
+			result.Copied++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+// migrateBlob copy a blob if it is not already in the destination
+func migrateBlob(from Store, to Store, key string) (bool, error) {
+	object, err := from.Open(key)
+	if err != nil {
+		return false, err
+	}
+	defer object.Close()
+
+	info := object.Info()
+
+	existing, err := to.Stat(key)
+	if err == nil && existing.Size == info.Size {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return false, err
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return true, to.Put(key, data, contentType)
+}
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultS3Endpoint is the endpoint of AWS S3
+	defaultS3Endpoint = "https://s3.amazonaws.com"
+	// defaultS3Region is the region of the buckets when none is given
+	defaultS3Region = "us-east-1"
+	// maxPresignExpiry is the longest validity of a presigned URL allowed by S3
+	maxPresignExpiry = 7 * 24 * time.Hour
+	// s3Timeout is the maximum duration of a request to S3, including the read of the body
+	s3Timeout = 2 * time.Minute
+)
+
+// S3Config is the configuration of an S3Store
+type S3Config struct {
+	// Endpoint is the URL of the S3 API, e.g. http://localhost:9000 for a local MinIO,
+	// AWS S3 by default
+	Endpoint string
+	// Region is the region of the bucket, us-east-1 by default
+	Region string
+	Bucket string
+	// Prefix is prepended to the keys, so several stores can share a bucket
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// PathStyle puts the bucket in the path of the URLs rather than in the host name,
+	// as required by most S3-compatible storages
+	PathStyle bool
+}
+
+// S3Store stores the blobs as the objects of a bucket of an S3-compatible storage
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	signer   signer
+	client   *http.Client
+	// now returns the signing time
+	now func() time.Time
+}
+
+// NewS3Store is to create a new S3 store from a configuration
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("missing S3 bucket")
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = defaultS3Endpoint
+	}
+
+	if config.Region == "" {
+		config.Region = defaultS3Region
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	config.Prefix = strings.Trim(config.Prefix, "/")
+	if config.Prefix != "" && !ValidKey(config.Prefix) {
+		return nil, fmt.Errorf("invalid S3 prefix %q", config.Prefix)
+	}
+
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		signer:   signer{accessKey: config.AccessKey, secretKey: config.SecretKey, region: config.Region},
+		client:   &http.Client{Timeout: s3Timeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Put upload the data of a key as an object
+func (s *S3Store) Put(key string, data []byte, contentType string) error {
+	req, err := s.objectRequest(http.MethodPut, key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := s.do(req, sha256Hex(data))
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+// Open returns the object of a key, its content is downloaded as it is read
+func (s *S3Store) Open(key string) (Object, error) {
+	info, err := s.Stat(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Object{store: s, info: info}, nil
+}
+
+// Stat returns the description of the object of a key
+func (s *S3Store) Stat(key string) (Info, error) {
+	req, err := s.objectRequest(http.MethodHead, key, nil)
+	if err != nil {
+		return Info{}, err
+	}
+
+	res, err := s.do(req, emptyPayload)
+	if err != nil {
+		return Info{}, err
+	}
+	res.Body.Close()
+
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+
+	return Info{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+		ModTime:     modTime,
+	}, nil
+}
+
+// Delete remove the object of a key
+func (s *S3Store) Delete(key string) error {
+	req, err := s.objectRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req, emptyPayload)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
+
+// listResult is the response of the ListObjectsV2 action
+type listResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List returns the keys of the objects starting with a prefix, page by page
+func (s *S3Store) List(prefix string) ([]string, error) {
+	keys := []string{}
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.objectName(prefix)}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.request(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := s.do(req, emptyPayload)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &listResult{}
+		err = xml.NewDecoder(res.Body).Decode(result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			key := content.Key
+			if s.config.Prefix != "" {
+				key = strings.TrimPrefix(key, s.config.Prefix+"/")
+			}
+
+			if ValidKey(key) {
+				keys = append(keys, key)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// PresignGet returns a URL to download the object of a key without credentials. The
+// signing time is rounded down to the expiry and the URL is valid for twice the expiry,
+// so the same URL is given during an expiry and the clients can cache the object.
+func (s *S3Store) PresignGet(key string, expires time.Duration) (string, error) {
+	if expires <= 0 || 2*expires > maxPresignExpiry {
+		return "", fmt.Errorf("invalid presigned URL expiry %s", expires)
+	}
+
+	req, err := s.objectRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return "", err
+	}
+
+	s.signer.presign(req, 2*expires, s.now().UTC().Truncate(expires))
+
+	return req.URL.String(), nil
+}
+
+// objectName returns the name of the object of a key in the bucket
+func (s *S3Store) objectName(key string) string {
+	if s.config.Prefix == "" {
+		return key
+	}
+
+	return s.config.Prefix + "/" + key
+}
+
+// objectRequest returns a request on the object of a key
+func (s *S3Store) objectRequest(method, key string, body io.Reader) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	return s.request(method, key, nil, body)
+}
+
+// request returns a request on the object of a key, or on the bucket without key
+func (s *S3Store) request(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.config.PathStyle {
+		path += "/" + s.config.Bucket
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+	}
+
+	if key != "" {
+		path += "/" + s.objectName(key)
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	u.Path = path
+	u.RawPath = uriEscape(path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	return http.NewRequest(method, u.String(), body)
+}
+
+// do sign and send a request to S3, the error responses are returned as errors
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signer.sign(req, payloadHash, s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	// the error responses have an XML body, except the responses to HEAD requests
+	content := &struct {
+		Code    string
+		Message string
+	}{}
+	_ = xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(content)
+
+	return nil, fmt.Errorf("S3 %s %s: %s %s %s", req.Method, req.URL.Path, res.Status, content.Code, content.Message)
+}
+
+// s3Object is an opened object of an S3Store, it is downloaded from the read offset
+// with range requests
+type s3Object struct {
+	store  *S3Store
+	info   Info
+	offset int64
+	body   io.ReadCloser
+}
+
+// Info returns the description of the object
+func (o *s3Object) Info() Info {
+	return o.info
+}
+
+// Read download the object from the offset on the first read after a seek
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.info.Size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		req, err := o.store.objectRequest(http.MethodGet, o.info.Key, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+
+		res, err := o.store.do(req, emptyPayload)
+		if err != nil {
+			return 0, err
+		}
+
+		o.body = res.Body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+
+	return n, err
+}
+
+// Seek change the read offset, the download restarts from the new offset
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.info.Size
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if offset != o.offset {
+		o.Close()
+		o.offset = offset
+	}
+
+	return offset, nil
+}
+
+// Close stop the download in progress
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+
+	return err
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// signingAlgorithm is the algorithm of the AWS signature version 4
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	// amzDateFormat is the format of the dates of the signatures
+	amzDateFormat = "20060102T150405Z"
+	// unsignedPayload is the payload hash of the presigned URLs
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayload is the payload hash of the requests without body
+	emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// signer signs the requests to S3 with the AWS signature version 4
+type signer struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// sign add the authorization headers to a request, all its headers are signed
+func (s signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers, signedHeaders := canonicalHeaders(req)
+	signature := s.signature(req, headers, signedHeaders, payloadHash, now)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, s.accessKey, s.scope(now), signedHeaders, signature,
+	))
+}
+
+// presign add the signature of a presigned URL to the query of a GET request, the URL
+// is valid for the expiry from the signing time
+func (s signer) presign(req *http.Request, expires time.Duration, now time.Time) {
+	now = now.UTC()
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	req.URL.RawQuery = canonicalQuery(query)
+
+	headers := "host:" + req.URL.Host + "\n"
+	query.Set("X-Amz-Signature", s.signature(req, headers, "host", unsignedPayload, now))
+	req.URL.RawQuery = canonicalQuery(query)
+}
+
+// signature returns the signature of a canonical request
+func (s signer) signature(req *http.Request, headers, signedHeaders, payloadHash string, now time.Time) string {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope returns the credential scope of a signature
+func (s signer) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// canonicalHeaders returns the canonical headers of a request and the list of their names
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	for name, value := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(value, ","))
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := &strings.Builder{}
+	for _, name := range names {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return headers.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery returns the query sorted by name with the values escaped as required
+// by the signature
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []string{}
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, uriEscape(name, true)+"="+uriEscape(value, true))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+// uriEscape escape every byte except the unreserved characters, and the '/' when it is
+// not a query value
+func uriEscape(value string, escapeSlash bool) string {
+	escaped := &strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			escaped.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(escaped, "%%%02X", c)
+	}
+
+	return escaped.String()
+}
+
+// sha256Hex returns the hexadecimal SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
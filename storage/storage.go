@@ -0,0 +1,101 @@
+// Package storage stores blobs, like the files of the uploaded media, by key in a
+// directory of the local filesystem or in a bucket of an S3-compatible object storage
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a key is not in a store
+	ErrNotFound = errors.New("blob not found")
+	// ErrInvalidKey is returned when a key is not a relative path of simple names, so
+	// it can not escape the directory or the prefix of a store
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// validKey matches the keys: names of letters, digits, '.', '-' or '_' separated by '/',
+// a name can not start with a '.' so the keys never contain ".."
+var validKey = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+
+// Info describes a stored blob
+type Info struct {
+	Key  string
+	Size int64
+	// ContentType is the type given when the blob was put, it is empty when the store
+	// does not keep it
+	ContentType string
+	ModTime     time.Time
+}
+
+// Object is an opened blob, it can be read from any offset
+type Object interface {
+	io.ReadSeekCloser
+	Info() Info
+}
+
+// Store is the interface implemented by the blob storage backends
+type Store interface {
+	// Put store the data of a key, an existing blob is replaced
+	Put(key string, data []byte, contentType string) error
+	// Open returns the blob of a key to read it
+	Open(key string) (Object, error)
+	// Stat returns the description of the blob of a key
+	Stat(key string) (Info, error)
+	// Delete remove the blob of a key, a missing blob is not an error
+	Delete(key string) error
+	// List returns the keys starting with a prefix in lexical order
+	List(prefix string) ([]string, error)
+}
+
+// Presigner is implemented by the stores which can give a temporary URL to download a
+// blob directly from the storage, without going through the API
+type Presigner interface {
+	PresignGet(key string, expires time.Duration) (string, error)
+}
+
+// ValidKey check if a key can be used in the stores
+func ValidKey(key string) bool {
+	return validKey.MatchString(key)
+}
+
+// Open returns the store of a location: a directory of the local filesystem, or an
+// S3 bucket as s3://bucket/prefix?endpoint=http://localhost:9000&region=us-east-1&path-style=true
+// where only the bucket is required. The credentials of the S3 buckets are read from the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+func Open(location string) (Store, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return NewFileStore(strings.TrimPrefix(location, "file://"))
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	config := S3Config{
+		Endpoint:  query.Get("endpoint"),
+		Region:    query.Get("region"),
+		Bucket:    u.Host,
+		Prefix:    strings.Trim(u.Path, "/"),
+		AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+	}
+
+	if pathStyle := query.Get("path-style"); pathStyle != "" {
+		config.PathStyle, err = strconv.ParseBool(pathStyle)
+		if err != nil {
+			return nil, errors.New("invalid path-style in " + location)
+		}
+	}
+
+	return NewS3Store(config)
+}
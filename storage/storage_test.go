@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an S3-compatible server storing the objects of a bucket in memory, it
+// checks the signatures of the requests like a MinIO server
+type fakeS3 struct {
+	t       *testing.T
+	bucket  string
+	signer  signer
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+// pageSize is the number of keys listed per page, small enough to test the pagination
+const pageSize = 2
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.validSignature(r) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+f.bucket)
+	key := strings.TrimPrefix(path, "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, r.URL.Query())
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(data) {
+			http.Error(w, "<Error><Code>XAmzContentSHA256Mismatch</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		object, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", object.contentType)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(object.data)))
+	}
+}
+
+// list respond a page of the keys with a prefix
+func (f *fakeS3) list(w http.ResponseWriter, query url.Values) {
+	keys := []string{}
+	for key := range f.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []struct{ Key string }
+		IsTruncated           bool
+		NextContinuationToken string
+	}{}
+
+	for i, key := range keys {
+		if i == pageSize {
+			result.IsTruncated = true
+			result.NextContinuationToken = keys[i-1]
+			break
+		}
+		result.Contents = append(result.Contents, struct{ Key string }{key})
+	}
+
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// validSignature check the signature of a request or a presigned URL
+func (f *fakeS3) validSignature(r *http.Request) bool {
+	req := r.Clone(r.Context())
+	req.URL.Host = r.Host
+
+	query := req.URL.Query()
+	if signature := query.Get("X-Amz-Signature"); signature != "" {
+		date, _ := time.Parse(amzDateFormat, query.Get("X-Amz-Date"))
+		expires, _ := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if time.Now().After(date.Add(time.Duration(expires) * time.Second)) {
+			return false
+		}
+
+		query.Del("X-Amz-Signature")
+		req.URL.RawQuery = canonicalQuery(query)
+		return f.signer.signature(req, "host:"+req.URL.Host+"\n", "host", unsignedPayload, date) == signature
+	}
+
+	authorization := r.Header.Get("Authorization")
+	signedHeaders := strings.Split(between(authorization, "SignedHeaders=", ","), ";")
+
+	req.Header = http.Header{}
+	for _, name := range signedHeaders {
+		if name != "host" {
+			req.Header.Set(name, r.Header.Get(name))
+		}
+	}
+
+	date, _ := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	headers, names := canonicalHeaders(req)
+	signature := f.signer.signature(req, headers, names, r.Header.Get("X-Amz-Content-Sha256"), date)
+
+	return strings.HasSuffix(authorization, "Signature="+signature)
+}
+
+// between returns the part of a string between two delimiters
+func between(s, start, end string) string {
+	i := strings.Index(s, start)
+	if i < 0 {
+		return ""
+	}
+
+	s = s[i+len(start):]
+	if j := strings.Index(s, end); j >= 0 {
+		return s[:j]
+	}
+
+	return s
+}
+
+// newFakeS3Store returns a store of a fake S3 server and the server
+func newFakeS3Store(t *testing.T, prefix string) (*S3Store, *fakeS3) {
+	credentials := signer{accessKey: "minio", secretKey: "minio-secret", region: "eu-west-3"}
+	fake := &fakeS3{t: t, bucket: "ada", signer: credentials, objects: map[string]fakeObject{}}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Region:    credentials.region,
+		Bucket:    fake.bucket,
+		Prefix:    prefix,
+		AccessKey: credentials.accessKey,
+		SecretKey: credentials.secretKey,
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, fake
+}
+
+// testStore check the behavior shared by all the stores
+func testStore(t *testing.T, store Store) {
+	for i, key := range []string{"ab/abc", "ab/abd-small", "cd/cde", "readme.txt"} {
+		err := store.Put(key, []byte(fmt.Sprintf("content %d of %s", i, key)), "text/plain")
+		if err != nil {
+			t.Fatalf("Put(%v) = %v", key, err)
+		}
+	}
+
+	// an existing blob is replaced
+	err := store.Put("cd/cde", []byte("0123456789"), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := store.List("ab/")
+	if err != nil || strings.Join(keys, ",") != "ab/abc,ab/abd-small" {
+		t.Errorf("List = %v %v, want the keys of ab/", keys, err)
+	}
+
+	keys, _ = store.List("")
+	if len(keys) != 4 {
+		t.Errorf("List got %v, want all the keys", keys)
+	}
+
+	info, err := store.Stat("cd/cde")
+	if err != nil || info.Size != 10 {
+		t.Errorf("Stat = %+v %v, want a size of 10", info, err)
+	}
+
+	object, err := store.Open("cd/cde")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = object.Seek(4, io.SeekStart)
+	data, err := io.ReadAll(object)
+	if err != nil || string(data) != "456789" {
+		t.Errorf("Read after Seek got %q %v, want the end of the content", data, err)
+	}
+
+	_, _ = object.Seek(0, io.SeekStart)
+	data, _ = io.ReadAll(object)
+	if string(data) != "0123456789" {
+		t.Errorf("Read after a second Seek got %q, want the whole content", data)
+	}
+	object.Close()
+
+	err = store.Delete("cd/cde")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.Delete("cd/cde"); err != nil {
+		t.Errorf("Delete of a missing key = %v, want nil", err)
+	}
+	if _, err = store.Open("cd/cde"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open of a deleted key = %v, want %v", err, ErrNotFound)
+	}
+	if _, err = store.Stat("cd/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat of a missing key = %v, want %v", err, ErrNotFound)
+	}
+
+	for _, key := range []string{"../escape", "/absolute", "ab/../cd", "ab//cd", ".hidden", ""} {
+		if err := store.Put(key, []byte("x"), ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStore(t, store)
+}
+
+func TestS3Store(t *testing.T) {
+	store, fake := newFakeS3Store(t, "media")
+
+	testStore(t, store)
+
+	if _, ok := fake.objects["media/ab/abc"]; !ok {
+		t.Errorf("S3Store got the objects %v, want them in the prefix", fake.objects)
+	}
+
+	t.Run("presigned URL", func(t *testing.T) {
+		presigned, err := store.PresignGet("ab/abc", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again, _ := store.PresignGet("ab/abc", time.Hour)
+		if again != presigned {
+			t.Errorf("PresignGet got %v then %v, want a stable URL", presigned, again)
+		}
+
+		res, err := http.Get(presigned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != 200 || string(data) != "content 0 of ab/abc" || res.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("GET presigned URL = %v %q, want the object", res.StatusCode, data)
+		}
+
+		if _, err := store.PresignGet("ab/abc", 5*24*time.Hour); err == nil {
+			t.Error("PresignGet longer than a week got no error")
+		}
+	})
+
+	t.Run("wrong credentials", func(t *testing.T) {
+		store.signer.secretKey = "wrong"
+		defer func() { store.signer.secretKey = fake.signer.secretKey }()
+
+		if err := store.Put("ab/abc", []byte("x"), ""); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+			t.Errorf("Put with wrong credentials = %v, want a signature error", err)
+		}
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	from, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to, _ := newFakeS3Store(t, "")
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	_ = from.Put("ab/abc", png, "")
+	_ = from.Put("ab/abd", []byte("some text"), "")
+	_ = from.Put("cd/cde", []byte("other"), "")
+
+	result, err := Migrate(from, to, "ab/")
+	if err != nil || result != (MigrateResult{Copied: 2}) {
+		t.Fatalf("Migrate = %+v %v, want 2 copies", result, err)
+	}
+
+	info, _ := to.Stat("ab/abc")
+	if info.ContentType != "image/png" {
+		t.Errorf("Migrate got the type %v, want the detected type", info.ContentType)
+	}
+
+	result, err = Migrate(from, to, "")
+	if err != nil || result != (MigrateResult{Copied: 1, Skipped: 2}) {
+		t.Errorf("Migrate again = %+v %v, want the migrated blobs skipped", result, err)
+	}
+
+	if keys, _ := from.List(""); len(keys) != 3 {
+		t.Errorf("Migrate left %v in the source, want it untouched", keys)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"net/http/httptest"
 	"strings"
 
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/realtime"
+	"github.com/ada-social-network/api/repository"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -24,6 +27,19 @@ func InitDB(model interface{}) *gorm.DB {
 	return db
 }
 
+// InitAllDB Initialize in-memory database and auto-migrate all the models, so a test does not
+// depend on the tables used by the features its handler goes through
+func InitAllDB() *gorm.DB {
+	db, _ := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+
+	err := db.AutoMigrate(models.All()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return db
+}
+
 // AddRequestWithBodyToContext hydrate context with request
 func AddRequestWithBodyToContext(c *gin.Context, body interface{}) {
 	bodyBytes, err := json.Marshal(body)
@@ -45,6 +61,22 @@ func InitHTTPTest() (res *httptest.ResponseRecorder, ctx *gin.Context, engine *g
 
 	res = httptest.NewRecorder()
 	ctx, engine = gin.CreateTestContext(res)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 
 	return res, ctx, engine
 }
+
+// UnitOfWork is a unit of work running operations directly on the given repositories,
+// it allows handlers to be tested with in-memory fakes
+type UnitOfWork struct {
+	Repositories *repository.Repositories
+}
+
+// Transaction call fn with the unit of work repositories, their events are kept
+func (u *UnitOfWork) Transaction(fn func(repositories *repository.Repositories) error) error {
+	if u.Repositories.Events == nil {
+		u.Repositories.Events = realtime.NewBuffer()
+	}
+
+	return fn(u.Repositories)
+}
@@ -0,0 +1,226 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the maximum duration to fetch a page, redirects included
+	DefaultTimeout = 5 * time.Second
+	// DefaultMaxSize is the maximum number of bytes read from a page, the metadata are
+	// at the beginning of the page
+	DefaultMaxSize int64 = 512 << 10
+	// MaxRedirects is the maximum number of redirects followed to fetch a page
+	MaxRedirects = 5
+	// UserAgent is the user agent of the requests fetching the previews
+	UserAgent = "ada-api-link-preview/1.0"
+)
+
+var (
+	// ErrForbiddenAddress is an error when a link resolves to a private, loopback or
+	// reserved address, which must not be reached from the server
+	ErrForbiddenAddress = errors.New("the address of the link is not public")
+	// ErrUnsupportedScheme is an error when a link is not http or https
+	ErrUnsupportedScheme = errors.New("only http and https links can be previewed")
+	// ErrUnsupportedContent is an error when a link is neither a page nor an image
+	ErrUnsupportedContent = errors.New("the content of the link can not be previewed")
+	// errTooManyRedirects is an error when a page redirects more than MaxRedirects times
+	errTooManyRedirects = fmt.Errorf("stopped after %d redirects", MaxRedirects)
+)
+
+// reservedNetworks are the networks which are not public, in addition to the private,
+// loopback, link local and multicast ones known by net.IP
+var reservedNetworks = parseNetworks(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, broadcast included
+	"64:ff9b::/96",  // NAT64, which can reach private IPv4 addresses
+)
+
+// Preview is the metadata of a link
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher fetches the previews of links on the public internet only: the address of
+// each connection is checked once resolved, so a link or a redirect can not reach the
+// private networks of the server, even with a DNS name.
+type Fetcher struct {
+	client       *http.Client
+	maxSize      int64
+	allowPrivate bool
+}
+
+// NewFetcher is to create a new fetcher of previews with a timeout by link and a maximum
+// size read by page
+func NewFetcher(timeout time.Duration, maxSize int64) *Fetcher {
+	f := &Fetcher{maxSize: maxSize}
+
+	dialer := &net.Dialer{Timeout: timeout, Control: f.checkAddress}
+	transport := &http.Transport{
+		// a proxy would connect to the addresses on behalf of the fetcher
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       time.Minute,
+	}
+
+	f.client = &http.Client{
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: checkRedirect,
+	}
+
+	return f
+}
+
+// WithPrivateNetworks allow the fetcher to reach the private networks, e.g. to test it
+// against a local server
+func (f *Fetcher) WithPrivateNetworks() *Fetcher {
+	f.allowPrivate = true
+	return f
+}
+
+// Fetch returns the preview of a link from the metadata of its page. The OpenGraph
+// metadata come first, then the Twitter card and oEmbed ones, then the title and the
+// description of the page. The preview of an image is the image itself.
+func (f *Fetcher) Fetch(ctx context.Context, link string) (*Preview, error) {
+	resp, err := f.get(ctx, link, "text/html,application/xhtml+xml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// the link of the page once redirected, to resolve the relative links of the page
+	page := resp.Request.URL
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+	case strings.HasPrefix(mediaType, "image/"):
+		return &Preview{ImageURL: page.String(), SiteName: siteName(page)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContent, mediaType)
+	}
+
+	meta := parseMetadata(io.LimitReader(resp.Body, f.maxSize))
+
+	var embed *oEmbed
+	if meta.oEmbedURL != "" && (meta.first(titleKeys...) == "" || meta.first(imageKeys...) == "") {
+		embed = f.fetchOEmbed(ctx, resolve(page, meta.oEmbedURL))
+	}
+
+	return meta.preview(page, embed), nil
+}
+
+// get send a GET request accepting a content type and returns the successful responses
+func (f *Fetcher) get(ctx context.Context, link string, accept string) (*http.Response, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// checkAddress refuse the connections to the addresses which are not public
+func (f *Fetcher) checkAddress(network, address string, _ syscall.RawConn) error {
+	if f.allowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
+
+// checkRedirect follow at most MaxRedirects redirects to http and https links
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= MaxRedirects {
+		return errTooManyRedirects
+	}
+
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+
+	return nil
+}
+
+// isPublic returns true when an address can be reached on the public internet
+func isPublic(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseNetworks returns the networks of CIDR notations
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
@@ -0,0 +1,202 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pages are the responses of the stub server by path
+var pages = map[string]struct {
+	contentType string
+	body        string
+}{
+	"/opengraph": {"text/html; charset=utf-8", `<!DOCTYPE html><html><head>
+		<title>Fallback title</title>
+		<meta name="twitter:title" content="Twitter title">
+		<meta property="og:title" content="  OpenGraph
+			title ">
+		<meta property="og:description" content="A &amp; B">
+		<meta property="og:image" content="/images/cover.png">
+		<meta property="og:site_name" content="The Site">
+	</head><body><meta property="og:title" content="In the body"></body></html>`},
+	"/twitter": {"text/html", `<html><head>
+		<meta name="twitter:title" content="Twitter title">
+		<meta name="twitter:description" content="Twitter description">
+		<meta name="twitter:image" content="https://cdn.example.com/card.jpg">
+	</head></html>`},
+	"/title": {"text/html", `<html><head><title>Just a &lt;title&gt;</title><meta name="description" content="Described"></head></html>`},
+	"/oembed": {"text/html", `<html><head>
+		<link rel="alternate" type="application/json+oembed" href="/oembed.json">
+		<meta property="og:description" content="Description of the page">
+	</head></html>`},
+	"/oembed.json": {"application/json", `{"type": "photo", "title": "oEmbed title", "url": "https://cdn.example.com/photo.jpg", "provider_name": "Provider"}`},
+	"/image.png":   {"image/png", "\x89PNG"},
+	"/archive.zip": {"application/zip", "PK"},
+	"/large": {"text/html", `<html><head><meta property="og:title" content="Large page">` +
+		strings.Repeat("<!-- padding -->", 1000) + `<meta property="og:description" content="After the limit"></head></html>`},
+}
+
+// newStub returns a local server responding the pages and redirecting /redirect to the
+// page given by the to parameter
+func newStub(t *testing.T) *httptest.Server {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+			return
+		}
+
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", page.contentType)
+		fmt.Fprint(w, page.body)
+	}))
+	t.Cleanup(stub.Close)
+
+	return stub
+}
+
+func TestFetch(t *testing.T) {
+	stub := newStub(t)
+	fetcher := NewFetcher(time.Second, 4096).WithPrivateNetworks()
+
+	tests := []struct {
+		name    string
+		link    string
+		want    *Preview
+		wantErr error
+	}{
+		{
+			name: "opengraph",
+			link: stub.URL + "/opengraph",
+			want: &Preview{Title: "OpenGraph title", Description: "A & B", ImageURL: stub.URL + "/images/cover.png", SiteName: "The Site"},
+		},
+		{
+			name: "twitter card",
+			link: stub.URL + "/twitter",
+			want: &Preview{Title: "Twitter title", Description: "Twitter description", ImageURL: "https://cdn.example.com/card.jpg", SiteName: "127.0.0.1"},
+		},
+		{
+			name: "title and description",
+			link: stub.URL + "/title",
+			want: &Preview{Title: "Just a <title>", Description: "Described", SiteName: "127.0.0.1"},
+		},
+		{
+			name: "oembed",
+			link: stub.URL + "/oembed",
+			want: &Preview{Title: "oEmbed title", Description: "Description of the page", ImageURL: "https://cdn.example.com/photo.jpg", SiteName: "Provider"},
+		},
+		{
+			name: "image",
+			link: stub.URL + "/image.png",
+			want: &Preview{ImageURL: stub.URL + "/image.png", SiteName: "127.0.0.1"},
+		},
+		{
+			name: "redirect",
+			link: stub.URL + "/redirect?to=/title",
+			want: &Preview{Title: "Just a <title>", Description: "Described", SiteName: "127.0.0.1"},
+		},
+		{
+			name: "size limit",
+			link: stub.URL + "/large",
+			want: &Preview{Title: "Large page", SiteName: "127.0.0.1"},
+		},
+		{name: "unsupported content", link: stub.URL + "/archive.zip", wantErr: ErrUnsupportedContent},
+		{name: "unsupported scheme", link: "file:///etc/passwd", wantErr: ErrUnsupportedScheme},
+		{name: "redirect to another scheme", link: stub.URL + "/redirect?to=ftp://example.com/", wantErr: ErrUnsupportedScheme},
+		{name: "not found", link: stub.URL + "/unknown", wantErr: errors.New("unexpected status 404")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := fetcher.Fetch(context.Background(), test.link)
+			if test.wantErr != nil {
+				if err == nil || (!errors.Is(err, test.wantErr) && !strings.Contains(err.Error(), test.wantErr.Error())) {
+					t.Errorf("Fetch error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Fetch error = %v", err)
+			}
+
+			if *got != *test.want {
+				t.Errorf("Fetch = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchPrivateNetworks(t *testing.T) {
+	stub := newStub(t)
+	fetcher := NewFetcher(time.Second, DefaultMaxSize)
+
+	// the host name resolves to the loopback address, it is checked once resolved
+	port := stub.URL[strings.LastIndex(stub.URL, ":")+1:]
+
+	for _, link := range []string{stub.URL + "/title", "http://localhost:" + port + "/title"} {
+		_, err := fetcher.Fetch(context.Background(), link)
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Fetch %s error = %v, want %v", link, err, ErrForbiddenAddress)
+		}
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	fetcher := NewFetcher(50*time.Millisecond, DefaultMaxSize).WithPrivateNetworks()
+
+	start := time.Now()
+	_, err := fetcher.Fetch(context.Background(), slow.URL)
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Fetch of a slow page = %v after %v, want a timeout", err, time.Since(start))
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "::ffff:10.0.0.1", want: false},
+		{ip: "64:ff9b::a00:1", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			if got := isPublic(net.ParseIP(test.ip)); got != test.want {
+				t.Errorf("isPublic(%s) = %v, want %v", test.ip, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package unfurl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The maximum lengths of the metadata of a preview, in characters
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxSiteNameLength    = 100
+)
+
+// The metadata keys of each field of a preview, by priority
+var (
+	titleKeys       = []string{"og:title", "twitter:title"}
+	descriptionKeys = []string{"og:description", "twitter:description", "description"}
+	imageKeys       = []string{"og:image", "og:image:url", "og:image:secure_url", "twitter:image", "twitter:image:src"}
+	siteNameKeys    = []string{"og:site_name", "application-name"}
+)
+
+// metadata are the metadata found in the head of a page
+type metadata struct {
+	// properties are the content of the meta elements by property or name, the first
+	// one is kept
+	properties map[string]string
+	title      string
+	oEmbedURL  string
+}
+
+// oEmbed is the oEmbed response of a page, see https://oembed.com
+type oEmbed struct {
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// parseMetadata returns the metadata of the head of an HTML page, the body is not read
+func parseMetadata(r io.Reader) *metadata {
+	meta := &metadata{properties: map[string]string{}}
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return meta
+		case html.TextToken:
+			if inTitle && meta.title == "" {
+				meta.title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+			case atom.Head:
+				return meta
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch atom.Lookup(name) {
+			case atom.Body:
+				return meta
+			case atom.Title:
+				inTitle = true
+			case atom.Meta:
+				meta.addProperty(attrs)
+			case atom.Link:
+				if strings.EqualFold(attrs["rel"], "alternate") && strings.EqualFold(attrs["type"], "application/json+oembed") && meta.oEmbedURL == "" {
+					meta.oEmbedURL = attrs["href"]
+				}
+			}
+		}
+	}
+}
+
+// addProperty keep the content of a meta element, OpenGraph uses the property attribute
+// and the other metadata the name attribute
+func (m *metadata) addProperty(attrs map[string]string) {
+	key := attrs["property"]
+	if key == "" {
+		key = attrs["name"]
+	}
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	content := strings.TrimSpace(attrs["content"])
+	if key == "" || content == "" {
+		return
+	}
+
+	if _, ok := m.properties[key]; !ok {
+		m.properties[key] = content
+	}
+}
+
+// first returns the first metadata found among keys
+func (m *metadata) first(keys ...string) string {
+	for _, key := range keys {
+		if value := m.properties[key]; value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// preview returns the preview of a page from its metadata and its oEmbed response
+func (m *metadata) preview(page *url.URL, embed *oEmbed) *Preview {
+	if embed == nil {
+		embed = &oEmbed{}
+	}
+
+	image := m.first(imageKeys...)
+	if image == "" {
+		image = embed.ThumbnailURL
+	}
+	if image == "" && embed.Type == "photo" {
+		image = embed.URL
+	}
+
+	site := firstOf(m.first(siteNameKeys...), embed.ProviderName)
+	if site == "" {
+		site = siteName(page)
+	}
+
+	return &Preview{
+		Title:       truncate(firstOf(m.first(titleKeys...), embed.Title, m.title), maxTitleLength),
+		Description: truncate(m.first(descriptionKeys...), maxDescriptionLength),
+		ImageURL:    resolveImage(page, image),
+		SiteName:    truncate(site, maxSiteNameLength),
+	}
+}
+
+// fetchOEmbed returns the oEmbed response of a page, or nil when it can not be fetched as
+// it only completes the metadata of the page
+func (f *Fetcher) fetchOEmbed(ctx context.Context, link string) *oEmbed {
+	resp, err := f.get(ctx, link, "application/json")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	embed := &oEmbed{}
+	err = json.NewDecoder(io.LimitReader(resp.Body, f.maxSize)).Decode(embed)
+	if err != nil {
+		return nil
+	}
+
+	return embed
+}
+
+// siteName returns the host of a page without www
+func siteName(page *url.URL) string {
+	return strings.TrimPrefix(page.Hostname(), "www.")
+}
+
+// resolve returns a link of a page as an absolute link
+func resolve(page *url.URL, link string) string {
+	u, err := page.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+
+	return u.String()
+}
+
+// resolveImage returns the absolute link of an image of a page, only http and https
+// images are kept
+func resolveImage(page *url.URL, link string) string {
+	if link == "" {
+		return ""
+	}
+
+	image := resolve(page, link)
+	if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+		return ""
+	}
+
+	return image
+}
+
+// firstOf returns the first value which is not empty
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// truncate returns a text on a single line, cut to a maximum number of characters
+func truncate(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
@@ -0,0 +1,114 @@
+package unfurl
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ada-social-network/api/models"
+	"github.com/ada-social-network/api/repository"
+)
+
+const (
+	// DefaultInterval is the duration the worker waits for new links once all the pending
+	// previews have been fetched
+	DefaultInterval = 5 * time.Second
+	// DefaultCacheTTL is the duration a fetched preview is reused for the same link
+	DefaultCacheTTL = 24 * time.Hour
+	// WorkerBatch is the number of pending previews the worker loads at once
+	WorkerBatch = 10
+)
+
+// Worker fetches in background the link previews created pending with the posts. A link
+// fetched recently for another post is not fetched again, its preview is copied.
+type Worker struct {
+	store    repository.LinkPreviewStore
+	fetcher  *Fetcher
+	interval time.Duration
+	cacheTTL time.Duration
+}
+
+// NewWorker is to create a new worker fetching the pending previews of a store
+func NewWorker(store repository.LinkPreviewStore, fetcher *Fetcher, interval time.Duration, cacheTTL time.Duration) *Worker {
+	return &Worker{store: store, fetcher: fetcher, interval: interval, cacheTTL: cacheTTL}
+}
+
+// Run fetch the pending previews until the context is done
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		count, err := w.RunOnce(ctx)
+		if err != nil {
+			log.Printf("link previews: %v", err)
+		}
+
+		// a full batch may be followed by other pending previews
+		if err == nil && count == WorkerBatch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce fetch a batch of pending previews and returns the number of previews done, the
+// previews which can not be fetched are failed
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	previews := &[]models.LinkPreview{}
+
+	err := w.store.ListPendingLinkPreviews(previews, WorkerBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range *previews {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+
+		preview := &(*previews)[i]
+		w.unfurl(ctx, preview)
+
+		err = w.store.SaveFetchedLinkPreview(preview)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(*previews), nil
+}
+
+// unfurl set the metadata and the status of a preview, from the cache or from its page
+func (w *Worker) unfurl(ctx context.Context, preview *models.LinkPreview) {
+	now := time.Now()
+	cached := &models.LinkPreview{}
+
+	found, err := w.store.GetFetchedLinkPreview(cached, preview.URL, now.Add(-w.cacheTTL))
+	if err == nil && found {
+		preview.Status = models.FetchedPreview
+		preview.Title = cached.Title
+		preview.Description = cached.Description
+		preview.ImageURL = cached.ImageURL
+		preview.SiteName = cached.SiteName
+		preview.FetchedAt = cached.FetchedAt
+		return
+	}
+
+	preview.FetchedAt = &now
+
+	fetched, err := w.fetcher.Fetch(ctx, preview.URL)
+	if err != nil {
+		log.Printf("link previews: %s: %v", preview.URL, err)
+		preview.Status = models.FailedPreview
+		return
+	}
+
+	preview.Status = models.FetchedPreview
+	preview.Title = fetched.Title
+	preview.Description = fetched.Description
+	preview.ImageURL = fetched.ImageURL
+	preview.SiteName = fetched.SiteName
+}